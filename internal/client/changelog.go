@@ -0,0 +1,132 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/google/go-github/v57/github"
+)
+
+// maxAllocationsSources bounds the allocations sources kept for reads never
+// written back.
+const maxAllocationsSources = 16
+
+// rememberAllocationsSource keeps the allocations file read at a SHA, so a
+// later write over it can be diffed for the changelog. When the bound is
+// reached the oldest source is evicted.
+func (c *GitHubClient) rememberAllocationsSource(sha string, content []byte) {
+	if !c.opts.Changelog {
+		return
+	}
+	c.changesMu.Lock()
+	defer c.changesMu.Unlock()
+	if c.allocsSrc == nil {
+		c.allocsSrc = make(map[string][]byte)
+	}
+	if _, ok := c.allocsSrc[sha]; !ok {
+		if len(c.allocsOrder) >= maxAllocationsSources {
+			delete(c.allocsSrc, c.allocsOrder[0])
+			c.allocsOrder = c.allocsOrder[1:]
+		}
+		c.allocsOrder = append(c.allocsOrder, sha)
+	}
+	c.allocsSrc[sha] = content
+}
+
+// forgetAllocationsSource drops the source at sha once a write replaced it.
+// The caller holds changesMu.
+func (c *GitHubClient) forgetAllocationsSource(sha string) {
+	delete(c.allocsSrc, sha)
+	for i, s := range c.allocsOrder {
+		if s == sha {
+			c.allocsOrder = append(c.allocsOrder[:i], c.allocsOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+// recordChanges adds the changes a committed write over the file at sha
+// made to the pending changelog entry. A write creating the file is
+// diffed against an empty database; one over a file this client never
+// read is not recorded.
+func (c *GitHubClient) recordChanges(sha string, db *ipam.AllocationsDatabase) {
+	if !c.opts.Changelog {
+		return
+	}
+	c.changesMu.Lock()
+	defer c.changesMu.Unlock()
+
+	before := ipam.NewAllocationsDatabase()
+	if sha != "" {
+		original, ok := c.allocsSrc[sha]
+		if !ok {
+			return
+		}
+		// The file no longer has this SHA, so nothing will write over it again
+		c.forgetAllocationsSource(sha)
+		parsed, err := ipam.ParseAllocations(original)
+		if err != nil {
+			return
+		}
+		before = parsed
+	}
+	c.changes = append(c.changes, ipam.DiffAllocations(before, db)...)
+}
+
+// PendingChanges returns the changes recorded since the changelog was last
+// written.
+func (c *GitHubClient) PendingChanges() []ipam.ChangeRecord {
+	c.changesMu.Lock()
+	defer c.changesMu.Unlock()
+	return append([]ipam.ChangeRecord(nil), c.changes...)
+}
+
+// writeChangelog prepends the pending changes to the changelog as one
+// entry. Changes are only cleared once written, so a failed write is
+// retried with the next docs refresh. Writes are serialized, so parallel
+// refreshes neither write an entry twice nor clear changes another wrote.
+func (c *GitHubClient) writeChangelog(ctx context.Context) error {
+	c.changelogMu.Lock()
+	defer c.changelogMu.Unlock()
+
+	changes := c.PendingChanges()
+	if len(changes) == 0 {
+		return nil
+	}
+
+	var existing string
+	fileContent, _, resp, err := c.client.Repositories.GetContents(
+		ctx,
+		c.owner,
+		c.repo,
+		ipam.ChangelogPath,
+		&github.RepositoryContentGetOptions{Ref: c.branch},
+	)
+	switch {
+	case err == nil && fileContent != nil && fileContent.Content != nil:
+		content, err := base64.StdEncoding.DecodeString(*fileContent.Content)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", ipam.ChangelogPath, err)
+		}
+		existing = string(content)
+	case resp != nil && resp.StatusCode == 404:
+	case err != nil:
+		return fmt.Errorf("failed to get %s: %w", ipam.ChangelogPath, err)
+	}
+
+	entry := ipam.FormatChangelogEntry(time.Now(), c.opts.CommitInfo.RunID, changes)
+	if err := c.writeFile(ctx, ipam.ChangelogPath, ipam.PrependChangelog(existing, entry)); err != nil {
+		return err
+	}
+
+	c.changesMu.Lock()
+	c.changes = c.changes[len(changes):]
+	c.changesMu.Unlock()
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+)
+
+func TestChangelog_RecordsCreate(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"config/pools.yaml": docsTestPools})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+	c.opts.Changelog = true
+	ctx := context.Background()
+
+	cidr, err := allocateOnce(ctx, c, NewRetryConfig(3, 1), "prod", "vpc", 24)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.0.0/24" {
+		t.Fatalf("expected 10.0.0.0/24, got %s", cidr)
+	}
+	if err := c.RefreshDocs(ctx); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	changelog, ok := repo.file(ipam.ChangelogPath)
+	if !ok {
+		t.Fatal("expected the changelog to be written")
+	}
+	if !strings.Contains(changelog, "allocated 10.0.0.0/24 in pool `prod`: vpc") {
+		t.Errorf("expected an allocated 10.0.0.0/24 entry, got:\n%s", changelog)
+	}
+	if len(c.PendingChanges()) != 0 {
+		t.Error("expected pending changes to be cleared once written")
+	}
+}
+
+func TestChangelog_AggregatesDeferredChanges(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"config/pools.yaml": docsTestPools})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+	c.opts.Changelog = true
+	c.opts.DeferDocs = true
+	ctx := context.Background()
+
+	for _, name := range []string{"vpc-a", "vpc-b"} {
+		if _, err := allocateOnce(ctx, c, NewRetryConfig(3, 1), "prod", name, 24); err != nil {
+			t.Fatalf("allocate %s: %v", name, err)
+		}
+	}
+	if err := c.FlushDocs(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	changelog, _ := repo.file(ipam.ChangelogPath)
+	if strings.Count(changelog, "\n## ") != 1 {
+		t.Errorf("expected one entry for the whole apply, got:\n%s", changelog)
+	}
+	for _, want := range []string{"allocated 10.0.0.0/24 in pool `prod`: vpc-a", "allocated 10.0.1.0/24 in pool `prod`: vpc-b"} {
+		if !strings.Contains(changelog, want) {
+			t.Errorf("expected %q in the changelog, got:\n%s", want, changelog)
+		}
+	}
+}
+
+func TestChangelog_DisabledByDefault(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"config/pools.yaml": docsTestPools})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+	ctx := context.Background()
+
+	if _, err := allocateOnce(ctx, c, NewRetryConfig(3, 1), "prod", "vpc", 24); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.RefreshDocs(ctx); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if _, ok := repo.file(ipam.ChangelogPath); ok {
+		t.Error("expected no changelog without the option")
+	}
+}
+
+func TestChangelog_ParallelRefreshesWriteOnce(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"config/pools.yaml": docsTestPools})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+	c.opts.Changelog = true
+	ctx := context.Background()
+
+	if _, err := allocateOnce(ctx, c, NewRetryConfig(3, 1), "prod", "vpc", 24); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// README writes may conflict; only the changelog matters here
+			_ = c.writeChangelog(ctx)
+		}()
+	}
+	wg.Wait()
+
+	changelog, _ := repo.file(ipam.ChangelogPath)
+	if n := strings.Count(changelog, "allocated 10.0.0.0/24"); n != 1 {
+		t.Errorf("expected the change written once, got %d times:\n%s", n, changelog)
+	}
+	if len(c.PendingChanges()) != 0 {
+		t.Error("expected pending changes to be cleared once written")
+	}
+}
+
+func TestChangelog_SourcesSurviveManyWrites(t *testing.T) {
+	c := &GitHubClient{opts: Options{Changelog: true}}
+	empty := []byte("allocations: {}\n")
+	c.rememberAllocationsSource("first", empty)
+
+	// Each write over a source uses it up, so the bound is never reached
+	for i := 0; i < 2*maxAllocationsSources; i++ {
+		sha := fmt.Sprintf("sha-%d", i)
+		c.rememberAllocationsSource(sha, empty)
+		c.recordChanges(sha, ipam.NewAllocationsDatabase())
+	}
+
+	db := ipam.NewAllocationsDatabase()
+	db.AddAllocation("prod", ipam.Allocation{CIDR: "10.0.0.0/24", ID: "vpc", Name: "vpc"})
+	c.recordChanges("first", db)
+	if changes := c.PendingChanges(); len(changes) != 1 || changes[0].CIDR != "10.0.0.0/24" {
+		t.Errorf("expected the write over the first source to be recorded, got %+v", changes)
+	}
+}
+
+func TestChangelog_SourcesEvictOldest(t *testing.T) {
+	c := &GitHubClient{opts: Options{Changelog: true}}
+	for i := 0; i <= maxAllocationsSources; i++ {
+		c.rememberAllocationsSource(fmt.Sprintf("sha-%d", i), nil)
+	}
+	if _, ok := c.allocsSrc["sha-0"]; ok {
+		t.Error("expected the oldest source to be evicted")
+	}
+	if len(c.allocsSrc) != maxAllocationsSources {
+		t.Errorf("expected %d sources kept, got %d", maxAllocationsSources, len(c.allocsSrc))
+	}
+}
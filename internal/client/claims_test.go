@@ -0,0 +1,111 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+)
+
+// claimOnce mirrors the claim loop of the next_available data source.
+func claimOnce(ctx context.Context, c *GitHubClient, poolID, name string, prefixLen int, ttl time.Duration) (*ipam.Allocation, error) {
+	var claimed *ipam.Allocation
+
+	err := WithRetry(ctx, DefaultRetryConfig(), func(ctx context.Context, attempt int) (bool, error) {
+		pools, err := c.GetPools(ctx)
+		if err != nil {
+			return false, err
+		}
+		poolDef, exists := pools.GetPool(poolID)
+		if !exists {
+			return false, fmt.Errorf("pool %s not found", poolID)
+		}
+
+		db, sha, err := c.GetAllocations(ctx)
+		if err != nil {
+			return false, err
+		}
+
+		claim, err := db.Claim(ipam.AllocationRequest{Name: name, PoolID: poolID, PrefixLen: prefixLen}, poolDef, name, time.Now().Add(ttl))
+		if err != nil {
+			return false, err
+		}
+
+		err = c.UpdateAllocations(ctx, db, sha, fmt.Sprintf("ipam: claim %s (%s)", claim.CIDR, name))
+		if c.IsConflictError(err) {
+			return true, err
+		}
+		if err == nil {
+			claimed = claim
+		}
+		return false, err
+	})
+	return claimed, err
+}
+
+func TestClaim_WritesReservationWithExpiry(t *testing.T) {
+	repo := newFakeRepo(map[string]string{
+		"config/pools.yaml": "pools:\n  prod:\n    cidr: [\"10.0.0.0/16\"]\n",
+	})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+
+	before := time.Now().Truncate(time.Second)
+	claim, err := claimOnce(context.Background(), c, "prod", "next-vpc", 24, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, _ := repo.file("config/allocations.yaml")
+	if !strings.Contains(content, "expires_at:") {
+		t.Errorf("expected expires_at in allocations file, got:\n%s", content)
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, claim.ExpiresAt)
+	if err != nil {
+		t.Fatalf("invalid expires_at %q: %v", claim.ExpiresAt, err)
+	}
+	if expiresAt.Before(before.Add(10*time.Minute)) || expiresAt.After(time.Now().Add(10*time.Minute)) {
+		t.Errorf("expected expiry ten minutes from now, got %s", expiresAt)
+	}
+
+	// The claimed block is held from other allocations
+	next, err := allocateOnce(context.Background(), c, DefaultRetryConfig(), "prod", "vpc-1", 24)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next == claim.CIDR {
+		t.Errorf("expected claimed block %s to be skipped", claim.CIDR)
+	}
+}
+
+func TestPreview_DoesNotWrite(t *testing.T) {
+	repo := newFakeRepo(map[string]string{
+		"config/pools.yaml":       "pools:\n  prod:\n    cidr: [\"10.0.0.0/16\"]\n",
+		"config/allocations.yaml": "version: \"1.1\"\nallocations: {}\n",
+	})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+
+	// The reads a preview without reserve_for_seconds makes
+	pools, err := c.GetPools(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	poolDef, _ := pools.GetPool("prod")
+	db, _, err := c.GetAllocations(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ipam.NewAllocator().FindNextAvailableInPoolWithOptions(poolDef, db.GetAllocationsForPool("prod"), 24, db.AllocateOptionsForPool("prod", poolDef)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if repo.writes != 0 {
+		t.Errorf("expected no writes, got %d", repo.writes)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// CommitInfo holds the values available to the commit_trailer template.
+type CommitInfo struct {
+	RunID     string // Terraform run that made the change
+	Workspace string // Terraform workspace that made the change
+}
+
+// CommitInfoFromEnv fills any unset fields from the environment variables
+// set by HCP Terraform / Terraform Enterprise runs.
+func CommitInfoFromEnv(info CommitInfo) CommitInfo {
+	if info.RunID == "" {
+		info.RunID = os.Getenv("TFC_RUN_ID")
+	}
+	if info.Workspace == "" {
+		info.Workspace = os.Getenv("TFC_WORKSPACE_NAME")
+	}
+	return info
+}
+
+// ValidateCommitTrailer checks that a commit_trailer template parses and renders.
+func ValidateCommitTrailer(trailer string) error {
+	_, err := FormatCommitMessage("validate", trailer, CommitInfo{})
+	return err
+}
+
+// FormatCommitMessage appends the rendered trailer to a commit message as a
+// git trailer, separated from the subject by a blank line. Control
+// characters in the subject, such as newlines in an allocation name, become
+// spaces so the subject stays one line and cannot forge a trailer. The
+// subject is otherwise returned unchanged when the trailer is empty or
+// renders to nothing.
+func FormatCommitMessage(subject, trailer string, info CommitInfo) (string, error) {
+	subject = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, subject)
+
+	if strings.TrimSpace(trailer) == "" {
+		return subject, nil
+	}
+
+	tmpl, err := template.New("commit_trailer").Option("missingkey=error").Parse(trailer)
+	if err != nil {
+		return "", fmt.Errorf("invalid commit_trailer template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, info); err != nil {
+		return "", fmt.Errorf("invalid commit_trailer template: %w", err)
+	}
+
+	rendered := strings.TrimSpace(buf.String())
+	if rendered == "" {
+		return subject, nil
+	}
+	return subject + "\n\n" + rendered, nil
+}
+
+// commitMessage applies the configured trailer to a commit subject.
+// The template is validated when the provider is configured, so a render
+// failure here falls back to the bare subject rather than blocking the write.
+func (c *GitHubClient) commitMessage(subject string) string {
+	msg, err := FormatCommitMessage(subject, c.opts.CommitTrailer, c.opts.CommitInfo)
+	if err != nil {
+		return subject
+	}
+	return msg
+}
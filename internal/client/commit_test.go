@@ -0,0 +1,94 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"testing"
+)
+
+func TestFormatCommitMessage_AppendsTrailer(t *testing.T) {
+	msg, err := FormatCommitMessage("ipam: allocate 10.0.0.0/24 (web)", "Run-ID: {{.RunID}}\nWorkspace: {{.Workspace}}", CommitInfo{
+		RunID:     "run-abc123",
+		Workspace: "network-prod",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "ipam: allocate 10.0.0.0/24 (web)\n\nRun-ID: run-abc123\nWorkspace: network-prod"
+	if msg != expected {
+		t.Errorf("expected %q, got %q", expected, msg)
+	}
+}
+
+func TestFormatCommitMessage_EmptyTemplate(t *testing.T) {
+	subject := "ipam: release 10.0.0.0/24 (web)"
+
+	for _, trailer := range []string{"", "   ", "{{.RunID}}"} {
+		msg, err := FormatCommitMessage(subject, trailer, CommitInfo{})
+		if err != nil {
+			t.Fatalf("trailer %q: unexpected error: %v", trailer, err)
+		}
+		if msg != subject {
+			t.Errorf("trailer %q: expected subject unchanged, got %q", trailer, msg)
+		}
+	}
+}
+
+func TestValidateCommitTrailer(t *testing.T) {
+	if err := ValidateCommitTrailer("Run-ID: {{.RunID}}"); err != nil {
+		t.Errorf("expected valid template, got %v", err)
+	}
+	if err := ValidateCommitTrailer("Run-ID: {{.RunID"); err == nil {
+		t.Error("expected parse error for unterminated action")
+	}
+	if err := ValidateCommitTrailer("Run-ID: {{.Unknown}}"); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestCommitInfoFromEnv(t *testing.T) {
+	t.Setenv("TFC_RUN_ID", "run-env")
+	t.Setenv("TFC_WORKSPACE_NAME", "ws-env")
+
+	info := CommitInfoFromEnv(CommitInfo{RunID: "run-explicit"})
+	if info.RunID != "run-explicit" {
+		t.Errorf("explicit RunID should win, got %q", info.RunID)
+	}
+	if info.Workspace != "ws-env" {
+		t.Errorf("expected workspace from env, got %q", info.Workspace)
+	}
+}
+
+func TestGitHubClient_CommitMessage(t *testing.T) {
+	c := &GitHubClient{opts: Options{
+		CommitTrailer: "Run-ID: {{.RunID}}",
+		CommitInfo:    CommitInfo{RunID: "run-1"},
+	}}
+	if got := c.commitMessage("ipam: update pool prod"); got != "ipam: update pool prod\n\nRun-ID: run-1" {
+		t.Errorf("unexpected message %q", got)
+	}
+
+	plain := &GitHubClient{}
+	if got := plain.commitMessage("ipam: update pool prod"); got != "ipam: update pool prod" {
+		t.Errorf("expected unchanged message, got %q", got)
+	}
+}
+
+func TestFormatCommitMessage_NameWithNewline(t *testing.T) {
+	subject := "ipam: allocate 10.0.0.0/24 (web\n\nSigned-off-by: someone)"
+
+	msg, err := FormatCommitMessage(subject, "Run-ID: {{.RunID}}", CommitInfo{RunID: "run-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "ipam: allocate 10.0.0.0/24 (web  Signed-off-by: someone)\n\nRun-ID: run-1"
+	if msg != expected {
+		t.Errorf("expected %q, got %q", expected, msg)
+	}
+
+	if plain, _ := FormatCommitMessage("ipam: release 10.0.0.0/24 (a\tb\r\n)", "", CommitInfo{}); plain != "ipam: release 10.0.0.0/24 (a b  )" {
+		t.Errorf("expected a one-line subject without a trailer, got %q", plain)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+)
+
+// commitAllocations mirrors the read-modify-write loop the resources run
+// against the allocations file. change edits the database read at the
+// current SHA and returns the commit message, or "" when there is nothing
+// to commit; a write that conflicts is retried from a fresh read.
+func commitAllocations(ctx context.Context, c *GitHubClient, config RetryConfig, change func(db *ipam.AllocationsDatabase) (string, error)) error {
+	return WithRetry(ctx, config, func(ctx context.Context, attempt int) (bool, error) {
+		db, sha, err := c.GetAllocations(ctx)
+		if err != nil {
+			return false, err
+		}
+		message, err := change(db)
+		if err != nil || message == "" {
+			return false, err
+		}
+
+		err = c.UpdateAllocations(ctx, db, sha, message)
+		if c.IsConflictError(err) {
+			return true, err
+		}
+		return false, err
+	})
+}
+
+// allocateOnce mirrors the allocation resource's Create against the given
+// client.
+func allocateOnce(ctx context.Context, c *GitHubClient, config RetryConfig, poolID, name string, prefixLen int) (string, error) {
+	allocator := ipam.NewAllocator()
+	var allocated string
+
+	err := commitAllocations(ctx, c, config, func(db *ipam.AllocationsDatabase) (string, error) {
+		pools, err := c.GetPools(ctx)
+		if err != nil {
+			return "", err
+		}
+		poolDef, exists := pools.GetPool(poolID)
+		if !exists {
+			return "", fmt.Errorf("pool %s not found", poolID)
+		}
+
+		cidr, err := allocator.FindNextAvailableInPool(poolDef, db.GetAllocationsForPool(poolID), prefixLen)
+		if err != nil {
+			return "", err
+		}
+		db.AddAllocation(poolID, ipam.Allocation{CIDR: cidr, ID: name, Name: name})
+		allocated = cidr
+		return fmt.Sprintf("ipam: allocate %s (%s)", cidr, name), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return allocated, nil
+}
+
+func TestConcurrentAllocations_NoDuplicateCIDRs(t *testing.T) {
+	const workers = 16
+
+	repo := newFakeRepo(map[string]string{
+		"config/pools.yaml": "pools:\n  prod:\n    cidr: [\"10.0.0.0/16\"]\n",
+	})
+	// Fail every third write with a spurious 409 on top of real SHA conflicts
+	repo.injectConflict = func(path string) bool {
+		return repo.writes%3 == 0
+	}
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+
+	config := NewRetryConfig(200, 1)
+	config.MaxDelay = 10 * time.Millisecond
+
+	var wg sync.WaitGroup
+	results := make([]string, workers)
+	errs := make([]error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = allocateOnce(context.Background(), c, config, "prod", fmt.Sprintf("alloc-%02d", i), 24)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("worker %d failed: %v", i, err)
+		}
+	}
+
+	seen := make(map[string]int)
+	for i, cidr := range results {
+		if other, dup := seen[cidr]; dup {
+			t.Errorf("workers %d and %d both received %s", other, i, cidr)
+		}
+		seen[cidr] = i
+	}
+
+	// The committed file must agree with what each worker was told
+	content, _ := repo.file("config/allocations.yaml")
+	db, err := ipam.ParseAllocations([]byte(content))
+	if err != nil {
+		t.Fatalf("failed to parse committed allocations: %v", err)
+	}
+	committed := db.GetAllocationsForPool("prod")
+	if len(committed) != workers {
+		t.Fatalf("expected %d committed allocations, got %d", workers, len(committed))
+	}
+	for _, alloc := range committed {
+		if i, ok := seen[alloc.CIDR]; !ok || results[i] != alloc.CIDR {
+			t.Errorf("committed %s (%s) was not reported to any worker", alloc.CIDR, alloc.Name)
+		}
+	}
+	for i := range committed {
+		others := append(append([]ipam.Allocation{}, committed[:i]...), committed[i+1:]...)
+		if err := ipam.NewAllocator().ValidateNoOverlap(others, committed[i].CIDR); err != nil {
+			t.Errorf("committed allocations overlap: %v", err)
+		}
+	}
+}
+
+func TestUpdateAllocations_ConcurrentCreateIsConflict(t *testing.T) {
+	repo := newFakeRepo(map[string]string{
+		"config/allocations.yaml": "version: \"1.1\"\nallocations: {}\n",
+	})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+
+	// An empty SHA means "create"; the file already exists, so GitHub says 422
+	err := c.UpdateAllocations(context.Background(), ipam.NewAllocationsDatabase(), "", "ipam: allocate")
+	if err == nil {
+		t.Fatal("expected error creating a file that already exists")
+	}
+	if !c.IsConflictError(err) {
+		t.Errorf("expected concurrent create to be reported as a conflict, got %v", err)
+	}
+}
+
+// deallocateOnce mirrors the allocation resource's Delete against the
+// given client, re-checking for children on every attempt.
+func deallocateOnce(ctx context.Context, c *GitHubClient, config RetryConfig, id string) error {
+	return commitAllocations(ctx, c, config, func(db *ipam.AllocationsDatabase) (string, error) {
+		_, poolID, found := db.FindAllocationByID(id)
+		if !found {
+			return "", nil
+		}
+		removed, err := db.RemoveLeafAllocation(poolID, id)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("ipam: deallocate %s", removed.CIDR), nil
+	})
+}
+
+func TestDelete_RefusesChildCreatedBetweenAttempts(t *testing.T) {
+	const allocationsFile = "config/allocations.yaml"
+
+	parent := "10.0.0.0/16"
+	seed := ipam.NewAllocationsDatabase()
+	seed.AddAllocation("prod", ipam.Allocation{CIDR: parent, ID: "vpc", Name: "vpc"})
+	seeded, err := seed.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := newFakeRepo(map[string]string{allocationsFile: string(seeded)})
+
+	// A concurrent writer sub-allocates from the VPC just before our first
+	// commit lands, so that commit fails on a stale SHA
+	childAdded := false
+	repo.injectConflict = func(path string) bool {
+		if path != allocationsFile || childAdded {
+			return false
+		}
+		childAdded = true
+		db, err := ipam.ParseAllocations([]byte(repo.files[path]))
+		if err != nil {
+			t.Errorf("parse seeded allocations: %v", err)
+			return true
+		}
+		db.AddAllocation("prod", ipam.Allocation{CIDR: "10.0.1.0/24", ID: "subnet", Name: "subnet", ParentCIDR: &parent})
+		content, _ := db.Marshal()
+		repo.files[path] = string(content)
+		return false
+	}
+
+	c := repo.client(t, "config/pools.yaml", allocationsFile)
+	err = deallocateOnce(context.Background(), c, NewRetryConfig(5, 1), "vpc")
+	if err == nil || !strings.Contains(err.Error(), "child allocations") {
+		t.Fatalf("expected delete to be refused once the child appears, got %v", err)
+	}
+
+	content, _ := repo.file(allocationsFile)
+	db, err := ipam.ParseAllocations([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, found := db.FindAllocationByID("vpc"); !found {
+		t.Error("parent should not have been removed")
+	}
+	if repo.writes != 1 {
+		t.Errorf("expected only the stale first commit to be attempted, got %d writes", repo.writes)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// DocsDeferred reports whether resources leave README regeneration to the
+// github-ipam_docs resource instead of regenerating after every change.
+func (c *GitHubClient) DocsDeferred() bool {
+	return c.opts.DeferDocs
+}
+
+// RefreshDocs is called by resources after a mutation. It regenerates the
+// docs immediately, or, when docs are deferred, only marks them dirty so a
+// later FlushDocs writes them once for the whole apply.
+func (c *GitHubClient) RefreshDocs(ctx context.Context) error {
+	if c.DocsDeferred() {
+		c.docsMu.Lock()
+		c.docsDirty = true
+		c.docsMu.Unlock()
+		return nil
+	}
+	return c.RegenerateREADME(ctx)
+}
+
+// RefreshDocsDiagnostics runs RefreshDocs for a resource that has already
+// committed its change. Failures are logged and swallowed by default; with
+// docs_strict they are returned as an error so the apply fails.
+func (c *GitHubClient) RefreshDocsDiagnostics(ctx context.Context) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	err := c.RefreshDocs(ctx)
+	if err == nil {
+		return diags
+	}
+
+	if c.opts.DocsStrict {
+		diags.AddError(
+			"Failed to Regenerate Docs",
+			fmt.Sprintf("The change was committed, but the IPAM docs could not be regenerated: %s", err),
+		)
+		return diags
+	}
+
+	tflog.Warn(ctx, "Failed to regenerate README", map[string]interface{}{
+		"error": err.Error(),
+	})
+	return diags
+}
+
+// DocsDirty reports whether a deferred regeneration is pending.
+func (c *GitHubClient) DocsDirty() bool {
+	c.docsMu.Lock()
+	defer c.docsMu.Unlock()
+	return c.docsDirty
+}
+
+// FlushDocs regenerates the docs and clears the dirty mark. The mark is
+// only cleared on success so a failed flush is retried by the next one.
+func (c *GitHubClient) FlushDocs(ctx context.Context) error {
+	c.docsMu.Lock()
+	defer c.docsMu.Unlock()
+
+	if err := c.RegenerateREADME(ctx); err != nil {
+		return err
+	}
+	c.docsDirty = false
+	return nil
+}
+
+// DocsWriteError reports the generated files RegenerateREADME could not
+// write. Every other file was written, so the docs are partly updated
+// until the next regeneration succeeds.
+type DocsWriteError struct {
+	Failed  []DocsFileError // In path order
+	Written int
+}
+
+// DocsFileError is one generated file that could not be written.
+type DocsFileError struct {
+	Path string
+	Err  error
+}
+
+func (e *DocsWriteError) Error() string {
+	failures := make([]string, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		failures = append(failures, fmt.Sprintf("%s: %v", f.Path, f.Err))
+	}
+	return fmt.Sprintf("failed to write %d of %d docs files: %s",
+		len(e.Failed), len(e.Failed)+e.Written, strings.Join(failures, "; "))
+}
+
+// Unwrap returns the individual write errors, so errors.Is and errors.As
+// see through the aggregate.
+func (e *DocsWriteError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		errs = append(errs, f.Err)
+	}
+	return errs
+}
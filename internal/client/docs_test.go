@@ -0,0 +1,203 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const docsTestPools = "pools:\n  prod:\n    cidr: [\"10.0.0.0/16\"]\n"
+
+func TestRefreshDocs_DeferredFlushesOnce(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"config/pools.yaml": docsTestPools})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+	c.opts.DeferDocs = true
+	ctx := context.Background()
+	config := NewRetryConfig(3, 1)
+
+	for i := 0; i < 3; i++ {
+		if _, err := allocateOnce(ctx, c, config, "prod", fmt.Sprintf("vpc-%d", i), 24); err != nil {
+			t.Fatalf("allocation %d: %v", i, err)
+		}
+		if err := c.RefreshDocs(ctx); err != nil {
+			t.Fatalf("refresh %d: %v", i, err)
+		}
+	}
+
+	if n := repo.commits[".github/README.md"]; n != 0 {
+		t.Errorf("deferred refresh should not write docs, got %d writes", n)
+	}
+	if !c.DocsDirty() {
+		t.Error("expected docs to be marked dirty")
+	}
+
+	if err := c.FlushDocs(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if n := repo.commits[".github/README.md"]; n != 1 {
+		t.Errorf("expected one docs flush, got %d", n)
+	}
+	if c.DocsDirty() {
+		t.Error("flush should clear the dirty mark")
+	}
+
+	readme, _ := repo.file(".github/README.md")
+	pool, _ := repo.file(".github/ipam/pools/prod.md")
+	if readme == "" || pool == "" {
+		t.Fatal("flush should write the README and pool pages")
+	}
+	if !strings.Contains(pool, "vpc-2") {
+		t.Error("pool page should include the last allocation")
+	}
+}
+
+func TestRefreshDocs_ImmediateByDefault(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"config/pools.yaml": docsTestPools})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := c.RefreshDocs(ctx); err != nil {
+			t.Fatalf("refresh %d: %v", i, err)
+		}
+	}
+
+	if n := repo.commits[".github/README.md"]; n != 2 {
+		t.Errorf("expected a docs write per refresh, got %d", n)
+	}
+	if c.DocsDirty() {
+		t.Error("immediate refresh should never mark docs dirty")
+	}
+}
+
+func TestRefreshDocs_DeletesStalePoolPages(t *testing.T) {
+	repo := newFakeRepo(map[string]string{
+		"config/pools.yaml":                 docsTestPools,
+		".github/ipam/pools/prod/page-2.md": "stale second page",
+		".github/ipam/pools/retired.md":     "removed pool",
+		".github/ipam/pools/notes.txt":      "not generated",
+	})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+
+	if err := c.RefreshDocs(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	for _, path := range []string{".github/ipam/pools/prod/page-2.md", ".github/ipam/pools/retired.md"} {
+		if _, ok := repo.file(path); ok {
+			t.Errorf("expected stale page %s to be deleted", path)
+		}
+	}
+	if _, ok := repo.file(".github/ipam/pools/prod.md"); !ok {
+		t.Error("expected the current pool page to be kept")
+	}
+	if _, ok := repo.file(".github/ipam/pools/notes.txt"); !ok {
+		t.Error("only Markdown pages should be deleted")
+	}
+}
+
+func TestRefreshDocs_ReadOnlyKeepsStalePoolPages(t *testing.T) {
+	repo := newFakeRepo(map[string]string{
+		"config/pools.yaml":             docsTestPools,
+		".github/ipam/pools/retired.md": "removed pool",
+	})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+	c.opts.ReadOnly = true
+
+	err := c.RefreshDocs(context.Background())
+	var docsErr *DocsWriteError
+	if !errors.As(err, &docsErr) || !errors.Is(err, ErrReadOnlyToken) {
+		t.Fatalf("expected a read-only docs error, got %v", err)
+	}
+	for _, f := range docsErr.Failed {
+		if !errors.Is(f.Err, ErrReadOnlyToken) {
+			t.Errorf("%s: expected ErrReadOnlyToken, got %v", f.Path, f.Err)
+		}
+	}
+	if _, ok := repo.file(".github/ipam/pools/retired.md"); !ok {
+		t.Error("a read-only client should not delete pages")
+	}
+}
+
+// failingDocsRepo returns a fake whose doc writes always fail.
+func failingDocsRepo() *fakeRepo {
+	repo := newFakeRepo(map[string]string{"config/pools.yaml": docsTestPools})
+	repo.injectConflict = func(path string) bool {
+		return strings.HasPrefix(path, ".github/")
+	}
+	return repo
+}
+
+func TestRefreshDocsDiagnostics_SwallowsByDefault(t *testing.T) {
+	c := failingDocsRepo().client(t, "config/pools.yaml", "config/allocations.yaml")
+
+	diags := c.RefreshDocsDiagnostics(context.Background())
+	if diags.HasError() {
+		t.Errorf("expected doc failures to be swallowed, got %v", diags)
+	}
+}
+
+func TestRefreshDocsDiagnostics_StrictFails(t *testing.T) {
+	c := failingDocsRepo().client(t, "config/pools.yaml", "config/allocations.yaml")
+	c.opts.DocsStrict = true
+
+	diags := c.RefreshDocsDiagnostics(context.Background())
+	if !diags.HasError() {
+		t.Fatal("expected an error diagnostic in strict mode")
+	}
+	if summary := diags.Errors()[0].Summary(); summary != "Failed to Regenerate Docs" {
+		t.Errorf("unexpected summary %q", summary)
+	}
+}
+
+func TestRefreshDocsDiagnostics_StrictDeferredDoesNotFail(t *testing.T) {
+	c := failingDocsRepo().client(t, "config/pools.yaml", "config/allocations.yaml")
+	c.opts.DocsStrict = true
+	c.opts.DeferDocs = true
+
+	// Nothing is written until the flush, so there is nothing to fail yet
+	if diags := c.RefreshDocsDiagnostics(context.Background()); diags.HasError() {
+		t.Errorf("deferred refresh should not fail, got %v", diags)
+	}
+}
+
+func TestRegenerateREADME_ReportsEveryFailedFile(t *testing.T) {
+	repo := newFakeRepo(map[string]string{
+		"config/pools.yaml": "pools:\n  dev:\n    cidr: [\"10.1.0.0/16\"]\n  prod:\n    cidr: [\"10.0.0.0/16\"]\n",
+	})
+	failing := map[string]bool{
+		".github/README.md":          true,
+		".github/ipam/pools/prod.md": true,
+	}
+	repo.injectConflict = func(path string) bool {
+		return failing[path]
+	}
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+
+	err := c.RegenerateREADME(context.Background())
+	var writeErr *DocsWriteError
+	if !errors.As(err, &writeErr) {
+		t.Fatalf("expected a DocsWriteError, got %v", err)
+	}
+	if len(writeErr.Failed) != 2 || writeErr.Written != 1 {
+		t.Errorf("expected 2 failed and 1 written, got %d and %d", len(writeErr.Failed), writeErr.Written)
+	}
+	for path := range failing {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("expected %s named in %q", path, err)
+		}
+	}
+	if !strings.Contains(err.Error(), "failed to write 2 of 3 docs files") {
+		t.Errorf("unexpected summary in %q", err)
+	}
+
+	// The file between the failures was still written
+	if _, ok := repo.file(".github/ipam/pools/dev.md"); !ok {
+		t.Error("expected dev.md written despite the other failures")
+	}
+}
@@ -0,0 +1,402 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// fakeRepo is an in-memory GitHub Contents API with the same optimistic
+// concurrency rules as GitHub: updates must carry the current SHA (409
+// otherwise), and creating a file that already exists fails with 422.
+type fakeRepo struct {
+	mu    sync.Mutex
+	files map[string]string // path -> content
+
+	// injectConflict, if set, is consulted on every write; returning true
+	// fails the write with a 409 even when its SHA is current.
+	injectConflict func(path string) bool
+	writes         int
+	commits        map[string]int // path -> successful writes
+	lookups        int            // repository and branch reads
+
+	// pulls are open pull requests; contents read at a pull request's head
+	// SHA are served from its files instead of the branch.
+	pulls []fakePull
+
+	// history holds past commits to the branch, newest first, as the
+	// commits API lists them. Contents read at a commit's SHA are served
+	// from its files.
+	history  []fakeCommit
+	refReads int // contents reads at a commit in history
+
+	// movedFrom, if set, is a former owner/repo of the repository. Requests
+	// to it are redirected with a 301, as GitHub does after a rename.
+	movedFrom string
+	redirects int
+
+	// fullName is the repository's owner/repo. Defaults to owner/repo.
+	fullName string
+
+	// badCredentials, if set, fails every request with a 401 as GitHub
+	// does for a revoked or mistyped token.
+	badCredentials bool
+
+	// rateRemaining, if set, is sent as X-RateLimit-Remaining on every
+	// response.
+	rateRemaining string
+
+	// oauthScopes, if set, is sent as X-OAuth-Scopes on every response, as
+	// GitHub does for classic tokens. Fine-grained tokens get no header.
+	oauthScopes *string
+
+	// private and pushDenied describe the repository and the token's
+	// permissions on it, as the repository read reports them.
+	private    bool
+	pushDenied bool
+}
+
+type fakePull struct {
+	number  int
+	base    string
+	headSHA string
+	files   map[string]string
+}
+
+type fakeCommit struct {
+	sha   string
+	date  time.Time
+	files map[string]string
+}
+
+func newFakeRepo(files map[string]string) *fakeRepo {
+	if files == nil {
+		files = make(map[string]string)
+	}
+	return &fakeRepo{files: files, commits: make(map[string]int)}
+}
+
+func fakeSHA(content string) string {
+	sum := sha1.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// file returns the current content of a path.
+func (f *fakeRepo) file(path string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	content, ok := f.files[path]
+	return content, ok
+}
+
+// name returns the repository's owner/repo.
+func (f *fakeRepo) name() string {
+	if f.fullName == "" {
+		return "owner/repo"
+	}
+	return f.fullName
+}
+
+// client returns a GitHubClient pointed at this fake.
+func (f *fakeRepo) client(t *testing.T, poolsFile, allocationsFile string) *GitHubClient {
+	t.Helper()
+	return fakeClient(t, f, poolsFile, allocationsFile)
+}
+
+// fakeHost serves several fakes from one server, routed by repository.
+func fakeHost(repos ...*fakeRepo) http.Handler {
+	mux := http.NewServeMux()
+	for _, repo := range repos {
+		mux.Handle("/repos/"+repo.name(), repo)
+		mux.Handle("/repos/"+repo.name()+"/", repo)
+	}
+	return mux
+}
+
+// fakeClient returns a GitHubClient for owner/repo pointed at handler.
+func fakeClient(t *testing.T, handler http.Handler, poolsFile, allocationsFile string) *GitHubClient {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	stats := &statsCounters{}
+	gh := github.NewClient(countingHTTPClient(&http.Client{}, stats))
+	baseURL, _ := url.Parse(srv.URL + "/")
+	gh.BaseURL = baseURL
+
+	return &GitHubClient{
+		client:          gh,
+		stats:           stats,
+		owner:           "owner",
+		repo:            "repo",
+		branch:          "main",
+		poolsFile:       poolsFile,
+		allocationsFile: allocationsFile,
+	}
+}
+
+func (f *fakeRepo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The fake hosts a single repository with a main branch
+	base := "/repos/" + f.name()
+	branchPrefix := base + "/branches/"
+	if f.rateRemaining != "" {
+		w.Header().Set("X-RateLimit-Remaining", f.rateRemaining)
+	}
+	if f.oauthScopes != nil {
+		w.Header().Set("X-OAuth-Scopes", *f.oauthScopes)
+	}
+	if f.badCredentials {
+		writeFakeError(w, http.StatusUnauthorized, "Bad credentials")
+		return
+	}
+	if old := "/repos/" + f.movedFrom; f.movedFrom != "" && (r.URL.Path == old || strings.HasPrefix(r.URL.Path, old+"/")) {
+		f.mu.Lock()
+		f.redirects++
+		f.mu.Unlock()
+		target := *r.URL
+		target.Path = base + strings.TrimPrefix(r.URL.Path, old)
+		http.Redirect(w, r, target.String(), http.StatusMovedPermanently)
+		return
+	}
+	switch {
+	case r.URL.Path == base:
+		f.countLookup()
+		owner, name, _ := strings.Cut(f.name(), "/")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"name":           name,
+			"full_name":      f.name(),
+			"owner":          map[string]string{"login": owner},
+			"default_branch": "main",
+			"private":        f.private,
+			"permissions":    map[string]bool{"pull": true, "push": !f.pushDenied},
+		})
+		return
+	case strings.HasPrefix(r.URL.Path, branchPrefix):
+		f.countLookup()
+		if strings.TrimPrefix(r.URL.Path, branchPrefix) != "main" {
+			writeFakeError(w, http.StatusNotFound, "Branch not found")
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"name": "main"})
+		return
+	case r.URL.Path == base+"/pulls":
+		f.servePulls(w, r)
+		return
+	case r.URL.Path == base+"/commits":
+		f.serveCommits(w, r)
+		return
+	case !strings.HasPrefix(r.URL.Path, base+"/"):
+		writeFakeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	prefix := base + "/contents/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		writeFakeError(w, http.StatusBadRequest, "unexpected request")
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, prefix)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		files := f.files
+		for _, pull := range f.pulls {
+			if ref := r.URL.Query().Get("ref"); ref != "" && ref == pull.headSHA {
+				files = pull.files
+			}
+		}
+		for _, commit := range f.history {
+			if r.URL.Query().Get("ref") == commit.sha {
+				files = commit.files
+				f.refReads++
+			}
+		}
+		f.serveGet(w, files, path)
+	case http.MethodPut:
+		f.servePut(w, r, path)
+	case http.MethodDelete:
+		f.serveDelete(w, r, path)
+	default:
+		writeFakeError(w, http.StatusMethodNotAllowed, "unsupported method")
+	}
+}
+
+func (f *fakeRepo) countLookup() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lookups++
+}
+
+func (f *fakeRepo) servePulls(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pulls := make([]map[string]interface{}, 0, len(f.pulls))
+	for _, pull := range f.pulls {
+		if base := r.URL.Query().Get("base"); base != "" && base != pull.base {
+			continue
+		}
+		pulls = append(pulls, map[string]interface{}{
+			"number": pull.number,
+			"state":  "open",
+			"base":   map[string]string{"ref": pull.base},
+			"head":   map[string]string{"sha": pull.headSHA},
+		})
+	}
+	_ = json.NewEncoder(w).Encode(pulls)
+}
+
+// serveCommits lists the commits in history that hold path, newest first,
+// honoring since and per_page. It serves a single page.
+func (f *fakeRepo) serveCommits(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	var since time.Time
+	if s := query.Get("since"); s != "" {
+		since, _ = time.Parse(time.RFC3339, s)
+	}
+	perPage := len(f.history)
+	if n, err := strconv.Atoi(query.Get("per_page")); err == nil && n > 0 {
+		perPage = n
+	}
+
+	commits := make([]map[string]interface{}, 0, len(f.history))
+	for _, commit := range f.history {
+		if _, ok := commit.files[query.Get("path")]; !ok || commit.date.Before(since) || len(commits) == perPage {
+			continue
+		}
+		commits = append(commits, map[string]interface{}{
+			"sha": commit.sha,
+			"commit": map[string]interface{}{
+				"committer": map[string]string{"date": commit.date.Format(time.RFC3339)},
+			},
+		})
+	}
+	_ = json.NewEncoder(w).Encode(commits)
+}
+
+func (f *fakeRepo) serveGet(w http.ResponseWriter, files map[string]string, path string) {
+	if content, ok := files[path]; ok {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"type":     "file",
+			"path":     path,
+			"sha":      fakeSHA(content),
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+		})
+		return
+	}
+
+	// Directory listing: direct children only, files and subdirectories
+	var entries []map[string]string
+	dirs := make(map[string]bool)
+	for filePath, content := range files {
+		rest := strings.TrimPrefix(filePath, path+"/")
+		if rest == filePath {
+			continue
+		}
+		if sub, _, nested := strings.Cut(rest, "/"); nested {
+			if !dirs[sub] {
+				dirs[sub] = true
+				entries = append(entries, map[string]string{"type": "dir", "path": path + "/" + sub})
+			}
+			continue
+		}
+		entries = append(entries, map[string]string{"type": "file", "path": filePath, "sha": fakeSHA(content)})
+	}
+	if entries == nil {
+		writeFakeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+func (f *fakeRepo) serveDelete(w http.ResponseWriter, r *http.Request, path string) {
+	var body struct {
+		SHA string `json:"sha"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeFakeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	current, exists := f.files[path]
+	switch {
+	case !exists:
+		writeFakeError(w, http.StatusNotFound, "Not Found")
+		return
+	case body.SHA != fakeSHA(current):
+		writeFakeError(w, http.StatusConflict, "sha does not match")
+		return
+	}
+	delete(f.files, path)
+	f.commits[path]++
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"content": nil})
+}
+
+func (f *fakeRepo) servePut(w http.ResponseWriter, r *http.Request, path string) {
+	var body struct {
+		Content string  `json:"content"`
+		SHA     *string `json:"sha"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeFakeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f.writes++
+	if f.injectConflict != nil && f.injectConflict(path) {
+		writeFakeError(w, http.StatusConflict, "injected conflict")
+		return
+	}
+
+	current, exists := f.files[path]
+	switch {
+	case body.SHA == nil && exists:
+		writeFakeError(w, http.StatusUnprocessableEntity, `Invalid request. "sha" wasn't supplied.`)
+		return
+	case body.SHA != nil && (!exists || *body.SHA != fakeSHA(current)):
+		writeFakeError(w, http.StatusConflict, "sha does not match")
+		return
+	}
+
+	content, err := base64.StdEncoding.DecodeString(body.Content)
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	f.files[path] = string(content)
+	f.commits[path]++
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"content": map[string]string{"path": path, "sha": fakeSHA(string(content))},
+	})
+}
+
+func writeFakeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -4,14 +4,19 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
 	"github.com/google/go-github/v57/github"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"golang.org/x/oauth2"
 	"gopkg.in/yaml.v3"
 )
@@ -26,13 +31,90 @@ type GitHubClient struct {
 	allocationsFile string // Read-write: allocations.yaml
 	maxRetries      int
 	baseDelay       time.Duration
+	opts            Options
+	canWrite        bool // See TokenCanWrite
+
+	docsMu    sync.Mutex // Guards docsDirty across parallel resource operations
+	docsDirty bool       // A deferred README regeneration is pending
+
+	poolsSrcMu sync.Mutex        // Guards poolsSrc
+	poolsSrc   map[string][]byte // Pools file content by SHA, for PreservePoolsFormat
+
+	changesMu   sync.Mutex          // Guards allocsSrc, allocsOrder and changes
+	allocsSrc   map[string][]byte   // Allocations file content by SHA, for Changelog
+	allocsOrder []string            // SHAs in allocsSrc, oldest first
+	changes     []ipam.ChangeRecord // Changes not yet written to the changelog
+
+	changelogMu sync.Mutex // Serializes changelog writes
+
+	stats *statsCounters // Counters for the github-ipam_provider_stats data source
+}
+
+// Options holds optional provider behaviors that resources consult.
+type Options struct {
+	AllowPublic    bool       // Permit pools and allocations outside private address space
+	AllowedRegions []string   // Regions an allocation may be tagged with; empty allows any
+	CommitTrailer  string     // Template appended as a git trailer to every commit
+	CommitInfo     CommitInfo // Values for the commit trailer template
+
+	// PoolsWriteFile is the file pool writes go to. Defaults to the pools
+	// file; required when the pools file is a glob or directory.
+	PoolsWriteFile string
+
+	// PoolsOwner, PoolsRepo and PoolsBranch locate the pools file in a
+	// separate repository, e.g. a platform repository that governs pool
+	// definitions while allocations live with the application. Each
+	// defaults to the client's own owner, repository and branch.
+	PoolsOwner  string
+	PoolsRepo   string
+	PoolsBranch string
+
+	DocsDetailLevel string // ipam.DocsDetailFull (default) or ipam.DocsDetailSummary
+	DeferDocs       bool   // Leave README regeneration to the github-ipam_docs resource
+	DocsStrict      bool   // Fail the apply when README regeneration fails
+	ImportBlocks    bool   // Also generate import blocks for every allocation
+	NetBoxExport    bool   // Also generate a NetBox prefix export
+
+	MaxNestingDepth int // Deepest allowed allocation level; 0 is unlimited
+
+	// PreservePoolsFormat writes pools.yaml as an edit of the file that was
+	// read, keeping comments and key order, instead of re-marshaling it.
+	PreservePoolsFormat bool
+
+	// IncludeOpenPRs makes plan-time pool reads include pools added by open
+	// pull requests against the branch. See GetPoolsWithProposed.
+	IncludeOpenPRs bool
+
+	// ArchiveDeletions keeps deleted allocations in the archive section of
+	// the allocations file instead of dropping them.
+	ArchiveDeletions bool
+
+	// StrictAllocations rejects unknown fields in the allocations file
+	// instead of ignoring them.
+	StrictAllocations bool
+
+	// Changelog records every change written to the allocations file and
+	// prepends them to ipam.ChangelogPath whenever the docs are written.
+	Changelog bool
+
+	IDScheme string // ipam.IDSchemeRandomUUID (default), ipam.IDSchemeUUID5 or ipam.IDSchemeULID
+
+	// TransientRetries caps the retries of GitHub 5xx responses, counted
+	// apart from the maxRetries that cap OCC conflict retries.
+	TransientRetries int
+
+	// ReadOnly refuses every write with ErrReadOnlyToken, for tokens that
+	// can only read. A missing pools file then reads as empty instead of
+	// being created.
+	ReadOnly bool
 }
 
 // NewGitHubClient creates a new GitHub client for IPAM operations.
-func NewGitHubClient(token, owner, repo, branch, poolsFile, allocationsFile string, maxRetries int, baseDelayMs int64) *GitHubClient {
+func NewGitHubClient(token, owner, repo, branch, poolsFile, allocationsFile string, maxRetries int, baseDelayMs int64, opts Options) *GitHubClient {
 	ctx := context.Background()
 	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	tc := oauth2.NewClient(ctx, ts)
+	stats := &statsCounters{}
+	tc := countingHTTPClient(oauth2.NewClient(ctx, ts), stats)
 	ghClient := github.NewClient(tc)
 
 	return &GitHubClient{
@@ -44,22 +126,34 @@ func NewGitHubClient(token, owner, repo, branch, poolsFile, allocationsFile stri
 		allocationsFile: allocationsFile,
 		maxRetries:      maxRetries,
 		baseDelay:       time.Duration(baseDelayMs) * time.Millisecond,
+		opts:            opts,
+		canWrite:        true,
+		stats:           stats,
 	}
 }
 
 // GetPools reads pools.yaml. If the file doesn't exist, it creates an empty one.
+// When the pools file is a glob or directory, all matching files are merged.
 func (c *GitHubClient) GetPools(ctx context.Context) (*ipam.PoolsConfig, error) {
+	owner, repo, branch := c.PoolsLocation()
+	if isPoolsPattern(c.poolsFile) {
+		return c.getMergedPools(ctx, branch)
+	}
+
 	fileContent, _, resp, err := c.client.Repositories.GetContents(
 		ctx,
-		c.owner,
-		c.repo,
+		owner,
+		repo,
 		c.poolsFile,
-		&github.RepositoryContentGetOptions{Ref: c.branch},
+		&github.RepositoryContentGetOptions{Ref: branch},
 	)
 	if err != nil {
 		if resp != nil && resp.StatusCode == 404 {
 			// File doesn't exist, try to create it with empty pools
 			emptyPools := ipam.NewPoolsConfig()
+			if c.opts.ReadOnly {
+				return emptyPools, nil
+			}
 			if createErr := c.createPoolsFile(ctx, emptyPools); createErr != nil {
 				// If we get a 409 conflict, another process created the file - retry read
 				if c.IsConflictError(createErr) {
@@ -78,14 +172,19 @@ func (c *GitHubClient) GetPools(ctx context.Context) (*ipam.PoolsConfig, error)
 	}
 
 	var pools ipam.PoolsConfig
-	if err := yaml.Unmarshal(content, &pools); err != nil {
-		return nil, fmt.Errorf("failed to parse pools YAML: %w", err)
+	if !isBlank(ctx, c.poolsFile, branch, content) {
+		if err := yaml.Unmarshal(content, &pools); err != nil {
+			return nil, fmt.Errorf("failed to parse pools YAML: %w", err)
+		}
 	}
 
 	// Ensure Pools map is initialized
 	if pools.Pools == nil {
 		pools.Pools = make(map[string]ipam.PoolDefinition)
 	}
+	if err := pools.ValidatePools(); err != nil {
+		return nil, fmt.Errorf("invalid pools file %s: %w", c.poolsFile, err)
+	}
 
 	return &pools, nil
 }
@@ -101,26 +200,38 @@ func (c *GitHubClient) createPoolsFile(ctx context.Context, pools *ipam.PoolsCon
 	header := []byte("# IPAM Pool Definitions\n# Define your IP address pools here.\n# Example:\n# pools:\n#   my-pool:\n#     cidr:\n#       - \"10.0.0.0/8\"\n#     description: \"My IP pool\"\n#     metadata:\n#       environment: \"production\"\n\n")
 	content = append(header, content...)
 
+	owner, repo, branch := c.PoolsLocation()
 	opts := &github.RepositoryContentFileOptions{
-		Message: github.String("Initialize IPAM pools configuration"),
+		Message: github.String(c.commitMessage("Initialize IPAM pools configuration")),
 		Content: content,
-		Branch:  github.String(c.branch),
+		Branch:  github.String(branch),
 	}
 
-	_, _, err = c.client.Repositories.CreateFile(ctx, c.owner, c.repo, c.poolsFile, opts)
-	// Return raw error to preserve type for IsConflictError detection
-	return err
+	writePath, err := c.poolsWritePath()
+	if err != nil {
+		return err
+	}
+
+	// Return conflict-aware error for IsConflictError detection
+	return c.createFile(ctx, owner, repo, writePath, opts)
 }
 
 // GetPoolsWithSHA reads pools.yaml and returns the SHA for OCC updates.
 // If the file doesn't exist, it creates an empty one and returns the new SHA.
+// When pools are split across files, only the writable file is read.
 func (c *GitHubClient) GetPoolsWithSHA(ctx context.Context) (*ipam.PoolsConfig, string, error) {
+	writePath, err := c.poolsWritePath()
+	if err != nil {
+		return nil, "", err
+	}
+
+	owner, repo, branch := c.PoolsLocation()
 	fileContent, _, resp, err := c.client.Repositories.GetContents(
 		ctx,
-		c.owner,
-		c.repo,
-		c.poolsFile,
-		&github.RepositoryContentGetOptions{Ref: c.branch},
+		owner,
+		repo,
+		writePath,
+		&github.RepositoryContentGetOptions{Ref: branch},
 	)
 	if err != nil {
 		if resp != nil && resp.StatusCode == 404 {
@@ -145,37 +256,111 @@ func (c *GitHubClient) GetPoolsWithSHA(ctx context.Context) (*ipam.PoolsConfig,
 	}
 
 	var pools ipam.PoolsConfig
-	if err := yaml.Unmarshal(content, &pools); err != nil {
-		return nil, "", fmt.Errorf("failed to parse pools YAML: %w", err)
+	if !isBlank(ctx, writePath, branch, content) {
+		if err := yaml.Unmarshal(content, &pools); err != nil {
+			return nil, "", fmt.Errorf("failed to parse pools YAML: %w", err)
+		}
 	}
 
 	// Ensure Pools map is initialized
 	if pools.Pools == nil {
 		pools.Pools = make(map[string]ipam.PoolDefinition)
 	}
+	// The write file may hold only some of the pools, whose avoid_pools
+	// and overlaps are checked on the merged pools instead
+	validate := pools.ValidatePools
+	if c.PoolsSplitAcrossFiles() {
+		validate = pools.ValidateEachPool
+	}
+	if err := validate(); err != nil {
+		return nil, "", fmt.Errorf("invalid pools file %s: %w", writePath, err)
+	}
+
+	if c.opts.PreservePoolsFormat {
+		c.rememberPoolsSource(*fileContent.SHA, content)
+	}
 
 	return &pools, *fileContent.SHA, nil
 }
 
+// LookupPool reads the named pool from every pools file without writing
+// anything: unlike GetPools, a missing pools file is not created, so
+// resources that only reference a pool never commit.
+func (c *GitHubClient) LookupPool(ctx context.Context, name string) (*ipam.PoolDefinition, bool, error) {
+	exists, err := c.PoolsFileExists(ctx)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+	pools, err := c.GetPools(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	pool, found := pools.GetPool(name)
+	return pool, found, nil
+}
+
+// maxPoolsSources bounds the pools sources kept for reads never written back.
+const maxPoolsSources = 16
+
+func (c *GitHubClient) rememberPoolsSource(sha string, content []byte) {
+	c.poolsSrcMu.Lock()
+	defer c.poolsSrcMu.Unlock()
+	if c.poolsSrc == nil || len(c.poolsSrc) >= maxPoolsSources {
+		c.poolsSrc = make(map[string][]byte)
+	}
+	c.poolsSrc[sha] = content
+}
+
+// marshalPools serializes pools for a write over the file with the given
+// SHA. With PreservePoolsFormat, the content read at that SHA is edited in
+// place; content that cannot be edited falls back to a plain marshal.
+func (c *GitHubClient) marshalPools(pools *ipam.PoolsConfig, sha string) ([]byte, error) {
+	if c.opts.PreservePoolsFormat && sha != "" {
+		c.poolsSrcMu.Lock()
+		original, ok := c.poolsSrc[sha]
+		c.poolsSrcMu.Unlock()
+		if ok {
+			if content, err := ipam.MarshalPoolsPreserving(original, pools); err == nil {
+				return content, nil
+			}
+		}
+	}
+
+	content, err := yaml.Marshal(pools)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize pools: %w", err)
+	}
+	return content, nil
+}
+
 // UpdatePools writes pools.yaml with OCC via SHA.
 func (c *GitHubClient) UpdatePools(ctx context.Context, pools *ipam.PoolsConfig, sha, commitMessage string) error {
-	content, err := yaml.Marshal(pools)
+	if err := c.checkWritable(); err != nil {
+		return err
+	}
+	content, err := c.marshalPools(pools, sha)
 	if err != nil {
-		return fmt.Errorf("failed to serialize pools: %w", err)
+		return err
 	}
 
+	owner, repo, branch := c.PoolsLocation()
 	opts := &github.RepositoryContentFileOptions{
-		Message: github.String(commitMessage),
+		Message: github.String(c.commitMessage(commitMessage)),
 		Content: content,
-		Branch:  github.String(c.branch),
+		Branch:  github.String(branch),
 	}
 
 	if sha != "" {
 		opts.SHA = github.String(sha)
 	}
 
-	_, _, err = c.client.Repositories.UpdateFile(ctx, c.owner, c.repo, c.poolsFile, opts)
-	return err
+	writePath, err := c.poolsWritePath()
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.client.Repositories.UpdateFile(ctx, owner, repo, writePath, opts)
+	return c.countConflict(err)
 }
 
 // GetAllocations reads allocations.yaml with SHA for OCC.
@@ -200,24 +385,48 @@ func (c *GitHubClient) GetAllocations(ctx context.Context) (*ipam.AllocationsDat
 		return nil, "", fmt.Errorf("failed to decode allocations content: %w", err)
 	}
 
-	var db ipam.AllocationsDatabase
-	if err := yaml.Unmarshal(content, &db); err != nil {
-		return nil, "", fmt.Errorf("failed to parse allocations YAML: %w", err)
+	isBlank(ctx, c.allocationsFile, c.branch, content)
+	parse := ipam.ParseAllocations
+	if c.opts.StrictAllocations {
+		parse = ipam.ParseAllocationsStrict
+	}
+	db, err := parse(content)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse allocations YAML in %s on branch %s: %w; "+
+			"the file may have been edited by hand, fix it or revert the commit that broke it", c.allocationsFile, c.branch, err)
 	}
+	c.rememberAllocationsSource(*fileContent.SHA, content)
+
+	return db, *fileContent.SHA, nil
+}
 
-	return &db, *fileContent.SHA, nil
+// isBlank reports whether a data file that exists holds nothing but
+// whitespace, logging a warning since that usually follows a botched hand
+// edit. Blank files read as empty; their SHA is still used for OCC.
+func isBlank(ctx context.Context, path, branch string, content []byte) bool {
+	if len(bytes.TrimSpace(content)) > 0 {
+		return false
+	}
+	tflog.Warn(ctx, "Data file is empty, treating it as holding no entries", map[string]interface{}{
+		"path":   path,
+		"branch": branch,
+	})
+	return true
 }
 
 // UpdateAllocations writes allocations.yaml with OCC via SHA.
 // If SHA is empty (file doesn't exist), creates the file.
 func (c *GitHubClient) UpdateAllocations(ctx context.Context, db *ipam.AllocationsDatabase, sha, commitMessage string) error {
-	content, err := yaml.Marshal(db)
+	if err := c.checkWritable(); err != nil {
+		return err
+	}
+	content, err := db.Marshal()
 	if err != nil {
 		return fmt.Errorf("failed to serialize allocations: %w", err)
 	}
 
 	opts := &github.RepositoryContentFileOptions{
-		Message: github.String(commitMessage),
+		Message: github.String(c.commitMessage(commitMessage)),
 		Content: content,
 		Branch:  github.String(c.branch),
 	}
@@ -228,16 +437,46 @@ func (c *GitHubClient) UpdateAllocations(ctx context.Context, db *ipam.Allocatio
 		_, _, err = c.client.Repositories.UpdateFile(ctx, c.owner, c.repo, c.allocationsFile, opts)
 	} else {
 		// Create new file
-		_, _, err = c.client.Repositories.CreateFile(ctx, c.owner, c.repo, c.allocationsFile, opts)
+		err = c.createFile(ctx, c.owner, c.repo, c.allocationsFile, opts)
 	}
-	return err
+	if err == nil {
+		c.recordChanges(sha, db)
+	}
+	return c.countConflict(err)
 }
 
 // IsConflictError checks if an error is a 409 Conflict from the GitHub API.
 func (c *GitHubClient) IsConflictError(err error) bool {
+	return isConflictError(err)
+}
+
+// errCreatedConcurrently marks a create that lost a race with another writer.
+var errCreatedConcurrently = errors.New("file was created concurrently")
+
+// createFile creates a new file. GitHub rejects creating a file that
+// already exists with 422 rather than 409, so that case is reported as a
+// conflict to let callers re-read and retry like any other OCC failure.
+func (c *GitHubClient) createFile(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentFileOptions) error {
+	if err := c.checkWritable(); err != nil {
+		return err
+	}
+	_, _, err := c.client.Repositories.CreateFile(ctx, owner, repo, path, opts)
+	var ghErr *github.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == 422 {
+		return fmt.Errorf("%w: %s: %w", errCreatedConcurrently, path, err)
+	}
+	return err
+}
+
+// isConflictError reports whether err is a 409 Conflict from the GitHub API,
+// or a create that lost a race with another writer.
+func isConflictError(err error) bool {
 	if err == nil {
 		return false
 	}
+	if errors.Is(err, errCreatedConcurrently) {
+		return true
+	}
 	var ghErr *github.ErrorResponse
 	if errors.As(err, &ghErr) {
 		return ghErr.Response != nil && ghErr.Response.StatusCode == 409
@@ -245,18 +484,62 @@ func (c *GitHubClient) IsConflictError(err error) bool {
 	return false
 }
 
-// MaxRetries returns the configured maximum retry attempts.
+// MaxRetries returns the configured maximum retry attempts on conflict.
 func (c *GitHubClient) MaxRetries() int {
 	return c.maxRetries
 }
 
+// TransientRetries returns the configured maximum retry attempts on
+// transient GitHub errors.
+func (c *GitHubClient) TransientRetries() int {
+	return c.opts.TransientRetries
+}
+
+// RetryConfig returns the retry configuration for write operations.
+func (c *GitHubClient) RetryConfig() RetryConfig {
+	config := NewRetryConfig(c.maxRetries, c.baseDelay.Milliseconds())
+	config.MaxTransientRetries = c.opts.TransientRetries
+	return config
+}
+
 // BaseDelay returns the configured base delay for backoff.
 func (c *GitHubClient) BaseDelay() time.Duration {
 	return c.baseDelay
 }
 
+// AllowPublic reports whether globally-routable CIDRs are permitted.
+func (c *GitHubClient) AllowPublic() bool {
+	return c.opts.AllowPublic
+}
+
+// AllowedRegions returns the regions allocations may be tagged with, or
+// nil when any region is allowed.
+func (c *GitHubClient) AllowedRegions() []string {
+	return c.opts.AllowedRegions
+}
+
+// ArchiveDeletions reports whether deleted allocations are archived.
+func (c *GitHubClient) ArchiveDeletions() bool {
+	return c.opts.ArchiveDeletions
+}
+
+// NewAllocationID returns an ID for a new allocation under the configured
+// ID scheme.
+func (c *GitHubClient) NewAllocationID(poolID, name string, prefixLen int) string {
+	return ipam.NewAllocationID(c.opts.IDScheme, poolID, name, prefixLen)
+}
+
+// MaxNestingDepth returns the deepest allowed allocation level, or 0 when
+// nesting is unlimited.
+func (c *GitHubClient) MaxNestingDepth() int {
+	return c.opts.MaxNestingDepth
+}
+
 // UpdateREADME updates the .github/README.md file with current IPAM status.
 func (c *GitHubClient) UpdateREADME(ctx context.Context, content string) error {
+	if err := c.checkWritable(); err != nil {
+		return err
+	}
 	readmePath := ".github/README.md"
 
 	// Try to get existing file for SHA
@@ -269,7 +552,7 @@ func (c *GitHubClient) UpdateREADME(ctx context.Context, content string) error {
 	)
 
 	opts := &github.RepositoryContentFileOptions{
-		Message: github.String("docs: update IPAM status"),
+		Message: github.String(c.commitMessage("docs: update IPAM status")),
 		Content: []byte(content),
 		Branch:  github.String(c.branch),
 	}
@@ -288,7 +571,9 @@ func (c *GitHubClient) UpdateREADME(ctx context.Context, content string) error {
 	return err
 }
 
-// RegenerateREADME regenerates all IPAM documentation files.
+// RegenerateREADME regenerates all IPAM documentation files. A file that
+// fails to write doesn't stop the others; the failures are returned
+// together as a *DocsWriteError.
 func (c *GitHubClient) RegenerateREADME(ctx context.Context) error {
 	pools, err := c.GetPools(ctx)
 	if err != nil {
@@ -301,20 +586,102 @@ func (c *GitHubClient) RegenerateREADME(ctx context.Context) error {
 	}
 
 	// Generate all files
-	files := ipam.GenerateAllFiles(pools, allocations)
+	files := ipam.GenerateAllFilesWithOptions(pools, allocations, ipam.GenerateOptions{
+		DetailLevel:  c.opts.DocsDetailLevel,
+		ImportBlocks: c.opts.ImportBlocks,
+		NetBoxExport: c.opts.NetBoxExport,
+	})
+
+	// Write each file in path order, carrying on past failures so one
+	// transient error doesn't leave the rest of the docs stale
+	paths := make([]string, 0, len(files.Files))
+	for path := range files.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
 
-	// Write each file
-	for path, content := range files.Files {
-		if err := c.writeFile(ctx, path, content); err != nil {
-			return fmt.Errorf("failed to write %s: %w", path, err)
+	result := &DocsWriteError{}
+	for _, path := range paths {
+		if err := c.writeFile(ctx, path, files.Files[path]); err != nil {
+			result.Failed = append(result.Failed, DocsFileError{Path: path, Err: err})
+			continue
 		}
+		result.Written++
 	}
 
+	// Pages of pools that were removed or shrank are no longer generated
+	deleted, failed := c.deleteStalePoolPages(ctx, ipam.PoolPagesDir, files.Files)
+	result.Written += deleted
+	result.Failed = append(result.Failed, failed...)
+
+	pending := len(c.PendingChanges()) > 0
+	if err := c.writeChangelog(ctx); err != nil {
+		result.Failed = append(result.Failed, DocsFileError{Path: ipam.ChangelogPath, Err: err})
+	} else if pending {
+		result.Written++
+	}
+	if len(result.Failed) > 0 {
+		return result
+	}
 	return nil
 }
 
+// deleteStalePoolPages deletes the Markdown files under dir, and its
+// subdirectories, that are not in keep. It returns how many were deleted
+// and the ones that could not be.
+func (c *GitHubClient) deleteStalePoolPages(ctx context.Context, dir string, keep map[string]string) (int, []DocsFileError) {
+	if err := c.checkWritable(); err != nil {
+		return 0, []DocsFileError{{Path: dir, Err: err}}
+	}
+	_, entries, resp, err := c.client.Repositories.GetContents(
+		ctx,
+		c.owner,
+		c.repo,
+		dir,
+		&github.RepositoryContentGetOptions{Ref: c.branch},
+	)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return 0, nil
+		}
+		return 0, []DocsFileError{{Path: dir, Err: fmt.Errorf("failed to list pool pages: %w", err)}}
+	}
+
+	deleted := 0
+	var failed []DocsFileError
+	for _, entry := range entries {
+		entryPath := entry.GetPath()
+		switch {
+		case entry.GetType() == "dir":
+			n, f := c.deleteStalePoolPages(ctx, entryPath, keep)
+			deleted += n
+			failed = append(failed, f...)
+			continue
+		case entry.GetType() != "file" || !strings.HasSuffix(entryPath, ".md"):
+			continue
+		}
+		if _, ok := keep[entryPath]; ok {
+			continue
+		}
+		_, _, err := c.client.Repositories.DeleteFile(ctx, c.owner, c.repo, entryPath, &github.RepositoryContentFileOptions{
+			Message: github.String(c.commitMessage(fmt.Sprintf("docs: remove %s", entryPath))),
+			SHA:     github.String(entry.GetSHA()),
+			Branch:  github.String(c.branch),
+		})
+		if err != nil {
+			failed = append(failed, DocsFileError{Path: entryPath, Err: err})
+			continue
+		}
+		deleted++
+	}
+	return deleted, failed
+}
+
 // writeFile writes or updates a file in the repository.
 func (c *GitHubClient) writeFile(ctx context.Context, path, content string) error {
+	if err := c.checkWritable(); err != nil {
+		return err
+	}
 	// Try to get existing file for SHA
 	fileContent, _, resp, err := c.client.Repositories.GetContents(
 		ctx,
@@ -325,7 +692,7 @@ func (c *GitHubClient) writeFile(ctx context.Context, path, content string) erro
 	)
 
 	opts := &github.RepositoryContentFileOptions{
-		Message: github.String(fmt.Sprintf("docs: update %s", path)),
+		Message: github.String(c.commitMessage(fmt.Sprintf("docs: update %s", path))),
 		Content: []byte(content),
 		Branch:  github.String(c.branch),
 	}
@@ -0,0 +1,218 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+)
+
+const handEditedAllocations = `version: "1.1"
+allocations:
+  prod:
+    - cidr: 10.0.0.0/24
+      id: vpc-1
+      name: vpc
+      vlna_id: 120
+`
+
+func TestGetAllocations_UnknownFieldTolerated(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"allocations.yaml": handEditedAllocations})
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+
+	db, _, err := c.GetAllocations(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(db.AllAllocations()) != 1 {
+		t.Errorf("expected 1 allocation, got %d", len(db.AllAllocations()))
+	}
+}
+
+func TestGetAllocations_UnknownFieldStrict(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"allocations.yaml": handEditedAllocations})
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+	c.opts.StrictAllocations = true
+
+	_, _, err := c.GetAllocations(context.Background())
+	if err == nil {
+		t.Fatal("expected strict mode to reject the unknown field")
+	}
+	for _, want := range []string{"allocations.yaml", "vlna_id", "line 7", "edited by hand"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in the error, got %v", want, err)
+		}
+	}
+}
+
+// Refresh and import both rebuild address_count from the block read back
+// from the allocations file, as Read does.
+func TestGetAllocations_AddressCountAfterRefresh(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"config/pools.yaml": docsTestPools})
+	ctx := context.Background()
+	if _, err := allocateOnce(ctx, repo.client(t, "config/pools.yaml", "config/allocations.yaml"), NewRetryConfig(3, 1), "prod", "vpc", 22); err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+
+	// A fresh client, as in a later run, refreshes from the file
+	db, _, err := repo.client(t, "config/pools.yaml", "config/allocations.yaml").GetAllocations(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	alloc, _, found := db.FindAllocationByName("vpc")
+	if !found {
+		t.Fatal("expected the allocation after refresh")
+	}
+	if count, ok := ipam.AddressCount(alloc.CIDR); !ok || count != 1024 {
+		t.Errorf("expected address_count 1024 for %s, got %d (%v)", alloc.CIDR, count, ok)
+	}
+}
+
+func TestGetAllocations_AddressCountAfterImport(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"allocations.yaml": handEditedAllocations})
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+
+	db, _, err := c.GetAllocations(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Import looks the entry up by its ID
+	alloc, _, found := db.FindAllocationByID("vpc-1")
+	if !found {
+		t.Fatal("expected the hand-edited allocation to be importable")
+	}
+	if count, ok := ipam.AddressCount(alloc.CIDR); !ok || count != 256 {
+		t.Errorf("expected address_count 256 for %s, got %d (%v)", alloc.CIDR, count, ok)
+	}
+}
+
+func TestGetAllocations_MalformedFile(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"allocations.yaml": "allocations:\n  prod: [\n"})
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+
+	_, _, err := c.GetAllocations(context.Background())
+	if err == nil {
+		t.Fatal("expected an error for malformed YAML")
+	}
+	if !strings.Contains(err.Error(), "line ") || !strings.Contains(err.Error(), "edited by hand") {
+		t.Errorf("expected a located error with a hint, got %v", err)
+	}
+}
+
+func TestGetAllocations_BlankFile(t *testing.T) {
+	for _, content := range []string{"", "  \n\t\n"} {
+		repo := newFakeRepo(map[string]string{"allocations.yaml": content})
+		c := repo.client(t, "pools.yaml", "allocations.yaml")
+		c.opts.StrictAllocations = true
+		ctx := context.Background()
+
+		db, sha, err := c.GetAllocations(ctx)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", content, err)
+		}
+		if len(db.AllAllocations()) != 0 || db.Allocations == nil {
+			t.Errorf("%q: expected a fresh database, got %+v", content, db)
+		}
+		if sha != fakeSHA(content) {
+			t.Errorf("%q: expected the file's SHA for OCC, got %q", content, sha)
+		}
+
+		db.AddAllocation("prod", ipam.Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc"})
+		if err := c.UpdateAllocations(ctx, db, sha, "Allocate vpc"); err != nil {
+			t.Fatalf("%q: expected the write over the blank file to succeed: %v", content, err)
+		}
+	}
+}
+
+func TestGetPools_BlankFile(t *testing.T) {
+	for _, content := range []string{"", "\n \t \n"} {
+		repo := newFakeRepo(map[string]string{"pools.yaml": content})
+		c := repo.client(t, "pools.yaml", "allocations.yaml")
+		ctx := context.Background()
+
+		pools, err := c.GetPools(ctx)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", content, err)
+		}
+		if pools.Pools == nil || len(pools.Pools) != 0 {
+			t.Errorf("%q: expected no pools, got %v", content, pools.Pools)
+		}
+
+		_, sha, err := c.GetPoolsWithSHA(ctx)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", content, err)
+		}
+		if sha != fakeSHA(content) {
+			t.Errorf("%q: expected the file's SHA for OCC, got %q", content, sha)
+		}
+		if repo.writes != 0 {
+			t.Errorf("%q: expected the blank file to be read, not recreated, got %d writes", content, repo.writes)
+		}
+	}
+}
+
+func TestLookupPool_ReflectsFileWithoutWriting(t *testing.T) {
+	// As merged from a PR that edited the pool outside Terraform
+	repo := newFakeRepo(map[string]string{"pools.yaml": `pools:
+  prod:
+    cidr: ["10.1.0.0/16"]
+    description: "Edited in review"
+    metadata:
+      owner: "network-team"
+`})
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+
+	pool, found, err := c.LookupPool(context.Background(), "prod")
+	if err != nil || !found {
+		t.Fatalf("expected prod to be found, got %v, %v", found, err)
+	}
+	if pool.CIDR[0] != "10.1.0.0/16" || pool.Description != "Edited in review" || pool.Metadata["owner"] != "network-team" {
+		t.Errorf("expected the values from pools.yaml, got %+v", pool)
+	}
+	if _, found, err := c.LookupPool(context.Background(), "dev"); err != nil || found {
+		t.Errorf("expected dev not to be found, got %v, %v", found, err)
+	}
+	if repo.writes != 0 {
+		t.Errorf("expected no writes, got %d", repo.writes)
+	}
+}
+
+func TestLookupPool_MissingFileNotCreated(t *testing.T) {
+	repo := newFakeRepo(map[string]string{})
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+
+	if _, found, err := c.LookupPool(context.Background(), "prod"); err != nil || found {
+		t.Errorf("expected prod not to be found, got %v, %v", found, err)
+	}
+	if repo.writes != 0 {
+		t.Errorf("expected no writes, got %d", repo.writes)
+	}
+	if _, ok := repo.file("pools.yaml"); ok {
+		t.Error("expected the pools file not to be created")
+	}
+}
+
+func TestGetPools_InvalidFileFailsAtRead(t *testing.T) {
+	// A reserved range outside the pool, as a hand edit might leave it
+	repo := newFakeRepo(map[string]string{"pools.yaml": `pools:
+  prod:
+    cidr: ["10.0.0.0/16"]
+    reserved_ranges: ["10.9.0.0/24"]
+`})
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+	ctx := context.Background()
+
+	if _, err := c.GetPools(ctx); err == nil || !strings.Contains(err.Error(), "invalid pools file pools.yaml") {
+		t.Errorf("expected GetPools to reject the file, got %v", err)
+	}
+	if _, _, err := c.GetPoolsWithSHA(ctx); err == nil || !strings.Contains(err.Error(), "not within any of its CIDRs") {
+		t.Errorf("expected GetPoolsWithSHA to reject the file, got %v", err)
+	}
+	if repo.writes != 0 {
+		t.Errorf("expected no writes, got %d", repo.writes)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// Health is the result of HealthCheck.
+type Health struct {
+	Reachable          bool     // The repository and branch could be read
+	PoolsFound         bool     // The pools file exists and parses
+	AllocationsFound   bool     // The allocations file exists and parses
+	RateLimitRemaining int64    // As in Stats; -1 when GitHub did not report it
+	Problems           []string // Why each failed check failed, for people
+}
+
+// Healthy reports whether every check passed.
+func (h Health) Healthy() bool {
+	return h.Reachable && h.PoolsFound && h.AllocationsFound
+}
+
+// HealthCheck checks that the repository and branch can be reached and
+// the pools and allocations files read, without writing anything: unlike
+// GetPools, a missing pools file is reported rather than created. Failures
+// are collected in Problems instead of returned, and the checks after an
+// unreachable repository are skipped.
+func (c *GitHubClient) HealthCheck(ctx context.Context) (health Health) {
+	defer func() {
+		health.RateLimitRemaining = c.Stats().RateLimitRemaining
+	}()
+
+	// Uncached, unlike CheckLocation, so a revoked token shows up
+	if err := c.checkLocation(ctx); err != nil {
+		health.Problems = append(health.Problems, describeHealthError(err))
+		return health
+	}
+	health.Reachable = true
+
+	owner, repo, branch := c.PoolsLocation()
+	exists, err := c.PoolsFileExists(ctx)
+	switch {
+	case err != nil:
+		health.Problems = append(health.Problems, describeHealthError(err))
+	case !exists:
+		health.Problems = append(health.Problems, fmt.Sprintf("pools file %s not found in %s/%s on branch %q", c.poolsFile, owner, repo, branch))
+	default:
+		if _, err := c.GetPools(ctx); err != nil {
+			health.Problems = append(health.Problems, describeHealthError(err))
+		} else {
+			health.PoolsFound = true
+		}
+	}
+
+	_, sha, err := c.GetAllocations(ctx)
+	switch {
+	case err != nil:
+		health.Problems = append(health.Problems, describeHealthError(err))
+	case sha == "":
+		health.Problems = append(health.Problems, fmt.Sprintf("allocations file %s not found in %s/%s on branch %q; it is created by the first allocation", c.allocationsFile, c.owner, c.repo, c.branch))
+	default:
+		health.AllocationsFound = true
+	}
+	return health
+}
+
+// describeHealthError explains the GitHub responses a misconfigured token
+// produces, which otherwise read as a bare status line.
+func describeHealthError(err error) string {
+	var ghErr *github.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil {
+		switch ghErr.Response.StatusCode {
+		case http.StatusUnauthorized:
+			return fmt.Sprintf("GitHub rejected the token (%s); check token or token_file", ghErr.Message)
+		case http.StatusForbidden:
+			return fmt.Sprintf("the token may not read the repository, or the rate limit is exhausted (%s)", ghErr.Message)
+		}
+	}
+	return err.Error()
+}
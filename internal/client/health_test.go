@@ -0,0 +1,67 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestHealthCheck_Healthy(t *testing.T) {
+	repo := newFakeRepo(map[string]string{
+		"config/pools.yaml":       "pools:\n  prod:\n    cidr: [\"10.0.0.0/16\"]\n",
+		"config/allocations.yaml": "version: \"1.1\"\nallocations: {}\n",
+	})
+	repo.rateRemaining = "4321"
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+
+	health := c.HealthCheck(context.Background())
+	if !health.Healthy() || len(health.Problems) != 0 {
+		t.Fatalf("expected a healthy backend, got %+v", health)
+	}
+	if health.RateLimitRemaining != 4321 {
+		t.Errorf("expected 4321 requests remaining, got %d", health.RateLimitRemaining)
+	}
+	if repo.writes != 0 {
+		t.Errorf("expected no writes, got %d", repo.writes)
+	}
+}
+
+func TestHealthCheck_MissingFiles(t *testing.T) {
+	repo := newFakeRepo(nil)
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+
+	health := c.HealthCheck(context.Background())
+	if !health.Reachable || health.PoolsFound || health.AllocationsFound {
+		t.Fatalf("expected a reachable backend without files, got %+v", health)
+	}
+	if len(health.Problems) != 2 || !strings.Contains(health.Problems[0], "pools file config/pools.yaml not found") {
+		t.Errorf("expected both files reported missing, got %q", health.Problems)
+	}
+	if health.RateLimitRemaining != -1 {
+		t.Errorf("expected an unreported rate limit, got %d", health.RateLimitRemaining)
+	}
+	if _, ok := repo.file("config/pools.yaml"); ok {
+		t.Error("expected the check not to create the pools file")
+	}
+}
+
+func TestHealthCheck_BadCredentials(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"config/pools.yaml": "pools: {}\n"})
+	repo.badCredentials = true
+	repo.rateRemaining = "59"
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+
+	health := c.HealthCheck(context.Background())
+	if health.Reachable || health.PoolsFound || health.AllocationsFound {
+		t.Fatalf("expected an unreachable backend, got %+v", health)
+	}
+	if len(health.Problems) != 1 || !strings.Contains(health.Problems[0], "GitHub rejected the token (Bad credentials)") {
+		t.Errorf("expected a readable auth error, got %q", health.Problems)
+	}
+	if health.RateLimitRemaining != 59 {
+		t.Errorf("expected 59 requests remaining, got %d", health.RateLimitRemaining)
+	}
+}
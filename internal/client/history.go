@@ -0,0 +1,112 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/google/go-github/v57/github"
+)
+
+// allocationsSnapshots caches the allocations file parsed at a commit, per
+// API endpoint, repository and path. A file at a commit never changes, so
+// entries never go stale and repeated history reads only list commits.
+var allocationsSnapshots sync.Map
+
+// GetAllocationsHistory reads the allocations file as of each of the last
+// maxCommits commits on the branch that touched it, newer than since if it
+// is set. Snapshots are returned newest first, the order GitHub lists
+// commits in. The databases are shared with the cache and must not be
+// modified.
+func (c *GitHubClient) GetAllocationsHistory(ctx context.Context, since time.Time, maxCommits int) ([]ipam.AllocationsSnapshot, error) {
+	commits, err := c.allocationsCommits(ctx, since, maxCommits)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]ipam.AllocationsSnapshot, 0, len(commits))
+	for _, commit := range commits {
+		db, err := c.allocationsAt(ctx, commit.GetSHA())
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, ipam.AllocationsSnapshot{
+			CommitSHA: commit.GetSHA(),
+			Timestamp: commit.GetCommit().GetCommitter().GetDate().Time,
+			DB:        db,
+		})
+	}
+	return snapshots, nil
+}
+
+// allocationsCommits lists up to maxCommits commits touching the
+// allocations file, newest first.
+func (c *GitHubClient) allocationsCommits(ctx context.Context, since time.Time, maxCommits int) ([]*github.RepositoryCommit, error) {
+	opts := &github.CommitsListOptions{
+		SHA:         c.branch,
+		Path:        c.allocationsFile,
+		Since:       since,
+		ListOptions: github.ListOptions{PerPage: min(maxCommits, 100)},
+	}
+
+	var all []*github.RepositoryCommit
+	for {
+		commits, resp, err := c.client.Repositories.ListCommits(ctx, c.owner, c.repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits to %s: %w", c.allocationsFile, err)
+		}
+		all = append(all, commits...)
+		if len(all) >= maxCommits {
+			return all[:maxCommits], nil
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// allocationsAt reads the allocations file at a commit. A commit that
+// deleted the file yields an empty database.
+func (c *GitHubClient) allocationsAt(ctx context.Context, sha string) (*ipam.AllocationsDatabase, error) {
+	key := fmt.Sprintf("%s|%s/%s|%s@%s", c.client.BaseURL, c.owner, c.repo, c.allocationsFile, sha)
+	if cached, ok := allocationsSnapshots.Load(key); ok {
+		return cached.(*ipam.AllocationsDatabase), nil
+	}
+
+	fileContent, _, resp, err := c.client.Repositories.GetContents(
+		ctx,
+		c.owner,
+		c.repo,
+		c.allocationsFile,
+		&github.RepositoryContentGetOptions{Ref: sha},
+	)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			db := ipam.NewAllocationsDatabase()
+			allocationsSnapshots.Store(key, db)
+			return db, nil
+		}
+		return nil, fmt.Errorf("failed to get allocations file at %s: %w", sha, err)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(*fileContent.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode allocations content at %s: %w", sha, err)
+	}
+
+	// Old revisions are read leniently whatever tolerate_unknown_fields
+	// says: they may predate fields that have since been renamed
+	db, err := ipam.ParseAllocations(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse allocations YAML at %s: %w", sha, err)
+	}
+	allocationsSnapshots.Store(key, db)
+	return db, nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+)
+
+const (
+	historyOneAllocation = `version: "1.1"
+allocations:
+  prod:
+    - cidr: 10.0.0.0/24
+      id: id-1
+      name: vpc-a
+`
+	historyTwoAllocations = historyOneAllocation + `    - cidr: 10.0.1.0/24
+      id: id-2
+      name: vpc-b
+`
+)
+
+func historyRepo() *fakeRepo {
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakeRepo(map[string]string{"allocations.yaml": historyTwoAllocations})
+	repo.history = []fakeCommit{
+		{sha: "c2", date: base.Add(48 * time.Hour), files: map[string]string{"allocations.yaml": historyTwoAllocations}},
+		{sha: "c1", date: base.Add(24 * time.Hour), files: map[string]string{"allocations.yaml": historyOneAllocation}},
+		{sha: "c0", date: base, files: map[string]string{"allocations.yaml": "version: \"1.1\"\nallocations: {}\n"}},
+	}
+	return repo
+}
+
+func TestGetAllocationsHistory_ReflectsGrowth(t *testing.T) {
+	repo := historyRepo()
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+
+	snapshots, err := c.GetAllocationsHistory(context.Background(), time.Time{}, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	points := ipam.UtilizationSeries(ipam.PoolDefinition{CIDR: []string{"10.0.0.0/22"}}, "prod", snapshots)
+	if len(points) != 3 {
+		t.Fatalf("expected 3 points, got %d", len(points))
+	}
+	for i, want := range []int{0, 1, 2} {
+		if points[i].Allocations != want {
+			t.Errorf("point %d: expected %d allocations, got %d", i, want, points[i].Allocations)
+		}
+	}
+	if points[2].CommitSHA != "c2" || points[2].Utilization != 50 {
+		t.Errorf("expected the newest point last at 50%%, got %+v", points[2])
+	}
+}
+
+func TestGetAllocationsHistory_Bounds(t *testing.T) {
+	repo := historyRepo()
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+
+	snapshots, err := c.GetAllocationsHistory(context.Background(), time.Time{}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].CommitSHA != "c2" || snapshots[1].CommitSHA != "c1" {
+		t.Errorf("expected the 2 newest commits, got %+v", snapshots)
+	}
+
+	since := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	snapshots, err = c.GetAllocationsHistory(context.Background(), since, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].CommitSHA != "c2" {
+		t.Errorf("expected only the commit after since, got %+v", snapshots)
+	}
+}
+
+func TestGetAllocationsHistory_CachesSnapshots(t *testing.T) {
+	repo := historyRepo()
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetAllocationsHistory(context.Background(), time.Time{}, 10); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if repo.refReads != 3 {
+		t.Errorf("expected each commit's file to be read once, got %d reads", repo.refReads)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/google/go-github/v57/github"
+)
+
+// GetPoolsWithProposed reads pools like GetPools and, with include_open_prs
+// set, adds the pools that open pull requests against the branch would add,
+// read at each pull request's head and marked provisional. It is for
+// plan-time reads only: writes go through GetPools and GetPoolsWithSHA, so
+// nothing read from a pull request is ever written or written to.
+func (c *GitHubClient) GetPoolsWithProposed(ctx context.Context) (*ipam.PoolsConfig, error) {
+	pools, err := c.GetPools(ctx)
+	if err != nil || !c.opts.IncludeOpenPRs {
+		return pools, err
+	}
+
+	prs, err := c.openPullRequests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pr := range prs {
+		proposed, err := c.poolsAt(ctx, pr.GetHead().GetSHA())
+		if err != nil {
+			// A pull request with a missing or broken pools file proposes
+			// nothing; it must not fail plans on the branch
+			continue
+		}
+		pools.AddProposed(proposed, pr.GetNumber())
+	}
+	return pools, nil
+}
+
+// openPullRequests lists open pull requests targeting the branch, oldest
+// first, so the first to propose a pool keeps it.
+func (c *GitHubClient) openPullRequests(ctx context.Context) ([]*github.PullRequest, error) {
+	owner, repo, branch := c.PoolsLocation()
+	opts := &github.PullRequestListOptions{
+		State:       "open",
+		Base:        branch,
+		Sort:        "created",
+		Direction:   "asc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var all []*github.PullRequest
+	for {
+		prs, resp, err := c.client.PullRequests.List(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list open pull requests: %w", err)
+		}
+		all = append(all, prs...)
+		if resp == nil || resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// poolsAt reads the pools file, or every file it matches, at ref.
+func (c *GitHubClient) poolsAt(ctx context.Context, ref string) (*ipam.PoolsConfig, error) {
+	if isPoolsPattern(c.poolsFile) {
+		return c.getMergedPools(ctx, ref)
+	}
+	return c.readPoolsFile(ctx, c.poolsFile, ref)
+}
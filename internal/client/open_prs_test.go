@@ -0,0 +1,87 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"testing"
+)
+
+const branchPools = "pools:\n  prod:\n    cidr: [\"10.0.0.0/16\"]\n"
+
+func newRepoWithOpenPR() *fakeRepo {
+	repo := newFakeRepo(map[string]string{"config/pools.yaml": branchPools})
+	repo.pulls = []fakePull{
+		{
+			number:  7,
+			base:    "main",
+			headSHA: "head7",
+			files: map[string]string{
+				"config/pools.yaml": branchPools + "  staging:\n    cidr: [\"10.1.0.0/16\"]\n",
+			},
+		},
+		{
+			number:  8,
+			base:    "release",
+			headSHA: "head8",
+			files: map[string]string{
+				"config/pools.yaml": branchPools + "  other:\n    cidr: [\"10.2.0.0/16\"]\n",
+			},
+		},
+		{
+			number:  9,
+			base:    "main",
+			headSHA: "head9",
+			files:   map[string]string{"config/pools.yaml": "pools: [not, a, mapping"},
+		},
+	}
+	return repo
+}
+
+func TestGetPoolsWithProposed_IncludesOpenPRPools(t *testing.T) {
+	repo := newRepoWithOpenPR()
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+	c.opts.IncludeOpenPRs = true
+
+	pools, err := c.GetPoolsWithProposed(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := pools.GetPool("staging"); !ok {
+		t.Fatalf("expected staging from the open PR to be visible, got %v", pools.Pools)
+	}
+	if pr, ok := pools.ProposedIn("staging"); !ok || pr != 7 {
+		t.Errorf("expected staging to be provisional from PR #7, got %d %v", pr, ok)
+	}
+	if _, ok := pools.ProposedIn("prod"); ok {
+		t.Error("expected prod not to be provisional")
+	}
+	if _, ok := pools.GetPool("other"); ok {
+		t.Error("expected pools from PRs against other branches to be ignored")
+	}
+
+	// Write paths never see provisional pools, and nothing is written
+	base, err := c.GetPools(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := base.GetPool("staging"); ok {
+		t.Error("expected GetPools to exclude provisional pools")
+	}
+	if repo.writes != 0 {
+		t.Errorf("expected no writes, got %d", repo.writes)
+	}
+}
+
+func TestGetPoolsWithProposed_Disabled(t *testing.T) {
+	c := newRepoWithOpenPR().client(t, "config/pools.yaml", "config/allocations.yaml")
+
+	pools, err := c.GetPoolsWithProposed(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := pools.GetPool("staging"); ok {
+		t.Error("expected open PRs to be ignored without include_open_prs")
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/google/go-github/v57/github"
+	"gopkg.in/yaml.v3"
+)
+
+// isPoolsPattern reports whether pools_file names several files: a glob
+// such as network/pools/*.yaml, or a directory ending in "/".
+func isPoolsPattern(poolsFile string) bool {
+	return strings.HasSuffix(poolsFile, "/") || strings.ContainsAny(poolsFile, "*?[")
+}
+
+// splitPoolsPattern returns the directory to list and the patterns its
+// files must match. Only the final path element may contain wildcards.
+func splitPoolsPattern(poolsFile string) (string, []string, error) {
+	if strings.HasSuffix(poolsFile, "/") {
+		dir := strings.TrimSuffix(poolsFile, "/")
+		return dir, []string{path.Join(dir, "*.yaml"), path.Join(dir, "*.yml")}, nil
+	}
+
+	dir := path.Dir(poolsFile)
+	if strings.ContainsAny(dir, "*?[") {
+		return "", nil, fmt.Errorf("pools_file %q: wildcards are only supported in the file name", poolsFile)
+	}
+	if _, err := path.Match(poolsFile, ""); err != nil {
+		return "", nil, fmt.Errorf("pools_file %q: %w", poolsFile, err)
+	}
+	return dir, []string{poolsFile}, nil
+}
+
+// selectPoolsFiles returns the paths of directory entries that are files
+// matching any of the patterns, sorted.
+func selectPoolsFiles(entries []*github.RepositoryContent, patterns []string) []string {
+	var paths []string
+	for _, entry := range entries {
+		if entry.GetType() != "file" {
+			continue
+		}
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, entry.GetPath()); ok {
+				paths = append(paths, entry.GetPath())
+				break
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// getMergedPools reads every file matched by pools_file at ref and merges
+// them. A missing directory yields an empty configuration; nothing is created.
+func (c *GitHubClient) getMergedPools(ctx context.Context, ref string) (*ipam.PoolsConfig, error) {
+	dir, patterns, err := splitPoolsPattern(c.poolsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, repo, _ := c.PoolsLocation()
+	_, entries, resp, err := c.client.Repositories.GetContents(
+		ctx,
+		owner,
+		repo,
+		dir,
+		&github.RepositoryContentGetOptions{Ref: ref},
+	)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return ipam.NewPoolsConfig(), nil
+		}
+		return nil, fmt.Errorf("failed to list pools directory %s: %w", dir, err)
+	}
+
+	files := make(map[string]*ipam.PoolsConfig)
+	for _, filePath := range selectPoolsFiles(entries, patterns) {
+		pools, err := c.readPoolsFile(ctx, filePath, ref)
+		if err != nil {
+			return nil, err
+		}
+		files[filePath] = pools
+	}
+
+	merged, err := ipam.MergePoolsConfigs(files)
+	if err != nil {
+		return nil, err
+	}
+	// Each file is valid on its own; pools in different files may still
+	// overlap or avoid unknown pools
+	if err := merged.ValidatePools(); err != nil {
+		return nil, fmt.Errorf("invalid pools files %s: %w", c.poolsFile, err)
+	}
+	return merged, nil
+}
+
+// readPoolsFile reads and parses a single pools file at ref.
+func (c *GitHubClient) readPoolsFile(ctx context.Context, filePath, ref string) (*ipam.PoolsConfig, error) {
+	owner, repo, _ := c.PoolsLocation()
+	fileContent, _, _, err := c.client.Repositories.GetContents(
+		ctx,
+		owner,
+		repo,
+		filePath,
+		&github.RepositoryContentGetOptions{Ref: ref},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pools file %s: %w", filePath, err)
+	}
+	if fileContent == nil {
+		return nil, fmt.Errorf("pools file %s is not a file", filePath)
+	}
+
+	content, err := fileContent.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode pools file %s: %w", filePath, err)
+	}
+
+	var pools ipam.PoolsConfig
+	if !isBlank(ctx, filePath, ref, []byte(content)) {
+		if err := yaml.Unmarshal([]byte(content), &pools); err != nil {
+			return nil, fmt.Errorf("failed to parse pools file %s: %w", filePath, err)
+		}
+	}
+	if pools.Pools == nil {
+		pools.Pools = make(map[string]ipam.PoolDefinition)
+	}
+	// Pools may avoid or overlap pools in other files; getMergedPools
+	// checks those once every file is read
+	if err := pools.ValidateEachPool(); err != nil {
+		return nil, fmt.Errorf("invalid pools file %s: %w", filePath, err)
+	}
+
+	return &pools, nil
+}
+
+// poolsWritePath returns the single file that pool writes go to.
+func (c *GitHubClient) poolsWritePath() (string, error) {
+	if c.opts.PoolsWriteFile != "" {
+		return c.opts.PoolsWriteFile, nil
+	}
+	if isPoolsPattern(c.poolsFile) {
+		return "", fmt.Errorf("pools_write_file must be set to manage pools when pools_file is a pattern (%s)", c.poolsFile)
+	}
+	return c.poolsFile, nil
+}
+
+// PoolsSplitAcrossFiles reports whether pools are read from several files,
+// in which case the writable file holds only some of them.
+func (c *GitHubClient) PoolsSplitAcrossFiles() bool {
+	return isPoolsPattern(c.poolsFile)
+}
@@ -0,0 +1,209 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+)
+
+// newFakeContentsClient returns a client backed by a fake Contents API
+// serving the given files.
+func newFakeContentsClient(t *testing.T, poolsFile string, files map[string]string) *GitHubClient {
+	t.Helper()
+	return newFakeRepo(files).client(t, poolsFile, "config/allocations.yaml")
+}
+
+func TestGetPools_MergesGlob(t *testing.T) {
+	c := newFakeContentsClient(t, "network/pools/*.yaml", map[string]string{
+		"network/pools/team-a.yaml": "pools:\n  team-a:\n    cidr: [\"10.0.0.0/16\"]\n",
+		"network/pools/team-b.yaml": "pools:\n  team-b:\n    cidr: [\"10.1.0.0/16\"]\n",
+		"network/pools/README.md":   "not a pools file",
+	})
+
+	pools, err := c.GetPools(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pools.Pools) != 2 {
+		t.Fatalf("expected 2 pools, got %v", pools.Pools)
+	}
+	if pool, exists := pools.GetPool("team-b"); !exists || pool.CIDR[0] != "10.1.0.0/16" {
+		t.Errorf("expected team-b with 10.1.0.0/16, got %v", pool)
+	}
+}
+
+func TestGetPools_MergesDirectory(t *testing.T) {
+	c := newFakeContentsClient(t, "network/pools/", map[string]string{
+		"network/pools/a.yaml": "pools:\n  a:\n    cidr: [\"10.0.0.0/16\"]\n",
+		"network/pools/b.yml":  "pools:\n  b:\n    cidr: [\"10.1.0.0/16\"]\n",
+	})
+
+	pools, err := c.GetPools(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pools.Pools) != 2 {
+		t.Errorf("expected 2 pools, got %v", pools.Pools)
+	}
+}
+
+func TestGetPools_DuplicateAcrossFiles(t *testing.T) {
+	c := newFakeContentsClient(t, "network/pools/*.yaml", map[string]string{
+		"network/pools/a.yaml": "pools:\n  shared:\n    cidr: [\"10.0.0.0/16\"]\n",
+		"network/pools/b.yaml": "pools:\n  shared:\n    cidr: [\"10.1.0.0/16\"]\n",
+	})
+
+	_, err := c.GetPools(context.Background())
+	if err == nil {
+		t.Fatal("expected error for duplicate pool ID")
+	}
+	if !strings.Contains(err.Error(), "pool shared is defined in both network/pools/a.yaml and network/pools/b.yaml") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestGetPools_MissingDirectory(t *testing.T) {
+	c := newFakeContentsClient(t, "network/pools/*.yaml", map[string]string{})
+
+	pools, err := c.GetPools(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pools.Pools) != 0 {
+		t.Errorf("expected no pools, got %v", pools.Pools)
+	}
+}
+
+func TestSplitPoolsPattern(t *testing.T) {
+	dir, patterns, err := splitPoolsPattern("network/pools/*.yaml")
+	if err != nil || dir != "network/pools" || len(patterns) != 1 {
+		t.Errorf("unexpected result: %q %v %v", dir, patterns, err)
+	}
+
+	if _, _, err := splitPoolsPattern("network/*/pools.yaml"); err == nil {
+		t.Error("expected error for wildcard in directory")
+	}
+	if _, _, err := splitPoolsPattern("network/[pools.yaml"); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
+
+func TestPoolsWritePath(t *testing.T) {
+	single := &GitHubClient{poolsFile: "config/pools.yaml"}
+	if p, err := single.poolsWritePath(); err != nil || p != "config/pools.yaml" {
+		t.Errorf("expected pools file as write path, got %q %v", p, err)
+	}
+
+	split := &GitHubClient{poolsFile: "network/pools/*.yaml"}
+	if _, err := split.poolsWritePath(); err == nil {
+		t.Error("expected error when pools_write_file is unset for a pattern")
+	}
+
+	split.opts.PoolsWriteFile = "network/pools/terraform.yaml"
+	if p, err := split.poolsWritePath(); err != nil || p != "network/pools/terraform.yaml" {
+		t.Errorf("expected configured write file, got %q %v", p, err)
+	}
+}
+
+func TestUpdatePools_PreservesComments(t *testing.T) {
+	original := "pools:\n  # Owned by the network team\n  prod:\n    cidr: [\"10.0.0.0/16\"]\n  dev:\n    cidr: [\"10.1.0.0/16\"]\n"
+	repo := newFakeRepo(map[string]string{"config/pools.yaml": original})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+	c.opts.PreservePoolsFormat = true
+	ctx := context.Background()
+
+	pools, sha, err := c.GetPoolsWithSHA(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pools.AddPool("staging", ipam.PoolDefinition{CIDR: []string{"10.2.0.0/16"}})
+	if err := c.UpdatePools(ctx, pools, sha, "add staging"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(repo.files["config/pools.yaml"], "# Owned by the network team\n  prod:") {
+		t.Errorf("expected comment to survive AddPool, got:\n%s", repo.files["config/pools.yaml"])
+	}
+
+	pools, sha, err = c.GetPoolsWithSHA(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pools.RemovePool("dev"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.UpdatePools(ctx, pools, sha, "remove dev"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := repo.files["config/pools.yaml"]
+	if !strings.Contains(content, "# Owned by the network team") || strings.Contains(content, "dev:") {
+		t.Errorf("expected comment kept and dev removed, got:\n%s", content)
+	}
+}
+
+func TestUpdatePools_DropsCommentsByDefault(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"config/pools.yaml": "pools:\n  # note\n  prod:\n    cidr: [\"10.0.0.0/16\"]\n"})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+	ctx := context.Background()
+
+	pools, sha, err := c.GetPoolsWithSHA(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.UpdatePools(ctx, pools, sha, "rewrite"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(repo.files["config/pools.yaml"], "# note") {
+		t.Errorf("expected plain marshal without preserve_pools_format, got:\n%s", repo.files["config/pools.yaml"])
+	}
+}
+
+func TestGetPools_OverlapAcrossFiles(t *testing.T) {
+	c := newFakeContentsClient(t, "network/pools/*.yaml", map[string]string{
+		"network/pools/a.yaml": "pools:\n  a:\n    cidr: [\"10.0.0.0/16\"]\n",
+		"network/pools/b.yaml": "pools:\n  b:\n    cidr: [\"10.0.128.0/17\"]\n",
+	})
+
+	_, err := c.GetPools(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "overlap") {
+		t.Errorf("expected an overlap error, got %v", err)
+	}
+}
+
+func TestGetPools_AvoidPoolsAcrossFiles(t *testing.T) {
+	c := newFakeContentsClient(t, "network/pools/*.yaml", map[string]string{
+		"network/pools/a.yaml": "pools:\n  a:\n    cidr: [\"10.0.0.0/16\"]\n    avoid_pools: [b]\n",
+		"network/pools/b.yaml": "pools:\n  b:\n    cidr: [\"10.1.0.0/16\"]\n",
+	})
+	c.opts.PoolsWriteFile = "network/pools/a.yaml"
+	ctx := context.Background()
+
+	pools, err := c.GetPools(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a, ok := pools.GetPool("a"); !ok || len(a.AvoidPools) != 1 {
+		t.Errorf("expected pool a to avoid b, got %+v", pools.Pools)
+	}
+
+	// The write file alone names a pool it doesn't define
+	if _, _, err := c.GetPoolsWithSHA(ctx); err != nil {
+		t.Errorf("expected the write file to load, got %v", err)
+	}
+}
+
+func TestGetPools_AvoidUnknownPoolAcrossFiles(t *testing.T) {
+	c := newFakeContentsClient(t, "network/pools/*.yaml", map[string]string{
+		"network/pools/a.yaml": "pools:\n  a:\n    cidr: [\"10.0.0.0/16\"]\n    avoid_pools: [missing]\n",
+		"network/pools/b.yaml": "pools:\n  b:\n    cidr: [\"10.1.0.0/16\"]\n",
+	})
+
+	_, err := c.GetPools(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "avoids unknown pool missing") {
+		t.Errorf("expected an unknown pool error, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+)
+
+const platformPools = `pools:
+  prod:
+    cidr: [10.0.0.0/16]
+`
+
+// newSplitRepos returns a client reading pools from platform/pools and
+// keeping allocations in owner/repo.
+func newSplitRepos(t *testing.T) (*GitHubClient, *fakeRepo, *fakeRepo) {
+	t.Helper()
+	pools := newFakeRepo(map[string]string{"pools.yaml": platformPools})
+	pools.fullName = "platform/pools"
+	app := newFakeRepo(nil)
+
+	c := fakeClient(t, fakeHost(pools, app), "pools.yaml", "allocations.yaml")
+	c.opts.PoolsOwner = "platform"
+	c.opts.PoolsRepo = "pools"
+	return c, pools, app
+}
+
+func TestPoolsRepository_ReadsPoolsFromPoolsRepo(t *testing.T) {
+	c, _, app := newSplitRepos(t)
+	ctx := context.Background()
+
+	pools, err := c.GetPools(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := pools.Pools["prod"]; !ok {
+		t.Fatalf("expected the prod pool from platform/pools, got %v", pools.Pools)
+	}
+	if _, ok := app.file("pools.yaml"); ok {
+		t.Error("expected no pools file to be created in the allocations repository")
+	}
+}
+
+func TestPoolsRepository_WritesAllocationsToOwnRepo(t *testing.T) {
+	c, pools, app := newSplitRepos(t)
+	ctx := context.Background()
+
+	db := ipam.NewAllocationsDatabase()
+	db.AddAllocation("prod", ipam.Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc"})
+	if err := c.UpdateAllocations(ctx, db, "", "Allocate vpc"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, ok := app.file("allocations.yaml")
+	if !ok || !strings.Contains(content, "10.0.0.0/24") {
+		t.Errorf("expected the allocation in owner/repo, got %q", content)
+	}
+	if _, ok := pools.file("allocations.yaml"); ok {
+		t.Error("expected no allocations file in platform/pools")
+	}
+}
+
+func TestPoolsRepository_WritesPoolsToPoolsRepo(t *testing.T) {
+	c, pools, app := newSplitRepos(t)
+	ctx := context.Background()
+
+	config, sha, err := c.GetPoolsWithSHA(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config.AddPool("staging", ipam.PoolDefinition{CIDR: []string{"10.1.0.0/16"}})
+	if err := c.UpdatePools(ctx, config, sha, "Add staging"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if content, _ := pools.file("pools.yaml"); !strings.Contains(content, "10.1.0.0/16") {
+		t.Errorf("expected the staging pool in platform/pools, got %q", content)
+	}
+	if app.writes != 0 {
+		t.Errorf("expected no writes to owner/repo, got %d", app.writes)
+	}
+}
+
+func TestPoolsLocation_DefaultsToOwnRepo(t *testing.T) {
+	c := newFakeRepo(nil).client(t, "pools.yaml", "allocations.yaml")
+	c.opts.PoolsBranch = "pools"
+
+	owner, repo, branch := c.PoolsLocation()
+	if owner != "owner" || repo != "repo" || branch != "pools" {
+		t.Errorf("expected owner/repo@pools, got %s/%s@%s", owner, repo, branch)
+	}
+}
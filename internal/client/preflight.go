@@ -0,0 +1,142 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	// ErrRepositoryNotFound means the configured repository does not exist
+	// or is not visible to the token.
+	ErrRepositoryNotFound = errors.New("repository not found")
+
+	// ErrBranchNotFound means the repository exists but the configured
+	// branch does not.
+	ErrBranchNotFound = errors.New("branch not found")
+)
+
+// locationChecks caches CheckLocation results per API endpoint, repository
+// and branch, so provider instances sharing a repository check it once.
+var locationChecks sync.Map
+
+type locationResult struct {
+	err         error
+	owner, repo string // Canonical location, when the check passed
+	canWrite    bool   // Whether the token may write, when the check passed
+}
+
+// CheckLocation verifies that the configured repository and branch exist,
+// so a typo surfaces as a precise error instead of a 404 on every file
+// read. Definite answers are cached; transient failures are not.
+//
+// GitHub matches repository names case-insensitively and redirects reads
+// of a renamed or transferred repository, but not writes. The check
+// switches the client to the canonical owner and name the API reports, so
+// later calls go straight to the repository; see Location.
+func (c *GitHubClient) CheckLocation(ctx context.Context) error {
+	key := fmt.Sprintf("%s|%s/%s|%s", c.client.BaseURL, c.owner, c.repo, c.branch)
+	if cached, ok := locationChecks.Load(key); ok {
+		result := cached.(locationResult)
+		if result.err == nil {
+			c.owner, c.repo = result.owner, result.repo
+			c.canWrite = result.canWrite
+		}
+		return result.err
+	}
+
+	err := c.checkLocation(ctx)
+	if err == nil || errors.Is(err, ErrRepositoryNotFound) || errors.Is(err, ErrBranchNotFound) {
+		locationChecks.Store(key, locationResult{err: err, owner: c.owner, repo: c.repo, canWrite: c.canWrite})
+	}
+	return err
+}
+
+// TokenCanWrite reports whether the token may write to the repository, as
+// found by CheckLocation; see tokenCanWrite. It is true until a check has
+// passed.
+func (c *GitHubClient) TokenCanWrite() bool {
+	return c.canWrite
+}
+
+// Location returns the owner and repository the client talks to.
+func (c *GitHubClient) Location() (owner, repo string) {
+	return c.owner, c.repo
+}
+
+// PoolsLocation returns where the pools file lives: the pools repository
+// options, falling back to the client's own owner, repository and branch.
+func (c *GitHubClient) PoolsLocation() (owner, repo, branch string) {
+	owner, repo, branch = c.owner, c.repo, c.branch
+	if c.opts.PoolsOwner != "" {
+		owner = c.opts.PoolsOwner
+	}
+	if c.opts.PoolsRepo != "" {
+		repo = c.opts.PoolsRepo
+	}
+	if c.opts.PoolsBranch != "" {
+		branch = c.opts.PoolsBranch
+	}
+	return owner, repo, branch
+}
+
+func (c *GitHubClient) checkLocation(ctx context.Context) error {
+	repo, resp, err := c.client.Repositories.Get(ctx, c.owner, c.repo)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return fmt.Errorf("%w: %s/%s does not exist or is not visible to the configured token", ErrRepositoryNotFound, c.owner, c.repo)
+		}
+		return fmt.Errorf("failed to get repository %s/%s: %w", c.owner, c.repo, err)
+	}
+
+	if owner, name := repo.GetOwner().GetLogin(), repo.GetName(); owner != "" && name != "" && (owner != c.owner || name != c.repo) {
+		tflog.Info(ctx, "Using canonical repository location", map[string]interface{}{
+			"configured": c.owner + "/" + c.repo,
+			"canonical":  owner + "/" + name,
+		})
+		c.owner, c.repo = owner, name
+	}
+	c.canWrite = tokenCanWrite(resp, repo)
+
+	_, resp, err = c.client.Repositories.GetBranch(ctx, c.owner, c.repo, c.branch, 1)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return fmt.Errorf("%w: %s/%s has no branch %q (default branch is %q)", ErrBranchNotFound, c.owner, c.repo, c.branch, repo.GetDefaultBranch())
+		}
+		return fmt.Errorf("failed to get branch %q of %s/%s: %w", c.branch, c.owner, c.repo, err)
+	}
+	return nil
+}
+
+// PoolsFileExists reports whether the configured pools file is present on
+// the branch. A missing file is not an error, since the first read creates
+// it, but on an existing repository it usually means a wrong pools_file.
+// Globs and directories are always reported as present.
+func (c *GitHubClient) PoolsFileExists(ctx context.Context) (bool, error) {
+	if isPoolsPattern(c.poolsFile) {
+		return true, nil
+	}
+
+	owner, repo, branch := c.PoolsLocation()
+	_, _, resp, err := c.client.Repositories.GetContents(
+		ctx,
+		owner,
+		repo,
+		c.poolsFile,
+		&github.RepositoryContentGetOptions{Ref: branch},
+	)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get pools file %s: %w", c.poolsFile, err)
+	}
+	return true, nil
+}
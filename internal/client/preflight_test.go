@@ -0,0 +1,184 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckLocation(t *testing.T) {
+	repo := newFakeRepo(nil)
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+
+	if err := c.CheckLocation(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The result is cached for the same repository and branch
+	lookups := repo.lookups
+	if err := c.CheckLocation(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.lookups != lookups {
+		t.Errorf("expected cached result, got %d more lookups", repo.lookups-lookups)
+	}
+}
+
+func TestCheckLocation_WrongRepository(t *testing.T) {
+	c := newFakeRepo(nil).client(t, "pools.yaml", "allocations.yaml")
+	c.repo = "repo-typo"
+
+	err := c.CheckLocation(context.Background())
+	if !errors.Is(err, ErrRepositoryNotFound) {
+		t.Fatalf("expected ErrRepositoryNotFound, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "owner/repo-typo") {
+		t.Errorf("expected repository in error, got %v", err)
+	}
+}
+
+func TestCheckLocation_WrongBranch(t *testing.T) {
+	c := newFakeRepo(nil).client(t, "pools.yaml", "allocations.yaml")
+	c.branch = "mian"
+
+	err := c.CheckLocation(context.Background())
+	if !errors.Is(err, ErrBranchNotFound) {
+		t.Fatalf("expected ErrBranchNotFound, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `no branch "mian"`) || !strings.Contains(err.Error(), `default branch is "main"`) {
+		t.Errorf("expected branch details in error, got %v", err)
+	}
+}
+
+func TestPoolsFileExists(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"config/pools.yaml": "pools: {}\n"})
+
+	exists, err := repo.client(t, "config/pools.yaml", "allocations.yaml").PoolsFileExists(context.Background())
+	if err != nil || !exists {
+		t.Errorf("expected pools file to exist, got %v, %v", exists, err)
+	}
+
+	exists, err = repo.client(t, "config/pool.yaml", "allocations.yaml").PoolsFileExists(context.Background())
+	if err != nil || exists {
+		t.Errorf("expected missing pools file, got %v, %v", exists, err)
+	}
+	if _, ok := repo.file("config/pool.yaml"); ok {
+		t.Error("expected the check not to create the file")
+	}
+}
+
+func TestCheckLocation_RenamedRepository(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"allocations.yaml": "version: \"1.1\"\nallocations: {}\n"})
+	repo.movedFrom = "old-owner/old-repo"
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+	c.owner, c.repo = "old-owner", "old-repo"
+
+	if err := c.CheckLocation(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if owner, name := c.Location(); owner != "owner" || name != "repo" {
+		t.Fatalf("expected the canonical owner/repo, got %s/%s", owner, name)
+	}
+
+	if repo.redirects == 0 {
+		t.Fatal("expected the check to be redirected")
+	}
+
+	// Later calls go straight to the new location
+	redirects := repo.redirects
+	if _, _, err := c.GetAllocations(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.redirects != redirects {
+		t.Errorf("expected no more redirects, got %d", repo.redirects-redirects)
+	}
+}
+
+func TestCheckLocation_CachedCanonicalLocation(t *testing.T) {
+	repo := newFakeRepo(nil)
+	repo.movedFrom = "old-owner/old-repo"
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+	c.owner, c.repo = "old-owner", "old-repo"
+	if err := c.CheckLocation(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A second client for the same configured location reuses the result
+	c.owner, c.repo = "old-owner", "old-repo"
+	lookups := repo.lookups
+	if err := c.CheckLocation(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.lookups != lookups {
+		t.Errorf("expected cached result, got %d more lookups", repo.lookups-lookups)
+	}
+	if owner, name := c.Location(); owner != "owner" || name != "repo" {
+		t.Errorf("expected the cached canonical owner/repo, got %s/%s", owner, name)
+	}
+}
+
+func TestCheckLocation_TokenCanWrite(t *testing.T) {
+	scopes := func(s string) *string { return &s }
+	for _, tc := range []struct {
+		name       string
+		scopes     *string
+		private    bool
+		pushDenied bool
+		canWrite   bool
+	}{
+		{"classic repo scope", scopes("read:org, repo"), true, false, true},
+		{"classic public_repo on a public repository", scopes("public_repo"), false, false, true},
+		{"classic public_repo on a private repository", scopes("public_repo"), true, false, false},
+		{"classic without repo scope", scopes("read:org"), false, false, false},
+		{"classic repo scope without push", scopes("repo"), true, true, false},
+		{"fine-grained with push", nil, true, false, true},
+		{"fine-grained without push", nil, true, true, false},
+	} {
+		repo := newFakeRepo(nil)
+		repo.oauthScopes, repo.private, repo.pushDenied = tc.scopes, tc.private, tc.pushDenied
+		c := repo.client(t, "pools.yaml", "allocations.yaml")
+
+		if err := c.CheckLocation(context.Background()); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if got := c.TokenCanWrite(); got != tc.canWrite {
+			t.Errorf("%s: expected TokenCanWrite %v, got %v", tc.name, tc.canWrite, got)
+		}
+	}
+}
+
+func TestReadOnlyClient(t *testing.T) {
+	repo := newFakeRepo(map[string]string{"allocations.yaml": "allocations: {}\n"})
+	c := repo.client(t, "pools.yaml", "allocations.yaml")
+	c.opts.ReadOnly = true
+	ctx := context.Background()
+
+	// Reads work, and the missing pools file is not created
+	pools, err := c.GetPools(ctx)
+	if err != nil || len(pools.Pools) != 0 {
+		t.Fatalf("expected empty pools, got %v, %v", pools, err)
+	}
+	db, sha, err := c.GetAllocations(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Writes fail before reaching GitHub
+	err = c.UpdateAllocations(ctx, db, sha, "allocate")
+	if !errors.Is(err, ErrReadOnlyToken) || !strings.Contains(err.Error(), "owner/repo") {
+		t.Errorf("expected ErrReadOnlyToken naming the repository, got %v", err)
+	}
+	if err := c.UpdatePools(ctx, pools, "", "add pool"); !errors.Is(err, ErrReadOnlyToken) {
+		t.Errorf("expected ErrReadOnlyToken, got %v", err)
+	}
+	if repo.writes != 0 {
+		t.Errorf("expected no writes, got %d", repo.writes)
+	}
+	if _, ok := repo.file("pools.yaml"); ok {
+		t.Error("expected the pools file not to be created")
+	}
+}
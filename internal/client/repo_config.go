@@ -0,0 +1,185 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/google/go-github/v57/github"
+	"gopkg.in/yaml.v3"
+)
+
+// RepoConfigPath is the repository file holding provider defaults.
+const RepoConfigPath = ".github/ipam/config.yaml"
+
+// RepoConfig holds provider settings that can be defaulted per repository,
+// so root modules sharing an IPAM repository need not repeat them. Nil
+// fields are unset.
+type RepoConfig struct {
+	PoolsFile            *string  `yaml:"pools_file"`
+	PoolsWriteFile       *string  `yaml:"pools_write_file"`
+	PoolsOwner           *string  `yaml:"pools_owner"`
+	PoolsRepository      *string  `yaml:"pools_repository"`
+	PoolsBranch          *string  `yaml:"pools_branch"`
+	AllocationsFile      *string  `yaml:"allocations_file"`
+	MaxRetries           *int64   `yaml:"max_retries"`
+	ConflictRetries      *int64   `yaml:"conflict_retries"`
+	TransientRetries     *int64   `yaml:"transient_retries"`
+	BaseDelayMs          *int64   `yaml:"base_delay_ms"`
+	AllowPublic          *bool    `yaml:"allow_public"`
+	CommitTrailer        *string  `yaml:"commit_trailer"`
+	DocsDetailLevel      *string  `yaml:"docs_detail_level"`
+	DeferDocs            *bool    `yaml:"defer_docs"`
+	DocsStrict           *bool    `yaml:"docs_strict"`
+	GenerateImportBlocks *bool    `yaml:"generate_import_blocks"`
+	MaxNestingDepth      *int64   `yaml:"max_nesting_depth"`
+	PreservePoolsFormat  *bool    `yaml:"preserve_pools_format"`
+	IncludeOpenPRs       *bool    `yaml:"include_open_prs"`
+	GenerateNetBoxExport *bool    `yaml:"generate_netbox_export"`
+	GenerateChangelog    *bool    `yaml:"generate_changelog"`
+	AllowedRegions       []string `yaml:"allowed_regions"`
+	ArchiveDeletions     *bool    `yaml:"archive_deletions"`
+	TolerateUnknown      *bool    `yaml:"tolerate_unknown_fields"`
+	IDScheme             *string  `yaml:"id_scheme"`
+}
+
+// Overlay returns the config with every field set in explicit replacing
+// the value from the repository.
+func (rc RepoConfig) Overlay(explicit RepoConfig) RepoConfig {
+	out := rc
+	if explicit.PoolsFile != nil {
+		out.PoolsFile = explicit.PoolsFile
+	}
+	if explicit.PoolsWriteFile != nil {
+		out.PoolsWriteFile = explicit.PoolsWriteFile
+	}
+	if explicit.PoolsOwner != nil {
+		out.PoolsOwner = explicit.PoolsOwner
+	}
+	if explicit.PoolsRepository != nil {
+		out.PoolsRepository = explicit.PoolsRepository
+	}
+	if explicit.PoolsBranch != nil {
+		out.PoolsBranch = explicit.PoolsBranch
+	}
+	if explicit.AllocationsFile != nil {
+		out.AllocationsFile = explicit.AllocationsFile
+	}
+	if explicit.MaxRetries != nil {
+		out.MaxRetries = explicit.MaxRetries
+	}
+	if explicit.ConflictRetries != nil {
+		out.ConflictRetries = explicit.ConflictRetries
+	}
+	if explicit.TransientRetries != nil {
+		out.TransientRetries = explicit.TransientRetries
+	}
+	if explicit.BaseDelayMs != nil {
+		out.BaseDelayMs = explicit.BaseDelayMs
+	}
+	if explicit.AllowPublic != nil {
+		out.AllowPublic = explicit.AllowPublic
+	}
+	if explicit.CommitTrailer != nil {
+		out.CommitTrailer = explicit.CommitTrailer
+	}
+	if explicit.DocsDetailLevel != nil {
+		out.DocsDetailLevel = explicit.DocsDetailLevel
+	}
+	if explicit.DeferDocs != nil {
+		out.DeferDocs = explicit.DeferDocs
+	}
+	if explicit.DocsStrict != nil {
+		out.DocsStrict = explicit.DocsStrict
+	}
+	if explicit.GenerateImportBlocks != nil {
+		out.GenerateImportBlocks = explicit.GenerateImportBlocks
+	}
+	if explicit.MaxNestingDepth != nil {
+		out.MaxNestingDepth = explicit.MaxNestingDepth
+	}
+	if explicit.PreservePoolsFormat != nil {
+		out.PreservePoolsFormat = explicit.PreservePoolsFormat
+	}
+	if explicit.IncludeOpenPRs != nil {
+		out.IncludeOpenPRs = explicit.IncludeOpenPRs
+	}
+	if explicit.GenerateNetBoxExport != nil {
+		out.GenerateNetBoxExport = explicit.GenerateNetBoxExport
+	}
+	if explicit.GenerateChangelog != nil {
+		out.GenerateChangelog = explicit.GenerateChangelog
+	}
+	if explicit.AllowedRegions != nil {
+		out.AllowedRegions = explicit.AllowedRegions
+	}
+	if explicit.ArchiveDeletions != nil {
+		out.ArchiveDeletions = explicit.ArchiveDeletions
+	}
+	if explicit.TolerateUnknown != nil {
+		out.TolerateUnknown = explicit.TolerateUnknown
+	}
+	if explicit.IDScheme != nil {
+		out.IDScheme = explicit.IDScheme
+	}
+	return out
+}
+
+// Validate checks the values the provider schema would otherwise check,
+// since settings from the repository bypass it.
+func (rc RepoConfig) Validate() error {
+	if rc.DocsDetailLevel != nil {
+		switch *rc.DocsDetailLevel {
+		case ipam.DocsDetailFull, ipam.DocsDetailSummary:
+		default:
+			return fmt.Errorf("docs_detail_level must be %q or %q, got %q", ipam.DocsDetailFull, ipam.DocsDetailSummary, *rc.DocsDetailLevel)
+		}
+	}
+	if rc.IDScheme != nil {
+		switch *rc.IDScheme {
+		case ipam.IDSchemeRandomUUID, ipam.IDSchemeUUID5, ipam.IDSchemeULID:
+		default:
+			return fmt.Errorf("id_scheme must be %q, %q or %q, got %q", ipam.IDSchemeRandomUUID, ipam.IDSchemeUUID5, ipam.IDSchemeULID, *rc.IDScheme)
+		}
+	}
+	if rc.MaxNestingDepth != nil && *rc.MaxNestingDepth < 1 {
+		return fmt.Errorf("max_nesting_depth must be at least 1, got %d", *rc.MaxNestingDepth)
+	}
+	return nil
+}
+
+// GetRepoConfig reads the repository's provider defaults. A missing file
+// yields an empty config.
+func (c *GitHubClient) GetRepoConfig(ctx context.Context) (*RepoConfig, error) {
+	fileContent, _, resp, err := c.client.Repositories.GetContents(
+		ctx,
+		c.owner,
+		c.repo,
+		RepoConfigPath,
+		&github.RepositoryContentGetOptions{Ref: c.branch},
+	)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return &RepoConfig{}, nil
+		}
+		return nil, fmt.Errorf("failed to get %s: %w", RepoConfigPath, err)
+	}
+	if fileContent == nil || fileContent.Content == nil {
+		return nil, fmt.Errorf("%s is not a file", RepoConfigPath)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(*fileContent.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", RepoConfigPath, err)
+	}
+
+	var config RepoConfig
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", RepoConfigPath, err)
+	}
+	return &config, nil
+}
@@ -0,0 +1,119 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestGetRepoConfig_Defaults(t *testing.T) {
+	c := newFakeRepo(map[string]string{
+		RepoConfigPath: "pools_file: network/pools/\nallocations_file: network/allocations.yaml\nmax_retries: 5\ndefer_docs: true\n",
+	}).client(t, "", "")
+
+	config, err := c.GetRepoConfig(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.PoolsFile == nil || *config.PoolsFile != "network/pools/" {
+		t.Errorf("expected pools_file from config, got %v", config.PoolsFile)
+	}
+	if config.AllocationsFile == nil || *config.AllocationsFile != "network/allocations.yaml" {
+		t.Errorf("expected allocations_file from config, got %v", config.AllocationsFile)
+	}
+	if config.MaxRetries == nil || *config.MaxRetries != 5 {
+		t.Errorf("expected max_retries 5, got %v", config.MaxRetries)
+	}
+	if config.DeferDocs == nil || !*config.DeferDocs {
+		t.Errorf("expected defer_docs true, got %v", config.DeferDocs)
+	}
+	if config.DocsStrict != nil {
+		t.Errorf("expected docs_strict unset, got %v", *config.DocsStrict)
+	}
+}
+
+func TestGetRepoConfig_Absent(t *testing.T) {
+	c := newFakeRepo(nil).client(t, "", "")
+
+	config, err := c.GetRepoConfig(context.Background())
+	if err != nil {
+		t.Fatalf("expected a missing config to be ignored, got %v", err)
+	}
+	if !reflect.DeepEqual(*config, RepoConfig{}) {
+		t.Errorf("expected empty config, got %+v", config)
+	}
+}
+
+func TestGetRepoConfig_Invalid(t *testing.T) {
+	c := newFakeRepo(map[string]string{RepoConfigPath: "max_retries: [not, a, number]\n"}).client(t, "", "")
+
+	if _, err := c.GetRepoConfig(context.Background()); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestRepoConfig_ExplicitOverrides(t *testing.T) {
+	fromRepo := RepoConfig{
+		PoolsFile:  strPtr("network/pools/"),
+		MaxRetries: int64Ptr(5),
+		DeferDocs:  boolPtr(true),
+	}
+	explicit := RepoConfig{
+		PoolsFile: strPtr("config/pools.yaml"),
+		DeferDocs: boolPtr(false),
+	}
+
+	merged := fromRepo.Overlay(explicit)
+	if *merged.PoolsFile != "config/pools.yaml" {
+		t.Errorf("expected explicit pools_file to win, got %s", *merged.PoolsFile)
+	}
+	if *merged.DeferDocs {
+		t.Error("expected explicit defer_docs false to win over the repository's true")
+	}
+	if merged.MaxRetries == nil || *merged.MaxRetries != 5 {
+		t.Errorf("expected max_retries from the repository, got %v", merged.MaxRetries)
+	}
+}
+
+func TestRepoConfig_AllowedRegions(t *testing.T) {
+	c := newFakeRepo(map[string]string{
+		RepoConfigPath: "allowed_regions:\n  - us-east-1\n  - eu-west-1\n",
+	}).client(t, "", "")
+
+	config, err := c.GetRepoConfig(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(config.AllowedRegions, []string{"us-east-1", "eu-west-1"}) {
+		t.Fatalf("expected allowed regions from the repository, got %v", config.AllowedRegions)
+	}
+
+	if merged := config.Overlay(RepoConfig{}); len(merged.AllowedRegions) != 2 {
+		t.Errorf("expected the repository's regions without an explicit list, got %v", merged.AllowedRegions)
+	}
+	if merged := config.Overlay(RepoConfig{AllowedRegions: []string{"ap-south-1"}}); !reflect.DeepEqual(merged.AllowedRegions, []string{"ap-south-1"}) {
+		t.Errorf("expected the explicit list to win, got %v", merged.AllowedRegions)
+	}
+}
+
+func TestRepoConfig_Validate(t *testing.T) {
+	if err := (RepoConfig{DocsDetailLevel: strPtr("verbose")}).Validate(); err == nil {
+		t.Error("expected error for unknown docs_detail_level")
+	}
+	if err := (RepoConfig{MaxNestingDepth: int64Ptr(0)}).Validate(); err == nil {
+		t.Error("expected error for max_nesting_depth 0")
+	}
+	if err := (RepoConfig{IDScheme: strPtr("uuid4")}).Validate(); err == nil {
+		t.Error("expected error for unknown id_scheme")
+	}
+	if err := (RepoConfig{DocsDetailLevel: strPtr("summary"), MaxNestingDepth: int64Ptr(3), IDScheme: strPtr("ulid")}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func strPtr(s string) *string { return &s }
+func int64Ptr(n int64) *int64 { return &n }
+func boolPtr(b bool) *bool    { return &b }
@@ -5,33 +5,44 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
+	"strings"
 	"time"
 
+	"github.com/google/go-github/v57/github"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // RetryConfig holds configuration for exponential backoff retry logic.
+//
+// The two caps are counted separately. MaxRetries bounds the retries a
+// function asks for, which are OCC conflicts; MaxTransientRetries bounds
+// the retries of GitHub 5xx responses, which are retried whether or not
+// the function asks.
 type RetryConfig struct {
-	MaxRetries int
-	BaseDelay  time.Duration
-	MaxDelay   time.Duration
-	JitterPct  float64 // 0.0 to 1.0
+	MaxRetries          int
+	MaxTransientRetries int
+	BaseDelay           time.Duration
+	MaxDelay            time.Duration
+	JitterPct           float64 // 0.0 to 1.0
 }
 
 // DefaultRetryConfig returns the default retry configuration.
 func DefaultRetryConfig() RetryConfig {
 	return RetryConfig{
-		MaxRetries: 10,
-		BaseDelay:  200 * time.Millisecond,
-		MaxDelay:   5 * time.Second,
-		JitterPct:  0.5,
+		MaxRetries:          10,
+		MaxTransientRetries: 3,
+		BaseDelay:           200 * time.Millisecond,
+		MaxDelay:            5 * time.Second,
+		JitterPct:           0.5,
 	}
 }
 
 // NewRetryConfig creates a new retry configuration with custom values.
+// Transient errors are not retried; set MaxTransientRetries to retry them.
 func NewRetryConfig(maxRetries int, baseDelayMs int64) RetryConfig {
 	return RetryConfig{
 		MaxRetries: maxRetries,
@@ -72,37 +83,91 @@ func (c *RetryConfig) CalculateBackoff(attempt int) time.Duration {
 // the operation will be retried. If shouldRetry is false, the operation stops.
 type RetryableFunc func(ctx context.Context, attempt int) (shouldRetry bool, err error)
 
+// retryBudgetRecentErrors is how many error messages a retryBudget keeps.
+const retryBudgetRecentErrors = 3
+
+// retryBudget accumulates what a retry loop spent, so that exhausting the
+// retries reports more than the last error.
+type retryBudget struct {
+	attempts  int
+	conflicts int           // Failures that were GitHub 409 conflicts
+	slept     time.Duration // Total backoff slept between attempts
+	recent    []string      // Most recent error messages, oldest first
+}
+
+// record notes a failed attempt.
+func (b *retryBudget) record(err error) {
+	b.attempts++
+	if isConflictError(err) {
+		b.conflicts++
+	}
+	b.recent = append(b.recent, err.Error())
+	if len(b.recent) > retryBudgetRecentErrors {
+		b.recent = b.recent[len(b.recent)-retryBudgetRecentErrors:]
+	}
+}
+
+// summary returns a one-line description of the budget spent.
+func (b *retryBudget) summary() string {
+	return fmt.Sprintf("%d attempts, %d conflicts, %d other errors, slept %s; last errors: %s",
+		b.attempts, b.conflicts, b.attempts-b.conflicts,
+		b.slept.Round(time.Millisecond), strings.Join(b.recent, "; "))
+}
+
 // WithRetry executes a function with exponential backoff retry logic.
+// Retries the function asks for count against MaxRetries, and transient
+// GitHub errors against MaxTransientRetries; exhausting either cap fails.
 func WithRetry(ctx context.Context, config RetryConfig, fn RetryableFunc) error {
-	var lastErr error
+	var budget retryBudget
+	var retries, transientRetries int
 
-	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
+	for attempt := 0; ; attempt++ {
 		shouldRetry, err := fn(ctx, attempt)
 		if err == nil {
 			return nil
 		}
-		lastErr = err
-
-		if !shouldRetry {
+		budget.record(err)
+
+		message := "Optimistic lock conflict, retrying"
+		switch {
+		case isTransientError(err):
+			if transientRetries >= config.MaxTransientRetries {
+				if config.MaxTransientRetries == 0 {
+					return err
+				}
+				return fmt.Errorf("exceeded transient retries (%d) [%s]: %w", config.MaxTransientRetries, budget.summary(), err)
+			}
+			transientRetries++
+			message = "Transient GitHub error, retrying"
+		case shouldRetry:
+			if retries >= config.MaxRetries {
+				return fmt.Errorf("exceeded max retries (%d) [%s]: %w", config.MaxRetries, budget.summary(), err)
+			}
+			retries++
+		default:
 			return err
 		}
 
-		if attempt < config.MaxRetries {
-			backoff := config.CalculateBackoff(attempt)
-			tflog.Warn(ctx, "Optimistic lock conflict, retrying", map[string]interface{}{
-				"attempt":     attempt + 1,
-				"max_retries": config.MaxRetries,
-				"backoff_ms":  backoff.Milliseconds(),
-			})
-
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(backoff):
-				continue
-			}
+		backoff := config.CalculateBackoff(attempt)
+		tflog.Warn(ctx, message, map[string]interface{}{
+			"attempt":               attempt + 1,
+			"max_retries":           config.MaxRetries,
+			"max_transient_retries": config.MaxTransientRetries,
+			"backoff_ms":            backoff.Milliseconds(),
+		})
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+			budget.slept += backoff
 		}
 	}
+}
 
-	return fmt.Errorf("exceeded max retries (%d): %w", config.MaxRetries, lastErr)
+// isTransientError reports whether err is a GitHub 5xx response, which
+// usually succeeds when repeated.
+func isTransientError(err error) bool {
+	var ghErr *github.ErrorResponse
+	return errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode >= 500
 }
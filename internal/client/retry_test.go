@@ -6,8 +6,13 @@ package client
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/google/go-github/v57/github"
 )
 
 func TestNewRetryConfig(t *testing.T) {
@@ -81,6 +86,54 @@ func TestWithRetry_MaxRetriesExceeded(t *testing.T) {
 	}
 }
 
+func conflictError() error {
+	return &github.ErrorResponse{
+		Response: &http.Response{StatusCode: http.StatusConflict},
+		Message:  "is at abc but expected def",
+	}
+}
+
+func TestWithRetry_BudgetSummary(t *testing.T) {
+	config := NewRetryConfig(3, 10)
+	config.JitterPct = 0 // Deterministic backoff: 10ms + 20ms + 40ms
+
+	err := WithRetry(context.Background(), config, func(ctx context.Context, attempt int) (bool, error) {
+		if attempt == 1 {
+			return true, errors.New("transient read failure")
+		}
+		return true, fmt.Errorf("attempt %d: %w", attempt, conflictError())
+	})
+
+	if err == nil {
+		t.Fatal("expected error after max retries")
+	}
+	msg := err.Error()
+
+	if !strings.Contains(msg, "exceeded max retries (3)") {
+		t.Errorf("missing retry limit: %s", msg)
+	}
+	if !strings.Contains(msg, "4 attempts, 3 conflicts, 1 other errors") {
+		t.Errorf("summary should count conflicts separately: %s", msg)
+	}
+	if !strings.Contains(msg, "slept 70ms") {
+		t.Errorf("summary should report slept time: %s", msg)
+	}
+
+	// Only the most recent errors are kept
+	if strings.Contains(msg, "attempt 0:") {
+		t.Errorf("oldest error should have been dropped: %s", msg)
+	}
+	if !strings.Contains(msg, "transient read failure; attempt 2:") {
+		t.Errorf("recent errors should be listed oldest first: %s", msg)
+	}
+
+	// The last error is still wrapped
+	var ghErr *github.ErrorResponse
+	if !errors.As(err, &ghErr) {
+		t.Error("expected the last conflict error to be wrapped")
+	}
+}
+
 func TestWithRetry_NoRetryOnNonRetryableError(t *testing.T) {
 	config := NewRetryConfig(3, 10)
 	attempts := 0
@@ -255,3 +308,84 @@ func TestCalculateBackoff_ZeroBaseDelay(t *testing.T) {
 		t.Errorf("expected 0 delay with 0 base delay, got %v", result)
 	}
 }
+
+func serverError() error {
+	return &github.ErrorResponse{
+		Response: &http.Response{StatusCode: http.StatusBadGateway},
+		Message:  "Server Error",
+	}
+}
+
+func TestWithRetry_TransientCap(t *testing.T) {
+	config := NewRetryConfig(5, 1)
+	config.MaxTransientRetries = 2
+	attempts := 0
+
+	// Transient errors are retried even when the function does not ask
+	err := WithRetry(context.Background(), config, func(ctx context.Context, attempt int) (bool, error) {
+		attempts++
+		return false, fmt.Errorf("failed to read pools: %w", serverError())
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "exceeded transient retries (2)") {
+		t.Errorf("expected the transient cap to be reported, got %v", err)
+	}
+	if attempts != 3 { // Initial + 2 transient retries, not 5
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestWithRetry_IndependentCaps(t *testing.T) {
+	config := NewRetryConfig(3, 1)
+	config.MaxTransientRetries = 2
+	attempts := 0
+
+	// Three conflicts and two server errors fit their own caps
+	err := WithRetry(context.Background(), config, func(ctx context.Context, attempt int) (bool, error) {
+		attempts++
+		switch attempt {
+		case 0, 2, 4:
+			return true, conflictError()
+		case 1, 3:
+			return false, serverError()
+		}
+		return false, nil
+	})
+
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if attempts != 6 {
+		t.Errorf("expected 6 attempts, got %d", attempts)
+	}
+
+	// A fourth conflict exceeds the conflict cap despite transient budget left
+	attempts = 0
+	err = WithRetry(context.Background(), config, func(ctx context.Context, attempt int) (bool, error) {
+		attempts++
+		return true, conflictError()
+	})
+	if err == nil || !strings.Contains(err.Error(), "exceeded max retries (3)") {
+		t.Errorf("expected the conflict cap to be reported, got %v", err)
+	}
+	if attempts != 4 {
+		t.Errorf("expected 4 attempts, got %d", attempts)
+	}
+}
+
+func TestWithRetry_NoTransientRetries(t *testing.T) {
+	attempts := 0
+
+	err := WithRetry(context.Background(), NewRetryConfig(3, 1), func(ctx context.Context, attempt int) (bool, error) {
+		attempts++
+		return true, serverError()
+	})
+
+	var ghErr *github.ErrorResponse
+	if !errors.As(err, &ghErr) || ghErr.Response.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected the server error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt without transient retries, got %d", attempts)
+	}
+}
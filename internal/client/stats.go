@@ -0,0 +1,100 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// Stats are counters accumulated over the life of a provider instance, for
+// spotting runaway retry loops in CI.
+type Stats struct {
+	APICalls           int64 // GitHub API requests sent, including retries
+	ConflictsRetried   int64 // Writes rejected by a concurrent change
+	AllocationsCreated int64
+	AllocationsDeleted int64
+
+	// RateLimitRemaining is the GitHub API requests left in the current
+	// rate limit window, as last reported by GitHub; -1 before any report.
+	RateLimitRemaining int64
+}
+
+// statsCounters backs Stats. It is shared by pointer so resources and data
+// sources configured from one provider instance count together.
+type statsCounters struct {
+	apiCalls  atomic.Int64
+	conflicts atomic.Int64
+	created   atomic.Int64
+	deleted   atomic.Int64
+
+	rateReported  atomic.Bool
+	rateRemaining atomic.Int64
+}
+
+// countingTransport counts every request sent through it, and notes the
+// rate limit GitHub reports on each response.
+type countingTransport struct {
+	base  http.RoundTripper
+	stats *statsCounters
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.stats.apiCalls.Add(1)
+	resp, err := t.base.RoundTrip(req)
+	if resp != nil {
+		if remaining, convErr := strconv.ParseInt(resp.Header.Get("X-RateLimit-Remaining"), 10, 64); convErr == nil {
+			t.stats.rateRemaining.Store(remaining)
+			t.stats.rateReported.Store(true)
+		}
+	}
+	return resp, err
+}
+
+// countingHTTPClient returns a copy of hc whose requests are counted.
+func countingHTTPClient(hc *http.Client, stats *statsCounters) *http.Client {
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	counted := *hc
+	counted.Transport = &countingTransport{base: base, stats: stats}
+	return &counted
+}
+
+// Stats returns a snapshot of the provider instance's counters.
+func (c *GitHubClient) Stats() Stats {
+	if c.stats == nil {
+		return Stats{RateLimitRemaining: -1}
+	}
+	stats := Stats{
+		APICalls:           c.stats.apiCalls.Load(),
+		ConflictsRetried:   c.stats.conflicts.Load(),
+		AllocationsCreated: c.stats.created.Load(),
+		AllocationsDeleted: c.stats.deleted.Load(),
+		RateLimitRemaining: -1,
+	}
+	if c.stats.rateReported.Load() {
+		stats.RateLimitRemaining = c.stats.rateRemaining.Load()
+	}
+	return stats
+}
+
+// RecordAllocations counts allocations a resource has committed.
+func (c *GitHubClient) RecordAllocations(created, deleted int) {
+	if c.stats == nil {
+		return
+	}
+	c.stats.created.Add(int64(created))
+	c.stats.deleted.Add(int64(deleted))
+}
+
+// countConflict counts err if it is a conflict, and returns it unchanged.
+func (c *GitHubClient) countConflict(err error) error {
+	if c.stats != nil && isConflictError(err) {
+		c.stats.conflicts.Add(1)
+	}
+	return err
+}
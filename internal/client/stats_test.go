@@ -0,0 +1,65 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStats_CountsOperations(t *testing.T) {
+	repo := newFakeRepo(map[string]string{
+		"config/pools.yaml": "pools:\n  prod:\n    cidr: [\"10.0.0.0/16\"]\n",
+	})
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+	ctx := context.Background()
+
+	if _, err := allocateOnce(ctx, c, DefaultRetryConfig(), "prod", "vpc-1", 24); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.RecordAllocations(1, 0)
+
+	// Reads pools, reads allocations, creates the allocations file
+	stats := c.Stats()
+	if stats.APICalls != 3 {
+		t.Errorf("expected 3 API calls, got %d", stats.APICalls)
+	}
+	if stats.ConflictsRetried != 0 || stats.AllocationsCreated != 1 {
+		t.Errorf("expected no conflicts and 1 allocation, got %+v", stats)
+	}
+
+	// A stale SHA is rejected and counted
+	db, _, err := c.GetAllocations(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.UpdateAllocations(ctx, db, "stale", "ipam: test"); !c.IsConflictError(err) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	c.RecordAllocations(0, 2)
+
+	stats = c.Stats()
+	if stats.APICalls != 5 || stats.ConflictsRetried != 1 || stats.AllocationsDeleted != 2 {
+		t.Errorf("expected 5 calls, 1 conflict and 2 deletions, got %+v", stats)
+	}
+}
+
+func TestStats_CountsRetriedConflicts(t *testing.T) {
+	repo := newFakeRepo(map[string]string{
+		"config/pools.yaml": "pools:\n  prod:\n    cidr: [\"10.0.0.0/16\"]\n",
+	})
+	injected := 0
+	repo.injectConflict = func(path string) bool {
+		injected++
+		return injected <= 2
+	}
+	c := repo.client(t, "config/pools.yaml", "config/allocations.yaml")
+
+	if _, err := allocateOnce(context.Background(), c, NewRetryConfig(5, 1), "prod", "vpc-1", 24); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := c.Stats().ConflictsRetried; got != 2 {
+		t.Errorf("expected 2 conflicts retried, got %d", got)
+	}
+}
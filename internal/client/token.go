@@ -0,0 +1,97 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// ErrNoToken is returned when no token source is set.
+var ErrNoToken = errors.New("no GitHub token: set token or token_file, or the GITHUB_TOKEN or GITHUB_TOKEN_FILE environment variable")
+
+// ErrReadOnlyToken is returned by writes from a client whose token may
+// read the repository but not write to it.
+var ErrReadOnlyToken = errors.New("token lacks repo write scope")
+
+// ResolveToken picks the token to authenticate with. Sources are tried in
+// order: the token attribute, the token_file attribute, GITHUB_TOKEN and
+// GITHUB_TOKEN_FILE. Setting both attributes is an error; the environment
+// only fills in when neither is set.
+func ResolveToken(token, tokenFile string) (string, error) {
+	if token != "" && tokenFile != "" {
+		return "", errors.New("token and token_file are mutually exclusive")
+	}
+	switch {
+	case token != "":
+		return token, nil
+	case tokenFile != "":
+		return readTokenFile(tokenFile)
+	}
+	if env := os.Getenv("GITHUB_TOKEN"); env != "" {
+		return env, nil
+	}
+	if env := os.Getenv("GITHUB_TOKEN_FILE"); env != "" {
+		return readTokenFile(env)
+	}
+	return "", ErrNoToken
+}
+
+// readTokenFile reads a token mounted as a file, trimming the surrounding
+// whitespace and trailing newline secret mounts usually carry.
+func readTokenFile(name string) (string, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", name)
+	}
+	return token, nil
+}
+
+// tokenCanWrite reports whether the token a repository was read with may
+// write to it, from the response to that read. Classic tokens list their
+// OAuth scopes in X-OAuth-Scopes: repo allows writing, and public_repo does
+// for public repositories. Fine-grained and GitHub App tokens send no
+// scopes, so only the push permission GitHub reports for the repository
+// decides; a response carrying neither is taken as writable.
+func tokenCanWrite(resp *github.Response, repo *github.Repository) bool {
+	if push, ok := repo.GetPermissions()["push"]; ok && !push {
+		return false
+	}
+	if resp == nil {
+		return true
+	}
+	values, ok := resp.Header["X-Oauth-Scopes"]
+	if !ok {
+		return true
+	}
+	for _, scope := range strings.Split(strings.Join(values, ","), ",") {
+		switch strings.TrimSpace(scope) {
+		case "repo":
+			return true
+		case "public_repo":
+			if !repo.GetPrivate() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkWritable returns ErrReadOnlyToken when the client was configured
+// read-only, so writes fail before reaching GitHub.
+func (c *GitHubClient) checkWritable() error {
+	if !c.opts.ReadOnly {
+		return nil
+	}
+	return fmt.Errorf("%w: the configured token can read %s/%s but not write to it; "+
+		"use a classic token with the repo scope, or a fine-grained token with Contents read and write access", ErrReadOnlyToken, c.owner, c.repo)
+}
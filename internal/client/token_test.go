@@ -0,0 +1,96 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTokenFile writes content to a file in a test directory and returns
+// its path.
+func writeTokenFile(t *testing.T, content string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+	return name
+}
+
+func TestResolveToken_ReadsFile(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN_FILE", "")
+
+	token, err := ResolveToken("", writeTokenFile(t, "  ghp_fromfile\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "ghp_fromfile" {
+		t.Errorf("expected the trimmed file contents, got %q", token)
+	}
+}
+
+func TestResolveToken_MissingFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "absent")
+
+	_, err := ResolveToken("", name)
+	if err == nil {
+		t.Fatal("expected an error for a missing token file")
+	}
+	if !errors.Is(err, os.ErrNotExist) || !strings.Contains(err.Error(), name) {
+		t.Errorf("expected a not-found error naming the file, got %v", err)
+	}
+}
+
+func TestResolveToken_EmptyFile(t *testing.T) {
+	if _, err := ResolveToken("", writeTokenFile(t, " \n")); err == nil {
+		t.Fatal("expected an error for an empty token file")
+	}
+}
+
+func TestResolveToken_Precedence(t *testing.T) {
+	envFile := writeTokenFile(t, "from-env-file")
+	attrFile := writeTokenFile(t, "from-attr-file")
+
+	for _, tc := range []struct {
+		name, token, tokenFile, env, envFile, want string
+	}{
+		{"attribute wins over environment", "from-attr", "", "from-env", envFile, "from-attr"},
+		{"token_file wins over environment", "", attrFile, "from-env", envFile, "from-attr-file"},
+		{"GITHUB_TOKEN wins over GITHUB_TOKEN_FILE", "", "", "from-env", envFile, "from-env"},
+		{"GITHUB_TOKEN_FILE last", "", "", "", envFile, "from-env-file"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GITHUB_TOKEN", tc.env)
+			t.Setenv("GITHUB_TOKEN_FILE", tc.envFile)
+
+			got, err := ResolveToken(tc.token, tc.tokenFile)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestResolveToken_BothAttributes(t *testing.T) {
+	if _, err := ResolveToken("from-attr", writeTokenFile(t, "from-file")); err == nil {
+		t.Fatal("expected token and token_file together to be refused")
+	}
+}
+
+func TestResolveToken_NoSource(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN_FILE", "")
+
+	if _, err := ResolveToken("", ""); !errors.Is(err, ErrNoToken) {
+		t.Errorf("expected ErrNoToken, got %v", err)
+	}
+}
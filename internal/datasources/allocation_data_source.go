@@ -8,6 +8,7 @@ import (
 	"fmt"
 
 	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
@@ -32,7 +33,15 @@ type AllocationDataSourceModel struct {
 	CIDR       types.String `tfsdk:"cidr"`
 	PoolID     types.String `tfsdk:"pool_id"`
 	ParentCIDR types.String `tfsdk:"parent_cidr"`
+	VLANID     types.Int64  `tfsdk:"vlan_id"`
+	Region     types.String `tfsdk:"region"`
 	Metadata   types.Map    `tfsdk:"metadata"`
+
+	FirstIP       types.String `tfsdk:"first_ip"`
+	LastIP        types.String `tfsdk:"last_ip"`
+	UsableFirstIP types.String `tfsdk:"usable_first_ip"`
+	UsableLastIP  types.String `tfsdk:"usable_last_ip"`
+	AddressCount  types.Int64  `tfsdk:"address_count"`
 }
 
 // NewAllocationDataSource creates a new data source.
@@ -46,8 +55,8 @@ func (d *AllocationDataSource) Metadata(ctx context.Context, req datasource.Meta
 
 func (d *AllocationDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: "Looks up a specific allocation by ID or name.",
-		MarkdownDescription: `Looks up a specific allocation by ID or name.
+		Description: "Looks up a specific allocation by ID, name or CIDR.",
+		MarkdownDescription: `Looks up a specific allocation by ID, name or CIDR.
 
 This data source is useful when you need to reference an allocation created by another
 Terraform workspace or process.
@@ -61,29 +70,35 @@ data "github-ipam_allocation" "vpc" {
 resource "aws_vpc" "main" {
   cidr_block = data.github-ipam_allocation.vpc.cidr
 }
+
+data "github-ipam_allocation" "peer" {
+  cidr = "10.20.0.0/16"
+}
 ` + "```",
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
-				Description:         "Allocation ID (UUID). Either id or name must be specified.",
-				MarkdownDescription: "Allocation ID (UUID). Either `id` or `name` must be specified.",
+				Description:         "Allocation ID (UUID). Exactly one of id, name or cidr must be specified.",
+				MarkdownDescription: "Allocation ID (UUID). Exactly one of `id`, `name` or `cidr` must be specified.",
 				Optional:            true,
 				Computed:            true,
 				Validators: []validator.String{
 					stringvalidator.ExactlyOneOf(path.Expressions{
 						path.MatchRoot("id"),
 						path.MatchRoot("name"),
+						path.MatchRoot("cidr"),
 					}...),
 				},
 			},
 			"name": schema.StringAttribute{
-				Description:         "Allocation name. Either id or name must be specified.",
-				MarkdownDescription: "Allocation name. Either `id` or `name` must be specified.",
+				Description:         "Allocation name. Exactly one of id, name or cidr must be specified.",
+				MarkdownDescription: "Allocation name. Exactly one of `id`, `name` or `cidr` must be specified.",
 				Optional:            true,
 				Computed:            true,
 			},
 			"cidr": schema.StringAttribute{
-				Description:         "The allocated CIDR block.",
-				MarkdownDescription: "The allocated CIDR block.",
+				Description:         "The allocated CIDR block. Exactly one of id, name or cidr must be specified. A cidr to look up must be in canonical form, e.g. 10.0.0.0/24 rather than 10.0.0.1/24.",
+				MarkdownDescription: "The allocated CIDR block. Exactly one of `id`, `name` or `cidr` must be specified. A `cidr` to look up must be in canonical form, e.g. `10.0.0.0/24` rather than `10.0.0.1/24`.",
+				Optional:            true,
 				Computed:            true,
 			},
 			"pool_id": schema.StringAttribute{
@@ -96,12 +111,47 @@ resource "aws_vpc" "main" {
 				MarkdownDescription: "Parent CIDR if this is a sub-allocation.",
 				Computed:            true,
 			},
+			"vlan_id": schema.Int64Attribute{
+				Description:         "802.1Q VLAN ID of the allocation, if one is set.",
+				MarkdownDescription: "802.1Q VLAN ID of the allocation, if one is set.",
+				Computed:            true,
+			},
+			"region": schema.StringAttribute{
+				Description:         "Region of the allocation, if one is set.",
+				MarkdownDescription: "Region of the allocation, if one is set.",
+				Computed:            true,
+			},
 			"metadata": schema.MapAttribute{
 				Description:         "Key-value metadata for the allocation.",
 				MarkdownDescription: "Key-value metadata for the allocation.",
 				ElementType:         types.StringType,
 				Computed:            true,
 			},
+			"first_ip": schema.StringAttribute{
+				Description:         "First address of the block.",
+				MarkdownDescription: "First address of the block.",
+				Computed:            true,
+			},
+			"last_ip": schema.StringAttribute{
+				Description:         "Last address of the block.",
+				MarkdownDescription: "Last address of the block.",
+				Computed:            true,
+			},
+			"usable_first_ip": schema.StringAttribute{
+				Description:         "First host address of the block, skipping the network address for IPv4 blocks larger than /31.",
+				MarkdownDescription: "First host address of the block, skipping the network address for IPv4 blocks larger than /31.",
+				Computed:            true,
+			},
+			"address_count": schema.Int64Attribute{
+				Description:         "Number of addresses in the block. Null for IPv6 blocks too large to represent.",
+				MarkdownDescription: "Number of addresses in the block. Null for IPv6 blocks too large to represent.",
+				Computed:            true,
+			},
+			"usable_last_ip": schema.StringAttribute{
+				Description:         "Last host address of the block, skipping the broadcast address for IPv4 blocks larger than /31.",
+				MarkdownDescription: "Last host address of the block, skipping the broadcast address for IPv4 blocks larger than /31.",
+				Computed:            true,
+			},
 		},
 	}
 }
@@ -137,60 +187,97 @@ func (d *AllocationDataSource) Read(ctx context.Context, req datasource.ReadRequ
 		return
 	}
 
+	var alloc *ipam.Allocation
+	var poolID string
 	var found bool
 
 	if !config.ID.IsNull() && config.ID.ValueString() != "" {
 		// Look up by ID
-		alloc, poolID, ok := db.FindAllocationByID(config.ID.ValueString())
-		if ok {
-			found = true
-			config.Name = types.StringValue(alloc.Name)
-			config.CIDR = types.StringValue(alloc.CIDR)
-			config.PoolID = types.StringValue(poolID)
-			if alloc.ParentCIDR != nil {
-				config.ParentCIDR = types.StringValue(*alloc.ParentCIDR)
-			} else {
-				config.ParentCIDR = types.StringNull()
-			}
-			if len(alloc.Metadata) > 0 {
-				metadataValue, diags := types.MapValueFrom(ctx, types.StringType, alloc.Metadata)
-				resp.Diagnostics.Append(diags...)
-				config.Metadata = metadataValue
-			} else {
-				config.Metadata = types.MapNull(types.StringType)
-			}
-		}
+		alloc, poolID, found = db.FindAllocationByID(config.ID.ValueString())
 	} else if !config.Name.IsNull() && config.Name.ValueString() != "" {
 		// Look up by name
-		alloc, poolID, ok := db.FindAllocationByName(config.Name.ValueString())
-		if ok {
-			found = true
-			config.ID = types.StringValue(alloc.ID)
-			config.CIDR = types.StringValue(alloc.CIDR)
-			config.PoolID = types.StringValue(poolID)
-			if alloc.ParentCIDR != nil {
-				config.ParentCIDR = types.StringValue(*alloc.ParentCIDR)
-			} else {
-				config.ParentCIDR = types.StringNull()
-			}
-			if len(alloc.Metadata) > 0 {
-				metadataValue, diags := types.MapValueFrom(ctx, types.StringType, alloc.Metadata)
-				resp.Diagnostics.Append(diags...)
-				config.Metadata = metadataValue
-			} else {
-				config.Metadata = types.MapNull(types.StringType)
-			}
+		alloc, poolID, found = db.FindAllocationByName(config.Name.ValueString())
+	} else if !config.CIDR.IsNull() && config.CIDR.ValueString() != "" {
+		// Look up by CIDR, which must be written the way allocations are
+		// stored so the configured value can be kept as is
+		alloc, poolID, found, err = db.LookupAllocationByCIDR(config.CIDR.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("cidr"), "Invalid CIDR", err.Error())
+			return
 		}
 	}
 
 	if !found {
-		if !config.ID.IsNull() {
+		switch {
+		case !config.ID.IsNull():
 			resp.Diagnostics.AddError("Allocation not found", fmt.Sprintf("No allocation found with ID %q", config.ID.ValueString()))
-		} else {
+		case !config.Name.IsNull():
 			resp.Diagnostics.AddError("Allocation not found", fmt.Sprintf("No allocation found with name %q", config.Name.ValueString()))
+		default:
+			resp.Diagnostics.AddError("Allocation not found", fmt.Sprintf("No allocation found with CIDR %q", config.CIDR.ValueString()))
 		}
 		return
 	}
 
+	config.ID = types.StringValue(alloc.ID)
+	config.Name = types.StringValue(alloc.Name)
+	if config.CIDR.IsNull() {
+		config.CIDR = types.StringValue(alloc.CIDR)
+	}
+	config.PoolID = types.StringValue(poolID)
+	config.FirstIP, config.LastIP, config.UsableFirstIP, config.UsableLastIP = addressBoundsValues(alloc.CIDR)
+	config.AddressCount = addressCountValue(alloc.CIDR)
+	config.VLANID = vlanIDValue(alloc.VLANID)
+	config.Region = regionValue(alloc.Region)
+	if alloc.ParentCIDR != nil {
+		config.ParentCIDR = types.StringValue(*alloc.ParentCIDR)
+	} else {
+		config.ParentCIDR = types.StringNull()
+	}
+	if len(alloc.Metadata) > 0 {
+		metadataValue, diags := types.MapValueFrom(ctx, types.StringType, alloc.Metadata)
+		resp.Diagnostics.Append(diags...)
+		config.Metadata = metadataValue
+	} else {
+		config.Metadata = types.MapNull(types.StringType)
+	}
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
 }
+
+// addressBoundsValues returns the first, last, usable first and usable last
+// addresses of a block, or nulls if the CIDR cannot be parsed.
+func addressBoundsValues(cidr string) (first, last, usableFirst, usableLast types.String) {
+	bounds, err := ipam.BlockBounds(cidr)
+	if err != nil {
+		return types.StringNull(), types.StringNull(), types.StringNull(), types.StringNull()
+	}
+	return types.StringValue(bounds.First), types.StringValue(bounds.Last),
+		types.StringValue(bounds.UsableFirst), types.StringValue(bounds.UsableLast)
+}
+
+// addressCountValue returns the number of addresses in a block, or null if
+// it cannot be represented.
+func addressCountValue(cidr string) types.Int64 {
+	count, ok := ipam.AddressCount(cidr)
+	if !ok {
+		return types.Int64Null()
+	}
+	return types.Int64Value(count)
+}
+
+// vlanIDValue returns a stored VLAN ID, null when none is set.
+func vlanIDValue(id int) types.Int64 {
+	if id == 0 {
+		return types.Int64Null()
+	}
+	return types.Int64Value(int64(id))
+}
+
+// regionValue returns a stored region, null when none is set.
+func regionValue(region string) types.String {
+	if region == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(region)
+}
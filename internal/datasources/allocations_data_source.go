@@ -9,8 +9,10 @@ import (
 
 	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
 	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
@@ -28,6 +30,10 @@ type AllocationsDataSourceModel struct {
 	ID          types.String             `tfsdk:"id"`
 	PoolID      types.String             `tfsdk:"pool_id"`
 	ParentCIDR  types.String             `tfsdk:"parent_cidr"`
+	Region      types.String             `tfsdk:"region"`
+	Limit       types.Int64              `tfsdk:"limit"`
+	Offset      types.Int64              `tfsdk:"offset"`
+	Total       types.Int64              `tfsdk:"total"`
 	Allocations []AllocationSummaryModel `tfsdk:"allocations"`
 }
 
@@ -39,6 +45,14 @@ type AllocationSummaryModel struct {
 	PoolID     types.String `tfsdk:"pool_id"`
 	ParentCIDR types.String `tfsdk:"parent_cidr"`
 	CreatedAt  types.String `tfsdk:"created_at"`
+	VLANID     types.Int64  `tfsdk:"vlan_id"`
+	Region     types.String `tfsdk:"region"`
+
+	FirstIP       types.String `tfsdk:"first_ip"`
+	LastIP        types.String `tfsdk:"last_ip"`
+	UsableFirstIP types.String `tfsdk:"usable_first_ip"`
+	UsableLastIP  types.String `tfsdk:"usable_last_ip"`
+	AddressCount  types.Int64  `tfsdk:"address_count"`
 }
 
 // NewAllocationsDataSource creates a new data source.
@@ -52,8 +66,10 @@ func (d *AllocationsDataSource) Metadata(ctx context.Context, req datasource.Met
 
 func (d *AllocationsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description:         "Lists allocations, optionally filtered by pool_id or parent_cidr.",
-		MarkdownDescription: "Lists allocations from `allocations.yaml`, optionally filtered by `pool_id` or `parent_cidr`.",
+		Description: "Lists allocations, optionally filtered by pool_id or parent_cidr and by region. " +
+			"Results are ordered by CIDR and can be paged with limit and offset.",
+		MarkdownDescription: "Lists allocations from `allocations.yaml`, optionally filtered by `pool_id` or `parent_cidr` and by `region`. " +
+			"Results are ordered by CIDR and can be paged with `limit` and `offset`.",
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
 				Description: "Identifier for this data source.",
@@ -67,8 +83,30 @@ func (d *AllocationsDataSource) Schema(ctx context.Context, req datasource.Schem
 				Description: "Filter allocations by parent CIDR. Mutually exclusive with pool_id.",
 				Optional:    true,
 			},
+			"region": schema.StringAttribute{
+				Description: "Filter allocations by region. Combines with pool_id or parent_cidr.",
+				Optional:    true,
+			},
+			"limit": schema.Int64Attribute{
+				Description: "Maximum number of allocations to return. Returns all when unset.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"offset": schema.Int64Attribute{
+				Description: "Number of matching allocations to skip, in CIDR order. Defaults to 0.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"total": schema.Int64Attribute{
+				Description: "Number of allocations matching the filter, regardless of limit and offset.",
+				Computed:    true,
+			},
 			"allocations": schema.ListNestedAttribute{
-				Description: "List of allocations matching the filter criteria.",
+				Description: "The requested page of allocations matching the filter criteria, ordered by CIDR.",
 				Computed:    true,
 				NestedObject: schema.NestedAttributeObject{
 					Attributes: map[string]schema.Attribute{
@@ -96,6 +134,34 @@ func (d *AllocationsDataSource) Schema(ctx context.Context, req datasource.Schem
 							Description: "Timestamp when the allocation was created.",
 							Computed:    true,
 						},
+						"vlan_id": schema.Int64Attribute{
+							Description: "802.1Q VLAN ID of the allocation, if one is set.",
+							Computed:    true,
+						},
+						"region": schema.StringAttribute{
+							Description: "Region of the allocation, if one is set.",
+							Computed:    true,
+						},
+						"first_ip": schema.StringAttribute{
+							Description: "First address of the block.",
+							Computed:    true,
+						},
+						"last_ip": schema.StringAttribute{
+							Description: "Last address of the block.",
+							Computed:    true,
+						},
+						"usable_first_ip": schema.StringAttribute{
+							Description: "First host address of the block, skipping the network address for IPv4 blocks larger than /31.",
+							Computed:    true,
+						},
+						"address_count": schema.Int64Attribute{
+							Description: "Number of addresses in the block. Null for IPv6 blocks too large to represent.",
+							Computed:    true,
+						},
+						"usable_last_ip": schema.StringAttribute{
+							Description: "Last host address of the block, skipping the broadcast address for IPv4 blocks larger than /31.",
+							Computed:    true,
+						},
 					},
 				},
 			},
@@ -169,16 +235,27 @@ func (d *AllocationsDataSource) Read(ctx context.Context, req datasource.ReadReq
 		}
 		filterID = "all"
 	}
+	if !data.Region.IsNull() {
+		filtered = ipam.FilterByRegion(filtered, data.Region.ValueString())
+		filterID += "|region:" + data.Region.ValueString()
+	}
+
+	// Order deterministically, then apply the requested page
+	page, total := ipam.PageAllocations(filtered, int(data.Offset.ValueInt64()), int(data.Limit.ValueInt64()))
 
 	// Convert to data source model
-	allocations := make([]AllocationSummaryModel, len(filtered))
-	for i, alloc := range filtered {
+	allocations := make([]AllocationSummaryModel, len(page))
+	for i, alloc := range page {
 		model := AllocationSummaryModel{
 			ID:        types.StringValue(alloc.ID),
 			CIDR:      types.StringValue(alloc.CIDR),
 			Name:      types.StringValue(alloc.Name),
 			CreatedAt: types.StringValue(alloc.CreatedAt),
+			VLANID:    vlanIDValue(alloc.VLANID),
+			Region:    regionValue(alloc.Region),
 		}
+		model.FirstIP, model.LastIP, model.UsableFirstIP, model.UsableLastIP = addressBoundsValues(alloc.CIDR)
+		model.AddressCount = addressCountValue(alloc.CIDR)
 
 		// Set pool_id based on how we found the allocation
 		if hasPoolID {
@@ -206,6 +283,7 @@ func (d *AllocationsDataSource) Read(ctx context.Context, req datasource.ReadReq
 
 	data.ID = types.StringValue(filterID)
 	data.Allocations = allocations
+	data.Total = types.Int64Value(int64(total))
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
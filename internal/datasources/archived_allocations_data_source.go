@@ -0,0 +1,197 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &ArchivedAllocationsDataSource{}
+var _ datasource.DataSourceWithConfigure = &ArchivedAllocationsDataSource{}
+
+// ArchivedAllocationsDataSource defines the data source implementation.
+type ArchivedAllocationsDataSource struct {
+	client *client.GitHubClient
+}
+
+// ArchivedAllocationsDataSourceModel describes the data source data model.
+type ArchivedAllocationsDataSourceModel struct {
+	ID          types.String                     `tfsdk:"id"`
+	PoolID      types.String                     `tfsdk:"pool_id"`
+	Name        types.String                     `tfsdk:"name"`
+	Allocations []ArchivedAllocationSummaryModel `tfsdk:"allocations"`
+}
+
+// ArchivedAllocationSummaryModel describes one archived allocation.
+type ArchivedAllocationSummaryModel struct {
+	ID         types.String `tfsdk:"id"`
+	CIDR       types.String `tfsdk:"cidr"`
+	Name       types.String `tfsdk:"name"`
+	PoolID     types.String `tfsdk:"pool_id"`
+	ParentCIDR types.String `tfsdk:"parent_cidr"`
+	Status     types.String `tfsdk:"status"`
+	CreatedAt  types.String `tfsdk:"created_at"`
+	DeletedAt  types.String `tfsdk:"deleted_at"`
+	Metadata   types.Map    `tfsdk:"metadata"`
+}
+
+// NewArchivedAllocationsDataSource creates a new data source.
+func NewArchivedAllocationsDataSource() datasource.DataSource {
+	return &ArchivedAllocationsDataSource{}
+}
+
+func (d *ArchivedAllocationsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_archived_allocations"
+}
+
+func (d *ArchivedAllocationsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists deleted allocations kept in the archive when the provider runs with archive_deletions, most recently deleted first.",
+		MarkdownDescription: "Lists deleted allocations kept in the `archived` section of `allocations.yaml` when the provider runs with " +
+			"`archive_deletions`, most recently deleted first. Optionally filtered by `pool_id` and `name`.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"pool_id": schema.StringAttribute{
+				Description: "Only list allocations deleted from this pool.",
+				Optional:    true,
+			},
+			"name": schema.StringAttribute{
+				Description: "Only list allocations with this name. A name can appear more than once if it was reused.",
+				Optional:    true,
+			},
+			"allocations": schema.ListNestedAttribute{
+				Description: "Archived allocations, most recently deleted first.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							Description: "Allocation ID.",
+							Computed:    true,
+						},
+						"cidr": schema.StringAttribute{
+							Description: "The block the allocation held.",
+							Computed:    true,
+						},
+						"name": schema.StringAttribute{
+							Description: "Allocation name.",
+							Computed:    true,
+						},
+						"pool_id": schema.StringAttribute{
+							Description: "Pool the allocation was deleted from.",
+							Computed:    true,
+						},
+						"parent_cidr": schema.StringAttribute{
+							Description: "Parent CIDR if it was a sub-allocation.",
+							Computed:    true,
+						},
+						"status": schema.StringAttribute{
+							Description: "Lifecycle status at the time of deletion.",
+							Computed:    true,
+						},
+						"created_at": schema.StringAttribute{
+							Description: "Timestamp when the allocation was created.",
+							Computed:    true,
+						},
+						"deleted_at": schema.StringAttribute{
+							Description: "Timestamp when the allocation was deleted.",
+							Computed:    true,
+						},
+						"metadata": schema.MapAttribute{
+							Description: "Key-value metadata the allocation had.",
+							ElementType: types.StringType,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *ArchivedAllocationsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *ArchivedAllocationsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ArchivedAllocationsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	allocsDB, _, err := d.client.GetAllocations(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Allocations",
+			fmt.Sprintf("Unable to read allocations from GitHub: %s", err),
+		)
+		return
+	}
+
+	filterID := "archived"
+	if !data.PoolID.IsNull() {
+		filterID += "|pool:" + data.PoolID.ValueString()
+	}
+	if !data.Name.IsNull() {
+		filterID += "|name:" + data.Name.ValueString()
+	}
+
+	allocations := make([]ArchivedAllocationSummaryModel, 0)
+	for _, archived := range allocsDB.ArchivedAllocations(data.PoolID.ValueString()) {
+		if !data.Name.IsNull() && archived.Name != data.Name.ValueString() {
+			continue
+		}
+
+		metadata := types.MapNull(types.StringType)
+		if len(archived.Metadata) > 0 {
+			value, diags := types.MapValueFrom(ctx, types.StringType, archived.Metadata)
+			resp.Diagnostics.Append(diags...)
+			metadata = value
+		}
+
+		allocations = append(allocations, ArchivedAllocationSummaryModel{
+			ID:         types.StringValue(archived.ID),
+			CIDR:       types.StringValue(archived.CIDR),
+			Name:       types.StringValue(archived.Name),
+			PoolID:     types.StringValue(archived.PoolID),
+			ParentCIDR: types.StringPointerValue(archived.ParentCIDR),
+			Status:     types.StringValue(archived.GetStatus()),
+			CreatedAt:  types.StringValue(archived.CreatedAt),
+			DeletedAt:  types.StringValue(archived.DeletedAt),
+			Metadata:   metadata,
+		})
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.ID = types.StringValue(filterID)
+	data.Allocations = allocations
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
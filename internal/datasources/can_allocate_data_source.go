@@ -0,0 +1,164 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &CanAllocateDataSource{}
+var _ datasource.DataSourceWithConfigure = &CanAllocateDataSource{}
+
+// CanAllocateDataSource defines the data source implementation.
+type CanAllocateDataSource struct {
+	client *client.GitHubClient
+}
+
+// CanAllocateDataSourceModel describes the data source data model.
+type CanAllocateDataSourceModel struct {
+	ID            types.String `tfsdk:"id"`
+	PoolID        types.String `tfsdk:"pool_id"`
+	ParentCIDR    types.String `tfsdk:"parent_cidr"`
+	CIDRMask      types.Int64  `tfsdk:"cidr_mask"`
+	CanAllocate   types.Bool   `tfsdk:"can_allocate"`
+	CandidateCIDR types.String `tfsdk:"candidate_cidr"`
+	Reason        types.String `tfsdk:"reason"`
+}
+
+// NewCanAllocateDataSource creates a new data source.
+func NewCanAllocateDataSource() datasource.DataSource {
+	return &CanAllocateDataSource{}
+}
+
+func (d *CanAllocateDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_can_allocate"
+}
+
+func (d *CanAllocateDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Reports whether a pool or parent CIDR can satisfy an allocation request, without allocating.",
+		MarkdownDescription: `Reports whether a pool or parent CIDR can satisfy an allocation request, without allocating.
+
+Unlike ` + "`github-ipam_next_available`" + `, reading does not fail when the request cannot be met:
+` + "`can_allocate`" + ` is false and ` + "`reason`" + ` says why, so the answer can drive conditional
+configuration or a ` + "`check`" + ` block in CI. Only failures to read the repository are errors. As with
+` + "`next_available`" + `, the candidate is not reserved and may be taken before it is used.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"pool_id": schema.StringAttribute{
+				Description: "Pool ID to check (Mode 1). Mutually exclusive with parent_cidr.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("pool_id"), path.MatchRoot("parent_cidr")),
+				},
+			},
+			"parent_cidr": schema.StringAttribute{
+				Description: "Parent CIDR to check (Mode 2). Must be an existing allocation.",
+				Optional:    true,
+			},
+			"cidr_mask": schema.Int64Attribute{
+				Description: "The prefix length of the requested block (e.g., 24 for /24).",
+				Required:    true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 128),
+				},
+			},
+			"can_allocate": schema.BoolAttribute{
+				Description: "Whether an allocation of cidr_mask would succeed now.",
+				Computed:    true,
+			},
+			"candidate_cidr": schema.StringAttribute{
+				Description: "The block the allocation would be given. Null when can_allocate is false.",
+				Computed:    true,
+			},
+			"reason": schema.StringAttribute{
+				Description: "Why the allocation would be refused. Null when can_allocate is true.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *CanAllocateDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *CanAllocateDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data CanAllocateDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pools, err := d.client.GetPoolsWithProposed(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Pools",
+			fmt.Sprintf("Unable to read pools from GitHub: %s", err),
+		)
+		return
+	}
+
+	allocsDB, _, err := d.client.GetAllocations(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Allocations",
+			fmt.Sprintf("Unable to read allocations from GitHub: %s", err),
+		)
+		return
+	}
+
+	prefixLen := int(data.CIDRMask.ValueInt64())
+	request := ipam.AllocationRequest{
+		PoolID:     data.PoolID.ValueString(),
+		ParentCIDR: data.ParentCIDR.ValueString(),
+		PrefixLen:  prefixLen,
+	}
+	cidr, err := ipam.NewAllocator().PreviewAllocation(pools, allocsDB, request, d.client.MaxNestingDepth())
+
+	source := request.PoolID
+	if source == "" {
+		source = request.ParentCIDR
+	}
+	data.ID = types.StringValue(fmt.Sprintf("can_allocate:%s:/%d", source, prefixLen))
+	data.CanAllocate = types.BoolValue(err == nil)
+	data.CandidateCIDR = types.StringNull()
+	data.Reason = types.StringNull()
+	if err != nil {
+		data.Reason = types.StringValue(err.Error())
+	} else {
+		data.CandidateCIDR = types.StringValue(cidr)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
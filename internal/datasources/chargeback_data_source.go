@@ -0,0 +1,155 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &ChargebackDataSource{}
+var _ datasource.DataSourceWithConfigure = &ChargebackDataSource{}
+
+// ChargebackDataSource defines the data source implementation.
+type ChargebackDataSource struct {
+	client *client.GitHubClient
+}
+
+// ChargebackDataSourceModel describes the data source data model.
+type ChargebackDataSourceModel struct {
+	ID             types.String                    `tfsdk:"id"`
+	GroupBy        types.String                    `tfsdk:"group_by"`
+	TotalAddresses types.Int64                     `tfsdk:"total_addresses"`
+	Groups         map[string]ChargebackGroupModel `tfsdk:"groups"`
+}
+
+// ChargebackGroupModel describes the space charged to one group.
+type ChargebackGroupModel struct {
+	Allocations types.Int64   `tfsdk:"allocations"`
+	Addresses   types.Int64   `tfsdk:"addresses"`
+	Percent     types.Float64 `tfsdk:"percent"`
+}
+
+// NewChargebackDataSource creates a new data source.
+func NewChargebackDataSource() datasource.DataSource {
+	return &ChargebackDataSource{}
+}
+
+func (d *ChargebackDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_chargeback"
+}
+
+func (d *ChargebackDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Totals the address space allocated to each cost center across all pools, for internal chargeback.",
+		MarkdownDescription: `Totals the address space allocated to each cost center across all pools, for internal chargeback.
+
+Allocations are grouped by their ` + "`cost_center`" + `, or by a metadata key with ` + "`group_by`" + `. Only top-level
+allocations and reservations count: sub-allocations use space their parent is already charged for, and claims
+are not yet anyone's. Allocations without a value fall into the ` + "`" + ipam.ChargebackUnassigned + "`" + ` group.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"group_by": schema.StringAttribute{
+				Description:         "Metadata key to group allocations by instead of cost_center.",
+				MarkdownDescription: "Metadata key to group allocations by instead of `cost_center`.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"total_addresses": schema.Int64Attribute{
+				Description: "Addresses charged across all groups.",
+				Computed:    true,
+			},
+			"groups": schema.MapNestedAttribute{
+				Description: "Charged space keyed by cost center, or by metadata value with group_by.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"allocations": schema.Int64Attribute{
+							Description: "Number of allocations charged to the group.",
+							Computed:    true,
+						},
+						"addresses": schema.Int64Attribute{
+							Description: "Addresses held by the group's allocations.",
+							Computed:    true,
+						},
+						"percent": schema.Float64Attribute{
+							Description: "The group's share of total_addresses, 0-100.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *ChargebackDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *ChargebackDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ChargebackDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	allocsDB, _, err := d.client.GetAllocations(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Allocations",
+			fmt.Sprintf("Unable to read allocations from GitHub: %s", err),
+		)
+		return
+	}
+
+	groupBy := data.GroupBy.ValueString()
+	var total uint64
+	groups := make(map[string]ChargebackGroupModel)
+	for _, group := range ipam.Chargeback(allocsDB.AllAllocations(), groupBy) {
+		total += group.Addresses
+		groups[group.Key] = ChargebackGroupModel{
+			Allocations: types.Int64Value(int64(group.Allocations)),
+			Addresses:   types.Int64Value(int64(group.Addresses)),
+			Percent:     types.Float64Value(group.Percent),
+		}
+	}
+
+	data.ID = types.StringValue("chargeback")
+	if groupBy != "" {
+		data.ID = types.StringValue("chargeback|metadata:" + groupBy)
+	}
+	data.TotalAddresses = types.Int64Value(int64(total))
+	data.Groups = groups
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
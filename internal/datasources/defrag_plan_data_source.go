@@ -0,0 +1,191 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &DefragPlanDataSource{}
+var _ datasource.DataSourceWithConfigure = &DefragPlanDataSource{}
+
+// DefragPlanDataSource defines the data source implementation.
+type DefragPlanDataSource struct {
+	client *client.GitHubClient
+}
+
+// DefragPlanDataSourceModel describes the data source data model.
+type DefragPlanDataSourceModel struct {
+	ID                 types.String      `tfsdk:"id"`
+	PoolID             types.String      `tfsdk:"pool_id"`
+	Moves              []DefragMoveModel `tfsdk:"moves"`
+	LargestFreeBefore  types.Int64       `tfsdk:"largest_free_before"`
+	LargestFreeAfter   types.Int64       `tfsdk:"largest_free_after"`
+	ReclaimedAddresses types.Int64       `tfsdk:"reclaimed_addresses"`
+}
+
+// DefragMoveModel describes a single suggested move.
+type DefragMoveModel struct {
+	ID       types.String `tfsdk:"id"`
+	Name     types.String `tfsdk:"name"`
+	FromCIDR types.String `tfsdk:"from_cidr"`
+	ToCIDR   types.String `tfsdk:"to_cidr"`
+}
+
+// NewDefragPlanDataSource creates a new data source.
+func NewDefragPlanDataSource() datasource.DataSource {
+	return &DefragPlanDataSource{}
+}
+
+func (d *DefragPlanDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_defrag_plan"
+}
+
+func (d *DefragPlanDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Suggests a re-layout that packs a pool's allocations tightly to reclaim contiguous space. Advisory only.",
+		MarkdownDescription: `Suggests a re-layout that packs a pool's top-level allocations tightly from the pool start,
+reporting the moves required and the contiguous space reclaimed.
+
+This data source is **advisory only**. It never moves allocations, since re-addressing a live
+network is disruptive. Sub-allocations are not listed; they move with their parent.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"pool_id": schema.StringAttribute{
+				Description: "The pool to plan a re-layout for.",
+				Required:    true,
+			},
+			"moves": schema.ListNestedAttribute{
+				Description: "Suggested moves. Empty when the pool is already tightly packed.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							Description: "ID of the allocation to move.",
+							Computed:    true,
+						},
+						"name": schema.StringAttribute{
+							Description: "Name of the allocation to move.",
+							Computed:    true,
+						},
+						"from_cidr": schema.StringAttribute{
+							Description: "Current CIDR of the allocation.",
+							Computed:    true,
+						},
+						"to_cidr": schema.StringAttribute{
+							Description: "Suggested CIDR for the allocation.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+			"largest_free_before": schema.Int64Attribute{
+				Description: "Size of the largest contiguous free range today, in addresses.",
+				Computed:    true,
+			},
+			"largest_free_after": schema.Int64Attribute{
+				Description: "Size of the largest contiguous free range after applying the moves, in addresses.",
+				Computed:    true,
+			},
+			"reclaimed_addresses": schema.Int64Attribute{
+				Description: "Contiguous addresses gained by applying the moves.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *DefragPlanDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *DefragPlanDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data DefragPlanDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	poolID := data.PoolID.ValueString()
+
+	poolsConfig, err := d.client.GetPoolsWithProposed(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Pools",
+			fmt.Sprintf("Unable to read pools from GitHub: %s", err),
+		)
+		return
+	}
+
+	poolDef, exists := poolsConfig.GetPool(poolID)
+	if !exists {
+		resp.Diagnostics.AddError(
+			"Pool Not Found",
+			fmt.Sprintf("Pool %q not found in pools.yaml", poolID),
+		)
+		return
+	}
+
+	allocsDB, _, err := d.client.GetAllocations(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Allocations",
+			fmt.Sprintf("Unable to read allocations from GitHub: %s", err),
+		)
+		return
+	}
+
+	opts := ipam.AllocateOptions{Avoid: allocsDB.AllocationsToAvoid(poolDef)}
+	plan, err := ipam.NewAllocator().PlanDefrag(poolDef, allocsDB.GetAllocationsForPool(poolID), opts)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Plan Re-layout",
+			fmt.Sprintf("Unable to compute a re-layout for pool %q: %s", poolID, err),
+		)
+		return
+	}
+
+	moves := make([]DefragMoveModel, len(plan.Moves))
+	for i, m := range plan.Moves {
+		moves[i] = DefragMoveModel{
+			ID:       types.StringValue(m.ID),
+			Name:     types.StringValue(m.Name),
+			FromCIDR: types.StringValue(m.FromCIDR),
+			ToCIDR:   types.StringValue(m.ToCIDR),
+		}
+	}
+
+	data.ID = types.StringValue("defrag:" + poolID)
+	data.Moves = moves
+	data.LargestFreeBefore = types.Int64Value(int64(plan.LargestFreeBefore))
+	data.LargestFreeAfter = types.Int64Value(int64(plan.LargestFreeAfter))
+	data.ReclaimedAddresses = types.Int64Value(int64(plan.ReclaimedAddresses))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
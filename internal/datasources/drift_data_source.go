@@ -0,0 +1,177 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &DriftDataSource{}
+var _ datasource.DataSourceWithConfigure = &DriftDataSource{}
+
+// DriftDataSource defines the data source implementation.
+type DriftDataSource struct {
+	client *client.GitHubClient
+}
+
+// DriftDataSourceModel describes the data source data model.
+type DriftDataSourceModel struct {
+	ID                 types.String           `tfsdk:"id"`
+	KnownIDs           types.Set              `tfsdk:"known_ids"`
+	SHA                types.String           `tfsdk:"sha"`
+	Drifted            types.Bool             `tfsdk:"drifted"`
+	UnknownAllocations []DriftAllocationModel `tfsdk:"unknown_allocations"`
+}
+
+// DriftAllocationModel describes an allocation missing from known_ids.
+type DriftAllocationModel struct {
+	ID         types.String `tfsdk:"id"`
+	CIDR       types.String `tfsdk:"cidr"`
+	Name       types.String `tfsdk:"name"`
+	PoolID     types.String `tfsdk:"pool_id"`
+	ParentCIDR types.String `tfsdk:"parent_cidr"`
+}
+
+// NewDriftDataSource creates a new data source.
+func NewDriftDataSource() datasource.DataSource {
+	return &DriftDataSource{}
+}
+
+func (d *DriftDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_drift"
+}
+
+func (d *DriftDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Reports the allocations file's SHA and the allocations in it whose IDs are not in known_ids.",
+		MarkdownDescription: `Reports the allocations file's SHA and the allocations in it whose IDs are not in ` + "`known_ids`" + `.
+
+Pass the IDs Terraform manages to find allocations added outside it, by hand or from another workspace.
+Allocations in ` + "`known_ids`" + ` but no longer in the file are not reported; Terraform already sees
+those when it refreshes the resources that own them.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"known_ids": schema.SetAttribute{
+				Description: "IDs of the allocations expected in the file, typically those of the github-ipam_allocation resources in the configuration.",
+				MarkdownDescription: "IDs of the allocations expected in the file, typically those of the `github-ipam_allocation` " +
+					"resources in the configuration.",
+				ElementType: types.StringType,
+				Required:    true,
+			},
+			"sha": schema.StringAttribute{
+				Description: "Blob SHA of the allocations file as read. Null when the file does not exist yet.",
+				Computed:    true,
+			},
+			"drifted": schema.BoolAttribute{
+				Description: "Whether the file holds any allocation not in known_ids.",
+				Computed:    true,
+			},
+			"unknown_allocations": schema.ListNestedAttribute{
+				Description: "Allocations in the file whose IDs are not in known_ids, ordered by CIDR.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							Description: "Unique identifier for the allocation.",
+							Computed:    true,
+						},
+						"cidr": schema.StringAttribute{
+							Description: "The allocated CIDR block.",
+							Computed:    true,
+						},
+						"name": schema.StringAttribute{
+							Description: "Human-readable name for the allocation.",
+							Computed:    true,
+						},
+						"pool_id": schema.StringAttribute{
+							Description: "The pool ID the allocation is recorded under.",
+							Computed:    true,
+						},
+						"parent_cidr": schema.StringAttribute{
+							Description: "The parent CIDR the allocation is carved from.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *DriftDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *DriftDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data DriftDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var knownIDs []string
+	resp.Diagnostics.Append(data.KnownIDs.ElementsAs(ctx, &knownIDs, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	allocsDB, sha, err := d.client.GetAllocations(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Allocations",
+			fmt.Sprintf("Unable to read allocations from GitHub: %s", err),
+		)
+		return
+	}
+
+	unknown := ipam.UnknownAllocations(allocsDB, knownIDs)
+
+	data.ID = types.StringValue("drift")
+	data.SHA = types.StringNull()
+	if sha != "" {
+		data.SHA = types.StringValue(sha)
+	}
+	data.Drifted = types.BoolValue(len(unknown) > 0)
+	data.UnknownAllocations = make([]DriftAllocationModel, 0, len(unknown))
+	for _, alloc := range unknown {
+		model := DriftAllocationModel{
+			ID:         types.StringValue(alloc.ID),
+			CIDR:       types.StringValue(alloc.CIDR),
+			Name:       types.StringValue(alloc.Name),
+			PoolID:     types.StringValue(alloc.PoolID),
+			ParentCIDR: types.StringNull(),
+		}
+		if alloc.ParentCIDR != nil {
+			model.ParentCIDR = types.StringValue(*alloc.ParentCIDR)
+		}
+		data.UnknownAllocations = append(data.UnknownAllocations, model)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,126 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &HealthcheckDataSource{}
+var _ datasource.DataSourceWithConfigure = &HealthcheckDataSource{}
+
+// HealthcheckDataSource defines the data source implementation.
+type HealthcheckDataSource struct {
+	client *client.GitHubClient
+}
+
+// HealthcheckDataSourceModel describes the data source data model.
+type HealthcheckDataSourceModel struct {
+	ID                 types.String `tfsdk:"id"`
+	Healthy            types.Bool   `tfsdk:"healthy"`
+	Reachable          types.Bool   `tfsdk:"reachable"`
+	PoolsFound         types.Bool   `tfsdk:"pools_found"`
+	AllocationsFound   types.Bool   `tfsdk:"allocations_found"`
+	RateLimitRemaining types.Int64  `tfsdk:"rate_limit_remaining"`
+	Error              types.String `tfsdk:"error"`
+}
+
+// NewHealthcheckDataSource creates a new data source.
+func NewHealthcheckDataSource() datasource.DataSource {
+	return &HealthcheckDataSource{}
+}
+
+func (d *HealthcheckDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_healthcheck"
+}
+
+func (d *HealthcheckDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Checks that the provider can reach GitHub and read the pools and allocations files.",
+		MarkdownDescription: `Checks that the provider can reach GitHub and read the pools and allocations files.
+
+Reading never fails on an unhealthy backend: each check is reported in its own attribute, the reasons in
+` + "`error`" + `, and a warning is raised. Nothing is written, so a missing pools file is reported rather than created.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"healthy": schema.BoolAttribute{
+				Description: "Whether every check passed.",
+				Computed:    true,
+			},
+			"reachable": schema.BoolAttribute{
+				Description: "Whether the repository and branch could be read with the configured token.",
+				Computed:    true,
+			},
+			"pools_found": schema.BoolAttribute{
+				Description: "Whether the pools file exists and parses.",
+				Computed:    true,
+			},
+			"allocations_found": schema.BoolAttribute{
+				Description: "Whether the allocations file exists and parses. It is created by the first allocation.",
+				Computed:    true,
+			},
+			"rate_limit_remaining": schema.Int64Attribute{
+				Description: "GitHub API requests left in the current rate limit window, or -1 if GitHub did not report it.",
+				Computed:    true,
+			},
+			"error": schema.StringAttribute{
+				Description: "Why the failed checks failed, or empty when healthy.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *HealthcheckDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *HealthcheckDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data HealthcheckDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	health := d.client.HealthCheck(ctx)
+	message := strings.Join(health.Problems, "; ")
+	if !health.Healthy() {
+		resp.Diagnostics.AddWarning("IPAM Backend Unhealthy", message)
+	}
+
+	data.ID = types.StringValue("healthcheck")
+	data.Healthy = types.BoolValue(health.Healthy())
+	data.Reachable = types.BoolValue(health.Reachable)
+	data.PoolsFound = types.BoolValue(health.PoolsFound)
+	data.AllocationsFound = types.BoolValue(health.AllocationsFound)
+	data.RateLimitRemaining = types.Int64Value(health.RateLimitRemaining)
+	data.Error = types.StringValue(message)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,169 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &LocateDataSource{}
+var _ datasource.DataSourceWithConfigure = &LocateDataSource{}
+
+// LocateDataSource defines the data source implementation.
+type LocateDataSource struct {
+	client *client.GitHubClient
+}
+
+// LocateDataSourceModel describes the data source data model.
+type LocateDataSourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	CIDR           types.String `tfsdk:"cidr"`
+	IP             types.String `tfsdk:"ip"`
+	PoolID         types.String `tfsdk:"pool_id"`
+	PoolCIDR       types.String `tfsdk:"pool_cidr"`
+	AllocationID   types.String `tfsdk:"allocation_id"`
+	AllocationName types.String `tfsdk:"allocation_name"`
+	AllocationCIDR types.String `tfsdk:"allocation_cidr"`
+}
+
+// NewLocateDataSource creates a new data source.
+func NewLocateDataSource() datasource.DataSource {
+	return &LocateDataSource{}
+}
+
+func (d *LocateDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_locate"
+}
+
+func (d *LocateDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Finds the pool, and the tightest allocation, containing a CIDR or IP address.",
+		MarkdownDescription: `Finds the pool, and the tightest allocation, containing a CIDR or IP address.
+
+Useful when adopting external blocks: ` + "`pool_id`" + ` is empty when no managed pool contains the
+address, and the ` + "`allocation_*`" + ` attributes are null when no allocation does.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"cidr": schema.StringAttribute{
+				Description:         "CIDR to locate. Exactly one of cidr or ip must be specified.",
+				MarkdownDescription: "CIDR to locate. Exactly one of `cidr` or `ip` must be specified.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.Expressions{
+						path.MatchRoot("cidr"),
+						path.MatchRoot("ip"),
+					}...),
+				},
+			},
+			"ip": schema.StringAttribute{
+				Description: "Single IP address to locate.",
+				Optional:    true,
+			},
+			"pool_id": schema.StringAttribute{
+				Description: "Pool containing the address, or empty when no pool does.",
+				Computed:    true,
+			},
+			"pool_cidr": schema.StringAttribute{
+				Description: "The pool CIDR containing the address, or empty when no pool does.",
+				Computed:    true,
+			},
+			"allocation_id": schema.StringAttribute{
+				Description: "ID of the tightest allocation containing the address.",
+				Computed:    true,
+			},
+			"allocation_name": schema.StringAttribute{
+				Description: "Name of the tightest allocation containing the address.",
+				Computed:    true,
+			},
+			"allocation_cidr": schema.StringAttribute{
+				Description: "CIDR of the tightest allocation containing the address.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *LocateDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *LocateDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data LocateDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	query, attr := data.CIDR.ValueString(), path.Root("cidr")
+	if !data.IP.IsNull() {
+		query, attr = data.IP.ValueString(), path.Root("ip")
+	}
+
+	poolsConfig, err := d.client.GetPoolsWithProposed(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Pools",
+			fmt.Sprintf("Unable to read pools from GitHub: %s", err),
+		)
+		return
+	}
+
+	allocsDB, _, err := d.client.GetAllocations(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Allocations",
+			fmt.Sprintf("Unable to read allocations from GitHub: %s", err),
+		)
+		return
+	}
+
+	loc, err := ipam.Locate(poolsConfig, allocsDB, query)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(attr, "Invalid Address", err.Error())
+		return
+	}
+
+	data.ID = types.StringValue("locate:" + query)
+	data.PoolID = types.StringValue(loc.PoolID)
+	data.PoolCIDR = types.StringValue(loc.PoolCIDR)
+	if loc.Allocation != nil {
+		data.AllocationID = types.StringValue(loc.Allocation.ID)
+		data.AllocationName = types.StringValue(loc.Allocation.Name)
+		data.AllocationCIDR = types.StringValue(loc.Allocation.CIDR)
+	} else {
+		data.AllocationID = types.StringNull()
+		data.AllocationName = types.StringNull()
+		data.AllocationCIDR = types.StringNull()
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -6,15 +6,18 @@ package datasources
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
 	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
@@ -33,6 +36,9 @@ type NextAvailableDataSourceModel struct {
 	ParentCIDR types.String `tfsdk:"parent_cidr"`
 	CIDRMask   types.Int64  `tfsdk:"cidr_mask"`
 	CIDR       types.String `tfsdk:"cidr"`
+	Name       types.String `tfsdk:"name"`
+	ReserveFor types.Int64  `tfsdk:"reserve_for_seconds"`
+	ExpiresAt  types.String `tfsdk:"expires_at"`
 }
 
 // NewNextAvailableDataSource creates a new data source.
@@ -53,7 +59,13 @@ func (d *NextAvailableDataSource) Schema(ctx context.Context, req datasource.Sch
 This data source is useful for planning and dry-run scenarios. Note that the returned CIDR
 is not reserved and may be claimed by another allocation before you use it.
 
-**Important:** Either ` + "`pool_id`" + ` or ` + "`parent_cidr`" + ` must be specified, but not both.`,
+**Important:** Either ` + "`pool_id`" + ` or ` + "`parent_cidr`" + ` must be specified, but not both.
+
+**Claims:** Setting ` + "`reserve_for_seconds`" + ` makes this data source write to allocations.yaml, which
+data sources otherwise never do. The block is recorded as a reservation named ` + "`name`" + ` that lapses
+at ` + "`expires_at`" + `, so no one else is handed it in the meantime. Every read renews the claim. An
+allocation with the same ` + "`name`" + ` and ` + "`adopt_existing = true`" + ` takes it over permanently.
+Claims are only supported with ` + "`pool_id`" + `.`,
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
 				Description: "Identifier for this data source.",
@@ -78,6 +90,25 @@ is not reserved and may be claimed by another allocation before you use it.
 				Description: "The next available CIDR block that would be allocated.",
 				Computed:    true,
 			},
+			"name": schema.StringAttribute{
+				Description: "Name to record the claim under when reserve_for_seconds is set.",
+				Optional:    true,
+			},
+			"reserve_for_seconds": schema.Int64Attribute{
+				Description: "Hold the returned block for this many seconds by writing a claim. " +
+					"Unlike other data source reads this commits to the repository. Requires name and pool_id.",
+				MarkdownDescription: "Hold the returned block for this many seconds by writing a claim. " +
+					"Unlike other data source reads this commits to the repository. Requires `name` and `pool_id`.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+					int64validator.AlsoRequires(path.MatchRoot("name"), path.MatchRoot("pool_id")),
+				},
+			},
+			"expires_at": schema.StringAttribute{
+				Description: "RFC3339 time the claim lapses. Null when reserve_for_seconds is not set.",
+				Computed:    true,
+			},
 		},
 	}
 }
@@ -131,6 +162,12 @@ func (d *NextAvailableDataSource) Read(ctx context.Context, req datasource.ReadR
 
 	allocator := ipam.NewAllocator()
 	prefixLen := int(data.CIDRMask.ValueInt64())
+	data.ExpiresAt = types.StringNull()
+
+	if !data.ReserveFor.IsNull() {
+		d.claim(ctx, &data, prefixLen, resp)
+		return
+	}
 
 	var cidr string
 	var err error
@@ -140,7 +177,7 @@ func (d *NextAvailableDataSource) Read(ctx context.Context, req datasource.ReadR
 		poolID := data.PoolID.ValueString()
 
 		// Get pools
-		poolsConfig, poolErr := d.client.GetPools(ctx)
+		poolsConfig, poolErr := d.client.GetPoolsWithProposed(ctx)
 		if poolErr != nil {
 			resp.Diagnostics.AddError(
 				"Failed to Read Pools",
@@ -158,6 +195,12 @@ func (d *NextAvailableDataSource) Read(ctx context.Context, req datasource.ReadR
 			return
 		}
 
+		// Previews must not offer space an allocation would be refused
+		if err := ipam.CheckPoolAllocatable(poolID, poolDef); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("pool_id"), "Pool Not Allocatable", err.Error())
+			return
+		}
+
 		// Get existing allocations
 		allocsDB, _, allocErr := d.client.GetAllocations(ctx)
 		if allocErr != nil {
@@ -169,7 +212,8 @@ func (d *NextAvailableDataSource) Read(ctx context.Context, req datasource.ReadR
 		}
 
 		existing := allocsDB.GetAllocationsForPool(poolID)
-		cidr, err = allocator.FindNextAvailableInPool(poolDef, existing, prefixLen)
+		opts := allocsDB.AllocateOptionsForPool(poolID, poolDef)
+		cidr, err = allocator.FindNextAvailableInPoolWithOptions(poolDef, existing, prefixLen, opts)
 
 		data.ID = types.StringValue(fmt.Sprintf("next:%s:/%d", poolID, prefixLen))
 
@@ -188,7 +232,7 @@ func (d *NextAvailableDataSource) Read(ctx context.Context, req datasource.ReadR
 		}
 
 		// Verify parent CIDR exists as an allocation
-		_, _, found := allocsDB.FindAllocationByCIDR(parentCIDR)
+		parentAlloc, _, found := allocsDB.FindAllocationByCIDR(parentCIDR)
 		if !found {
 			resp.Diagnostics.AddError(
 				"Parent CIDR Not Found",
@@ -197,8 +241,13 @@ func (d *NextAvailableDataSource) Read(ctx context.Context, req datasource.ReadR
 			return
 		}
 
+		if err := ipam.CheckParentAllocatable(parentCIDR, parentAlloc); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("parent_cidr"), "Parent Not Allocatable", err.Error())
+			return
+		}
+
 		children := allocsDB.GetAllocationsForParent(parentCIDR)
-		cidr, err = allocator.FindNextAvailableInParent(parentCIDR, children, prefixLen)
+		cidr, err = allocator.FindNextAvailableInParent(parentCIDR, children, prefixLen, ipam.PlacementLow)
 
 		data.ID = types.StringValue(fmt.Sprintf("next:%s:/%d", parentCIDR, prefixLen))
 	}
@@ -215,3 +264,65 @@ func (d *NextAvailableDataSource) Read(ctx context.Context, req datasource.ReadR
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
+
+// claim holds the next available block of a pool by writing a reservation
+// that expires after reserve_for_seconds.
+func (d *NextAvailableDataSource) claim(ctx context.Context, data *NextAvailableDataSourceModel, prefixLen int, resp *datasource.ReadResponse) {
+	poolID := data.PoolID.ValueString()
+	ttl := time.Duration(data.ReserveFor.ValueInt64()) * time.Second
+	req := ipam.AllocationRequest{
+		Name:      data.Name.ValueString(),
+		PoolID:    poolID,
+		PrefixLen: prefixLen,
+	}
+
+	var claimed *ipam.Allocation
+	retryConfig := d.client.RetryConfig()
+
+	err := client.WithRetry(ctx, retryConfig, func(ctx context.Context, attempt int) (bool, error) {
+		pools, err := d.client.GetPools(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read pools: %w", err)
+		}
+		poolDef, exists := pools.GetPool(poolID)
+		if !exists {
+			return false, fmt.Errorf("pool %q not found in pools.yaml", poolID)
+		}
+
+		db, sha, err := d.client.GetAllocations(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read allocations: %w", err)
+		}
+
+		claim, err := db.Claim(req, poolDef, uuid.New().String(), time.Now().Add(ttl))
+		if err != nil {
+			return false, err
+		}
+
+		commitMsg := fmt.Sprintf("ipam: claim %s (%s) until %s", claim.CIDR, claim.Name, claim.ExpiresAt)
+		err = d.client.UpdateAllocations(ctx, db, sha, commitMsg)
+		if d.client.IsConflictError(err) {
+			tflog.Debug(ctx, "Conflict detected, will retry", map[string]interface{}{
+				"attempt": attempt,
+			})
+			return true, err
+		}
+		if err == nil {
+			claimed = claim
+		}
+		return false, err
+	})
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Claim CIDR",
+			fmt.Sprintf("Unable to claim a /%d block: %s", prefixLen, err),
+		)
+		return
+	}
+
+	data.ID = types.StringValue(fmt.Sprintf("next:%s:/%d", poolID, prefixLen))
+	data.CIDR = types.StringValue(claimed.CIDR)
+	data.ExpiresAt = types.StringValue(claimed.ExpiresAt)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, data)...)
+}
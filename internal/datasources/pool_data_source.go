@@ -29,6 +29,8 @@ type PoolDataSourceModel struct {
 	Description types.String `tfsdk:"description"`
 	CIDRs       types.List   `tfsdk:"cidrs"`
 	Metadata    types.Map    `tfsdk:"metadata"`
+	Provisional types.Bool   `tfsdk:"provisional"`
+	PullRequest types.Int64  `tfsdk:"pull_request"`
 }
 
 // NewPoolDataSource creates a new data source.
@@ -67,6 +69,17 @@ func (d *PoolDataSource) Schema(ctx context.Context, req datasource.SchemaReques
 				ElementType: types.StringType,
 				Computed:    true,
 			},
+			"provisional": schema.BoolAttribute{
+				Description: "True if the pool is only defined in an open pull request (see include_open_prs). " +
+					"Allocations from it fail until the pull request is merged.",
+				MarkdownDescription: "True if the pool is only defined in an open pull request (see `include_open_prs`). " +
+					"Allocations from it fail until the pull request is merged.",
+				Computed: true,
+			},
+			"pull_request": schema.Int64Attribute{
+				Description: "Number of the pull request a provisional pool was read from. Null for merged pools.",
+				Computed:    true,
+			},
 		},
 	}
 }
@@ -99,7 +112,7 @@ func (d *PoolDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 	poolID := data.PoolID.ValueString()
 
 	// Fetch pools from GitHub
-	poolsConfig, err := d.client.GetPools(ctx)
+	poolsConfig, err := d.client.GetPoolsWithProposed(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to Read Pools",
@@ -141,6 +154,12 @@ func (d *PoolDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 	data.Description = types.StringValue(poolDef.Description)
 	data.CIDRs = cidrs
 	data.Metadata = metadata
+	data.Provisional = types.BoolValue(false)
+	data.PullRequest = types.Int64Null()
+	if prNumber, ok := poolsConfig.ProposedIn(poolID); ok {
+		data.Provisional = types.BoolValue(true)
+		data.PullRequest = types.Int64Value(int64(prNumber))
+	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
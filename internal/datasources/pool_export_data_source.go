@@ -0,0 +1,256 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &PoolExportDataSource{}
+var _ datasource.DataSourceWithConfigure = &PoolExportDataSource{}
+
+// PoolExportDataSource defines the data source implementation.
+type PoolExportDataSource struct {
+	client *client.GitHubClient
+}
+
+// PoolExportDataSourceModel describes the data source data model.
+type PoolExportDataSourceModel struct {
+	ID             types.String                         `tfsdk:"id"`
+	PoolID         types.String                         `tfsdk:"pool_id"`
+	Description    types.String                         `tfsdk:"description"`
+	CIDRs          types.List                           `tfsdk:"cidrs"`
+	Allocations    map[string]PoolExportAllocationModel `tfsdk:"allocations"`
+	SubAllocations map[string]PoolExportAllocationModel `tfsdk:"sub_allocations"`
+}
+
+// PoolExportAllocationModel describes one exported allocation.
+type PoolExportAllocationModel struct {
+	ID         types.String `tfsdk:"id"`
+	CIDR       types.String `tfsdk:"cidr"`
+	CIDRMask   types.Int64  `tfsdk:"cidr_mask"`
+	Name       types.String `tfsdk:"name"`
+	Status     types.String `tfsdk:"status"`
+	ParentCIDR types.String `tfsdk:"parent_cidr"`
+	VLANID     types.Int64  `tfsdk:"vlan_id"`
+	Region     types.String `tfsdk:"region"`
+	Metadata   types.Map    `tfsdk:"metadata"`
+}
+
+// NewPoolExportDataSource creates a new data source.
+func NewPoolExportDataSource() datasource.DataSource {
+	return &PoolExportDataSource{}
+}
+
+func (d *PoolExportDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_pool_export"
+}
+
+func (d *PoolExportDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	allocationAttributes := map[string]schema.Attribute{
+		"id": schema.StringAttribute{
+			Description: "Allocation ID, for import blocks.",
+			Computed:    true,
+		},
+		"cidr": schema.StringAttribute{
+			Description: "Allocated CIDR block.",
+			Computed:    true,
+		},
+		"cidr_mask": schema.Int64Attribute{
+			Description: "Prefix length of the block.",
+			Computed:    true,
+		},
+		"name": schema.StringAttribute{
+			Description: "Allocation name.",
+			Computed:    true,
+		},
+		"status": schema.StringAttribute{
+			Description: "Lifecycle status: allocation, reservation or decommissioning.",
+			Computed:    true,
+		},
+		"parent_cidr": schema.StringAttribute{
+			Description: "CIDR of the parent allocation. Null for top-level allocations.",
+			Computed:    true,
+		},
+		"vlan_id": schema.Int64Attribute{
+			Description: "802.1Q VLAN ID of the allocation, if one is set.",
+			Computed:    true,
+		},
+		"region": schema.StringAttribute{
+			Description: "Region of the allocation, if one is set.",
+			Computed:    true,
+		},
+		"metadata": schema.MapAttribute{
+			Description: "Key-value metadata for the allocation, including pool defaults.",
+			ElementType: types.StringType,
+			Computed:    true,
+		},
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Exports a pool with all of its allocations, keyed by allocation name, to generate resource blocks from live state.",
+		MarkdownDescription: `Exports a pool with all of its allocations, keyed by allocation name, so a module can generate
+` + "`github-ipam_allocation`" + ` resources and import blocks from live state with ` + "`for_each`" + `.
+Top-level allocations and sub-allocations are returned separately. Claims held by
+` + "`github-ipam_next_available`" + ` are left out.
+
+**Example:**
+` + "```hcl" + `
+data "github-ipam_pool_export" "prod" {
+  pool_id = "prod"
+}
+
+import {
+  for_each = data.github-ipam_pool_export.prod.allocations
+  to       = github-ipam_allocation.vpc[each.key]
+  id       = each.value.id
+}
+
+resource "github-ipam_allocation" "vpc" {
+  for_each  = data.github-ipam_pool_export.prod.allocations
+  pool_id   = "prod"
+  cidr_mask = each.value.cidr_mask
+  name      = each.value.name
+}
+` + "```",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"pool_id": schema.StringAttribute{
+				Description: "The pool to export.",
+				Required:    true,
+			},
+			"description": schema.StringAttribute{
+				Description: "Human-readable description of the pool.",
+				Computed:    true,
+			},
+			"cidrs": schema.ListAttribute{
+				Description: "CIDR ranges of the pool.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"allocations": schema.MapNestedAttribute{
+				Description:  "Top-level allocations of the pool, keyed by name.",
+				Computed:     true,
+				NestedObject: schema.NestedAttributeObject{Attributes: allocationAttributes},
+			},
+			"sub_allocations": schema.MapNestedAttribute{
+				Description:  "Sub-allocations at any depth below the pool's allocations, keyed by name.",
+				Computed:     true,
+				NestedObject: schema.NestedAttributeObject{Attributes: allocationAttributes},
+			},
+		},
+	}
+}
+
+func (d *PoolExportDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *PoolExportDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data PoolExportDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	poolID := data.PoolID.ValueString()
+
+	poolsConfig, err := d.client.GetPools(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Pools",
+			fmt.Sprintf("Unable to read pools from GitHub: %s", err),
+		)
+		return
+	}
+
+	allocsDB, _, err := d.client.GetAllocations(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Allocations",
+			fmt.Sprintf("Unable to read allocations from GitHub: %s", err),
+		)
+		return
+	}
+
+	export, err := ipam.ExportPool(poolsConfig, allocsDB, poolID)
+	if err != nil {
+		resp.Diagnostics.AddError("Pool Not Found", err.Error())
+		return
+	}
+
+	cidrs, diags := types.ListValueFrom(ctx, types.StringType, export.Pool.CIDR)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.ID = types.StringValue("export:" + poolID)
+	data.Description = types.StringValue(export.Pool.Description)
+	data.CIDRs = cidrs
+	data.Allocations = exportAllocationModels(ctx, export.Allocations, &resp.Diagnostics)
+	data.SubAllocations = exportAllocationModels(ctx, export.SubAllocations, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// exportAllocationModels converts allocations to the nested models, keyed
+// by name. Names are unique across the database, so keys are too.
+func exportAllocationModels(ctx context.Context, allocations []ipam.Allocation, diags *diag.Diagnostics) map[string]PoolExportAllocationModel {
+	models := make(map[string]PoolExportAllocationModel, len(allocations))
+	for _, alloc := range allocations {
+		model := PoolExportAllocationModel{
+			ID:         types.StringValue(alloc.ID),
+			CIDR:       types.StringValue(alloc.CIDR),
+			CIDRMask:   types.Int64Null(),
+			Name:       types.StringValue(alloc.Name),
+			Status:     types.StringValue(alloc.GetStatus()),
+			ParentCIDR: types.StringPointerValue(alloc.ParentCIDR),
+			VLANID:     vlanIDValue(alloc.VLANID),
+			Region:     regionValue(alloc.Region),
+			Metadata:   types.MapNull(types.StringType),
+		}
+		if _, network, err := net.ParseCIDR(alloc.CIDR); err == nil {
+			ones, _ := network.Mask.Size()
+			model.CIDRMask = types.Int64Value(int64(ones))
+		}
+		if len(alloc.Metadata) > 0 {
+			metadata, d := types.MapValueFrom(ctx, types.StringType, alloc.Metadata)
+			diags.Append(d...)
+			model.Metadata = metadata
+		}
+		models[alloc.Name] = model
+	}
+	return models
+}
@@ -0,0 +1,199 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// defaultHistoryCommits bounds pool_history when max_commits is unset.
+const defaultHistoryCommits = 30
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &PoolHistoryDataSource{}
+var _ datasource.DataSourceWithConfigure = &PoolHistoryDataSource{}
+
+// PoolHistoryDataSource defines the data source implementation.
+type PoolHistoryDataSource struct {
+	client *client.GitHubClient
+}
+
+// PoolHistoryDataSourceModel describes the data source data model.
+type PoolHistoryDataSourceModel struct {
+	ID         types.String            `tfsdk:"id"`
+	PoolID     types.String            `tfsdk:"pool_id"`
+	Since      types.String            `tfsdk:"since"`
+	MaxCommits types.Int64             `tfsdk:"max_commits"`
+	Points     []PoolHistoryPointModel `tfsdk:"points"`
+}
+
+// PoolHistoryPointModel describes the pool's usage at one commit.
+type PoolHistoryPointModel struct {
+	CommitSHA          types.String  `tfsdk:"commit_sha"`
+	Timestamp          types.String  `tfsdk:"timestamp"`
+	AllocationCount    types.Int64   `tfsdk:"allocation_count"`
+	UsedAddresses      types.Int64   `tfsdk:"used_addresses"`
+	UtilizationPercent types.Float64 `tfsdk:"utilization_percent"`
+}
+
+// NewPoolHistoryDataSource creates a new data source.
+func NewPoolHistoryDataSource() datasource.DataSource {
+	return &PoolHistoryDataSource{}
+}
+
+func (d *PoolHistoryDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_pool_history"
+}
+
+func (d *PoolHistoryDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Reconstructs a pool's utilization at each recent commit to the allocations file, for capacity forecasting.",
+		MarkdownDescription: "Reconstructs a pool's utilization at each recent commit to the allocations file, for capacity forecasting. " +
+			"Every point is measured against the pool's current CIDRs. Each commit costs one file read the first time it is seen; " +
+			"later reads in the same provider process are served from a cache.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"pool_id": schema.StringAttribute{
+				Description: "The pool to report on.",
+				Required:    true,
+			},
+			"since": schema.StringAttribute{
+				Description: "Only include commits after this RFC3339 timestamp.",
+				Optional:    true,
+			},
+			"max_commits": schema.Int64Attribute{
+				Description: fmt.Sprintf("Maximum number of commits to walk back, newest first. Defaults to %d.", defaultHistoryCommits),
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 1000),
+				},
+			},
+			"points": schema.ListNestedAttribute{
+				Description: "The pool's usage at each commit, oldest first.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"commit_sha": schema.StringAttribute{
+							Description: "Commit that wrote this version of the allocations file.",
+							Computed:    true,
+						},
+						"timestamp": schema.StringAttribute{
+							Description: "Commit time, RFC3339.",
+							Computed:    true,
+						},
+						"allocation_count": schema.Int64Attribute{
+							Description: "Allocations in the pool, including reservations and sub-allocations.",
+							Computed:    true,
+						},
+						"used_addresses": schema.Int64Attribute{
+							Description: "Addresses held by top-level allocations.",
+							Computed:    true,
+						},
+						"utilization_percent": schema.Float64Attribute{
+							Description: "Used addresses as a percentage of the pool's current size.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *PoolHistoryDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *PoolHistoryDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data PoolHistoryDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var since time.Time
+	if !data.Since.IsNull() {
+		parsed, err := time.Parse(time.RFC3339, data.Since.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("since"), "Invalid Timestamp", err.Error())
+			return
+		}
+		since = parsed
+	}
+	maxCommits := defaultHistoryCommits
+	if !data.MaxCommits.IsNull() {
+		maxCommits = int(data.MaxCommits.ValueInt64())
+	}
+
+	poolID := data.PoolID.ValueString()
+	poolsConfig, err := d.client.GetPools(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Pools",
+			fmt.Sprintf("Unable to read pools from GitHub: %s", err),
+		)
+		return
+	}
+	poolDef, exists := poolsConfig.GetPool(poolID)
+	if !exists {
+		resp.Diagnostics.AddError(
+			"Pool Not Found",
+			fmt.Sprintf("Pool with ID %q not found in pools.yaml", poolID),
+		)
+		return
+	}
+
+	snapshots, err := d.client.GetAllocationsHistory(ctx, since, maxCommits)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Allocations History",
+			fmt.Sprintf("Unable to read allocations history from GitHub: %s", err),
+		)
+		return
+	}
+
+	points := make([]PoolHistoryPointModel, 0, len(snapshots))
+	for _, point := range ipam.UtilizationSeries(*poolDef, poolID, snapshots) {
+		points = append(points, PoolHistoryPointModel{
+			CommitSHA:          types.StringValue(point.CommitSHA),
+			Timestamp:          types.StringValue(point.Timestamp.UTC().Format(time.RFC3339)),
+			AllocationCount:    types.Int64Value(int64(point.Allocations)),
+			UsedAddresses:      types.Int64Value(int64(point.UsedAddrs)),
+			UtilizationPercent: types.Float64Value(point.Utilization),
+		})
+	}
+
+	data.ID = types.StringValue("history:" + poolID)
+	data.Points = points
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -33,6 +33,7 @@ type PoolSummaryModel struct {
 	PoolID      types.String `tfsdk:"pool_id"`
 	Description types.String `tfsdk:"description"`
 	CIDRs       types.List   `tfsdk:"cidrs"`
+	Provisional types.Bool   `tfsdk:"provisional"`
 }
 
 // NewPoolsDataSource creates a new data source.
@@ -72,6 +73,10 @@ func (d *PoolsDataSource) Schema(ctx context.Context, req datasource.SchemaReque
 							ElementType: types.StringType,
 							Computed:    true,
 						},
+						"provisional": schema.BoolAttribute{
+							Description: "True if the pool is only defined in an open pull request.",
+							Computed:    true,
+						},
 					},
 				},
 			},
@@ -105,7 +110,7 @@ func (d *PoolsDataSource) Read(ctx context.Context, req datasource.ReadRequest,
 	}
 
 	// Fetch pools from GitHub
-	poolsConfig, err := d.client.GetPools(ctx)
+	poolsConfig, err := d.client.GetPoolsWithProposed(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to Read Pools",
@@ -128,10 +133,12 @@ func (d *PoolsDataSource) Read(ctx context.Context, req datasource.ReadRequest,
 			return
 		}
 
+		_, provisional := poolsConfig.ProposedIn(poolID)
 		pools = append(pools, PoolSummaryModel{
 			PoolID:      types.StringValue(poolID),
 			Description: types.StringValue(poolDef.Description),
 			CIDRs:       cidrs,
+			Provisional: types.BoolValue(provisional),
 		})
 	}
 
@@ -0,0 +1,197 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &PrefixListDataSource{}
+var _ datasource.DataSourceWithConfigure = &PrefixListDataSource{}
+
+// PrefixListDataSource defines the data source implementation.
+type PrefixListDataSource struct {
+	client *client.GitHubClient
+}
+
+// PrefixListDataSourceModel describes the data source data model.
+type PrefixListDataSourceModel struct {
+	ID            types.String           `tfsdk:"id"`
+	PoolID        types.String           `tfsdk:"pool_id"`
+	Labels        types.List             `tfsdk:"labels"`
+	AddressFamily types.String           `tfsdk:"address_family"`
+	MaxEntries    types.Int64            `tfsdk:"max_entries"`
+	Entries       []PrefixListEntryModel `tfsdk:"entries"`
+}
+
+// PrefixListEntryModel describes one prefix list entry.
+type PrefixListEntryModel struct {
+	CIDR        types.String `tfsdk:"cidr"`
+	Description types.String `tfsdk:"description"`
+}
+
+// NewPrefixListDataSource creates a new data source.
+func NewPrefixListDataSource() datasource.DataSource {
+	return &PrefixListDataSource{}
+}
+
+func (d *PrefixListDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_prefix_list"
+}
+
+func (d *PrefixListDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Returns allocations as entries for an AWS managed prefix list, filtered by pool and labels.",
+		MarkdownDescription: `Returns allocations as entries for an AWS managed prefix list, filtered by pool and labels.
+
+Each entry is an allocation's CIDR, described by its name. Blocks inside another listed block, such as
+sub-allocations of a listed VPC, would only repeat it and are left out, as are claims. Reading fails when
+there are more entries than ` + "`max_entries`" + `, so the prefix list is never silently cut short.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"pool_id": schema.StringAttribute{
+				Description: "Only list allocations in this pool.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.AtLeastOneOf(path.MatchRoot("pool_id"), path.MatchRoot("labels")),
+				},
+			},
+			"labels": schema.ListAttribute{
+				Description: "Only list allocations carrying every one of these labels.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"address_family": schema.StringAttribute{
+				Description:         "Only list blocks of this family, IPv4 or IPv6, as a prefix list holds one. Lists both when unset.",
+				MarkdownDescription: "Only list blocks of this family, `IPv4` or `IPv6`, as a prefix list holds one. Lists both when unset.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("IPv4", "IPv6"),
+				},
+			},
+			"max_entries": schema.Int64Attribute{
+				Description:         fmt.Sprintf("The max_entries of the prefix list the entries are for. Defaults to %d, the most AWS allows.", ipam.PrefixListMaxEntries),
+				MarkdownDescription: fmt.Sprintf("The `max_entries` of the prefix list the entries are for. Defaults to `%d`, the most AWS allows.", ipam.PrefixListMaxEntries),
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, ipam.PrefixListMaxEntries),
+				},
+			},
+			"entries": schema.ListNestedAttribute{
+				Description: "Prefix list entries, in address order.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"cidr": schema.StringAttribute{
+							Description: "The entry's CIDR block.",
+							Computed:    true,
+						},
+						"description": schema.StringAttribute{
+							Description: "The allocation name, cut to the 255 characters AWS allows.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *PrefixListDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *PrefixListDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data PrefixListDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var labels []string
+	if !data.Labels.IsNull() {
+		resp.Diagnostics.Append(data.Labels.ElementsAs(ctx, &labels, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	allocsDB, _, err := d.client.GetAllocations(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Allocations",
+			fmt.Sprintf("Unable to read allocations from GitHub: %s", err),
+		)
+		return
+	}
+
+	filterID := "prefix_list"
+	allocations := allocsDB.AllAllocations()
+	if !data.PoolID.IsNull() {
+		allocations = allocsDB.GetAllocationsForPool(data.PoolID.ValueString())
+		filterID += "|pool:" + data.PoolID.ValueString()
+	}
+	if len(labels) > 0 {
+		allocations = ipam.FilterByLabels(allocations, labels)
+		filterID += "|labels:" + strings.Join(labels, ",")
+	}
+	if !data.AddressFamily.IsNull() {
+		filterID += "|family:" + data.AddressFamily.ValueString()
+	}
+
+	entries := ipam.PrefixListEntries(allocations, data.AddressFamily.ValueString())
+	maxEntries := ipam.PrefixListMaxEntries
+	if !data.MaxEntries.IsNull() {
+		maxEntries = int(data.MaxEntries.ValueInt64())
+	}
+	if err := ipam.CheckPrefixListSize(entries, maxEntries); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("max_entries"),
+			"Too Many Prefix List Entries",
+			fmt.Sprintf("The filtered allocations yield %s. Raise max_entries on the prefix list, or narrow the filter.", err),
+		)
+		return
+	}
+
+	data.ID = types.StringValue(filterID)
+	data.Entries = make([]PrefixListEntryModel, 0, len(entries))
+	for _, entry := range entries {
+		data.Entries = append(data.Entries, PrefixListEntryModel{
+			CIDR:        types.StringValue(entry.CIDR),
+			Description: types.StringValue(entry.Description),
+		})
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
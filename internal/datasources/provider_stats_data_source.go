@@ -0,0 +1,105 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &ProviderStatsDataSource{}
+var _ datasource.DataSourceWithConfigure = &ProviderStatsDataSource{}
+
+// ProviderStatsDataSource defines the data source implementation.
+type ProviderStatsDataSource struct {
+	client *client.GitHubClient
+}
+
+// ProviderStatsDataSourceModel describes the data source data model.
+type ProviderStatsDataSourceModel struct {
+	ID                 types.String `tfsdk:"id"`
+	APICalls           types.Int64  `tfsdk:"api_calls"`
+	ConflictsRetried   types.Int64  `tfsdk:"conflicts_retried"`
+	AllocationsCreated types.Int64  `tfsdk:"allocations_created"`
+	AllocationsDeleted types.Int64  `tfsdk:"allocations_deleted"`
+}
+
+// NewProviderStatsDataSource creates a new data source.
+func NewProviderStatsDataSource() datasource.DataSource {
+	return &ProviderStatsDataSource{}
+}
+
+func (d *ProviderStatsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_provider_stats"
+}
+
+func (d *ProviderStatsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Counters accumulated by this provider instance so far, for self-monitoring.",
+		MarkdownDescription: `Counters accumulated by this provider instance so far, for self-monitoring.
+
+The values cover only the current run: Terraform starts a new provider instance for each plan and apply, and a
+data source is read before the resources it sits beside are changed. Read it from an ` + "`output`" + ` with
+` + "`depends_on`" + ` on the resources of interest, or compare runs, to spot runaway retry loops in CI.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"api_calls": schema.Int64Attribute{
+				Description: "GitHub API requests sent, including retries.",
+				Computed:    true,
+			},
+			"conflicts_retried": schema.Int64Attribute{
+				Description: "Writes rejected because another writer changed the file first.",
+				Computed:    true,
+			},
+			"allocations_created": schema.Int64Attribute{
+				Description: "Allocations and reservations committed.",
+				Computed:    true,
+			},
+			"allocations_deleted": schema.Int64Attribute{
+				Description: "Allocations and reservations removed.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *ProviderStatsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *ProviderStatsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	stats := d.client.Stats()
+
+	data := ProviderStatsDataSourceModel{
+		ID:                 types.StringValue("provider_stats"),
+		APICalls:           types.Int64Value(stats.APICalls),
+		ConflictsRetried:   types.Int64Value(stats.ConflictsRetried),
+		AllocationsCreated: types.Int64Value(stats.AllocationsCreated),
+		AllocationsDeleted: types.Int64Value(stats.AllocationsDeleted),
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,109 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"net"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &ReverseZonesDataSource{}
+
+// ReverseZonesDataSource defines the data source implementation.
+type ReverseZonesDataSource struct{}
+
+// ReverseZonesDataSourceModel describes the data source data model.
+type ReverseZonesDataSourceModel struct {
+	ID      types.String `tfsdk:"id"`
+	CIDR    types.String `tfsdk:"cidr"`
+	Zones   types.List   `tfsdk:"zones"`
+	Partial types.Bool   `tfsdk:"partial"`
+}
+
+// NewReverseZonesDataSource creates a new data source.
+func NewReverseZonesDataSource() datasource.DataSource {
+	return &ReverseZonesDataSource{}
+}
+
+func (d *ReverseZonesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_reverse_zones"
+}
+
+func (d *ReverseZonesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the in-addr.arpa reverse DNS zones covering an IPv4 block.",
+		MarkdownDescription: `Lists the /24-aligned ` + "`in-addr.arpa`" + ` reverse DNS zones covering an IPv4 block.
+
+A block larger than /24 spans several zones. A block smaller than /24 returns the zone
+containing it and sets ` + "`partial`" + `, since the zone also holds addresses outside the block.
+
+**Example:**
+` + "```hcl" + `
+data "github-ipam_reverse_zones" "vpc" {
+  cidr = github-ipam_allocation.vpc.cidr
+}
+
+resource "aws_route53_zone" "reverse" {
+  for_each = toset(data.github-ipam_reverse_zones.vpc.zones)
+  name     = each.value
+}
+` + "```",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"cidr": schema.StringAttribute{
+				Description: "IPv4 block to list reverse zones for, typically an allocation's cidr.",
+				Required:    true,
+			},
+			"zones": schema.ListAttribute{
+				Description: "Reverse zones covering the block, in address order.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"partial": schema.BoolAttribute{
+				Description: "True when the block is smaller than /24 and only fills part of its zone.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *ReverseZonesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ReverseZonesDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	cidr := data.CIDR.ValueString()
+	zones, err := ipam.ReverseZones(cidr)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("cidr"), "Invalid CIDR", err.Error())
+		return
+	}
+
+	zonesValue, diags := types.ListValueFrom(ctx, types.StringType, zones)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, network, _ := net.ParseCIDR(cidr)
+	prefixLen, _ := network.Mask.Size()
+	data.ID = types.StringValue("reverse:" + cidr)
+	data.Zones = zonesValue
+	data.Partial = types.BoolValue(prefixLen > 24)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
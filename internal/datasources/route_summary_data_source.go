@@ -0,0 +1,130 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &RouteSummaryDataSource{}
+var _ datasource.DataSourceWithConfigure = &RouteSummaryDataSource{}
+
+// RouteSummaryDataSource defines the data source implementation.
+type RouteSummaryDataSource struct {
+	client *client.GitHubClient
+}
+
+// RouteSummaryDataSourceModel describes the data source data model.
+type RouteSummaryDataSourceModel struct {
+	ID     types.String `tfsdk:"id"`
+	PoolID types.String `tfsdk:"pool_id"`
+	Routes types.List   `tfsdk:"routes"`
+}
+
+// NewRouteSummaryDataSource creates a new data source.
+func NewRouteSummaryDataSource() datasource.DataSource {
+	return &RouteSummaryDataSource{}
+}
+
+func (d *RouteSummaryDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_route_summary"
+}
+
+func (d *RouteSummaryDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Returns the smallest set of aggregate routes covering a pool's allocations, for routing config.",
+		MarkdownDescription: `Returns the smallest set of aggregate routes covering a pool's allocations, for routing config.
+
+Blocks contained in another are dropped and adjacent blocks are merged into a supernet wherever they cover it
+fully, so two neighbouring /24s on a /23 boundary become one /23. Reservations and claims carry no traffic and
+are left out; decommissioning blocks are still routed.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"pool_id": schema.StringAttribute{
+				Description: "The pool to summarize.",
+				Required:    true,
+			},
+			"routes": schema.ListAttribute{
+				Description: "Aggregate CIDRs, IPv4 before IPv6, each in address order.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *RouteSummaryDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *RouteSummaryDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data RouteSummaryDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	poolID := data.PoolID.ValueString()
+
+	poolsConfig, err := d.client.GetPools(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Pools",
+			fmt.Sprintf("Unable to read pools from GitHub: %s", err),
+		)
+		return
+	}
+	if _, exists := poolsConfig.GetPool(poolID); !exists {
+		resp.Diagnostics.AddError(
+			"Pool Not Found",
+			fmt.Sprintf("Pool %q not found in pools.yaml", poolID),
+		)
+		return
+	}
+
+	allocsDB, _, err := d.client.GetAllocations(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Allocations",
+			fmt.Sprintf("Unable to read allocations from GitHub: %s", err),
+		)
+		return
+	}
+
+	routes, diags := types.ListValueFrom(ctx, types.StringType, ipam.RouteSummary(allocsDB.GetAllocationsForPool(poolID)))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.ID = types.StringValue("routes:" + poolID)
+	data.Routes = routes
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
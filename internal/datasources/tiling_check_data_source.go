@@ -0,0 +1,150 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package datasources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ datasource.DataSource = &TilingCheckDataSource{}
+var _ datasource.DataSourceWithConfigure = &TilingCheckDataSource{}
+
+// TilingCheckDataSource defines the data source implementation.
+type TilingCheckDataSource struct {
+	client *client.GitHubClient
+}
+
+// TilingCheckDataSourceModel describes the data source data model.
+type TilingCheckDataSourceModel struct {
+	ID         types.String `tfsdk:"id"`
+	ParentCIDR types.String `tfsdk:"parent_cidr"`
+	Tiled      types.Bool   `tfsdk:"tiled"`
+	ChildCount types.Int64  `tfsdk:"child_count"`
+	Gaps       types.List   `tfsdk:"gaps"`
+	Overlaps   types.List   `tfsdk:"overlaps"`
+}
+
+// NewTilingCheckDataSource creates a new data source.
+func NewTilingCheckDataSource() datasource.DataSource {
+	return &TilingCheckDataSource{}
+}
+
+func (d *TilingCheckDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_tiling_check"
+}
+
+func (d *TilingCheckDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Checks whether an allocation's sub-allocations exactly tile it, with no gaps and no overlaps.",
+		MarkdownDescription: `Checks whether an allocation's sub-allocations exactly tile it, with no gaps and no overlaps.
+
+Pair it with a ` + "`check`" + ` block or a ` + "`precondition`" + ` to assert a VPC is fully subdivided before it is
+considered complete.`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source.",
+				Computed:    true,
+			},
+			"parent_cidr": schema.StringAttribute{
+				Description: "CIDR of the allocation whose sub-allocations are checked.",
+				Required:    true,
+			},
+			"tiled": schema.BoolAttribute{
+				Description: "True when the sub-allocations cover the parent exactly, with no gaps or overlaps.",
+				Computed:    true,
+			},
+			"child_count": schema.Int64Attribute{
+				Description: "Number of direct sub-allocations of the parent.",
+				Computed:    true,
+			},
+			"gaps": schema.ListAttribute{
+				Description: "Space in the parent no sub-allocation covers, as the fewest aligned CIDRs, in address order.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"overlaps": schema.ListAttribute{
+				Description: "Sub-allocations overlapping an earlier sibling, in address order.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *TilingCheckDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *TilingCheckDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data TilingCheckDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	allocsDB, _, err := d.client.GetAllocations(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Allocations",
+			fmt.Sprintf("Unable to read allocations from GitHub: %s", err),
+		)
+		return
+	}
+
+	parentCIDR := data.ParentCIDR.ValueString()
+	if _, _, found := allocsDB.FindAllocationByCIDR(parentCIDR); !found {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("parent_cidr"),
+			"Parent Allocation Not Found",
+			fmt.Sprintf("No allocation with CIDR %s exists.", parentCIDR),
+		)
+		return
+	}
+
+	children := allocsDB.GetAllocationsForParent(parentCIDR)
+	result, err := ipam.CheckTiling(parentCIDR, children)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("parent_cidr"), "Invalid Parent CIDR", err.Error())
+		return
+	}
+
+	gaps, diags := types.ListValueFrom(ctx, types.StringType, result.Gaps)
+	resp.Diagnostics.Append(diags...)
+	overlaps, diags := types.ListValueFrom(ctx, types.StringType, result.Overlaps)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.ID = types.StringValue("tiling:" + parentCIDR)
+	data.Tiled = types.BoolValue(result.Tiled)
+	data.ChildCount = types.Int64Value(int64(len(children)))
+	data.Gaps = gaps
+	data.Overlaps = overlaps
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
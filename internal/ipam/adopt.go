@@ -0,0 +1,53 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"net"
+)
+
+// CheckAdoptable reports whether an existing allocation, found by name in
+// existingPoolID, matches a request closely enough to be adopted instead of
+// creating a new one. Adoption is rejected when the existing block's mask,
+// pool, or parent conflicts with the request.
+func CheckAdoptable(existing *Allocation, existingPoolID string, req AllocationRequest) error {
+	_, network, err := net.ParseCIDR(existing.CIDR)
+	if err != nil {
+		return fmt.Errorf("cannot adopt allocation %q: invalid CIDR %s: %w", existing.Name, existing.CIDR, err)
+	}
+	ones, _ := network.Mask.Size()
+	if req.PrefixLen == 0 && req.MaxPrefixLen > 0 {
+		if ones < req.MinPrefixLen || ones > req.MaxPrefixLen {
+			return fmt.Errorf("cannot adopt allocation %q: existing CIDR %s is a /%d, config requests /%d to /%d",
+				existing.Name, existing.CIDR, ones, req.MinPrefixLen, req.MaxPrefixLen)
+		}
+	} else if ones != req.PrefixLen {
+		return fmt.Errorf("cannot adopt allocation %q: existing CIDR %s is a /%d, config requests a /%d",
+			existing.Name, existing.CIDR, ones, req.PrefixLen)
+	}
+
+	switch {
+	case req.PoolID != "":
+		if existing.ParentCIDR != nil {
+			return fmt.Errorf("cannot adopt allocation %q: existing %s is a sub-allocation of %s, config requests pool %q",
+				existing.Name, existing.CIDR, *existing.ParentCIDR, req.PoolID)
+		}
+		if existingPoolID != req.PoolID {
+			return fmt.Errorf("cannot adopt allocation %q: existing %s is in pool %q, config requests pool %q",
+				existing.Name, existing.CIDR, existingPoolID, req.PoolID)
+		}
+	case req.ParentCIDR != "":
+		if existing.ParentCIDR == nil || *existing.ParentCIDR != req.ParentCIDR {
+			parent := "no parent"
+			if existing.ParentCIDR != nil {
+				parent = "parent " + *existing.ParentCIDR
+			}
+			return fmt.Errorf("cannot adopt allocation %q: existing %s has %s, config requests parent %s",
+				existing.Name, existing.CIDR, parent, req.ParentCIDR)
+		}
+	}
+
+	return nil
+}
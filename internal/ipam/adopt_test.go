@@ -0,0 +1,107 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckAdoptable_PoolMatch(t *testing.T) {
+	existing := &Allocation{CIDR: "10.0.0.0/16", ID: "vpc-1", Name: "vpc-prod"}
+
+	err := CheckAdoptable(existing, "prod", AllocationRequest{
+		Name:      "vpc-prod",
+		PoolID:    "prod",
+		PrefixLen: 16,
+	})
+	if err != nil {
+		t.Errorf("expected adoption to succeed, got %v", err)
+	}
+}
+
+func TestCheckAdoptable_ParentMatch(t *testing.T) {
+	existing := &Allocation{CIDR: "10.0.1.0/24", ID: "sub-1", Name: "web", ParentCIDR: strPtr("10.0.0.0/16")}
+
+	err := CheckAdoptable(existing, "prod", AllocationRequest{
+		Name:       "web",
+		ParentCIDR: "10.0.0.0/16",
+		PrefixLen:  24,
+	})
+	if err != nil {
+		t.Errorf("expected adoption to succeed, got %v", err)
+	}
+}
+
+func TestCheckAdoptable_Mismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing Allocation
+		poolID   string
+		req      AllocationRequest
+		wantErr  string
+	}{
+		{
+			name:     "mask",
+			existing: Allocation{CIDR: "10.0.0.0/16", Name: "vpc"},
+			poolID:   "prod",
+			req:      AllocationRequest{Name: "vpc", PoolID: "prod", PrefixLen: 20},
+			wantErr:  "existing CIDR 10.0.0.0/16 is a /16, config requests a /20",
+		},
+		{
+			name:     "pool",
+			existing: Allocation{CIDR: "10.0.0.0/16", Name: "vpc"},
+			poolID:   "dev",
+			req:      AllocationRequest{Name: "vpc", PoolID: "prod", PrefixLen: 16},
+			wantErr:  `is in pool "dev", config requests pool "prod"`,
+		},
+		{
+			name:     "sub-allocation for pool request",
+			existing: Allocation{CIDR: "10.0.1.0/24", Name: "web", ParentCIDR: strPtr("10.0.0.0/16")},
+			poolID:   "prod",
+			req:      AllocationRequest{Name: "web", PoolID: "prod", PrefixLen: 24},
+			wantErr:  "is a sub-allocation of 10.0.0.0/16",
+		},
+		{
+			name:     "parent",
+			existing: Allocation{CIDR: "10.1.1.0/24", Name: "web", ParentCIDR: strPtr("10.1.0.0/16")},
+			poolID:   "prod",
+			req:      AllocationRequest{Name: "web", ParentCIDR: "10.0.0.0/16", PrefixLen: 24},
+			wantErr:  "has parent 10.1.0.0/16, config requests parent 10.0.0.0/16",
+		},
+		{
+			name:     "top-level for parent request",
+			existing: Allocation{CIDR: "10.0.1.0/24", Name: "web"},
+			poolID:   "prod",
+			req:      AllocationRequest{Name: "web", ParentCIDR: "10.0.0.0/16", PrefixLen: 24},
+			wantErr:  "has no parent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckAdoptable(&tt.existing, tt.poolID, tt.req)
+			if err == nil {
+				t.Fatal("expected adoption to be rejected")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %q", tt.wantErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestCheckAdoptable_PrefixRange(t *testing.T) {
+	existing := &Allocation{CIDR: "10.0.0.0/22", Name: "opportunistic"}
+	req := AllocationRequest{Name: "opportunistic", PoolID: "prod", MinPrefixLen: 20, MaxPrefixLen: 24}
+
+	if err := CheckAdoptable(existing, "prod", req); err != nil {
+		t.Errorf("expected /22 to be adoptable within /20 to /24, got %v", err)
+	}
+
+	req.MinPrefixLen, req.MaxPrefixLen = 23, 24
+	if err := CheckAdoptable(existing, "prod", req); err == nil || !strings.Contains(err.Error(), "config requests /23 to /24") {
+		t.Errorf("expected range mismatch error, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"math/big"
+	"net"
+	"sort"
+)
+
+// Aggregate returns the smallest set of CIDRs covering exactly the given
+// blocks: contained blocks are dropped, and adjacent blocks are merged into
+// a supernet wherever they cover it fully. Unparseable entries are ignored.
+// IPv4 blocks come before IPv6 ones, each in address order.
+func Aggregate(cidrs []string) []string {
+	families := map[int][]addressRange{}
+	for _, c := range cidrs {
+		_, network, err := net.ParseCIDR(c)
+		if err != nil {
+			continue
+		}
+		_, bits := network.Mask.Size()
+		families[bits] = append(families[bits], networkRange(network))
+	}
+
+	result := make([]string, 0)
+	for _, bits := range []int{32, 128} {
+		ranges := families[bits]
+		if len(ranges) == 0 {
+			continue
+		}
+		sort.Slice(ranges, func(i, j int) bool {
+			return ranges[i].start.Cmp(ranges[j].start) < 0
+		})
+
+		// Merge overlapping and touching ranges, then split each merged
+		// range back into aligned blocks
+		family := &net.IPNet{IP: make(net.IP, bits/8), Mask: net.CIDRMask(0, bits)}
+		current := ranges[0]
+		for _, r := range ranges[1:] {
+			next := new(big.Int).Add(current.end, big.NewInt(1))
+			if r.start.Cmp(next) <= 0 {
+				if r.end.Cmp(current.end) > 0 {
+					current.end = r.end
+				}
+				continue
+			}
+			result = append(result, rangeBlocks(current, family)...)
+			current = r
+		}
+		result = append(result, rangeBlocks(current, family)...)
+	}
+	return result
+}
+
+// RouteSummary returns the aggregate routes covering a pool's allocations
+// that carry traffic: everything but reservations and claims.
+func RouteSummary(allocations []Allocation) []string {
+	var cidrs []string
+	for _, alloc := range allocations {
+		if alloc.GetStatus() == StatusReservation {
+			continue
+		}
+		cidrs = append(cidrs, alloc.CIDR)
+	}
+	return Aggregate(cidrs)
+}
@@ -0,0 +1,55 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAggregate_AdjacentBlocks(t *testing.T) {
+	got := Aggregate([]string{"10.0.1.0/24", "10.0.0.0/24"})
+	if want := []string{"10.0.0.0/23"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestAggregate_NonAdjacentBlocks(t *testing.T) {
+	got := Aggregate([]string{"10.0.0.0/24", "10.0.2.0/24"})
+	if want := []string{"10.0.0.0/24", "10.0.2.0/24"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestAggregate_UnalignedNeighbours(t *testing.T) {
+	// 10.0.1.0/24 and 10.0.2.0/24 touch but do not form a /23
+	got := Aggregate([]string{"10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"})
+	if want := []string{"10.0.1.0/24", "10.0.2.0/23"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestAggregate_ContainedAndMixedFamilies(t *testing.T) {
+	got := Aggregate([]string{"fd00::/64", "10.0.0.0/16", "10.0.4.0/24", "fd00:0:0:1::/64", "bogus"})
+	if want := []string{"10.0.0.0/16", "fd00::/63"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := Aggregate(nil); len(got) != 0 {
+		t.Errorf("expected no routes, got %v", got)
+	}
+}
+
+func TestRouteSummary_SkipsReservations(t *testing.T) {
+	allocations := []Allocation{
+		{CIDR: "10.0.0.0/24", Name: "a"},
+		{CIDR: "10.0.1.0/24", Name: "b", Status: StatusDecommissioning},
+		{CIDR: "10.0.2.0/23", Name: "future", Status: StatusReservation},
+		{CIDR: "10.0.1.0/26", Name: "subnet", ParentCIDR: strPtr("10.0.1.0/24")},
+	}
+
+	got := RouteSummary(allocations)
+	if want := []string{"10.0.0.0/23"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
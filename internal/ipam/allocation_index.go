@@ -0,0 +1,179 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+)
+
+// allocationIndex answers next-fit queries against a snapshot of
+// allocations without re-sorting them on every call. Occupied space is kept
+// as sorted, disjoint address intervals, so a query binary searches to its
+// starting point instead of scanning every allocation.
+//
+// It returns the same blocks as findNextInCIDR: the lowest aligned block of
+// the requested size that overlaps nothing occupied.
+type allocationIndex struct {
+	occupied []addressRange
+
+	// cursors remembers, per container, prefix length and alignment, the
+	// lowest position that may still be free. Occupied space only grows, so
+	// repeated queries of one size never rescan space already ruled out.
+	cursors map[indexCursor]*big.Int
+}
+
+type indexCursor struct {
+	container string
+	prefixLen int
+	alignLen  int
+}
+
+// newAllocationIndex indexes the given allocations. Unparseable entries are
+// ignored, as they are by the linear search.
+func newAllocationIndex(allocations []Allocation) *allocationIndex {
+	ranges := make([]addressRange, 0, len(allocations))
+	for _, alloc := range allocations {
+		_, network, err := net.ParseCIDR(alloc.CIDR)
+		if err != nil {
+			continue
+		}
+		ranges = append(ranges, networkRange(network))
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start.Cmp(ranges[j].start) < 0
+	})
+
+	// Merge overlapping and adjacent intervals
+	occupied := make([]addressRange, 0, len(ranges))
+	for _, r := range ranges {
+		if n := len(occupied); n > 0 {
+			last := &occupied[n-1]
+			if r.start.Cmp(new(big.Int).Add(last.end, big.NewInt(1))) <= 0 {
+				if r.end.Cmp(last.end) > 0 {
+					last.end = r.end
+				}
+				continue
+			}
+		}
+		occupied = append(occupied, r)
+	}
+
+	return &allocationIndex{
+		occupied: occupied,
+		cursors:  make(map[indexCursor]*big.Int),
+	}
+}
+
+// insert marks a block as occupied.
+func (idx *allocationIndex) insert(cidrStr string) {
+	_, network, err := net.ParseCIDR(cidrStr)
+	if err != nil {
+		return
+	}
+	r := networkRange(network)
+
+	// Intervals in [i, j) overlap or touch r and are merged into it
+	before := new(big.Int).Sub(r.start, big.NewInt(1))
+	after := new(big.Int).Add(r.end, big.NewInt(1))
+	i := sort.Search(len(idx.occupied), func(k int) bool {
+		return idx.occupied[k].end.Cmp(before) >= 0
+	})
+	j := sort.Search(len(idx.occupied), func(k int) bool {
+		return idx.occupied[k].start.Cmp(after) > 0
+	})
+
+	if i < j {
+		if idx.occupied[i].start.Cmp(r.start) < 0 {
+			r.start = idx.occupied[i].start
+		}
+		if idx.occupied[j-1].end.Cmp(r.end) > 0 {
+			r.end = idx.occupied[j-1].end
+		}
+	}
+
+	merged := make([]addressRange, 0, len(idx.occupied)-(j-i)+1)
+	merged = append(merged, idx.occupied[:i]...)
+	merged = append(merged, r)
+	merged = append(merged, idx.occupied[j:]...)
+	idx.occupied = merged
+}
+
+// findInPool is the indexed equivalent of Allocator.findInPool.
+func (idx *allocationIndex) findInPool(poolDef *PoolDefinition, prefixLen int) (string, error) {
+	if err := poolDef.CheckGranularity(prefixLen); err != nil {
+		return "", err
+	}
+
+	var skippedReasons []string
+	for _, poolCIDRStr := range poolDef.searchOrder(prefixLen) {
+		cidrResult, err := idx.findInCIDR(poolCIDRStr, prefixLen, poolDef.alignmentFor(prefixLen))
+		if err == nil {
+			return cidrResult, nil
+		}
+		skippedReasons = append(skippedReasons, fmt.Sprintf("%s: %v", poolCIDRStr, err))
+	}
+	return "", poolExhaustedError(poolDef, prefixLen, skippedReasons)
+}
+
+// findInCIDR is the indexed equivalent of Allocator.findNextAlignedInCIDR.
+func (idx *allocationIndex) findInCIDR(containerCIDR string, prefixLen, alignLen int) (string, error) {
+	_, containerNet, err := net.ParseCIDR(containerCIDR)
+	if err != nil {
+		return "", fmt.Errorf("invalid container CIDR %s: %w", containerCIDR, err)
+	}
+
+	containerPrefixLen, bits := containerNet.Mask.Size()
+	if prefixLen < containerPrefixLen {
+		return "", fmt.Errorf("requested prefix /%d is larger than container /%d", prefixLen, containerPrefixLen)
+	}
+	if prefixLen > bits {
+		return "", fmt.Errorf("requested prefix /%d exceeds address size /%d", prefixLen, bits)
+	}
+
+	bounds := networkRange(containerNet)
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLen))
+	align := new(big.Int).Lsh(big.NewInt(1), uint(bits-alignLen))
+
+	key := indexCursor{container: containerCIDR, prefixLen: prefixLen, alignLen: alignLen}
+	candidate := bounds.start
+	if cursor, ok := idx.cursors[key]; ok {
+		candidate = cursor
+	}
+
+	i := sort.Search(len(idx.occupied), func(k int) bool {
+		return idx.occupied[k].end.Cmp(candidate) >= 0
+	})
+	for {
+		end := new(big.Int).Add(candidate, size)
+		end.Sub(end, big.NewInt(1))
+		if end.Cmp(bounds.end) > 0 {
+			break
+		}
+
+		for i < len(idx.occupied) && idx.occupied[i].end.Cmp(candidate) < 0 {
+			i++
+		}
+		if i == len(idx.occupied) || end.Cmp(idx.occupied[i].start) < 0 {
+			idx.cursors[key] = candidate
+			return blockString(candidate, prefixLen, containerNet), nil
+		}
+
+		// Move to the first aligned position past the occupied interval
+		candidate = new(big.Int).Add(idx.occupied[i].end, align)
+		candidate.Sub(candidate, new(big.Int).Mod(candidate, align))
+	}
+
+	idx.cursors[key] = candidate
+	return "", noBlockError(containerNet, freeWithin(bounds, idx.occupied), prefixLen, alignLen)
+}
+
+// blockString formats the block at the given address in the container's
+// address family.
+func blockString(start *big.Int, prefixLen int, container *net.IPNet) string {
+	block := &net.IPNet{IP: rangeIP(start, container), Mask: net.CIDRMask(prefixLen, len(container.IP)*8)}
+	return block.String()
+}
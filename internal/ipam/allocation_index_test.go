@@ -0,0 +1,162 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// linearBatch allocates count blocks one at a time with the plain search,
+// which is what the indexed batch must reproduce.
+func linearBatch(poolDef *PoolDefinition, existing []Allocation, prefixLen, count int, opts AllocateOptions) ([]string, error) {
+	allocator := NewAllocator()
+	working := append([]Allocation{}, existing...)
+
+	cidrs := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		next, err := allocator.FindNextAvailableInPoolWithOptions(poolDef, working, prefixLen, opts)
+		if err != nil {
+			return nil, fmt.Errorf("only %d of %d /%d blocks fit in pool: %w", i, count, prefixLen, err)
+		}
+		cidrs = append(cidrs, next)
+		working = append(working, Allocation{CIDR: next})
+	}
+	return cidrs, nil
+}
+
+// randomAllocations returns count aligned blocks scattered over base, which
+// may overlap one another like allocations from avoided pools do.
+func randomAllocations(rng *rand.Rand, base string, count int) []Allocation {
+	allocs := make([]Allocation, 0, count)
+	for i := 0; i < count; i++ {
+		prefixLen := 20 + rng.Intn(9)
+		offset := rng.Intn(1<<16) &^ (1<<(32-prefixLen) - 1)
+		allocs = append(allocs, Allocation{
+			CIDR: fmt.Sprintf("%s.%d.%d/%d", base, offset>>8, offset&0xff, prefixLen),
+		})
+	}
+	return allocs
+}
+
+func TestAllocationIndex_MatchesLinearBatch(t *testing.T) {
+	allocator := NewAllocator()
+	rng := rand.New(rand.NewSource(1))
+
+	for run := 0; run < 200; run++ {
+		poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16", "10.1.0.0/17"}}
+		if run%4 == 0 {
+			poolDef.AllocationGranularity = 20 + rng.Intn(6)
+		}
+		existing := randomAllocations(rng, "10.0", rng.Intn(80))
+		existing = append(existing, Allocation{CIDR: "10.0.1.0/28", ParentCIDR: strPtr("10.0.0.0/20")})
+		opts := AllocateOptions{Avoid: randomAllocations(rng, "10.1", rng.Intn(20))}
+		prefixLen := 20 + rng.Intn(10)
+		count := 1 + rng.Intn(40)
+
+		want, wantErr := linearBatch(poolDef, existing, prefixLen, count, opts)
+		got, gotErr := allocator.FindNextAvailableBatchInPool(poolDef, existing, prefixLen, count, opts)
+
+		if fmt.Sprint(wantErr) != fmt.Sprint(gotErr) {
+			t.Fatalf("run %d: expected error %v, got %v", run, wantErr, gotErr)
+		}
+		if fmt.Sprint(want) != fmt.Sprint(got) {
+			t.Fatalf("run %d: expected %v, got %v", run, want, got)
+		}
+	}
+}
+
+func TestAllocationIndex_MatchesLinearBatch_IPv6(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"fd00::/48"}}
+	existing := []Allocation{
+		{CIDR: "fd00::/64"},
+		{CIDR: "fd00:0:0:2::/63"},
+		{CIDR: "fd00:0:0:5::/64"},
+	}
+
+	want, wantErr := linearBatch(poolDef, existing, 64, 5, AllocateOptions{})
+	got, gotErr := allocator.FindNextAvailableBatchInPool(poolDef, existing, 64, 5, AllocateOptions{})
+	if wantErr != nil || gotErr != nil {
+		t.Fatalf("unexpected errors: %v, %v", wantErr, gotErr)
+	}
+	if fmt.Sprint(want) != fmt.Sprint(got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestAllocationIndex_Insert(t *testing.T) {
+	idx := newAllocationIndex([]Allocation{
+		{CIDR: "10.0.0.0/24"},
+		{CIDR: "10.0.2.0/24"},
+		{CIDR: "10.0.0.0/25"}, // contained, merged away
+	})
+	if len(idx.occupied) != 2 {
+		t.Fatalf("expected 2 intervals, got %d", len(idx.occupied))
+	}
+
+	// Filling the gap joins the neighbours into one interval
+	idx.insert("10.0.1.0/24")
+	if len(idx.occupied) != 1 {
+		t.Fatalf("expected 1 interval after filling the gap, got %d", len(idx.occupied))
+	}
+	if got := idx.occupied[0].size().Int64(); got != 768 {
+		t.Errorf("expected 768 occupied addresses, got %d", got)
+	}
+}
+
+func TestAllocationIndex_HostRoutes(t *testing.T) {
+	idx := newAllocationIndex([]Allocation{{CIDR: "10.0.0.0/32"}, {CIDR: "10.0.0.3/32"}})
+
+	for _, tc := range []struct {
+		prefixLen int
+		expected  string
+	}{
+		{32, "10.0.0.1/32"},
+		{31, "10.0.0.4/31"},
+	} {
+		got, err := idx.findInCIDR("10.0.0.0/24", tc.prefixLen, tc.prefixLen)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tc.expected {
+			t.Errorf("/%d: expected %s, got %s", tc.prefixLen, tc.expected, got)
+		}
+	}
+}
+
+// benchmarkPool returns a /8 pool holding n /24 allocations, with a free
+// /24 after every 1000th so each search has gaps to consider.
+func benchmarkPool(n int) (*PoolDefinition, []Allocation) {
+	existing := make([]Allocation, 0, n)
+	for i := 0; len(existing) < n; i++ {
+		if i%1000 == 999 {
+			continue
+		}
+		existing = append(existing, Allocation{CIDR: fmt.Sprintf("10.%d.%d.0/24", i>>8, i&0xff)})
+	}
+	return &PoolDefinition{CIDR: []string{"10.0.0.0/8"}}, existing
+}
+
+func BenchmarkBatch50k_Linear(b *testing.B) {
+	poolDef, existing := benchmarkPool(50000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := linearBatch(poolDef, existing, 24, 64, AllocateOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBatch50k_Indexed(b *testing.B) {
+	poolDef, existing := benchmarkPool(50000)
+	allocator := NewAllocator()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := allocator.FindNextAvailableBatchInPool(poolDef, existing, 24, 64, AllocateOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
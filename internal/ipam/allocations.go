@@ -4,17 +4,48 @@
 package ipam
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the allocations.yaml format version written by this provider.
+// Files with an older version are migrated on read; new fields are omitted
+// when empty so older tooling can still read what we write.
+const SchemaVersion = "1.1"
+
+// Allocation sources.
+const (
+	SourceProvider = "terraform-provider" // Created by this provider
+	SourceMigrated = "migrated"           // Existed before source was tracked
 )
 
 // AllocationsDatabase represents the allocations.yaml file structure.
 // This file is READ-WRITE by the provider with OCC via GitHub SHA.
 type AllocationsDatabase struct {
 	Version     string                  `yaml:"version"`
-	Allocations map[string][]Allocation `yaml:"allocations"` // pool_id -> allocations
+	Allocations map[string][]Allocation `yaml:"allocations"`        // pool_id -> allocations
+	Freed       map[string][]FreedRange `yaml:"freed,omitempty"`    // pool_id -> recently freed blocks
+	Archived    []ArchivedAllocation    `yaml:"archived,omitempty"` // Deleted allocations kept for audit
+}
+
+// FreedRange records a top-level block released from a pool, so that
+// pools with the cooldown_last reuse policy can reuse it last.
+type FreedRange struct {
+	CIDR    string `yaml:"cidr"`
+	FreedAt string `yaml:"freed_at"` // RFC3339 timestamp
 }
 
+// maxFreedRanges caps how many freed blocks are remembered per pool.
+const maxFreedRanges = 64
+
 // Allocation represents a single CIDR allocation.
 type Allocation struct {
 	CIDR           string            `yaml:"cidr"`
@@ -24,17 +55,173 @@ type Allocation struct {
 	Metadata       map[string]string `yaml:"metadata,omitempty"`        // Arbitrary key-value metadata
 	CreatedAt      string            `yaml:"created_at,omitempty"`      // RFC3339 timestamp
 	Reserved       bool              `yaml:"reserved,omitempty"`        // True if this is a reservation (cannot be allocated)
+	Status         string            `yaml:"status,omitempty"`          // Lifecycle status (allocation, reservation, decommissioning)
 	ContiguousWith *string           `yaml:"contiguous_with,omitempty"` // CIDR this reservation must be adjacent to
+	Owner          string            `yaml:"owner,omitempty"`           // Team or person responsible (v1.1)
+	Labels         []string          `yaml:"labels,omitempty"`          // Free-form labels (v1.1)
+	UpdatedAt      string            `yaml:"updated_at,omitempty"`      // RFC3339 timestamp of last change (v1.1)
+	Source         string            `yaml:"source,omitempty"`          // What created the entry (v1.1)
+	ExpiresAt      string            `yaml:"expires_at,omitempty"`      // RFC3339 time a claim lapses; empty for permanent entries
+	VLANID         int               `yaml:"vlan_id,omitempty"`         // 802.1Q VLAN ID; 0 is none
+	Region         string            `yaml:"region,omitempty"`          // Cloud region, e.g. us-east-1; empty is none
+	Group          string            `yaml:"group,omitempty"`           // ID of the stripe this block was allocated with
+	CostCenter     string            `yaml:"cost_center,omitempty"`     // Cost center charged for the space; empty is none
+	Locked         bool              `yaml:"locked,omitempty"`          // True if changes are refused until it is unlocked
+}
+
+// Valid 802.1Q VLAN IDs; 0 and 4095 are reserved by the standard.
+const (
+	MinVLANID = 1
+	MaxVLANID = 4094
+)
+
+// ValidateVLANID checks that id is a usable 802.1Q VLAN ID.
+func ValidateVLANID(id int) error {
+	if id < MinVLANID || id > MaxVLANID {
+		return fmt.Errorf("vlan_id must be between %d and %d, got %d", MinVLANID, MaxVLANID, id)
+	}
+	return nil
+}
+
+// ValidateRegion checks region against the allowed regions. With no
+// allowed regions configured any region is accepted.
+func ValidateRegion(region string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, candidate := range allowed {
+		if candidate == region {
+			return nil
+		}
+	}
+	return fmt.Errorf("region %q is not one of the allowed regions: %s", region, strings.Join(allowed, ", "))
+}
+
+// Allocation lifecycle statuses.
+const (
+	StatusAllocation      = "allocation"      // In active use
+	StatusReservation     = "reservation"     // Held for future use
+	StatusDecommissioning = "decommissioning" // Being torn down but not yet free
+)
+
+// AllocationStatuses lists all supported allocation statuses.
+var AllocationStatuses = []string{StatusAllocation, StatusReservation, StatusDecommissioning}
+
+// GetStatus returns the lifecycle status of the allocation.
+// Entries written before status was stored derive it from the Reserved flag.
+func (a *Allocation) GetStatus() string {
+	if a.Status != "" {
+		return a.Status
+	}
+	if a.Reserved {
+		return StatusReservation
+	}
+	return StatusAllocation
+}
+
+// SetStatus sets the lifecycle status, keeping Reserved in sync for back-compat.
+func (a *Allocation) SetStatus(status string) {
+	a.Status = status
+	a.Reserved = status == StatusReservation
+}
+
+// Location returns what the allocation resource tracks to place an entry
+// stored under poolID. Sub-allocations are placed by their parent CIDR
+// alone, with no pool ID, since the parent already implies the pool; a
+// pool ID there as well would show as drift whenever only one was set.
+func (a *Allocation) Location(poolID string) (trackedPoolID, parentCIDR string) {
+	if a.ParentCIDR != nil {
+		return "", *a.ParentCIDR
+	}
+	return poolID, ""
 }
 
 // NewAllocationsDatabase creates a new empty allocations database.
 func NewAllocationsDatabase() *AllocationsDatabase {
 	return &AllocationsDatabase{
-		Version:     "1.0",
+		Version:     SchemaVersion,
 		Allocations: make(map[string][]Allocation),
 	}
 }
 
+// ParseAllocations decodes allocations.yaml content and migrates it to the
+// current schema version. Both old and new files are accepted. Unknown
+// fields are ignored.
+func ParseAllocations(content []byte) (*AllocationsDatabase, error) {
+	return parseAllocations(content, false)
+}
+
+// ParseAllocationsStrict is ParseAllocations rejecting unknown fields, so a
+// misspelled key in a hand edit fails instead of being silently dropped on
+// the next write.
+func ParseAllocationsStrict(content []byte) (*AllocationsDatabase, error) {
+	return parseAllocations(content, true)
+}
+
+func parseAllocations(content []byte, strict bool) (*AllocationsDatabase, error) {
+	var db AllocationsDatabase
+	// A blank file holds no allocations; a tab would otherwise fail to
+	// tokenize. Anything else is parsed as written, since trimming would
+	// break a document whose first line is indented.
+	if len(bytes.TrimSpace(content)) > 0 {
+		dec := yaml.NewDecoder(bytes.NewReader(content))
+		dec.KnownFields(strict)
+		if err := dec.Decode(&db); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	}
+	if db.Allocations == nil {
+		db.Allocations = make(map[string][]Allocation)
+	}
+	now := time.Now().UTC()
+	db.migrate(now)
+	db.dropExpired(now)
+	return &db, nil
+}
+
+// Marshal encodes the database as YAML, stamped with the current schema version.
+// Pools and metadata keys are written in key order and each pool's entries
+// in CIDR order, then ID for entries sharing a CIDR, so the output depends
+// only on the content: remove-then-add updates don't reorder untouched
+// entries, and diffs stay small.
+func (d *AllocationsDatabase) Marshal() ([]byte, error) {
+	d.Version = SchemaVersion
+
+	out := *d
+	out.Allocations = make(map[string][]Allocation, len(d.Allocations))
+	for poolID, allocations := range d.Allocations {
+		sorted := append([]Allocation(nil), allocations...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if sorted[i].CIDR == sorted[j].CIDR {
+				return sorted[i].ID < sorted[j].ID
+			}
+			return compareCIDRs(sorted[i].CIDR, sorted[j].CIDR)
+		})
+		out.Allocations[poolID] = sorted
+	}
+	return yaml.Marshal(&out)
+}
+
+// migrate upgrades the database in place to the current schema version,
+// backfilling created_at and source on entries that predate them.
+// Backfilled timestamps record when the entry was first migrated, since the
+// original creation time is unknown.
+func (d *AllocationsDatabase) migrate(now time.Time) {
+	stamp := now.Format(time.RFC3339)
+	for poolID := range d.Allocations {
+		allocations := d.Allocations[poolID]
+		for i := range allocations {
+			if allocations[i].CreatedAt == "" {
+				allocations[i].CreatedAt = stamp
+			}
+			if allocations[i].Source == "" {
+				allocations[i].Source = SourceMigrated
+			}
+		}
+	}
+	d.Version = SchemaVersion
+}
+
 // FindAllocationByID searches all pools for an allocation by ID.
 func (d *AllocationsDatabase) FindAllocationByID(id string) (*Allocation, string, bool) {
 	for poolID, allocations := range d.Allocations {
@@ -59,6 +246,21 @@ func (d *AllocationsDatabase) FindAllocationByCIDR(cidr string) (*Allocation, st
 	return nil, "", false
 }
 
+// LookupAllocationByCIDR is FindAllocationByCIDR for user input. It
+// rejects a CIDR that is invalid or not written the way allocations are
+// stored, e.g. with host bits set, rather than matching a different block.
+func (d *AllocationsDatabase) LookupAllocationByCIDR(cidr string) (*Allocation, string, bool, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+	}
+	if network.String() != cidr {
+		return nil, "", false, fmt.Errorf("CIDR %s is not in canonical form (did you mean %s?)", cidr, network)
+	}
+	alloc, poolID, found := d.FindAllocationByCIDR(cidr)
+	return alloc, poolID, found, nil
+}
+
 // FindAllocationByName searches all pools for an allocation by name.
 func (d *AllocationsDatabase) FindAllocationByName(name string) (*Allocation, string, bool) {
 	for poolID, allocations := range d.Allocations {
@@ -71,6 +273,16 @@ func (d *AllocationsDatabase) FindAllocationByName(name string) (*Allocation, st
 	return nil, "", false
 }
 
+// ResolveParentName returns the CIDR of the allocation named name, for
+// sub-allocations that name their parent instead of giving its CIDR.
+func (d *AllocationsDatabase) ResolveParentName(name string) (string, error) {
+	parent, _, found := d.FindAllocationByName(name)
+	if !found {
+		return "", fmt.Errorf("parent_name %q not found in allocations", name)
+	}
+	return parent.CIDR, nil
+}
+
 // GetAllocationsForPool returns all allocations for a pool.
 func (d *AllocationsDatabase) GetAllocationsForPool(poolID string) []Allocation {
 	if d.Allocations == nil {
@@ -92,12 +304,159 @@ func (d *AllocationsDatabase) GetAllocationsForParent(parentCIDR string) []Alloc
 	return result
 }
 
-// AddAllocation adds an allocation to a pool.
+// FilterByRegion returns the allocations tagged with region.
+func FilterByRegion(allocations []Allocation, region string) []Allocation {
+	var result []Allocation
+	for _, alloc := range allocations {
+		if alloc.Region == region {
+			result = append(result, alloc)
+		}
+	}
+	return result
+}
+
+// PageAllocations orders allocations by CIDR and returns the page starting
+// at offset with at most limit entries, along with the number of entries
+// before paging. A limit of 0 returns everything from offset on.
+func PageAllocations(allocations []Allocation, offset, limit int) ([]Allocation, int) {
+	sorted := append([]Allocation(nil), allocations...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].CIDR == sorted[j].CIDR {
+			return sorted[i].ID < sorted[j].ID
+		}
+		return compareCIDRs(sorted[i].CIDR, sorted[j].CIDR)
+	})
+
+	total := len(sorted)
+	if offset >= total {
+		return []Allocation{}, total
+	}
+	page := sorted[offset:]
+	if limit > 0 && limit < len(page) {
+		page = page[:limit]
+	}
+	return page, total
+}
+
+// ParentChain returns the CIDRs from the top-level allocation down to
+// parentCIDR by following parent_cidr links.
+func (d *AllocationsDatabase) ParentChain(parentCIDR string) ([]string, error) {
+	var chain []string
+	seen := make(map[string]bool)
+	for cidr := parentCIDR; ; {
+		if seen[cidr] {
+			return nil, fmt.Errorf("parent_cidr chain of %s loops at %s", parentCIDR, cidr)
+		}
+		seen[cidr] = true
+
+		alloc, _, found := d.FindAllocationByCIDR(cidr)
+		if !found {
+			return nil, fmt.Errorf("parent_cidr %q not found in allocations", cidr)
+		}
+		chain = append([]string{cidr}, chain...)
+		if alloc.ParentCIDR == nil {
+			return chain, nil
+		}
+		cidr = *alloc.ParentCIDR
+	}
+}
+
+// CheckNestingDepth rejects a sub-allocation of parentCIDR that would sit
+// deeper than maxDepth levels, counting top-level allocations as level 1.
+// A maxDepth of 0 means unlimited.
+func (d *AllocationsDatabase) CheckNestingDepth(parentCIDR string, maxDepth int) error {
+	if maxDepth <= 0 {
+		return nil
+	}
+	chain, err := d.ParentChain(parentCIDR)
+	if err != nil {
+		return err
+	}
+	if depth := len(chain) + 1; depth > maxDepth {
+		return fmt.Errorf("cannot sub-allocate from %q: the new allocation would be at depth %d, exceeding max_nesting_depth %d (chain: %s)",
+			parentCIDR, depth, maxDepth, strings.Join(chain, " → "))
+	}
+	return nil
+}
+
+// AllocationsToAvoid returns the top-level allocations of every pool listed
+// in the pool's avoid_pools, for the allocator to treat as occupied.
+func (d *AllocationsDatabase) AllocationsToAvoid(poolDef *PoolDefinition) []Allocation {
+	var result []Allocation
+	for _, avoidID := range poolDef.AvoidPools {
+		result = append(result, filterTopLevelAllocations(d.GetAllocationsForPool(avoidID))...)
+	}
+	return result
+}
+
+// AllocateOptionsForPool returns the allocator options implied by the
+// database for a pool: the space of its avoid_pools and its freed blocks.
+func (d *AllocationsDatabase) AllocateOptionsForPool(poolID string, poolDef *PoolDefinition) AllocateOptions {
+	return AllocateOptions{
+		Avoid: d.AllocationsToAvoid(poolDef),
+		Freed: d.FreedRanges(poolID),
+	}
+}
+
+// RecordFreed remembers that a block was released from a pool.
+// Only the most recent maxFreedRanges blocks are kept.
+func (d *AllocationsDatabase) RecordFreed(poolID, cidr string) {
+	if d.Freed == nil {
+		d.Freed = make(map[string][]FreedRange)
+	}
+	freed := append(d.Freed[poolID], FreedRange{
+		CIDR:    cidr,
+		FreedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	if len(freed) > maxFreedRanges {
+		freed = freed[len(freed)-maxFreedRanges:]
+	}
+	d.Freed[poolID] = freed
+}
+
+// FreedRanges returns the blocks recently released from a pool.
+func (d *AllocationsDatabase) FreedRanges(poolID string) []FreedRange {
+	if d.Freed == nil {
+		return nil
+	}
+	return d.Freed[poolID]
+}
+
+// forgetFreed drops freed records overlapping a block that is in use again.
+func (d *AllocationsDatabase) forgetFreed(poolID, cidr string) {
+	freed := d.FreedRanges(poolID)
+	if len(freed) == 0 {
+		return
+	}
+	kept := freed[:0]
+	for _, f := range freed {
+		if !cidrsOverlap(f.CIDR, cidr) {
+			kept = append(kept, f)
+		}
+	}
+	if len(kept) == 0 {
+		delete(d.Freed, poolID)
+		return
+	}
+	d.Freed[poolID] = kept
+}
+
+// AddAllocation adds an allocation to a pool. A new allocation is stamped
+// with the current time; one that already has a created_at, such as an
+// update added back after RemoveAllocation, keeps it.
 func (d *AllocationsDatabase) AddAllocation(poolID string, alloc Allocation) {
 	if d.Allocations == nil {
 		d.Allocations = make(map[string][]Allocation)
 	}
-	alloc.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	if alloc.ParentCIDR == nil {
+		d.forgetFreed(poolID, alloc.CIDR)
+	}
+	if alloc.CreatedAt == "" {
+		alloc.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	if alloc.Source == "" {
+		alloc.Source = SourceProvider
+	}
 	d.Allocations[poolID] = append(d.Allocations[poolID], alloc)
 }
 
@@ -126,6 +485,115 @@ func (d *AllocationsDatabase) RemoveAllocation(poolID, id string) error {
 	return nil
 }
 
+// RemoveLeafAllocation removes an allocation only if nothing is
+// sub-allocated from it, and returns the removed entry. Callers run it
+// against a freshly read database on every retry attempt, so a child
+// created concurrently since an earlier attempt is seen before the removal
+// is committed.
+func (d *AllocationsDatabase) RemoveLeafAllocation(poolID, id string) (*Allocation, error) {
+	var removed *Allocation
+	for _, alloc := range d.Allocations[poolID] {
+		if alloc.ID == id {
+			found := alloc
+			removed = &found
+			break
+		}
+	}
+	if removed == nil {
+		return nil, fmt.Errorf("allocation %s not found in pool %s", id, poolID)
+	}
+
+	if children := d.GetAllocationsForParent(removed.CIDR); len(children) > 0 {
+		return nil, fmt.Errorf("cannot delete allocation %s: has %d child allocations", removed.CIDR, len(children))
+	}
+
+	if err := d.RemoveAllocation(poolID, id); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// RenameAllocation changes an allocation's CIDR and rewrites every
+// reference to the old CIDR, the parent_cidr of its children and any
+// contiguous_with pointing at it, in one step. It refuses a CIDR that would
+// leave a child outside the new range, leave the allocation outside its own
+// parent, or overlap a sibling. Callers check pool containment for
+// top-level allocations.
+func (d *AllocationsDatabase) RenameAllocation(id, newCIDR string) error {
+	alloc, poolID, found := d.FindAllocationByID(id)
+	if !found {
+		return fmt.Errorf("allocation %s not found", id)
+	}
+	_, newNet, err := net.ParseCIDR(newCIDR)
+	if err != nil {
+		return fmt.Errorf("invalid CIDR %q: %w", newCIDR, err)
+	}
+	if newNet.String() != newCIDR {
+		return fmt.Errorf("CIDR %s is not a network address (did you mean %s?)", newCIDR, newNet)
+	}
+	oldCIDR := alloc.CIDR
+	if newCIDR == oldCIDR {
+		return nil
+	}
+
+	if alloc.ParentCIDR != nil {
+		_, parentNet, err := net.ParseCIDR(*alloc.ParentCIDR)
+		if err != nil || !netContains(parentNet, newNet) {
+			return fmt.Errorf("cannot move %s to %s: outside its parent %s", oldCIDR, newCIDR, *alloc.ParentCIDR)
+		}
+	}
+
+	var orphaned []string
+	for _, child := range d.GetAllocationsForParent(oldCIDR) {
+		_, childNet, err := net.ParseCIDR(child.CIDR)
+		if err != nil || !netContains(newNet, childNet) {
+			orphaned = append(orphaned, child.CIDR)
+		}
+	}
+	if len(orphaned) > 0 {
+		return fmt.Errorf("cannot move %s to %s: would orphan child allocations %s", oldCIDR, newCIDR, strings.Join(orphaned, ", "))
+	}
+
+	for _, sibling := range d.Allocations[poolID] {
+		if sibling.ID == id || !sameParent(sibling.ParentCIDR, alloc.ParentCIDR) {
+			continue
+		}
+		if _, siblingNet, err := net.ParseCIDR(sibling.CIDR); err == nil && networksOverlap(newNet, siblingNet) {
+			return fmt.Errorf("cannot move %s to %s: overlaps allocation %s", oldCIDR, newCIDR, sibling.CIDR)
+		}
+	}
+
+	alloc.CIDR = newCIDR
+	for _, allocations := range d.Allocations {
+		for i := range allocations {
+			if ref := allocations[i].ParentCIDR; ref != nil && *ref == oldCIDR {
+				updated := newCIDR
+				allocations[i].ParentCIDR = &updated
+			}
+			if ref := allocations[i].ContiguousWith; ref != nil && *ref == oldCIDR {
+				updated := newCIDR
+				allocations[i].ContiguousWith = &updated
+			}
+		}
+	}
+	return nil
+}
+
+// netContains reports whether inner lies entirely within outer.
+func netContains(outer, inner *net.IPNet) bool {
+	outerOnes, _ := outer.Mask.Size()
+	innerOnes, _ := inner.Mask.Size()
+	return innerOnes >= outerOnes && outer.Contains(inner.IP)
+}
+
+// sameParent reports whether two parent_cidr values name the same parent.
+func sameParent(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
 // AllAllocations returns a flat list of all allocations across all pools.
 func (d *AllocationsDatabase) AllAllocations() []Allocation {
 	var result []Allocation
@@ -134,3 +602,56 @@ func (d *AllocationsDatabase) AllAllocations() []Allocation {
 	}
 	return result
 }
+
+// AllocationStats holds aggregate counts over an allocations database.
+type AllocationStats struct {
+	TotalAllocations     int               // All entries, including reservations and sub-allocations
+	Reservations         int               // Entries with reservation status
+	Decommissioning      int               // Entries being torn down
+	SubAllocations       int               // Entries with a parent_cidr
+	PoolsWithAllocations int               // Pools holding at least one entry
+	AllocatedAddresses   uint64            // Addresses covered by top-level entries
+	AddressesByPool      map[string]uint64 // Top-level addresses per pool
+	AllocationsByPool    map[string]int    // All entries per pool
+}
+
+// Stats computes aggregate counts across all pools.
+// Address totals only count top-level entries, since sub-allocations sit
+// inside their parent and would otherwise be counted twice.
+func (d *AllocationsDatabase) Stats() AllocationStats {
+	stats := AllocationStats{
+		AddressesByPool:   make(map[string]uint64),
+		AllocationsByPool: make(map[string]int),
+	}
+
+	for poolID, allocations := range d.Allocations {
+		if len(allocations) == 0 {
+			continue
+		}
+		stats.PoolsWithAllocations++
+		stats.AllocationsByPool[poolID] = len(allocations)
+
+		for i := range allocations {
+			alloc := &allocations[i]
+			stats.TotalAllocations++
+
+			switch alloc.GetStatus() {
+			case StatusReservation:
+				stats.Reservations++
+			case StatusDecommissioning:
+				stats.Decommissioning++
+			}
+
+			if alloc.ParentCIDR != nil {
+				stats.SubAllocations++
+				continue
+			}
+
+			addrs := cidrToAddresses(alloc.CIDR)
+			stats.AllocatedAddresses += addrs
+			stats.AddressesByPool[poolID] += addrs
+		}
+	}
+
+	return stats
+}
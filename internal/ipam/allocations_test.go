@@ -4,6 +4,8 @@
 package ipam
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -14,8 +16,8 @@ func TestNewAllocationsDatabase(t *testing.T) {
 		t.Fatal("NewAllocationsDatabase returned nil")
 		return
 	}
-	if db.Version != "1.0" {
-		t.Errorf("expected version '1.0', got '%s'", db.Version)
+	if db.Version != SchemaVersion {
+		t.Errorf("expected version '%s', got '%s'", SchemaVersion, db.Version)
 	}
 	if db.Allocations == nil {
 		t.Error("Allocations map should be initialized")
@@ -81,9 +83,7 @@ func TestAllocationsDatabase_AddAllocation_SetsCreatedAt(t *testing.T) {
 	}
 }
 
-func TestAllocationsDatabase_AddAllocation_OverwritesCreatedAt(t *testing.T) {
-	// Note: AddAllocation always sets CreatedAt to current time,
-	// regardless of what value was passed in. This documents the actual behavior.
+func TestAllocationsDatabase_AddAllocation_KeepsCreatedAt(t *testing.T) {
 	db := NewAllocationsDatabase()
 
 	originalTime := "2024-01-15T10:30:00Z"
@@ -94,13 +94,32 @@ func TestAllocationsDatabase_AddAllocation_OverwritesCreatedAt(t *testing.T) {
 		CreatedAt: originalTime,
 	})
 
-	alloc := db.Allocations["pool"][0]
-	// CreatedAt is always overwritten with current time
-	if alloc.CreatedAt == originalTime {
-		t.Error("CreatedAt should be overwritten with current time, but original was kept")
+	if alloc := db.Allocations["pool"][0]; alloc.CreatedAt != originalTime {
+		t.Errorf("expected created_at %s to be kept, got %s", originalTime, alloc.CreatedAt)
 	}
-	if alloc.CreatedAt == "" {
-		t.Error("CreatedAt should be set")
+}
+
+func TestAllocationsDatabase_UpdateKeepsCreatedAt(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("pool", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "test"})
+	alloc, _, _ := db.FindAllocationByID("id-1")
+	createdAt := alloc.CreatedAt
+
+	// In-place updates remove the entry and add the changed copy back
+	updated := *alloc
+	updated.Name = "renamed"
+	updated.UpdatedAt = "2099-01-01T00:00:00Z"
+	if err := db.RemoveAllocation("pool", updated.ID); err != nil {
+		t.Fatal(err)
+	}
+	db.AddAllocation("pool", updated)
+
+	got, _, _ := db.FindAllocationByID("id-1")
+	if got.CreatedAt != createdAt {
+		t.Errorf("expected created_at %s to survive the update, got %s", createdAt, got.CreatedAt)
+	}
+	if got.UpdatedAt == got.CreatedAt {
+		t.Error("updated_at should differ from created_at after an update")
 	}
 }
 
@@ -184,6 +203,47 @@ func TestAllocationsDatabase_FindAllocationByCIDR_NotFound(t *testing.T) {
 	}
 }
 
+func TestAllocationsDatabase_LookupAllocationByCIDR_Found(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("pool", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "target"})
+	db.AddAllocation("pool", Allocation{CIDR: "2001:db8::/48", ID: "id-2", Name: "v6"})
+
+	for cidr, name := range map[string]string{"10.0.0.0/24": "target", "2001:db8::/48": "v6"} {
+		alloc, poolID, found, err := db.LookupAllocationByCIDR(cidr)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", cidr, err)
+		}
+		if !found || poolID != "pool" || alloc.Name != name {
+			t.Errorf("%s: expected %s in pool, got found=%v pool=%q", cidr, name, found, poolID)
+		}
+	}
+}
+
+func TestAllocationsDatabase_LookupAllocationByCIDR_NotFound(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("pool", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "test"})
+
+	_, _, found, err := db.LookupAllocationByCIDR("10.1.0.0/24")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if found {
+		t.Error("allocation should not be found")
+	}
+}
+
+func TestAllocationsDatabase_LookupAllocationByCIDR_NonCanonical(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("pool", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "test"})
+	db.AddAllocation("pool", Allocation{CIDR: "2001:db8::/48", ID: "id-2", Name: "v6"})
+
+	for _, cidr := range []string{"10.0.0.1/24", "2001:DB8::/48", "2001:0db8::/48", "not-a-cidr"} {
+		if _, _, found, err := db.LookupAllocationByCIDR(cidr); err == nil || found {
+			t.Errorf("%s: expected an error, got found=%v err=%v", cidr, found, err)
+		}
+	}
+}
+
 func TestAllocationsDatabase_FindAllocationByName_Found(t *testing.T) {
 	db := NewAllocationsDatabase()
 	db.AddAllocation("pool-a", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-prod"})
@@ -211,6 +271,32 @@ func TestAllocationsDatabase_FindAllocationByName_NotFound(t *testing.T) {
 	}
 }
 
+func TestAllocationsDatabase_ResolveParentName(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("pool-a", Allocation{CIDR: "10.0.0.0/16", ID: "id-1", Name: "vpc-prod"})
+	db.AddAllocation("pool-a", Allocation{CIDR: "10.0.1.0/24", ID: "id-2", Name: "subnet-app", ParentCIDR: strPtr("10.0.0.0/16")})
+
+	for name, want := range map[string]string{"vpc-prod": "10.0.0.0/16", "subnet-app": "10.0.1.0/24"} {
+		cidr, err := db.ResolveParentName(name)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if cidr != want {
+			t.Errorf("%s: expected %s, got %s", name, want, cidr)
+		}
+	}
+}
+
+func TestAllocationsDatabase_ResolveParentName_NotFound(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("pool", Allocation{CIDR: "10.0.0.0/16", ID: "id-1", Name: "vpc-prod"})
+
+	_, err := db.ResolveParentName("vpc-prdo")
+	if err == nil || !strings.Contains(err.Error(), `parent_name "vpc-prdo" not found`) {
+		t.Errorf("expected not-found error, got %v", err)
+	}
+}
+
 func TestAllocationsDatabase_GetAllocationsForPool_Found(t *testing.T) {
 	db := NewAllocationsDatabase()
 	db.AddAllocation("target-pool", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "first"})
@@ -278,6 +364,31 @@ func TestAllocationsDatabase_RemoveAllocation_Success(t *testing.T) {
 	}
 }
 
+func TestAllocationsDatabase_RemoveLeafAllocation(t *testing.T) {
+	parent := "10.0.0.0/16"
+	db := NewAllocationsDatabase()
+	db.AddAllocation("pool", Allocation{CIDR: parent, ID: "vpc", Name: "vpc"})
+	db.AddAllocation("pool", Allocation{CIDR: "10.0.1.0/24", ID: "subnet", Name: "subnet", ParentCIDR: &parent})
+
+	if _, err := db.RemoveLeafAllocation("pool", "vpc"); err == nil || !strings.Contains(err.Error(), "has 1 child allocations") {
+		t.Errorf("expected refusal while a child exists, got %v", err)
+	}
+	if _, _, found := db.FindAllocationByID("vpc"); !found {
+		t.Fatal("refused delete should leave the parent in place")
+	}
+
+	removed, err := db.RemoveLeafAllocation("pool", "subnet")
+	if err != nil || removed.CIDR != "10.0.1.0/24" {
+		t.Fatalf("expected subnet to be removed, got %v, %v", removed, err)
+	}
+	if _, err := db.RemoveLeafAllocation("pool", "vpc"); err != nil {
+		t.Errorf("expected parent delete once childless, got %v", err)
+	}
+	if _, err := db.RemoveLeafAllocation("pool", "vpc"); err == nil {
+		t.Error("expected error for an allocation that is already gone")
+	}
+}
+
 func TestAllocationsDatabase_RemoveAllocation_PoolNotFound(t *testing.T) {
 	db := NewAllocationsDatabase()
 
@@ -328,6 +439,68 @@ func TestAllocationsDatabase_AllAllocations_MultiplePools(t *testing.T) {
 	}
 }
 
+func TestAllocationsDatabase_Stats_Empty(t *testing.T) {
+	db := NewAllocationsDatabase()
+
+	stats := db.Stats()
+	if stats.TotalAllocations != 0 || stats.Reservations != 0 || stats.SubAllocations != 0 {
+		t.Errorf("expected zero counts, got %+v", stats)
+	}
+	if stats.PoolsWithAllocations != 0 {
+		t.Errorf("expected 0 pools with allocations, got %d", stats.PoolsWithAllocations)
+	}
+	if stats.AllocatedAddresses != 0 {
+		t.Errorf("expected 0 allocated addresses, got %d", stats.AllocatedAddresses)
+	}
+}
+
+func TestAllocationsDatabase_Stats_Mixed(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.Allocations["prod"] = []Allocation{
+		{CIDR: "10.0.0.0/16", ID: "vpc"},
+		{CIDR: "10.0.0.0/24", ID: "subnet-a", ParentCIDR: strPtr("10.0.0.0/16")},
+		{CIDR: "10.0.1.0/24", ID: "subnet-b", ParentCIDR: strPtr("10.0.0.0/16"), Reserved: true},
+		{CIDR: "10.1.0.0/20", ID: "held", Status: StatusReservation, Reserved: true},
+	}
+	db.Allocations["dev"] = []Allocation{
+		{CIDR: "172.16.0.0/24", ID: "old", Status: StatusDecommissioning},
+	}
+	db.Allocations["empty"] = []Allocation{}
+
+	stats := db.Stats()
+
+	if stats.TotalAllocations != 5 {
+		t.Errorf("expected 5 total allocations, got %d", stats.TotalAllocations)
+	}
+	if stats.Reservations != 2 {
+		t.Errorf("expected 2 reservations, got %d", stats.Reservations)
+	}
+	if stats.Decommissioning != 1 {
+		t.Errorf("expected 1 decommissioning, got %d", stats.Decommissioning)
+	}
+	if stats.SubAllocations != 2 {
+		t.Errorf("expected 2 sub-allocations, got %d", stats.SubAllocations)
+	}
+	if stats.PoolsWithAllocations != 2 {
+		t.Errorf("expected 2 pools with allocations, got %d", stats.PoolsWithAllocations)
+	}
+
+	// Top-level only: /16 + /20 + /24
+	expected := uint64(65536 + 4096 + 256)
+	if stats.AllocatedAddresses != expected {
+		t.Errorf("expected %d allocated addresses, got %d", expected, stats.AllocatedAddresses)
+	}
+	if stats.AddressesByPool["prod"] != 65536+4096 {
+		t.Errorf("expected %d addresses in prod, got %d", 65536+4096, stats.AddressesByPool["prod"])
+	}
+	if stats.AddressesByPool["dev"] != 256 {
+		t.Errorf("expected 256 addresses in dev, got %d", stats.AddressesByPool["dev"])
+	}
+	if stats.AllocationsByPool["prod"] != 4 {
+		t.Errorf("expected 4 entries in prod, got %d", stats.AllocationsByPool["prod"])
+	}
+}
+
 func TestAllocation_Reserved(t *testing.T) {
 	alloc := Allocation{
 		CIDR:     "10.0.0.0/24",
@@ -341,6 +514,77 @@ func TestAllocation_Reserved(t *testing.T) {
 	}
 }
 
+func TestAllocation_GetStatus_DerivedFromReserved(t *testing.T) {
+	legacyReserved := Allocation{CIDR: "10.0.0.0/24", Reserved: true}
+	if got := legacyReserved.GetStatus(); got != StatusReservation {
+		t.Errorf("expected %q for legacy reserved entry, got %q", StatusReservation, got)
+	}
+
+	legacyAllocated := Allocation{CIDR: "10.0.1.0/24"}
+	if got := legacyAllocated.GetStatus(); got != StatusAllocation {
+		t.Errorf("expected %q for legacy entry, got %q", StatusAllocation, got)
+	}
+}
+
+func TestAllocation_Location(t *testing.T) {
+	top := Allocation{CIDR: "10.0.0.0/16"}
+	if poolID, parentCIDR := top.Location("prod"); poolID != "prod" || parentCIDR != "" {
+		t.Errorf("expected pool %q and no parent, got %q and %q", "prod", poolID, parentCIDR)
+	}
+
+	// Sub-allocations are stored under their parent's pool but never track it
+	sub := Allocation{CIDR: "10.0.1.0/24", ParentCIDR: strPtr("10.0.0.0/16")}
+	if poolID, parentCIDR := sub.Location("prod"); poolID != "" || parentCIDR != "10.0.0.0/16" {
+		t.Errorf("expected no pool and parent %q, got %q and %q", "10.0.0.0/16", poolID, parentCIDR)
+	}
+}
+
+func TestAllocation_SetStatus(t *testing.T) {
+	tests := []struct {
+		status       string
+		wantReserved bool
+	}{
+		{StatusAllocation, false},
+		{StatusReservation, true},
+		{StatusDecommissioning, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			alloc := Allocation{CIDR: "10.0.0.0/24"}
+			alloc.SetStatus(tt.status)
+			if alloc.GetStatus() != tt.status {
+				t.Errorf("expected status %q, got %q", tt.status, alloc.GetStatus())
+			}
+			if alloc.Reserved != tt.wantReserved {
+				t.Errorf("expected Reserved=%v for %q, got %v", tt.wantReserved, tt.status, alloc.Reserved)
+			}
+		})
+	}
+}
+
+func TestAllocation_SetStatus_Transitions(t *testing.T) {
+	alloc := Allocation{CIDR: "10.0.0.0/24"}
+
+	alloc.SetStatus(StatusReservation)
+	if !alloc.Reserved {
+		t.Error("reservation should set Reserved")
+	}
+
+	alloc.SetStatus(StatusDecommissioning)
+	if alloc.Reserved {
+		t.Error("decommissioning should clear Reserved")
+	}
+	if alloc.GetStatus() != StatusDecommissioning {
+		t.Errorf("expected decommissioning, got %q", alloc.GetStatus())
+	}
+
+	alloc.SetStatus(StatusAllocation)
+	if alloc.GetStatus() != StatusAllocation {
+		t.Errorf("expected allocation, got %q", alloc.GetStatus())
+	}
+}
+
 func TestAllocation_ParentCIDR(t *testing.T) {
 	parentCIDR := "10.0.0.0/16"
 	alloc := Allocation{
@@ -393,3 +637,623 @@ func TestAllocation_Metadata(t *testing.T) {
 		t.Error("metadata region mismatch")
 	}
 }
+
+const v10AllocationsFile = `version: "1.0"
+allocations:
+  prod:
+    - cidr: 10.0.0.0/16
+      id: vpc-1
+      name: vpc
+      created_at: "2024-01-01T00:00:00Z"
+    - cidr: 10.0.0.0/24
+      id: subnet-1
+      name: subnet
+      parent_cidr: 10.0.0.0/16
+`
+
+func TestParseAllocations_V10File(t *testing.T) {
+	db, err := ParseAllocations([]byte(v10AllocationsFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if db.Version != SchemaVersion {
+		t.Errorf("expected version %s after migration, got %s", SchemaVersion, db.Version)
+	}
+
+	vpc, _, found := db.FindAllocationByID("vpc-1")
+	if !found {
+		t.Fatal("vpc-1 not found")
+	}
+	if vpc.CreatedAt != "2024-01-01T00:00:00Z" {
+		t.Errorf("existing created_at should be kept, got %s", vpc.CreatedAt)
+	}
+	if vpc.Source != SourceMigrated {
+		t.Errorf("expected source %q, got %q", SourceMigrated, vpc.Source)
+	}
+
+	subnet, _, found := db.FindAllocationByID("subnet-1")
+	if !found {
+		t.Fatal("subnet-1 not found")
+	}
+	if _, err := time.Parse(time.RFC3339, subnet.CreatedAt); err != nil {
+		t.Errorf("missing created_at should be backfilled with RFC3339, got %q", subnet.CreatedAt)
+	}
+}
+
+func TestParseAllocations_WritesV11WithEmptyFieldsOmitted(t *testing.T) {
+	db, err := ParseAllocations([]byte(v10AllocationsFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, err := db.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := string(out)
+
+	if !strings.Contains(content, `version: "1.1"`) {
+		t.Errorf("expected version 1.1 in output:\n%s", content)
+	}
+	for _, field := range []string{"owner:", "labels:", "updated_at:"} {
+		if strings.Contains(content, field) {
+			t.Errorf("empty field %s should be omitted:\n%s", field, content)
+		}
+	}
+
+	// Round-trips cleanly
+	again, err := ParseAllocations(out)
+	if err != nil {
+		t.Fatalf("re-parse failed: %v", err)
+	}
+	if len(again.AllAllocations()) != 2 {
+		t.Errorf("expected 2 allocations after round-trip, got %d", len(again.AllAllocations()))
+	}
+}
+
+func TestParseAllocations_EmptyFile(t *testing.T) {
+	db, err := ParseAllocations([]byte(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.Allocations == nil {
+		t.Error("Allocations map should be initialized")
+	}
+}
+
+func TestParseAllocations_WhitespaceOnly(t *testing.T) {
+	db, err := ParseAllocations([]byte("\n\t  \n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.Allocations == nil || len(db.AllAllocations()) != 0 {
+		t.Errorf("expected an empty database, got %+v", db)
+	}
+}
+
+func TestParseAllocations_IndentedDocument(t *testing.T) {
+	content := "  version: \"1.1\"\n  allocations:\n    prod:\n      - cidr: 10.0.0.0/24\n        id: a\n        name: vpc\n"
+	for _, parse := range []func([]byte) (*AllocationsDatabase, error){ParseAllocations, ParseAllocationsStrict} {
+		db, err := parse([]byte(content))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if alloc, _, found := db.FindAllocationByID("a"); !found || alloc.CIDR != "10.0.0.0/24" {
+			t.Errorf("expected allocation a at 10.0.0.0/24, got %+v", db.Allocations)
+		}
+	}
+}
+
+func TestAllocationsDatabase_AddAllocation_SetsSource(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("pool", Allocation{CIDR: "10.0.0.0/24", ID: "a"})
+
+	alloc, _, _ := db.FindAllocationByID("a")
+	if alloc.Source != SourceProvider {
+		t.Errorf("expected source %q, got %q", SourceProvider, alloc.Source)
+	}
+}
+
+func TestAllocationsDatabase_RecordFreed(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.RecordFreed("prod", "10.0.0.0/24")
+
+	freed := db.FreedRanges("prod")
+	if len(freed) != 1 || freed[0].CIDR != "10.0.0.0/24" {
+		t.Fatalf("expected one freed range, got %v", freed)
+	}
+	if _, err := time.Parse(time.RFC3339, freed[0].FreedAt); err != nil {
+		t.Errorf("FreedAt should be RFC3339: %v", err)
+	}
+
+	// Reusing the space forgets it
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/25", ID: "reuse"})
+	if len(db.FreedRanges("prod")) != 0 {
+		t.Errorf("expected freed range to be forgotten once reused, got %v", db.FreedRanges("prod"))
+	}
+}
+
+func TestAllocationsDatabase_RecordFreed_Capped(t *testing.T) {
+	db := NewAllocationsDatabase()
+	for i := 0; i < maxFreedRanges+5; i++ {
+		db.RecordFreed("prod", fmt.Sprintf("10.%d.0.0/24", i))
+	}
+
+	freed := db.FreedRanges("prod")
+	if len(freed) != maxFreedRanges {
+		t.Fatalf("expected %d freed ranges, got %d", maxFreedRanges, len(freed))
+	}
+	if freed[0].CIDR != "10.5.0.0/24" {
+		t.Errorf("expected oldest entries to be dropped, first is %s", freed[0].CIDR)
+	}
+}
+
+func TestAllocationsDatabase_Marshal_StableOrderAcrossUpdates(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.2.0/24", ID: "c", Name: "c"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "a", Name: "a"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "b", Name: "b"})
+
+	before, err := db.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Update "a" the way a remove-then-add does, moving it to the end in memory
+	updated, _, _ := db.FindAllocationByID("a")
+	changed := *updated
+	changed.Metadata = map[string]string{"team": "payments"}
+	if err := db.RemoveAllocation("prod", "a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	db.AddAllocation("prod", changed)
+
+	after, err := db.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	order := func(content string) []int {
+		return []int{
+			strings.Index(content, "id: a"),
+			strings.Index(content, "id: b"),
+			strings.Index(content, "id: c"),
+		}
+	}
+	for _, content := range []string{string(before), string(after)} {
+		idx := order(content)
+		if !(idx[0] < idx[1] && idx[1] < idx[2]) {
+			t.Errorf("expected entries in CIDR order a, b, c:\n%s", content)
+		}
+	}
+
+	// Marshal does not reorder the in-memory slice
+	if allocs := db.GetAllocationsForPool("prod"); allocs[len(allocs)-1].ID != "a" {
+		t.Error("Marshal should not mutate allocation order in memory")
+	}
+}
+
+func TestAllocationsDatabase_Marshal_DeterministicRoundTrip(t *testing.T) {
+	entries := map[string][]Allocation{
+		"prod": {
+			{CIDR: "10.0.1.0/24", ID: "b", Name: "b", CreatedAt: "2024-01-15T10:30:00Z", Source: SourceProvider,
+				Metadata: map[string]string{"team": "payments", "env": "prod", "app": "api"}},
+			{CIDR: "10.0.0.0/16", ID: "vpc", Name: "vpc", CreatedAt: "2024-01-15T10:30:00Z", Source: SourceProvider},
+			{CIDR: "10.0.1.0/24", ID: "a", Name: "a-claim", CreatedAt: "2024-01-15T10:30:00Z", Source: SourceProvider, ExpiresAt: "2099-01-01T00:00:00Z"},
+		},
+		"dev": {
+			{CIDR: "fd00::/48", ID: "v6", Name: "v6", CreatedAt: "2024-01-15T10:30:00Z", Source: SourceProvider},
+			{CIDR: "172.16.0.0/12", ID: "v4", Name: "v4", CreatedAt: "2024-01-15T10:30:00Z", Source: SourceProvider},
+		},
+	}
+
+	// The same content built in opposite orders
+	forward := NewAllocationsDatabase()
+	reverse := NewAllocationsDatabase()
+	for poolID, allocations := range entries {
+		forward.Allocations[poolID] = append([]Allocation(nil), allocations...)
+		for i := len(allocations) - 1; i >= 0; i-- {
+			reverse.Allocations[poolID] = append(reverse.Allocations[poolID], allocations[i])
+		}
+	}
+
+	first, err := forward.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		again, err := reverse.Marshal()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(again) != string(first) {
+			t.Fatalf("expected identical output regardless of entry order:\n%s\nvs\n%s", first, again)
+		}
+	}
+
+	// Pools in key order, entries by CIDR then ID, metadata keys sorted
+	content := string(first)
+	for _, pair := range [][2]string{
+		{"dev:", "prod:"},
+		{"id: v4", "id: v6"},
+		{"id: vpc", "id: a"},
+		{"id: a", "id: b"},
+		{"app: api", "env: prod"},
+		{"env: prod", "team: payments"},
+	} {
+		if strings.Index(content, pair[0]) > strings.Index(content, pair[1]) {
+			t.Errorf("expected %q before %q:\n%s", pair[0], pair[1], content)
+		}
+	}
+
+	// Reading the file back and writing it again changes nothing
+	parsed, err := ParseAllocations(first)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rewritten, err := parsed.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(rewritten) != content {
+		t.Errorf("expected a round trip to reproduce the file:\n%s\nvs\n%s", content, rewritten)
+	}
+}
+
+func nestedDatabase() *AllocationsDatabase {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("pool", Allocation{CIDR: "10.0.0.0/16", ID: "vpc"})
+	db.AddAllocation("pool", Allocation{CIDR: "10.0.0.0/20", ID: "subnet", ParentCIDR: strPtr("10.0.0.0/16")})
+	db.AddAllocation("pool", Allocation{CIDR: "10.0.0.0/24", ID: "sub-subnet", ParentCIDR: strPtr("10.0.0.0/20")})
+	return db
+}
+
+func TestParentChain(t *testing.T) {
+	chain, err := nestedDatabase().ParentChain("10.0.0.0/24")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"10.0.0.0/16", "10.0.0.0/20", "10.0.0.0/24"}
+	if strings.Join(chain, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected chain %v, got %v", expected, chain)
+	}
+}
+
+func TestParentChain_Loop(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("pool", Allocation{CIDR: "10.0.0.0/24", ParentCIDR: strPtr("10.0.1.0/24")})
+	db.AddAllocation("pool", Allocation{CIDR: "10.0.1.0/24", ParentCIDR: strPtr("10.0.0.0/24")})
+
+	if _, err := db.ParentChain("10.0.0.0/24"); err == nil || !strings.Contains(err.Error(), "loops") {
+		t.Errorf("expected loop error, got %v", err)
+	}
+}
+
+func TestCheckNestingDepth(t *testing.T) {
+	db := nestedDatabase()
+
+	// The third level fits under a limit of 3
+	if err := db.CheckNestingDepth("10.0.0.0/20", 3); err != nil {
+		t.Errorf("expected third level to be allowed, got %v", err)
+	}
+
+	// A fourth level is rejected, naming the chain
+	err := db.CheckNestingDepth("10.0.0.0/24", 3)
+	if err == nil {
+		t.Fatal("expected fourth level to be rejected")
+	}
+	if !strings.Contains(err.Error(), "depth 4") || !strings.Contains(err.Error(), "10.0.0.0/16 → 10.0.0.0/20 → 10.0.0.0/24") {
+		t.Errorf("error should report depth and chain, got: %v", err)
+	}
+
+	// Unlimited by default
+	if err := db.CheckNestingDepth("10.0.0.0/24", 0); err != nil {
+		t.Errorf("expected no limit with max depth 0, got %v", err)
+	}
+}
+
+func TestPageAllocations(t *testing.T) {
+	allocs := []Allocation{
+		{CIDR: "10.0.3.0/24", ID: "d"},
+		{CIDR: "10.0.0.0/24", ID: "a"},
+		{CIDR: "10.0.10.0/24", ID: "e"},
+		{CIDR: "10.0.2.0/24", ID: "c"},
+		{CIDR: "10.0.1.0/24", ID: "b"},
+	}
+
+	ids := func(page []Allocation) string {
+		var out []string
+		for _, a := range page {
+			out = append(out, a.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	for _, tc := range []struct {
+		offset, limit int
+		expected      string
+	}{
+		{0, 0, "a,b,c,d,e"},
+		{0, 2, "a,b"},
+		{2, 2, "c,d"},
+		{4, 2, "e"},
+		{5, 2, ""},
+		{9, 0, ""},
+	} {
+		page, total := PageAllocations(allocs, tc.offset, tc.limit)
+		if got := ids(page); got != tc.expected {
+			t.Errorf("offset %d limit %d: expected %q, got %q", tc.offset, tc.limit, tc.expected, got)
+		}
+		if total != len(allocs) {
+			t.Errorf("offset %d limit %d: expected total %d, got %d", tc.offset, tc.limit, len(allocs), total)
+		}
+	}
+
+	// The input order is left alone
+	if allocs[0].ID != "d" {
+		t.Errorf("input slice should not be reordered, got %s first", allocs[0].ID)
+	}
+}
+
+func TestPageAllocations_StableAcrossInputOrder(t *testing.T) {
+	a := []Allocation{{CIDR: "fd00::/64", ID: "v6"}, {CIDR: "10.0.0.0/16", ID: "parent"}, {CIDR: "10.0.0.0/24", ID: "child"}}
+	b := []Allocation{a[2], a[0], a[1]}
+
+	pageA, _ := PageAllocations(a, 0, 0)
+	pageB, _ := PageAllocations(b, 0, 0)
+	for i := range pageA {
+		if pageA[i].ID != pageB[i].ID {
+			t.Fatalf("order depends on input: %v vs %v", pageA, pageB)
+		}
+	}
+	if pageA[0].ID != "parent" || pageA[1].ID != "child" {
+		t.Errorf("expected parent before child, got %v", pageA)
+	}
+}
+
+func TestAllocationsDatabase_RenameAllocation_UpdatesChildren(t *testing.T) {
+	db := NewAllocationsDatabase()
+	parent := "10.0.0.0/16"
+	db.AddAllocation("prod", Allocation{CIDR: parent, ID: "vpc-1", Name: "vpc"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "subnet-1", ParentCIDR: &parent})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.2.0/24", ID: "subnet-2", ParentCIDR: &parent})
+	db.AddAllocation("prod", Allocation{CIDR: "10.1.0.0/16", ID: "vpc-2", ContiguousWith: &parent})
+
+	// Shrinking to a /20 still holds both subnets
+	if err := db.RenameAllocation("vpc-1", "10.0.0.0/20"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if vpc, _, _ := db.FindAllocationByID("vpc-1"); vpc.CIDR != "10.0.0.0/20" {
+		t.Errorf("expected vpc-1 at 10.0.0.0/20, got %s", vpc.CIDR)
+	}
+	if children := db.GetAllocationsForParent("10.0.0.0/20"); len(children) != 2 {
+		t.Errorf("expected 2 children under the new CIDR, got %d", len(children))
+	}
+	if children := db.GetAllocationsForParent(parent); len(children) != 0 {
+		t.Errorf("expected no children left under the old CIDR, got %d", len(children))
+	}
+	if vpc2, _, _ := db.FindAllocationByID("vpc-2"); *vpc2.ContiguousWith != "10.0.0.0/20" {
+		t.Errorf("expected contiguous_with updated, got %s", *vpc2.ContiguousWith)
+	}
+}
+
+func TestAllocationsDatabase_RenameAllocation_RejectsOrphans(t *testing.T) {
+	db := NewAllocationsDatabase()
+	parent := "10.0.0.0/16"
+	db.AddAllocation("prod", Allocation{CIDR: parent, ID: "vpc-1", Name: "vpc"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "subnet-1", ParentCIDR: &parent})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.200.0/24", ID: "subnet-2", ParentCIDR: &parent})
+
+	err := db.RenameAllocation("vpc-1", "10.0.0.0/17")
+	if err == nil || !strings.Contains(err.Error(), "would orphan child allocations 10.0.200.0/24") {
+		t.Fatalf("expected orphan error, got %v", err)
+	}
+
+	// Nothing changed
+	if vpc, _, _ := db.FindAllocationByID("vpc-1"); vpc.CIDR != parent {
+		t.Errorf("expected vpc-1 unchanged, got %s", vpc.CIDR)
+	}
+	if children := db.GetAllocationsForParent(parent); len(children) != 2 {
+		t.Errorf("expected children unchanged, got %d", len(children))
+	}
+}
+
+func TestAllocationsDatabase_RenameAllocation_Rejects(t *testing.T) {
+	db := NewAllocationsDatabase()
+	parent := "10.0.0.0/16"
+	db.AddAllocation("prod", Allocation{CIDR: parent, ID: "vpc-1"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.1.0.0/16", ID: "vpc-2"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "subnet-1", ParentCIDR: &parent})
+
+	tests := []struct {
+		id, cidr, want string
+	}{
+		{"missing", "10.2.0.0/16", "not found"},
+		{"vpc-1", "10.0.0.1/16", "not a network address"},
+		{"vpc-1", "10.0.0.0/15", "overlaps allocation 10.1.0.0/16"},
+		{"subnet-1", "10.1.1.0/24", "outside its parent 10.0.0.0/16"},
+	}
+	for _, tt := range tests {
+		if err := db.RenameAllocation(tt.id, tt.cidr); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("RenameAllocation(%s, %s): expected error containing %q, got %v", tt.id, tt.cidr, tt.want, err)
+		}
+	}
+}
+
+func TestValidateVLANID(t *testing.T) {
+	for _, tc := range []struct {
+		id    int
+		valid bool
+	}{
+		{1, true},
+		{120, true},
+		{4094, true},
+		{0, false},
+		{4095, false},
+		{-1, false},
+	} {
+		err := ValidateVLANID(tc.id)
+		if tc.valid && err != nil {
+			t.Errorf("vlan_id %d: unexpected error: %v", tc.id, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("vlan_id %d: expected error", tc.id)
+		}
+	}
+}
+
+func TestAllocation_VLANIDRoundTrip(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "tagged", VLANID: 120})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "id-2", Name: "untagged"})
+
+	content, err := db.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(string(content), "vlan_id:") != 1 {
+		t.Errorf("expected vlan_id only on the tagged entry, got:\n%s", content)
+	}
+
+	parsed, err := ParseAllocations(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tagged, _, _ := parsed.FindAllocationByName("tagged"); tagged.VLANID != 120 {
+		t.Errorf("expected vlan_id 120, got %d", tagged.VLANID)
+	}
+	if untagged, _, _ := parsed.FindAllocationByName("untagged"); untagged.VLANID != 0 {
+		t.Errorf("expected no vlan_id, got %d", untagged.VLANID)
+	}
+}
+
+func TestValidateRegion(t *testing.T) {
+	if err := ValidateRegion("anywhere-1", nil); err != nil {
+		t.Errorf("expected any region without an allow list, got %v", err)
+	}
+
+	allowed := []string{"us-east-1", "europe-west4"}
+	if err := ValidateRegion("europe-west4", allowed); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := ValidateRegion("us-east-2", allowed)
+	if err == nil {
+		t.Fatal("expected an error for a region outside the allow list")
+	}
+	if !strings.Contains(err.Error(), "us-east-1, europe-west4") {
+		t.Errorf("expected the allowed regions in the error, got %v", err)
+	}
+}
+
+func TestAllocation_RegionRoundTrip(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "east", Region: "us-east-1"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "id-2", Name: "global"})
+
+	content, err := db.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(string(content), "region:") != 1 {
+		t.Errorf("expected region only on the tagged entry, got:\n%s", content)
+	}
+
+	parsed, err := ParseAllocations(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if east, _, _ := parsed.FindAllocationByName("east"); east.Region != "us-east-1" {
+		t.Errorf("expected region us-east-1, got %q", east.Region)
+	}
+}
+
+func TestFilterByRegion(t *testing.T) {
+	allocs := []Allocation{
+		{CIDR: "10.0.0.0/24", Region: "us-east-1"},
+		{CIDR: "10.0.1.0/24", Region: "eu-west-1"},
+		{CIDR: "10.0.2.0/24"},
+		{CIDR: "10.0.3.0/24", Region: "us-east-1"},
+	}
+
+	got := FilterByRegion(allocs, "us-east-1")
+	if len(got) != 2 || got[0].CIDR != "10.0.0.0/24" || got[1].CIDR != "10.0.3.0/24" {
+		t.Errorf("expected the two us-east-1 allocations, got %v", got)
+	}
+	if got := FilterByRegion(allocs, "ap-south-1"); len(got) != 0 {
+		t.Errorf("expected no allocations, got %v", got)
+	}
+}
+
+func TestAllocation_EmptyMetadataRoundTrip(t *testing.T) {
+	var poolDef *PoolDefinition
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "plain", Metadata: poolDef.EffectiveMetadata(map[string]string{})})
+
+	content, err := db.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(content), "metadata") {
+		t.Errorf("expected no metadata key, got:\n%s", content)
+	}
+
+	// Reading it back and writing it again changes nothing
+	parsed, err := ParseAllocations(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plain, _, _ := parsed.FindAllocationByName("plain"); plain.Metadata != nil {
+		t.Errorf("expected nil metadata, got %#v", plain.Metadata)
+	}
+	again, err := parsed.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(again) != string(content) {
+		t.Errorf("expected a stable encoding, got:\n%s\nthen:\n%s", content, again)
+	}
+}
+
+func TestParseAllocations_MalformedFileReportsLine(t *testing.T) {
+	content := "version: \"1.1\"\nallocations:\n  prod:\n    - cidr: 10.0.0.0/24\n     id: broken\n"
+
+	_, err := ParseAllocations([]byte(content))
+	if err == nil {
+		t.Fatal("expected an error for malformed YAML")
+	}
+	if !strings.Contains(err.Error(), "line ") {
+		t.Errorf("expected the error to give a line, got %v", err)
+	}
+}
+
+func TestParseAllocations_UnknownField(t *testing.T) {
+	content := "version: \"1.1\"\nallocations:\n  prod:\n    - cidr: 10.0.0.0/24\n      id: vpc-1\n      name: vpc\n      regoin: us-east-1\n"
+
+	db, err := ParseAllocations([]byte(content))
+	if err != nil {
+		t.Fatalf("tolerant parse should ignore the unknown field: %v", err)
+	}
+	if len(db.AllAllocations()) != 1 {
+		t.Errorf("expected 1 allocation, got %d", len(db.AllAllocations()))
+	}
+
+	_, err = ParseAllocationsStrict([]byte(content))
+	if err == nil {
+		t.Fatal("strict parse should reject the unknown field")
+	}
+	if !strings.Contains(err.Error(), "regoin") || !strings.Contains(err.Error(), "line 7") {
+		t.Errorf("expected the field and its line in the error, got %v", err)
+	}
+}
+
+func TestParseAllocationsStrict_EmptyFile(t *testing.T) {
+	db, err := ParseAllocationsStrict([]byte(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.Allocations == nil {
+		t.Error("Allocations map should be initialized")
+	}
+}
@@ -26,18 +26,79 @@ type sortableAllocation struct {
 	alloc   *Allocation
 }
 
+// AllocateOptions tunes a single allocation from a pool.
+type AllocateOptions struct {
+	// Avoid lists allocations from other pools that must be treated as
+	// occupied, e.g. those of the pool's avoid_pools.
+	Avoid []Allocation
+
+	// Freed lists blocks recently released from the pool. Pools with the
+	// cooldown_last reuse policy only reuse them when nothing else fits.
+	Freed []FreedRange
+}
+
 // FindNextAvailableInPool allocates from pool CIDRs defined in pools.yaml.
 // This is Mode 1: pool_id allocation.
 func (a *Allocator) FindNextAvailableInPool(poolDef *PoolDefinition, existingAllocations []Allocation, prefixLen int) (string, error) {
+	return a.FindNextAvailableInPoolWithOptions(poolDef, existingAllocations, prefixLen, AllocateOptions{})
+}
+
+// FindNextAvailableInPoolWithOptions is FindNextAvailableInPool with extra options.
+func (a *Allocator) FindNextAvailableInPoolWithOptions(poolDef *PoolDefinition, existingAllocations []Allocation, prefixLen int, opts AllocateOptions) (string, error) {
+	if poolDef.ReusePolicy == ReusePolicyCooldownLast && len(opts.Freed) > 0 {
+		return a.findInPoolCooldownLast(poolDef, existingAllocations, prefixLen, opts)
+	}
+	return a.findInPool(poolDef, existingAllocations, prefixLen, opts.Avoid)
+}
+
+// findInPoolCooldownLast holds freed blocks back, releasing them oldest
+// first only when the search cannot succeed without them. Once every freed
+// block is released this is plain first-fit.
+func (a *Allocator) findInPoolCooldownLast(poolDef *PoolDefinition, existingAllocations []Allocation, prefixLen int, opts AllocateOptions) (string, error) {
+	freed := make([]FreedRange, len(opts.Freed))
+	copy(freed, opts.Freed)
+	sort.SliceStable(freed, func(i, j int) bool {
+		return freed[i].FreedAt < freed[j].FreedAt
+	})
+
+	var lastErr error
+	for released := 0; released <= len(freed); released++ {
+		avoid := append([]Allocation{}, opts.Avoid...)
+		for _, f := range freed[released:] {
+			avoid = append(avoid, Allocation{CIDR: f.CIDR})
+		}
+
+		cidr, err := a.findInPool(poolDef, existingAllocations, prefixLen, avoid)
+		if err == nil {
+			return cidr, nil
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// findInPool tries each pool CIDR in fill order, treating existing top-level
+// allocations and the avoid set as occupied.
+func (a *Allocator) findInPool(poolDef *PoolDefinition, existingAllocations []Allocation, prefixLen int, avoid []Allocation) (string, error) {
+	if err := poolDef.CheckGranularity(prefixLen); err != nil {
+		return "", err
+	}
+
 	// Get top-level allocations (those without parent_cidr)
 	topLevelAllocations := filterTopLevelAllocations(existingAllocations)
+	topLevelAllocations = append(topLevelAllocations, filterTopLevelAllocations(avoid)...)
+	topLevelAllocations = append(topLevelAllocations, poolDef.occupiedBlocks(prefixLen)...)
 
 	// Track reasons for skipping each CIDR
 	var skippedReasons []string
 
 	// Try each CIDR in the pool until we find available space
-	for _, poolCIDRStr := range poolDef.CIDR {
-		cidrResult, err := a.findNextInCIDR(poolCIDRStr, topLevelAllocations, prefixLen)
+	for _, poolCIDRStr := range poolDef.searchOrder(prefixLen) {
+		find := a.findNextAlignedInCIDR
+		if poolDef.Distribute {
+			find = a.findDistributedInCIDR
+		}
+		cidrResult, err := find(poolCIDRStr, topLevelAllocations, prefixLen, poolDef.alignmentFor(prefixLen))
 		if err == nil {
 			return cidrResult, nil
 		}
@@ -45,20 +106,155 @@ func (a *Allocator) FindNextAvailableInPool(poolDef *PoolDefinition, existingAll
 		skippedReasons = append(skippedReasons, fmt.Sprintf("%s: %v", poolCIDRStr, err))
 	}
 
+	return "", poolExhaustedError(poolDef, prefixLen, skippedReasons)
+}
+
+// poolExhaustedError reports why no pool CIDR could hold the block.
+func poolExhaustedError(poolDef *PoolDefinition, prefixLen int, skippedReasons []string) error {
 	if len(skippedReasons) == 1 {
-		return "", fmt.Errorf("no available /%d block in pool: %s", prefixLen, skippedReasons[0])
+		return fmt.Errorf("no available /%d block in pool: %s", prefixLen, skippedReasons[0])
+	}
+	return fmt.Errorf("no available /%d block in pool (tried %d CIDRs): %v", prefixLen, len(poolDef.CIDR), skippedReasons)
+}
+
+// FindNextAvailableBatchInPool allocates count blocks from a pool in one pass.
+// Each block found is held in memory so the next search sees it as occupied,
+// which keeps the blocks contiguous wherever the pool's free space allows.
+// Either all count blocks are returned or an error is returned.
+//
+// The allocations are indexed once for the whole batch, so large pools are
+// not re-sorted for every block. Pools holding freed space back under
+// cooldown_last, and pools that distribute their blocks, use the plain
+// search.
+func (a *Allocator) FindNextAvailableBatchInPool(poolDef *PoolDefinition, existingAllocations []Allocation, prefixLen, count int, opts AllocateOptions) ([]string, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("count must be at least 1, got %d", count)
+	}
+
+	var find func() (string, error)
+	var hold func(cidr string)
+	if poolDef.Distribute || (poolDef.ReusePolicy == ReusePolicyCooldownLast && len(opts.Freed) > 0) {
+		working := make([]Allocation, len(existingAllocations), len(existingAllocations)+count)
+		copy(working, existingAllocations)
+		find = func() (string, error) {
+			return a.FindNextAvailableInPoolWithOptions(poolDef, working, prefixLen, opts)
+		}
+		hold = func(cidr string) {
+			working = append(working, Allocation{CIDR: cidr})
+		}
+	} else {
+		occupied := append(filterTopLevelAllocations(existingAllocations), filterTopLevelAllocations(opts.Avoid)...)
+		occupied = append(occupied, poolDef.occupiedBlocks(prefixLen)...)
+		index := newAllocationIndex(occupied)
+		find = func() (string, error) {
+			return index.findInPool(poolDef, prefixLen)
+		}
+		hold = index.insert
+	}
+
+	cidrs := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		next, err := find()
+		if err != nil {
+			return nil, fmt.Errorf("only %d of %d /%d blocks fit in pool: %w", i, count, prefixLen, err)
+		}
+		cidrs = append(cidrs, next)
+		hold(next)
+	}
+
+	return cidrs, nil
+}
+
+// FindLargestAvailable returns the largest free aligned block in the
+// container whose prefix length is within [minPrefix, maxPrefix], for
+// opportunistic allocations that take as much as is free up to a limit.
+func (a *Allocator) FindLargestAvailable(containerCIDR string, existingAllocations []Allocation, minPrefix, maxPrefix int) (string, error) {
+	return findLargest(minPrefix, maxPrefix, func(prefixLen int) (string, error) {
+		return a.findNextInCIDR(containerCIDR, existingAllocations, prefixLen)
+	})
+}
+
+// FindLargestAvailableInPool is FindLargestAvailable across a pool's CIDRs,
+// honouring the pool's options like FindNextAvailableInPoolWithOptions.
+func (a *Allocator) FindLargestAvailableInPool(poolDef *PoolDefinition, existingAllocations []Allocation, minPrefix, maxPrefix int, opts AllocateOptions) (string, error) {
+	return findLargest(minPrefix, maxPrefix, func(prefixLen int) (string, error) {
+		return a.FindNextAvailableInPoolWithOptions(poolDef, existingAllocations, prefixLen, opts)
+	})
+}
+
+// findLargest tries each prefix length from largest block to smallest and
+// returns the first block found.
+func findLargest(minPrefix, maxPrefix int, find func(prefixLen int) (string, error)) (string, error) {
+	if minPrefix < 0 || minPrefix > maxPrefix {
+		return "", fmt.Errorf("invalid prefix range /%d to /%d", minPrefix, maxPrefix)
 	}
-	return "", fmt.Errorf("no available /%d block in pool (tried %d CIDRs): %v", prefixLen, len(poolDef.CIDR), skippedReasons)
+
+	var lastErr error
+	for prefixLen := minPrefix; prefixLen <= maxPrefix; prefixLen++ {
+		cidr, err := find(prefixLen)
+		if err == nil {
+			return cidr, nil
+		}
+		lastErr = err
+	}
+	return "", fmt.Errorf("no free block between /%d and /%d: %w", minPrefix, maxPrefix, lastErr)
 }
 
+// Placements of a sub-allocation within its parent.
+const (
+	PlacementLow  = "low"  // Lowest free block, e.g. workload subnets (default)
+	PlacementHigh = "high" // Highest free block, e.g. NAT or transit subnets
+)
+
 // FindNextAvailableInParent allocates within an existing allocation's CIDR.
-// This is Mode 2: parent_cidr sub-allocation.
-func (a *Allocator) FindNextAvailableInParent(parentCIDR string, childAllocations []Allocation, prefixLen int) (string, error) {
+// This is Mode 2: parent_cidr sub-allocation. PlacementHigh takes the
+// highest free block instead of the lowest, so one parent can fill from
+// both ends; an empty placement is PlacementLow.
+func (a *Allocator) FindNextAvailableInParent(parentCIDR string, childAllocations []Allocation, prefixLen int, placement string) (string, error) {
+	if placement == PlacementHigh {
+		return a.findHighestInCIDR(parentCIDR, childAllocations, prefixLen)
+	}
 	return a.findNextInCIDR(parentCIDR, childAllocations, prefixLen)
 }
 
+// findHighestInCIDR finds the highest free /prefixLen block in a container.
+func (a *Allocator) findHighestInCIDR(containerCIDR string, existingAllocations []Allocation, prefixLen int) (string, error) {
+	_, containerNet, err := net.ParseCIDR(containerCIDR)
+	if err != nil {
+		return "", fmt.Errorf("invalid container CIDR %s: %w", containerCIDR, err)
+	}
+
+	containerPrefixLen, bits := containerNet.Mask.Size()
+	if prefixLen < containerPrefixLen {
+		return "", fmt.Errorf("requested prefix /%d is larger than container /%d", prefixLen, containerPrefixLen)
+	}
+	if prefixLen > bits {
+		return "", fmt.Errorf("requested prefix /%d exceeds address size /%d", prefixLen, bits)
+	}
+
+	free := freeRanges(containerNet, existingAllocations)
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLen))
+	for i := len(free) - 1; i >= 0; i-- {
+		// The last aligned block ending within the range
+		start := new(big.Int).Add(free[i].end, big.NewInt(1))
+		start.Sub(start, size)
+		start.Sub(start, new(big.Int).Mod(start, size))
+		if start.Cmp(free[i].start) >= 0 {
+			return blockString(start, prefixLen, containerNet), nil
+		}
+	}
+	return "", noBlockError(containerNet, free, prefixLen, prefixLen)
+}
+
 // findNextInCIDR finds the next available CIDR block within a given container CIDR.
 func (a *Allocator) findNextInCIDR(containerCIDR string, existingAllocations []Allocation, prefixLen int) (string, error) {
+	return a.findNextAlignedInCIDR(containerCIDR, existingAllocations, prefixLen, prefixLen)
+}
+
+// findNextAlignedInCIDR is findNextInCIDR with blocks starting on a /alignLen
+// boundary, for pools whose allocation_granularity is coarser than the
+// block. alignLen must not exceed prefixLen.
+func (a *Allocator) findNextAlignedInCIDR(containerCIDR string, existingAllocations []Allocation, prefixLen, alignLen int) (string, error) {
 	_, containerNet, err := net.ParseCIDR(containerCIDR)
 	if err != nil {
 		return "", fmt.Errorf("invalid container CIDR %s: %w", containerCIDR, err)
@@ -72,26 +268,16 @@ func (a *Allocator) findNextInCIDR(containerCIDR string, existingAllocations []A
 		return "", fmt.Errorf("requested prefix /%d exceeds address size /%d", prefixLen, bits)
 	}
 
-	// Filter allocations that are within this container
-	relevantAllocations := filterAllocationsInCIDR(existingAllocations, containerNet)
+	// Parse the allocations within this container once; invalid entries
+	// are skipped, as they should be fixed in the database separately
+	sortable := parseAllocationsInCIDR(existingAllocations, containerNet)
 
-	// Parse and sort existing allocations by network address
-	sortable := make([]sortableAllocation, 0, len(relevantAllocations))
-	var skippedInvalid int
-	for i := range relevantAllocations {
-		_, network, err := net.ParseCIDR(relevantAllocations[i].CIDR)
-		if err != nil {
-			skippedInvalid++
-			continue // Skip invalid entries
-		}
-		sortable = append(sortable, sortableAllocation{
-			network: network,
-			alloc:   &relevantAllocations[i],
-		})
+	// An allocation equal to (or covering) the whole container leaves
+	// nothing free; stepping past it would leave the container, or wrap
+	// to the start of the address space at its top
+	if coversContainer(sortable, containerNet) {
+		return "", noBlockError(containerNet, nil, prefixLen, alignLen)
 	}
-	// Note: skippedInvalid count is available for debugging but not exposed in errors
-	// as invalid allocations in the database should be fixed separately
-	_ = skippedInvalid
 
 	// Sort by network address
 	sort.Slice(sortable, func(i, j int) bool {
@@ -101,8 +287,8 @@ func (a *Allocator) findNextInCIDR(containerCIDR string, existingAllocations []A
 	// Start at the beginning of the container
 	candidateIP := cloneIP(containerNet.IP)
 
-	// Align candidate to the requested prefix length boundary
-	candidateIP = alignToPrefix(candidateIP, prefixLen, bits)
+	// Align candidate to the requested boundary
+	candidateIP = alignToPrefix(candidateIP, alignLen, bits)
 
 	for _, existing := range sortable {
 		// Create candidate network at current position
@@ -115,7 +301,7 @@ func (a *Allocator) findNextInCIDR(containerCIDR string, existingAllocations []A
 		_, candidateEnd := cidr.AddressRange(candidateNet)
 
 		// Check if candidate fits before existing allocation
-		if compareIPs(candidateEnd, existing.network.IP) <= 0 {
+		if compareIPs(candidateEnd, existing.network.IP) < 0 {
 			// Candidate fits in gap before existing
 			// Verify it's within the container
 			if containerNet.Contains(candidateIP) && containerNet.Contains(candidateEnd) {
@@ -123,12 +309,18 @@ func (a *Allocator) findNextInCIDR(containerCIDR string, existingAllocations []A
 			}
 		}
 
-		// Move candidate past the existing allocation
+		// Move candidate past the existing allocation, never backwards: with
+		// overlapping inputs (e.g. avoided pools) a later block can end
+		// before the current candidate
 		_, existingEnd := cidr.AddressRange(existing.network)
-		candidateIP = cidr.Inc(existingEnd)
-
-		// Align to prefix boundary
-		candidateIP = alignToPrefix(candidateIP, prefixLen, bits)
+		if isLastAddress(existingEnd) {
+			// Nothing follows the last address; cidr.Inc would wrap to zero
+			return "", noBlockError(containerNet, freeRanges(containerNet, existingAllocations), prefixLen, alignLen)
+		}
+		next := alignToPrefix(cidr.Inc(existingEnd), alignLen, bits)
+		if compareIPs(next, candidateIP) > 0 {
+			candidateIP = next
+		}
 	}
 
 	// Check if there's space after the last allocation
@@ -144,12 +336,36 @@ func (a *Allocator) findNextInCIDR(containerCIDR string, existingAllocations []A
 		return candidateNet.String(), nil
 	}
 
-	return "", fmt.Errorf("no available /%d block in %s", prefixLen, containerCIDR)
+	return "", noBlockError(containerNet, freeRanges(containerNet, existingAllocations), prefixLen, alignLen)
+}
+
+// coversContainer reports whether any allocation covers the whole container.
+func coversContainer(allocations []sortableAllocation, container *net.IPNet) bool {
+	containerPrefixLen, _ := container.Mask.Size()
+	for _, alloc := range allocations {
+		if allocPrefixLen, _ := alloc.network.Mask.Size(); allocPrefixLen <= containerPrefixLen && alloc.network.Contains(container.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLastAddress reports whether ip is the highest address of its family.
+func isLastAddress(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, b := range ip {
+		if b != 0xff {
+			return false
+		}
+	}
+	return true
 }
 
 // filterTopLevelAllocations returns allocations that have no parent_cidr.
 func filterTopLevelAllocations(allocations []Allocation) []Allocation {
-	result := make([]Allocation, 0)
+	result := make([]Allocation, 0, len(allocations))
 	for _, alloc := range allocations {
 		if alloc.ParentCIDR == nil {
 			result = append(result, alloc)
@@ -158,7 +374,8 @@ func filterTopLevelAllocations(allocations []Allocation) []Allocation {
 	return result
 }
 
-// filterAllocationsInCIDR returns allocations whose CIDR is within the container.
+// filterAllocationsInCIDR returns allocations that overlap the container,
+// including blocks that start before it and cover it entirely.
 func filterAllocationsInCIDR(allocations []Allocation, container *net.IPNet) []Allocation {
 	result := make([]Allocation, 0)
 	for _, alloc := range allocations {
@@ -166,13 +383,30 @@ func filterAllocationsInCIDR(allocations []Allocation, container *net.IPNet) []A
 		if err != nil {
 			continue
 		}
-		if container.Contains(allocNet.IP) {
+		if container.Contains(allocNet.IP) || allocNet.Contains(container.IP) {
 			result = append(result, alloc)
 		}
 	}
 	return result
 }
 
+// parseAllocationsInCIDR is filterAllocationsInCIDR for callers that go on
+// to use the parsed networks, so each CIDR is parsed once per search rather
+// than once per step. The entries point into allocations.
+func parseAllocationsInCIDR(allocations []Allocation, container *net.IPNet) []sortableAllocation {
+	result := make([]sortableAllocation, 0)
+	for i := range allocations {
+		_, allocNet, err := net.ParseCIDR(allocations[i].CIDR)
+		if err != nil {
+			continue
+		}
+		if container.Contains(allocNet.IP) || allocNet.Contains(container.IP) {
+			result = append(result, sortableAllocation{network: allocNet, alloc: &allocations[i]})
+		}
+	}
+	return result
+}
+
 // compareIPs compares two IP addresses.
 // Returns -1 if a < b, 0 if a == b, 1 if a > b.
 func compareIPs(a, b net.IP) int {
@@ -251,6 +485,34 @@ func (a *Allocator) ValidateNoOverlap(existingAllocations []Allocation, newCIDR
 	return nil
 }
 
+// ValidatePlan checks a set of allocations about to be committed together:
+// every entry must lie within container and no two may overlap. The first
+// conflict is returned with both CIDRs named.
+func (a *Allocator) ValidatePlan(container string, proposed []Allocation) error {
+	_, containerNet, err := net.ParseCIDR(container)
+	if err != nil {
+		return fmt.Errorf("invalid container CIDR %s: %w", container, err)
+	}
+	containerOnes, containerBits := containerNet.Mask.Size()
+
+	for i, entry := range proposed {
+		_, entryNet, err := net.ParseCIDR(entry.CIDR)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR %s in plan: %w", entry.CIDR, err)
+		}
+		ones, bits := entryNet.Mask.Size()
+		if bits != containerBits || ones < containerOnes || !containerNet.Contains(entryNet.IP) {
+			return fmt.Errorf("planned CIDR %s is outside %s", entry.CIDR, container)
+		}
+		for _, other := range proposed[:i] {
+			if cidrsOverlap(entry.CIDR, other.CIDR) {
+				return fmt.Errorf("planned CIDR %s overlaps planned CIDR %s", entry.CIDR, other.CIDR)
+			}
+		}
+	}
+	return nil
+}
+
 // CalculateAvailableSpace calculates available space in a pool or parent CIDR.
 func (a *Allocator) CalculateAvailableSpace(containerCIDR string, allocations []Allocation) (uint64, error) {
 	_, containerNet, err := net.ParseCIDR(containerCIDR)
@@ -263,13 +525,12 @@ func (a *Allocator) CalculateAvailableSpace(containerCIDR string, allocations []
 
 	// Sum allocated addresses
 	var allocatedAddresses uint64
-	relevant := filterAllocationsInCIDR(allocations, containerNet)
+	relevant := parseAllocationsInCIDR(allocations, containerNet)
+	if coversContainer(relevant, containerNet) {
+		return 0, nil
+	}
 	for _, alloc := range relevant {
-		_, allocNet, err := net.ParseCIDR(alloc.CIDR)
-		if err != nil {
-			continue
-		}
-		allocPrefixLen, allocBits := allocNet.Mask.Size()
+		allocPrefixLen, allocBits := alloc.network.Mask.Size()
 		allocatedAddresses += uint64(1) << uint(allocBits-allocPrefixLen)
 	}
 
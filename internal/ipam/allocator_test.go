@@ -4,7 +4,9 @@
 package ipam
 
 import (
+	"fmt"
 	"net"
+	"strings"
 	"testing"
 )
 
@@ -94,10 +96,195 @@ func TestFindNextAvailableInPool_LargerThanPool(t *testing.T) {
 	}
 }
 
+func TestFindNextAvailableBatchInPool_Feasible(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{
+		CIDR: []string{"10.0.0.0/16"},
+	}
+
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/20", ID: "existing"},
+	}
+
+	cidrs, err := allocator.FindNextAvailableBatchInPool(poolDef, existing, 20, 3, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"10.0.16.0/20", "10.0.32.0/20", "10.0.48.0/20"}
+	if len(cidrs) != len(expected) {
+		t.Fatalf("expected %d CIDRs, got %d: %v", len(expected), len(cidrs), cidrs)
+	}
+	for i := range expected {
+		if cidrs[i] != expected[i] {
+			t.Errorf("block %d: expected %s, got %s", i, expected[i], cidrs[i])
+		}
+	}
+
+	// The caller's slice must not be modified
+	if len(existing) != 1 {
+		t.Errorf("existing allocations should be untouched, got %d entries", len(existing))
+	}
+}
+
+func TestFindNextAvailableBatchInPool_Infeasible(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{
+		CIDR: []string{"10.0.0.0/19"},
+	}
+
+	// A /19 holds only two /20s
+	cidrs, err := allocator.FindNextAvailableBatchInPool(poolDef, nil, 20, 3, AllocateOptions{})
+	if err == nil {
+		t.Fatalf("expected error for infeasible batch, got %v", cidrs)
+	}
+	if cidrs != nil {
+		t.Errorf("expected no CIDRs on failure, got %v", cidrs)
+	}
+	if !containsString(err.Error(), "only 2 of 3") {
+		t.Errorf("error should report how many blocks fit, got: %v", err)
+	}
+}
+
+func TestFindNextAvailableBatchInPool_InvalidCount(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+
+	if _, err := allocator.FindNextAvailableBatchInPool(poolDef, nil, 24, 0, AllocateOptions{}); err == nil {
+		t.Error("expected error for zero count")
+	}
+}
+
+func TestFindNextAvailableInPool_AvoidPools(t *testing.T) {
+	allocator := NewAllocator()
+
+	db := NewAllocationsDatabase()
+	db.Allocations["pool-a"] = []Allocation{
+		{CIDR: "10.0.0.0/24", ID: "a1"},
+		{CIDR: "10.0.0.0/26", ID: "a1-sub", ParentCIDR: strPtr("10.0.0.0/24")},
+	}
+
+	// Pool B shares pool A's parent during a phased migration
+	poolB := &PoolDefinition{
+		CIDR:       []string{"10.0.0.0/16"},
+		AvoidPools: []string{"pool-a"},
+	}
+
+	avoid := db.AllocationsToAvoid(poolB)
+	if len(avoid) != 1 {
+		t.Fatalf("expected only pool A's top-level allocation to be avoided, got %v", avoid)
+	}
+
+	cidr, err := allocator.FindNextAvailableInPoolWithOptions(poolB, db.GetAllocationsForPool("pool-b"), 24, AllocateOptions{Avoid: avoid})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.1.0/24" {
+		t.Errorf("expected 10.0.1.0/24, got %s", cidr)
+	}
+
+	// Without avoid_pools the pool starts at its own first block
+	cidr, err = allocator.FindNextAvailableInPool(poolB, nil, 24)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.0.0/24" {
+		t.Errorf("expected 10.0.0.0/24, got %s", cidr)
+	}
+}
+
+func TestFindNextAvailableInPool_AvoidOverlapsOwnAllocations(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+
+	// An avoided /22 overlaps our own /24s; the search must not step backwards
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/24", ID: "own-1"},
+		{CIDR: "10.0.4.0/24", ID: "own-2"},
+	}
+	avoid := []Allocation{
+		{CIDR: "10.0.0.0/22", ID: "other"},
+	}
+
+	cidr, err := allocator.FindNextAvailableInPoolWithOptions(poolDef, existing, 24, AllocateOptions{Avoid: avoid})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.5.0/24" {
+		t.Errorf("expected 10.0.5.0/24, got %s", cidr)
+	}
+}
+
+func TestFindNextAvailableInPool_AvoidCoversPoolStart(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.1.0/24"}}
+
+	// An avoided block larger than the pool itself covers it entirely
+	avoid := []Allocation{{CIDR: "10.0.0.0/16", ID: "other"}}
+
+	if cidr, err := allocator.FindNextAvailableInPoolWithOptions(poolDef, nil, 26, AllocateOptions{Avoid: avoid}); err == nil {
+		t.Errorf("expected no space, got %s", cidr)
+	}
+}
+
+func TestFindNextAvailableInPool_CooldownLastSkipsFreedGap(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{
+		CIDR:        []string{"10.0.0.0/22"},
+		ReusePolicy: ReusePolicyCooldownLast,
+	}
+
+	// 10.0.0.0/24 was freed; 10.0.1.0/24 is in use; the rest was never used
+	existing := []Allocation{{CIDR: "10.0.1.0/24", ID: "in-use"}}
+	opts := AllocateOptions{Freed: []FreedRange{
+		{CIDR: "10.0.0.0/24", FreedAt: "2026-01-01T00:00:00Z"},
+	}}
+
+	cidr, err := allocator.FindNextAvailableInPoolWithOptions(poolDef, existing, 24, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.2.0/24" {
+		t.Errorf("expected never-used 10.0.2.0/24, got %s", cidr)
+	}
+
+	// first_fit ignores the freed hint
+	poolDef.ReusePolicy = ReusePolicyFirstFit
+	cidr, err = allocator.FindNextAvailableInPoolWithOptions(poolDef, existing, 24, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.0.0/24" {
+		t.Errorf("expected first-fit 10.0.0.0/24, got %s", cidr)
+	}
+}
+
+func TestFindNextAvailableInPool_CooldownLastReusesOldestFreedFirst(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{
+		CIDR:        []string{"10.0.0.0/23"},
+		ReusePolicy: ReusePolicyCooldownLast,
+	}
+
+	// The pool is only freed space; the older block should be reused first
+	opts := AllocateOptions{Freed: []FreedRange{
+		{CIDR: "10.0.0.0/24", FreedAt: "2026-03-01T00:00:00Z"},
+		{CIDR: "10.0.1.0/24", FreedAt: "2026-01-01T00:00:00Z"},
+	}}
+
+	cidr, err := allocator.FindNextAvailableInPoolWithOptions(poolDef, nil, 24, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.1.0/24" {
+		t.Errorf("expected oldest freed 10.0.1.0/24, got %s", cidr)
+	}
+}
+
 func TestFindNextAvailableInParent_EmptyParent(t *testing.T) {
 	allocator := NewAllocator()
 
-	cidr, err := allocator.FindNextAvailableInParent("10.0.0.0/16", []Allocation{}, 24)
+	cidr, err := allocator.FindNextAvailableInParent("10.0.0.0/16", []Allocation{}, 24, PlacementLow)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -114,7 +301,7 @@ func TestFindNextAvailableInParent_WithExisting(t *testing.T) {
 		{CIDR: "10.0.1.0/24", ID: "test-2", ParentCIDR: strPtr("10.0.0.0/16")},
 	}
 
-	cidr, err := allocator.FindNextAvailableInParent("10.0.0.0/16", existing, 24)
+	cidr, err := allocator.FindNextAvailableInParent("10.0.0.0/16", existing, 24, PlacementLow)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -131,7 +318,7 @@ func TestFindNextAvailableInParent_DifferentSizes(t *testing.T) {
 		{CIDR: "10.0.0.0/20", ID: "test-1", ParentCIDR: strPtr("10.0.0.0/16")},
 	}
 
-	cidr, err := allocator.FindNextAvailableInParent("10.0.0.0/16", existing, 20)
+	cidr, err := allocator.FindNextAvailableInParent("10.0.0.0/16", existing, 20, PlacementLow)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -149,7 +336,7 @@ func TestFindNextAvailableInParent_AlignsToBoundary(t *testing.T) {
 		{CIDR: "10.0.0.0/25", ID: "test-1", ParentCIDR: strPtr("10.0.0.0/16")},
 	}
 
-	cidr, err := allocator.FindNextAvailableInParent("10.0.0.0/16", existing, 24)
+	cidr, err := allocator.FindNextAvailableInParent("10.0.0.0/16", existing, 24, PlacementLow)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -266,6 +453,26 @@ func TestFindNextAvailableInPool_MultipleCIDRs(t *testing.T) {
 	}
 }
 
+func TestFindNextAvailableInPool_DecommissioningIsOccupied(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{
+		CIDR: []string{"10.0.0.0/16"},
+	}
+
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/24", ID: "old", Status: StatusDecommissioning},
+		{CIDR: "10.0.1.0/24", ID: "held", Status: StatusReservation, Reserved: true},
+	}
+
+	cidr, err := allocator.FindNextAvailableInPool(poolDef, existing, 24)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.2.0/24" {
+		t.Errorf("expected 10.0.2.0/24 (decommissioning and reserved blocks occupied), got %s", cidr)
+	}
+}
+
 func TestFindNextAvailableInPool_SkipsInvalidCIDRs(t *testing.T) {
 	allocator := NewAllocator()
 	poolDef := &PoolDefinition{
@@ -354,7 +561,7 @@ func TestFindNextAvailableInParent_Exhausted(t *testing.T) {
 		{CIDR: "10.0.0.128/25", ID: "test-2", ParentCIDR: strPtr("10.0.0.0/24")},
 	}
 
-	_, err := allocator.FindNextAvailableInParent("10.0.0.0/24", existing, 25)
+	_, err := allocator.FindNextAvailableInParent("10.0.0.0/24", existing, 25, PlacementLow)
 	if err == nil {
 		t.Error("expected error for exhausted parent")
 	}
@@ -363,7 +570,7 @@ func TestFindNextAvailableInParent_Exhausted(t *testing.T) {
 func TestFindNextAvailableInParent_InvalidParentCIDR(t *testing.T) {
 	allocator := NewAllocator()
 
-	_, err := allocator.FindNextAvailableInParent("not-a-cidr", []Allocation{}, 24)
+	_, err := allocator.FindNextAvailableInParent("not-a-cidr", []Allocation{}, 24, PlacementLow)
 	if err == nil {
 		t.Error("expected error for invalid parent CIDR")
 	}
@@ -373,7 +580,7 @@ func TestFindNextAvailableInParent_PrefixTooLarge(t *testing.T) {
 	allocator := NewAllocator()
 
 	// Try to allocate a /16 from a /24 parent
-	_, err := allocator.FindNextAvailableInParent("10.0.0.0/24", []Allocation{}, 16)
+	_, err := allocator.FindNextAvailableInParent("10.0.0.0/24", []Allocation{}, 16, PlacementLow)
 	if err == nil {
 		t.Error("expected error for prefix larger than parent")
 	}
@@ -389,7 +596,7 @@ func TestFindNextAvailableInParent_FiltersToParentOnly(t *testing.T) {
 	}
 
 	// Should find 10.0.1.0/24 (next in 10.0.0.0/16)
-	cidr, err := allocator.FindNextAvailableInParent("10.0.0.0/16", existing, 24)
+	cidr, err := allocator.FindNextAvailableInParent("10.0.0.0/16", existing, 24, PlacementLow)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -606,3 +813,568 @@ func containsStringHelper(s, substr string) bool {
 func parseIP(s string) net.IP {
 	return net.ParseIP(s)
 }
+
+func TestFindNextInCIDR_HostRouteNotReused(t *testing.T) {
+	allocator := NewAllocator()
+	existing := []Allocation{{CIDR: "10.0.0.0/32"}, {CIDR: "10.0.0.3/32"}}
+
+	// A block ending exactly on an existing /32 overlaps it
+	if got, _ := allocator.FindNextAvailableInParent("10.0.0.0/24", existing, 32, PlacementLow); got != "10.0.0.1/32" {
+		t.Errorf("expected 10.0.0.1/32, got %s", got)
+	}
+	if got, _ := allocator.FindNextAvailableInParent("10.0.0.0/24", existing, 31, PlacementLow); got != "10.0.0.4/31" {
+		t.Errorf("expected 10.0.0.4/31, got %s", got)
+	}
+}
+
+func TestFindNextAvailableInPool_Granularity(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}, AllocationGranularity: 24}
+	existing := []Allocation{{CIDR: "10.0.0.0/25"}}
+
+	// The free 10.0.0.128/25 is skipped: blocks start on a /24 boundary
+	got, err := allocator.FindNextAvailableInPool(poolDef, existing, 25)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.0.1.0/25" {
+		t.Errorf("expected 10.0.1.0/25, got %s", got)
+	}
+
+	// Blocks larger than the granularity are rejected
+	if _, err := allocator.FindNextAvailableInPool(poolDef, existing, 23); err == nil || !containsString(err.Error(), "allocation_granularity /24") {
+		t.Errorf("expected granularity error for /23, got %v", err)
+	}
+
+	// The batch path follows the same rule
+	cidrs, err := allocator.FindNextAvailableBatchInPool(poolDef, existing, 26, 2, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidrs[0] != "10.0.1.0/26" || cidrs[1] != "10.0.2.0/26" {
+		t.Errorf("expected 10.0.1.0/26 and 10.0.2.0/26, got %v", cidrs)
+	}
+}
+
+func TestFindContiguousInPool_Granularity(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}, AllocationGranularity: 24}
+	existing := []Allocation{{CIDR: "10.0.1.0/25"}}
+
+	// 10.0.1.128/25 is adjacent but off the /24 boundary; 10.0.0.0/25 is not
+	// adjacent, so nothing fits
+	if got, err := allocator.FindContiguousInPool(poolDef, existing, 25, "10.0.1.0/25", ContiguousEither); err == nil {
+		t.Errorf("expected no aligned adjacent block, got %s", got)
+	}
+
+	got, err := allocator.FindContiguousInPool(poolDef, []Allocation{{CIDR: "10.0.1.0/24"}}, 24, "10.0.1.0/24", ContiguousEither)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.0.0.0/24" {
+		t.Errorf("expected 10.0.0.0/24, got %s", got)
+	}
+}
+
+func TestFindNextAvailableInPool_FillOrderSmallestFirst(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16", "192.168.0.0/24"}}
+
+	// Declared order starts in the /16
+	got, err := allocator.FindNextAvailableInPool(poolDef, nil, 25)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.0.0.0/25" {
+		t.Errorf("declared: expected 10.0.0.0/25, got %s", got)
+	}
+
+	poolDef.FillOrder = FillOrderSmallestFirst
+	got, err = allocator.FindNextAvailableInPool(poolDef, nil, 25)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "192.168.0.0/25" {
+		t.Errorf("smallest_first: expected 192.168.0.0/25, got %s", got)
+	}
+
+	// The batch search follows the same order, moving on once the /24 is full
+	batch, err := allocator.FindNextAvailableBatchInPool(poolDef, nil, 25, 3, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"192.168.0.0/25", "192.168.0.128/25", "10.0.0.0/25"}; fmt.Sprint(batch) != fmt.Sprint(want) {
+		t.Errorf("smallest_first batch: expected %v, got %v", want, batch)
+	}
+
+	// Requests too large for the small CIDR still fit in the large one
+	got, err = allocator.FindNextAvailableInPool(poolDef, nil, 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.0.0.0/20" {
+		t.Errorf("smallest_first /20: expected 10.0.0.0/20, got %s", got)
+	}
+}
+
+func TestFindLargestAvailable(t *testing.T) {
+	allocator := NewAllocator()
+
+	// 10.0.0.0/20 is full apart from a /22-sized hole at 10.0.4.0
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/22"},
+		{CIDR: "10.0.8.0/21"},
+	}
+	got, err := allocator.FindLargestAvailable("10.0.0.0/20", existing, 20, 24)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.0.4.0/22" {
+		t.Errorf("expected 10.0.4.0/22, got %s", got)
+	}
+
+	// Only scattered /24s are free
+	existing = []Allocation{
+		{CIDR: "10.0.0.0/24"},
+		{CIDR: "10.0.2.0/23"},
+		{CIDR: "10.0.4.0/22"},
+		{CIDR: "10.0.8.0/21"},
+	}
+	got, err = allocator.FindLargestAvailable("10.0.0.0/20", existing, 20, 24)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.0.1.0/24" {
+		t.Errorf("expected 10.0.1.0/24, got %s", got)
+	}
+
+	// Nothing as large as max_mask is free
+	if _, err := allocator.FindLargestAvailable("10.0.0.0/20", existing, 20, 23); err == nil || !containsString(err.Error(), "no free block between /20 and /23") {
+		t.Errorf("expected no free block error, got %v", err)
+	}
+
+	if _, err := allocator.FindLargestAvailable("10.0.0.0/20", nil, 24, 20); err == nil {
+		t.Error("expected error for an inverted range")
+	}
+}
+
+func TestFindLargestAvailableInPool(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/24", "10.1.0.0/16"}}
+
+	// The whole range is capped at min_mask even when more is free
+	got, err := allocator.FindLargestAvailableInPool(poolDef, nil, 20, 26, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.1.0.0/20" {
+		t.Errorf("expected 10.1.0.0/20, got %s", got)
+	}
+
+	// Granularity rules out blocks larger than it
+	poolDef.AllocationGranularity = 22
+	got, err = allocator.FindLargestAvailableInPool(poolDef, nil, 20, 26, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.1.0.0/22" {
+		t.Errorf("expected 10.1.0.0/22 under granularity, got %s", got)
+	}
+}
+
+func TestFindNextAvailable_MisalignedContiguousFreeSpace(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/22"}}
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/24"},
+		{CIDR: "10.0.3.0/24"},
+	}
+	want := "10.0.1.0 - 10.0.2.255 is free (512 addresses) but a /23 must start on a /23 boundary; " +
+		"the largest aligned free block is 10.0.1.0/24"
+
+	_, err := allocator.FindNextAvailableInPool(poolDef, existing, 23)
+	if err == nil {
+		t.Fatal("expected error: the free /24s are contiguous but not /23-aligned")
+	}
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("expected alignment explanation %q, got: %v", want, err)
+	}
+
+	_, err = allocator.FindNextAvailableBatchInPool(poolDef, existing, 23, 1, AllocateOptions{})
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("expected the batch search to give the same explanation, got: %v", err)
+	}
+}
+
+func TestFindNextAvailable_NotEnoughFreeSpace(t *testing.T) {
+	allocator := NewAllocator()
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/24"},
+		{CIDR: "10.0.1.0/25"},
+	}
+
+	_, err := allocator.FindNextAvailableInParent("10.0.0.0/23", existing, 24, PlacementLow)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "the largest aligned free block is 10.0.1.128/25") {
+		t.Errorf("expected largest aligned free block, got: %v", err)
+	}
+	if strings.Contains(err.Error(), "boundary") {
+		t.Errorf("expected no alignment explanation when too little is free, got: %v", err)
+	}
+
+	_, err = allocator.FindNextAvailableInParent("10.0.0.0/24", []Allocation{{CIDR: "10.0.0.0/24"}}, 26, PlacementLow)
+	if err == nil || !strings.Contains(err.Error(), "no free space") {
+		t.Errorf("expected no free space, got: %v", err)
+	}
+}
+
+func TestValidatePlan_Valid(t *testing.T) {
+	allocator := NewAllocator()
+	proposed := []Allocation{{CIDR: "10.0.0.0/24"}, {CIDR: "10.0.1.0/24"}, {CIDR: "10.0.2.0/23"}}
+	if err := allocator.ValidatePlan("10.0.0.0/16", proposed); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := allocator.ValidatePlan("10.0.0.0/16", nil); err != nil {
+		t.Errorf("unexpected error for an empty plan: %v", err)
+	}
+}
+
+func TestValidatePlan_SelfOverlap(t *testing.T) {
+	allocator := NewAllocator()
+	proposed := []Allocation{{CIDR: "10.0.0.0/24"}, {CIDR: "10.0.2.0/24"}, {CIDR: "10.0.0.128/25"}}
+	err := allocator.ValidatePlan("10.0.0.0/16", proposed)
+	if err == nil {
+		t.Fatal("expected an overlap error")
+	}
+	if !strings.Contains(err.Error(), "10.0.0.128/25 overlaps planned CIDR 10.0.0.0/24") {
+		t.Errorf("expected both CIDRs named, got %v", err)
+	}
+}
+
+func TestValidatePlan_OutsideContainer(t *testing.T) {
+	allocator := NewAllocator()
+	for _, outside := range []string{"10.1.0.0/24", "10.0.0.0/15", "fd00::/64"} {
+		err := allocator.ValidatePlan("10.0.0.0/16", []Allocation{{CIDR: "10.0.0.0/24"}, {CIDR: outside}})
+		if err == nil {
+			t.Errorf("%s: expected a containment error", outside)
+			continue
+		}
+		if !strings.Contains(err.Error(), outside+" is outside 10.0.0.0/16") {
+			t.Errorf("%s: expected both CIDRs named, got %v", outside, err)
+		}
+	}
+}
+
+func TestSkipFirstBlock_RelativeToRequestedSize(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}, SkipFirstBlock: true}
+
+	for _, tc := range []struct {
+		prefixLen int
+		want      string
+	}{
+		{24, "10.0.1.0/24"},
+		{25, "10.0.0.128/25"},
+		{16, ""},
+	} {
+		got, err := allocator.FindNextAvailableInPool(poolDef, nil, tc.prefixLen)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("/%d: expected the whole-pool request to be refused, got %s", tc.prefixLen, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("/%d: unexpected error: %v", tc.prefixLen, err)
+		}
+		if got != tc.want {
+			t.Errorf("/%d: expected %s, got %s", tc.prefixLen, tc.want, got)
+		}
+	}
+}
+
+func TestSkipFirstBlock_LeavesLaterSpaceAlone(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16", "10.1.0.0/16"}, SkipFirstBlock: true}
+	existing := []Allocation{{CIDR: "10.0.1.0/24"}}
+
+	got, err := allocator.FindNextAvailableInPool(poolDef, existing, 24)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.0.2.0/24" {
+		t.Errorf("expected 10.0.2.0/24, got %s", got)
+	}
+
+	// A /25 may still use the second half of the skipped /24
+	got, err = allocator.FindNextAvailableInPool(poolDef, existing, 25)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.0.0.128/25" {
+		t.Errorf("expected 10.0.0.128/25, got %s", got)
+	}
+
+	batch, err := allocator.FindNextAvailableBatchInPool(poolDef, existing, 24, 2, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch[0] != "10.0.2.0/24" || batch[1] != "10.0.3.0/24" {
+		t.Errorf("expected the batch to skip the first /24 too, got %v", batch)
+	}
+}
+
+func TestFindNextAvailable_AllocationEqualsContainer(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+	existing := []Allocation{{CIDR: "10.0.0.0/16", Name: "everything"}}
+
+	for _, prefixLen := range []int{16, 24, 32} {
+		got, err := allocator.FindNextAvailableInPool(poolDef, existing, prefixLen)
+		if err == nil {
+			t.Fatalf("/%d: expected an error from a fully consumed pool, got %s", prefixLen, got)
+		}
+		if !strings.Contains(err.Error(), "no free space") {
+			t.Errorf("/%d: expected a no free space error, got %v", prefixLen, err)
+		}
+	}
+
+	available, err := allocator.CalculateAvailableSpace("10.0.0.0/16", existing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if available != 0 {
+		t.Errorf("expected 0 available addresses, got %d", available)
+	}
+}
+
+func TestFindNextAvailable_AllocationEqualsParent(t *testing.T) {
+	allocator := NewAllocator()
+	children := []Allocation{{CIDR: "10.0.4.0/24", Name: "whole"}}
+
+	if got, err := allocator.FindNextAvailableInParent("10.0.4.0/24", children, 26, PlacementLow); err == nil {
+		t.Errorf("expected an error from a fully consumed parent, got %s", got)
+	}
+}
+
+func TestFindNextAvailable_FullContainerAtTopOfAddressSpace(t *testing.T) {
+	allocator := NewAllocator()
+
+	// Stepping past the last allocation wraps to the first address, which
+	// must not be mistaken for free space at the start of the container
+	cases := []struct {
+		container string
+		existing  []Allocation
+		prefixLen int
+	}{
+		{"255.255.0.0/16", []Allocation{{CIDR: "255.255.0.0/16"}}, 24},
+		{"255.255.0.0/16", []Allocation{{CIDR: "255.255.0.0/17"}, {CIDR: "255.255.128.0/17"}}, 24},
+		{"ffff:ffff::/32", []Allocation{{CIDR: "ffff:ffff::/32"}}, 48},
+	}
+	for _, tc := range cases {
+		got, err := allocator.FindNextAvailableInParent(tc.container, tc.existing, tc.prefixLen, PlacementLow)
+		if err == nil {
+			t.Errorf("%s: expected an error, got %s", tc.container, got)
+		}
+		available, err := allocator.CalculateAvailableSpace(tc.container, tc.existing)
+		if err != nil || available != 0 {
+			t.Errorf("%s: expected 0 available addresses, got %d (%v)", tc.container, available, err)
+		}
+	}
+}
+
+func TestReservedRanges_BlockAllocation(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}, ReservedRanges: []string{"10.0.0.0/23", "10.0.3.0/24"}}
+
+	existing := []Allocation{}
+	for _, want := range []string{"10.0.2.0/24", "10.0.4.0/24"} {
+		got, err := allocator.FindNextAvailableInPool(poolDef, existing, 24)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+		existing = append(existing, Allocation{CIDR: got})
+	}
+
+	batch, err := allocator.FindNextAvailableBatchInPool(poolDef, nil, 24, 2, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch[0] != "10.0.2.0/24" || batch[1] != "10.0.4.0/24" {
+		t.Errorf("expected the batch to skip the reserved ranges, got %v", batch)
+	}
+}
+
+func TestReservedRanges_FillWholePool(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/24"}, ReservedRanges: []string{"10.0.0.0/25"}}
+
+	if got, err := allocator.FindNextAvailableInPool(poolDef, nil, 24); err == nil {
+		t.Errorf("expected the /24 to be refused with half of it reserved, got %s", got)
+	}
+	got, err := allocator.FindNextAvailableInPool(poolDef, nil, 25)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.0.0.128/25" {
+		t.Errorf("expected 10.0.0.128/25, got %s", got)
+	}
+}
+
+func TestFindNextAvailableInPool_SmallBlockRegion(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{
+		CIDR:             []string{"10.0.0.0/16"},
+		SmallBlockRegion: "10.0.240.0/20",
+	}
+
+	// Small blocks pack into the region, large ones start at the pool
+	for _, tc := range []struct {
+		prefixLen int
+		want      string
+	}{
+		{28, "10.0.240.0/28"},
+		{26, "10.0.240.0/26"},
+		{20, "10.0.0.0/20"},
+		{25, "10.0.0.0/25"},
+	} {
+		cidr, err := allocator.FindNextAvailableInPool(poolDef, []Allocation{}, tc.prefixLen)
+		if err != nil {
+			t.Fatalf("/%d: unexpected error: %v", tc.prefixLen, err)
+		}
+		if cidr != tc.want {
+			t.Errorf("/%d: expected %s, got %s", tc.prefixLen, tc.want, cidr)
+		}
+	}
+
+	// A /20 never takes the region, even as the last free /20
+	var existing []Allocation
+	for i := 0; i < 15; i++ {
+		existing = append(existing, Allocation{CIDR: fmt.Sprintf("10.0.%d.0/20", i*16)})
+	}
+	if cidr, err := allocator.FindNextAvailableInPool(poolDef, existing, 20); err == nil {
+		t.Errorf("expected the pool to be full for a /20, got %s", cidr)
+	}
+	if cidr, err := allocator.FindNextAvailableInPool(poolDef, existing, 28); err != nil || cidr != "10.0.240.0/28" {
+		t.Errorf("expected 10.0.240.0/28 in the region, got %s, %v", cidr, err)
+	}
+}
+
+func TestFindNextAvailableInPool_SmallBlockRegionFull(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{
+		CIDR:             []string{"10.0.0.0/16"},
+		SmallBlockRegion: "10.0.255.0/24",
+		SmallBlockMask:   25,
+	}
+	existing := []Allocation{{CIDR: "10.0.255.0/25"}, {CIDR: "10.0.255.128/25"}}
+
+	// Small blocks stay in their region rather than spilling into the rest
+	_, err := allocator.FindNextAvailableInPool(poolDef, existing, 26)
+	if err == nil || !strings.Contains(err.Error(), "10.0.255.0/24") {
+		t.Errorf("expected the region to be reported full, got %v", err)
+	}
+}
+
+func BenchmarkFindNextAvailableInPool100k(b *testing.B) {
+	poolDef, existing := benchmarkPool(100000)
+	allocator := NewAllocator()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := allocator.FindNextAvailableInPool(poolDef, existing, 24); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCalculateAvailableSpace100k(b *testing.B) {
+	_, existing := benchmarkPool(100000)
+	allocator := NewAllocator()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := allocator.CalculateAvailableSpace("10.0.0.0/8", existing); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestFindNextAvailableInPool_RouteAligned(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/14"}, RouteAligned: true, RouteBoundary: 16}
+	existing := []Allocation{{CIDR: "10.0.0.0/17"}}
+
+	// A /18 fits within the first /16
+	if cidr, err := allocator.FindNextAvailableInPool(poolDef, existing, 18); err != nil || cidr != "10.0.128.0/18" {
+		t.Errorf("expected 10.0.128.0/18, got %s, %v", cidr, err)
+	}
+
+	// A /15 would span two /16 route blocks
+	_, err := allocator.FindNextAvailableInPool(poolDef, existing, 15)
+	if err == nil || !strings.Contains(err.Error(), "would cross the pool's /16 route_boundary") {
+		t.Errorf("expected the /15 to be rejected, got %v", err)
+	}
+}
+
+func TestFindNextAvailableInParent_HighAndLowShareParent(t *testing.T) {
+	allocator := NewAllocator()
+	parent := "10.0.0.0/24"
+	var children []Allocation
+	allocate := func(prefixLen int, placement string) string {
+		t.Helper()
+		cidr, err := allocator.FindNextAvailableInParent(parent, children, prefixLen, placement)
+		if err != nil {
+			t.Fatalf("/%d %s: unexpected error: %v", prefixLen, placement, err)
+		}
+		children = append(children, Allocation{CIDR: cidr, ParentCIDR: &parent})
+		return cidr
+	}
+
+	if got := allocate(28, PlacementHigh); got != "10.0.0.240/28" {
+		t.Errorf("expected the high /28 at the top of the parent, got %s", got)
+	}
+	for _, want := range []string{"10.0.0.0/26", "10.0.0.64/26", "10.0.0.128/26"} {
+		if got := allocate(26, PlacementLow); got != want {
+			t.Errorf("expected low /26 %s, got %s", want, got)
+		}
+	}
+	if got := allocate(28, PlacementHigh); got != "10.0.0.224/28" {
+		t.Errorf("expected the next high /28 below the first, got %s", got)
+	}
+
+	// The fourth /26 would collide with the high /28s
+	if _, err := allocator.FindNextAvailableInParent(parent, children, 26, PlacementLow); err == nil {
+		t.Error("expected no /26 to be left")
+	}
+	for i, a := range children {
+		for _, b := range children[i+1:] {
+			if cidrsOverlap(a.CIDR, b.CIDR) {
+				t.Errorf("%s overlaps %s", a.CIDR, b.CIDR)
+			}
+		}
+	}
+}
+
+func TestFindNextAvailableInParent_HighAligned(t *testing.T) {
+	allocator := NewAllocator()
+	// 10.0.0.240/28 is free but a /27 must end on a /27 boundary below it
+	existing := []Allocation{{CIDR: "10.0.0.224/28"}}
+	cidr, err := allocator.FindNextAvailableInParent("10.0.0.0/24", existing, 27, PlacementHigh)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.0.192/27" {
+		t.Errorf("expected 10.0.0.192/27, got %s", cidr)
+	}
+
+	_, err = allocator.FindNextAvailableInParent("10.0.0.0/24", []Allocation{{CIDR: "10.0.0.0/24"}}, 26, PlacementHigh)
+	if err == nil || !strings.Contains(err.Error(), "no available /26 block") {
+		t.Errorf("expected no available block, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"sort"
+	"time"
+)
+
+// The archive keeps deleted allocations for audit trails when the provider
+// runs with archive_deletions. Archived entries sit outside the per-pool
+// allocation lists, so their blocks are free for reuse like any other
+// deleted allocation's.
+
+// ArchivedAllocation is a deleted allocation with the pool it belonged to
+// and when it was deleted.
+type ArchivedAllocation struct {
+	Allocation `yaml:",inline"`
+	PoolID     string `yaml:"pool_id"`
+	DeletedAt  string `yaml:"deleted_at"` // RFC3339 timestamp
+}
+
+// Archive records a removed allocation in the archive.
+func (d *AllocationsDatabase) Archive(poolID string, alloc Allocation, deletedAt time.Time) {
+	d.Archived = append(d.Archived, ArchivedAllocation{
+		Allocation: alloc,
+		PoolID:     poolID,
+		DeletedAt:  deletedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// ArchivedAllocations returns archived entries, optionally only those of
+// one pool, most recently deleted first.
+func (d *AllocationsDatabase) ArchivedAllocations(poolID string) []ArchivedAllocation {
+	var result []ArchivedAllocation
+	for _, archived := range d.Archived {
+		if poolID == "" || archived.PoolID == poolID {
+			result = append(result, archived)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DeletedAt > result[j].DeletedAt
+	})
+	return result
+}
@@ -0,0 +1,95 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"testing"
+	"time"
+)
+
+func TestArchive_DeletedAllocationIsArchived(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-old", Metadata: map[string]string{"team": "net"}})
+
+	removed, err := db.RemoveLeafAllocation("prod", "id-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deletedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	db.Archive("prod", *removed, deletedAt)
+
+	if _, _, found := db.FindAllocationByID("id-1"); found {
+		t.Error("expected the archived allocation to be gone from the pool")
+	}
+	archived := db.ArchivedAllocations("prod")
+	if len(archived) != 1 {
+		t.Fatalf("expected one archived allocation, got %v", archived)
+	}
+	if archived[0].Name != "vpc-old" || archived[0].CIDR != "10.0.0.0/24" || archived[0].PoolID != "prod" {
+		t.Errorf("unexpected archived entry: %+v", archived[0])
+	}
+	if archived[0].DeletedAt != "2026-03-01T12:00:00Z" {
+		t.Errorf("expected deleted_at to be stamped, got %q", archived[0].DeletedAt)
+	}
+	if len(db.ArchivedAllocations("dev")) != 0 {
+		t.Error("expected no archived allocations for another pool")
+	}
+}
+
+func TestArchive_CIDRIsReusable(t *testing.T) {
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-old"})
+
+	removed, err := db.RemoveLeafAllocation("prod", "id-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	db.Archive("prod", *removed, time.Now())
+
+	next, err := NewAllocator().FindNextAvailableInPool(poolDef, db.GetAllocationsForPool("prod"), 24)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next != "10.0.0.0/24" {
+		t.Errorf("expected the archived block to be reused, got %s", next)
+	}
+	if _, _, found := db.FindAllocationByName("vpc-old"); found {
+		t.Error("expected the archived name to be free for reuse")
+	}
+}
+
+func TestArchive_SurvivesWrites(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.Archive("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "first"}, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	content, err := db.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed, err := ParseAllocations(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A later write with an unrelated change keeps the archive
+	parsed.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-2", Name: "second"})
+	parsed.Archive("prod", Allocation{CIDR: "10.0.1.0/24", ID: "id-3", Name: "third"}, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	content, err = parsed.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reparsed, err := ParseAllocations(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	archived := reparsed.ArchivedAllocations("")
+	if len(archived) != 2 || archived[0].Name != "third" || archived[1].Name != "first" {
+		t.Fatalf("expected both archived entries, newest first, got %+v", archived)
+	}
+	if archived[1].PoolID != "prod" || archived[1].ID != "id-1" {
+		t.Errorf("expected pool and ID to round-trip, got %+v", archived[1])
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/apparentlymart/go-cidr/cidr"
+)
+
+// AddressBounds holds the first and last addresses of a block, and the
+// first and last addresses usable by hosts.
+type AddressBounds struct {
+	First       string
+	Last        string
+	UsableFirst string
+	UsableLast  string
+}
+
+// BlockBounds returns the address bounds of a CIDR block. IPv4 blocks
+// larger than /31 reserve the network and broadcast addresses; /31
+// point-to-point links (RFC 3021), /32 hosts and IPv6 blocks are usable
+// end to end.
+func BlockBounds(cidrStr string) (AddressBounds, error) {
+	_, network, err := net.ParseCIDR(cidrStr)
+	if err != nil {
+		return AddressBounds{}, fmt.Errorf("invalid CIDR %s: %w", cidrStr, err)
+	}
+
+	first, last := cidr.AddressRange(network)
+	usableFirst, usableLast := first, last
+
+	ones, bits := network.Mask.Size()
+	if bits == 32 && ones < 31 {
+		usableFirst = cidr.Inc(first)
+		usableLast = cidr.Dec(last)
+	}
+
+	return AddressBounds{
+		First:       first.String(),
+		Last:        last.String(),
+		UsableFirst: usableFirst.String(),
+		UsableLast:  usableLast.String(),
+	}, nil
+}
+
+// AddressCount returns the number of addresses in a CIDR block. It reports
+// false for invalid blocks and for IPv6 blocks too large for an int64.
+func AddressCount(cidrStr string) (int64, bool) {
+	_, network, err := net.ParseCIDR(cidrStr)
+	if err != nil {
+		return 0, false
+	}
+	ones, bits := network.Mask.Size()
+	if bits-ones > 62 {
+		return 0, false
+	}
+	return int64(1) << uint(bits-ones), true
+}
+
+// ChildSpace returns how many addresses of a block its children leave free,
+// as CalculateAvailableSpace counts them, and the percentage the children
+// use. A block without children is entirely free. Like AddressCount, it
+// reports false for invalid blocks and IPv6 blocks too large for an int64.
+func ChildSpace(cidrStr string, children []Allocation) (available int64, utilization float64, ok bool) {
+	total, ok := AddressCount(cidrStr)
+	if !ok {
+		return 0, 0, false
+	}
+	free, err := NewAllocator().CalculateAvailableSpace(cidrStr, children)
+	if err != nil {
+		return 0, 0, false
+	}
+	return int64(free), float64(total-int64(free)) / float64(total) * 100, true
+}
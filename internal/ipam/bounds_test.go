@@ -0,0 +1,93 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import "testing"
+
+func TestBlockBounds(t *testing.T) {
+	tests := []struct {
+		cidr string
+		want AddressBounds
+	}{
+		{"10.0.0.0/24", AddressBounds{"10.0.0.0", "10.0.0.255", "10.0.0.1", "10.0.0.254"}},
+		{"10.0.0.4/31", AddressBounds{"10.0.0.4", "10.0.0.5", "10.0.0.4", "10.0.0.5"}},
+		{"10.0.0.7/32", AddressBounds{"10.0.0.7", "10.0.0.7", "10.0.0.7", "10.0.0.7"}},
+		{"fd00::/126", AddressBounds{"fd00::", "fd00::3", "fd00::", "fd00::3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			got, err := BlockBounds(tt.cidr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("BlockBounds(%s) = %+v, want %+v", tt.cidr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockBounds_Invalid(t *testing.T) {
+	if _, err := BlockBounds("not-a-cidr"); err == nil {
+		t.Error("expected error for invalid CIDR")
+	}
+}
+
+func TestAddressCount(t *testing.T) {
+	tests := []struct {
+		cidr     string
+		expected int64
+		ok       bool
+	}{
+		{"10.0.0.0/16", 65536, true},
+		{"10.0.0.0/20", 4096, true},
+		{"10.0.0.0/24", 256, true},
+		{"10.0.0.4/31", 2, true},
+		{"10.0.0.7/32", 1, true},
+		{"fd00::/120", 256, true},
+		{"fd00::/64", 0, false}, // Too large for an int64
+		{"invalid", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := AddressCount(tt.cidr)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("AddressCount(%s) = %d, %v; want %d, %v", tt.cidr, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestChildSpace_VPCWithSubnets(t *testing.T) {
+	vpc := "10.0.0.0/16"
+	children := []Allocation{
+		{CIDR: "10.0.0.0/24", ID: "subnet-a", Name: "subnet-a", ParentCIDR: &vpc},
+		{CIDR: "10.0.1.0/24", ID: "subnet-b", Name: "subnet-b", ParentCIDR: &vpc},
+	}
+
+	available, utilization, ok := ChildSpace(vpc, children)
+	if !ok {
+		t.Fatal("expected ok for a /16")
+	}
+	if available != 65536-512 {
+		t.Errorf("expected %d addresses available, got %d", 65536-512, available)
+	}
+	if want := 512.0 / 65536 * 100; utilization != want {
+		t.Errorf("expected %.4f%% utilization, got %.4f%%", want, utilization)
+	}
+}
+
+func TestChildSpace_Leaf(t *testing.T) {
+	available, utilization, ok := ChildSpace("10.0.2.0/24", nil)
+	if !ok || available != 256 || utilization != 0 {
+		t.Errorf("ChildSpace(leaf) = %d, %v, %v; want 256, 0, true", available, utilization, ok)
+	}
+}
+
+func TestChildSpace_Unrepresentable(t *testing.T) {
+	for _, cidr := range []string{"fd00::/64", "invalid"} {
+		if _, _, ok := ChildSpace(cidr, nil); ok {
+			t.Errorf("ChildSpace(%s): expected not ok", cidr)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ChangelogPath is where the generated IPAM changelog is written.
+const ChangelogPath = ".github/ipam/changelog.md"
+
+// changelogHeading opens the changelog; entries follow, newest first.
+const changelogHeading = "# IPAM Changelog\n"
+
+// Change actions recorded in the changelog.
+const (
+	ChangeAllocated = "allocated"
+	ChangeReleased  = "released"
+	ChangeUpdated   = "updated"
+)
+
+// ChangeRecord is one change an apply made to the allocations file.
+type ChangeRecord struct {
+	Action string
+	CIDR   string
+	PoolID string
+	Name   string
+}
+
+// DiffAllocations returns the changes that turn before into after, matched
+// by allocation ID: allocations only in after are allocated, those only in
+// before are released, and those whose CIDR, name or status changed are
+// updated. Records are sorted by pool, then CIDR.
+func DiffAllocations(before, after *AllocationsDatabase) []ChangeRecord {
+	type entry struct {
+		poolID string
+		alloc  Allocation
+	}
+	index := func(db *AllocationsDatabase) map[string]entry {
+		entries := make(map[string]entry)
+		if db == nil {
+			return entries
+		}
+		for poolID, allocs := range db.Allocations {
+			for _, alloc := range allocs {
+				entries[alloc.ID] = entry{poolID: poolID, alloc: alloc}
+			}
+		}
+		return entries
+	}
+	old, cur := index(before), index(after)
+
+	var changes []ChangeRecord
+	for id, e := range cur {
+		prev, ok := old[id]
+		switch {
+		case !ok:
+			changes = append(changes, ChangeRecord{Action: ChangeAllocated, CIDR: e.alloc.CIDR, PoolID: e.poolID, Name: e.alloc.Name})
+		case prev.alloc.CIDR != e.alloc.CIDR || prev.alloc.Name != e.alloc.Name || prev.alloc.GetStatus() != e.alloc.GetStatus():
+			changes = append(changes, ChangeRecord{Action: ChangeUpdated, CIDR: e.alloc.CIDR, PoolID: e.poolID, Name: e.alloc.Name})
+		}
+	}
+	for id, e := range old {
+		if _, ok := cur[id]; !ok {
+			changes = append(changes, ChangeRecord{Action: ChangeReleased, CIDR: e.alloc.CIDR, PoolID: e.poolID, Name: e.alloc.Name})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].PoolID != changes[j].PoolID {
+			return changes[i].PoolID < changes[j].PoolID
+		}
+		if changes[i].CIDR != changes[j].CIDR {
+			return changes[i].CIDR < changes[j].CIDR
+		}
+		return changes[i].Action < changes[j].Action
+	})
+	return changes
+}
+
+// FormatChangelogEntry renders one changelog entry for the changes an apply
+// made, headed by its time and, when known, the run that made them.
+func FormatChangelogEntry(at time.Time, runID string, changes []ChangeRecord) string {
+	var b strings.Builder
+	b.WriteString("## " + at.UTC().Format(time.RFC3339))
+	if runID != "" {
+		b.WriteString(" (run " + runID + ")")
+	}
+	b.WriteString("\n\n")
+	for _, change := range changes {
+		fmt.Fprintf(&b, "- %s %s in pool `%s`", change.Action, change.CIDR, change.PoolID)
+		if change.Name != "" {
+			fmt.Fprintf(&b, ": %s", change.Name)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// PrependChangelog adds an entry to the top of an existing changelog,
+// starting a new one when existing is empty.
+func PrependChangelog(existing, entry string) string {
+	body := strings.TrimPrefix(existing, changelogHeading)
+	body = strings.TrimLeft(body, "\n")
+	if body == "" {
+		return changelogHeading + "\n" + entry
+	}
+	return changelogHeading + "\n" + entry + "\n" + body
+}
@@ -0,0 +1,63 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffAllocations(t *testing.T) {
+	before := NewAllocationsDatabase()
+	before.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-a"})
+	before.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "id-2", Name: "vpc-b"})
+
+	after := NewAllocationsDatabase()
+	after.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-a"})
+	after.AddAllocation("prod", Allocation{CIDR: "10.0.2.0/24", ID: "id-3", Name: "vpc-c"})
+	after.AddAllocation("dev", Allocation{CIDR: "10.1.0.0/24", ID: "id-4", Name: "vpc-d"})
+
+	want := []ChangeRecord{
+		{Action: ChangeAllocated, CIDR: "10.1.0.0/24", PoolID: "dev", Name: "vpc-d"},
+		{Action: ChangeReleased, CIDR: "10.0.1.0/24", PoolID: "prod", Name: "vpc-b"},
+		{Action: ChangeAllocated, CIDR: "10.0.2.0/24", PoolID: "prod", Name: "vpc-c"},
+	}
+	if got := DiffAllocations(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDiffAllocations_Updated(t *testing.T) {
+	before := NewAllocationsDatabase()
+	before.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-a"})
+	after := NewAllocationsDatabase()
+	after.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-renamed"})
+
+	got := DiffAllocations(before, after)
+	if len(got) != 1 || got[0].Action != ChangeUpdated || got[0].Name != "vpc-renamed" {
+		t.Errorf("expected one update, got %v", got)
+	}
+	if changes := DiffAllocations(after, after); len(changes) != 0 {
+		t.Errorf("expected no changes for an unchanged database, got %v", changes)
+	}
+}
+
+func TestPrependChangelog(t *testing.T) {
+	at := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	first := FormatChangelogEntry(at, "", []ChangeRecord{{Action: ChangeAllocated, CIDR: "10.0.0.0/24", PoolID: "prod", Name: "vpc"}})
+	second := FormatChangelogEntry(at.Add(time.Hour), "run-123", []ChangeRecord{{Action: ChangeReleased, CIDR: "10.0.0.0/24", PoolID: "prod"}})
+
+	log := PrependChangelog(PrependChangelog("", first), second)
+	if !strings.HasPrefix(log, "# IPAM Changelog\n\n## 2024-01-15T11:30:00Z (run run-123)\n") {
+		t.Errorf("expected the newest entry first, got:\n%s", log)
+	}
+	if !strings.Contains(log, "- allocated 10.0.0.0/24 in pool `prod`: vpc\n") {
+		t.Errorf("expected the first entry to be kept, got:\n%s", log)
+	}
+	if strings.Count(log, "# IPAM Changelog") != 1 {
+		t.Errorf("expected a single heading, got:\n%s", log)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import "sort"
+
+// ChargebackUnassigned is the group for allocations with no cost center, or
+// no value for the metadata key being grouped by.
+const ChargebackUnassigned = "unassigned"
+
+// ChargebackGroup is the address space charged to one cost center.
+type ChargebackGroup struct {
+	Key         string
+	Allocations int
+	Addresses   uint64
+	Percent     float64 // 0-100, share of all charged addresses
+}
+
+// Chargeback totals the address space held by top-level allocations per
+// cost center, or per value of metadataKey when it is set. Sub-allocations
+// use space their parent is already charged for, and claims are not yet
+// anyone's, so neither counts. Groups are ordered by addresses, largest
+// first, then by key.
+func Chargeback(allocations []Allocation, metadataKey string) []ChargebackGroup {
+	byKey := make(map[string]*ChargebackGroup)
+	var total uint64
+	for i := range allocations {
+		alloc := &allocations[i]
+		if alloc.ParentCIDR != nil || alloc.IsClaim() {
+			continue
+		}
+
+		key := alloc.CostCenter
+		if metadataKey != "" {
+			key = alloc.Metadata[metadataKey]
+		}
+		if key == "" {
+			key = ChargebackUnassigned
+		}
+
+		group, ok := byKey[key]
+		if !ok {
+			group = &ChargebackGroup{Key: key}
+			byKey[key] = group
+		}
+		addrs := cidrToAddresses(alloc.CIDR)
+		group.Allocations++
+		group.Addresses += addrs
+		total += addrs
+	}
+
+	groups := make([]ChargebackGroup, 0, len(byKey))
+	for _, group := range byKey {
+		if total > 0 {
+			group.Percent = float64(group.Addresses) / float64(total) * 100
+		}
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Addresses != groups[j].Addresses {
+			return groups[i].Addresses > groups[j].Addresses
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
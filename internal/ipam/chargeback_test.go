@@ -0,0 +1,53 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChargeback_TwoCostCenters(t *testing.T) {
+	parent := "10.0.0.0/16"
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/16", ID: "id-1", Name: "vpc-a", CostCenter: "cc-100"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-2", Name: "subnet-a", ParentCIDR: &parent, CostCenter: "cc-200"})
+	db.AddAllocation("dev", Allocation{CIDR: "10.1.0.0/17", ID: "id-3", Name: "vpc-b", CostCenter: "cc-200"})
+	db.AddAllocation("dev", Allocation{CIDR: "10.1.128.0/17", ID: "id-4", Name: "vpc-c", CostCenter: "cc-200"})
+	db.AddAllocation("dev", Allocation{CIDR: "10.2.0.0/24", ID: "id-5", Name: "claim", ExpiresAt: "2099-01-01T00:00:00Z"})
+
+	got := Chargeback(db.AllAllocations(), "")
+	want := []ChargebackGroup{
+		{Key: "cc-100", Allocations: 1, Addresses: 65536, Percent: 50},
+		{Key: "cc-200", Allocations: 2, Addresses: 65536, Percent: 50},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestChargeback_ByMetadataKey(t *testing.T) {
+	allocations := []Allocation{
+		{CIDR: "10.0.0.0/24", Metadata: map[string]string{"team": "payments"}},
+		{CIDR: "10.0.1.0/24", Metadata: map[string]string{"team": "payments"}},
+		{CIDR: "10.0.2.0/23", Metadata: map[string]string{"team": "search"}},
+		{CIDR: "10.0.4.0/24", CostCenter: "cc-100"},
+	}
+
+	got := Chargeback(allocations, "team")
+	want := []ChargebackGroup{
+		{Key: "payments", Allocations: 2, Addresses: 512, Percent: 40},
+		{Key: "search", Allocations: 1, Addresses: 512, Percent: 40},
+		{Key: ChargebackUnassigned, Allocations: 1, Addresses: 256, Percent: 20},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestChargeback_Empty(t *testing.T) {
+	if got := Chargeback(nil, ""); len(got) != 0 {
+		t.Errorf("expected no groups, got %+v", got)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Claims are reservations with an expiry, written by the next_available
+// data source so a previewed block is held until an allocation adopts it.
+// Once a claim lapses it is dropped on read and its block is free again.
+
+// IsClaim reports whether the entry is a claim rather than a permanent entry.
+func (a *Allocation) IsClaim() bool {
+	return a.ExpiresAt != ""
+}
+
+// Expired reports whether the entry is a claim whose hold has lapsed.
+// Unparseable expiries are kept rather than silently freeing the block.
+func (a *Allocation) Expired(now time.Time) bool {
+	if !a.IsClaim() {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, a.ExpiresAt)
+	return err == nil && !now.Before(expiresAt)
+}
+
+// dropExpired removes lapsed claims, so their blocks are free to allocators
+// and the next write removes them from the file.
+func (d *AllocationsDatabase) dropExpired(now time.Time) {
+	for poolID, allocations := range d.Allocations {
+		kept := allocations[:0]
+		for _, alloc := range allocations {
+			if !alloc.Expired(now) {
+				kept = append(kept, alloc)
+			}
+		}
+		d.Allocations[poolID] = kept
+	}
+}
+
+// Claim holds the next available block of the requested size in a pool
+// until expiresAt. The data source claiming it re-reads on every plan, so
+// a live claim with the same name and size is renewed in place; one of a
+// different size is released and claimed again.
+func (d *AllocationsDatabase) Claim(req AllocationRequest, poolDef *PoolDefinition, id string, expiresAt time.Time) (*Allocation, error) {
+	if existing, existingPoolID, found := d.FindAllocationByName(req.Name); found {
+		if !existing.IsClaim() {
+			return nil, fmt.Errorf("name %q is already used by allocation %s", req.Name, existing.CIDR)
+		}
+		if existingPoolID != req.PoolID {
+			return nil, fmt.Errorf("name %q is already used by a claim in pool %q", req.Name, existingPoolID)
+		}
+		if _, network, err := net.ParseCIDR(existing.CIDR); err == nil {
+			if ones, _ := network.Mask.Size(); ones == req.PrefixLen {
+				existing.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+				claim := *existing
+				return &claim, nil
+			}
+		}
+		if err := d.RemoveAllocation(existingPoolID, existing.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := CheckPoolAllocatable(req.PoolID, poolDef); err != nil {
+		return nil, err
+	}
+	cidr, err := NewAllocator().FindNextAvailableInPoolWithOptions(
+		poolDef,
+		d.GetAllocationsForPool(req.PoolID),
+		req.PrefixLen,
+		d.AllocateOptionsForPool(req.PoolID, poolDef),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	claim := Allocation{
+		CIDR:      cidr,
+		ID:        id,
+		Name:      req.Name,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+	}
+	claim.SetStatus(StatusReservation)
+	d.AddAllocation(req.PoolID, claim)
+	return &claim, nil
+}
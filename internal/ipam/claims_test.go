@@ -0,0 +1,119 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAllocationsDatabase_Claim(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "vpc-1", Name: "vpc-1"})
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+	expiresAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	claim, err := db.Claim(AllocationRequest{Name: "next-vpc", PoolID: "prod", PrefixLen: 24}, poolDef, "claim-1", expiresAt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claim.CIDR != "10.0.1.0/24" {
+		t.Errorf("expected 10.0.1.0/24, got %s", claim.CIDR)
+	}
+	if claim.ExpiresAt != "2026-01-02T03:04:05Z" {
+		t.Errorf("expected expiry 2026-01-02T03:04:05Z, got %s", claim.ExpiresAt)
+	}
+	if claim.GetStatus() != StatusReservation {
+		t.Errorf("expected reservation status, got %s", claim.GetStatus())
+	}
+	if _, _, found := db.FindAllocationByID("claim-1"); !found {
+		t.Error("expected claim to be recorded")
+	}
+}
+
+func TestAllocationsDatabase_Claim_Renews(t *testing.T) {
+	db := NewAllocationsDatabase()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+	req := AllocationRequest{Name: "next-vpc", PoolID: "prod", PrefixLen: 24}
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := db.Claim(req, poolDef, "claim-1", first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The same claim is extended rather than duplicated
+	renewed, err := db.Claim(req, poolDef, "claim-2", first.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renewed.ID != "claim-1" || renewed.CIDR != "10.0.0.0/24" || renewed.ExpiresAt != "2026-01-01T01:00:00Z" {
+		t.Errorf("expected claim-1 renewed until 01:00, got %+v", renewed)
+	}
+	if n := len(db.GetAllocationsForPool("prod")); n != 1 {
+		t.Errorf("expected 1 entry, got %d", n)
+	}
+
+	// A different size releases the old block and claims again
+	req.PrefixLen = 20
+	resized, err := db.Claim(req, poolDef, "claim-3", first)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resized.ID != "claim-3" || resized.CIDR != "10.0.0.0/20" {
+		t.Errorf("expected claim-3 for 10.0.0.0/20, got %+v", resized)
+	}
+	if n := len(db.GetAllocationsForPool("prod")); n != 1 {
+		t.Errorf("expected 1 entry after resize, got %d", n)
+	}
+}
+
+func TestAllocationsDatabase_Claim_NameInUse(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "vpc-1", Name: "vpc-1"})
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+
+	_, err := db.Claim(AllocationRequest{Name: "vpc-1", PoolID: "prod", PrefixLen: 24}, poolDef, "claim-1", time.Now())
+	if err == nil || !strings.Contains(err.Error(), "already used by allocation 10.0.0.0/24") {
+		t.Errorf("expected name in use error, got %v", err)
+	}
+}
+
+func TestAllocationsDatabase_Claim_ReservedPool(t *testing.T) {
+	db := NewAllocationsDatabase()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}, Reserved: true}
+
+	if _, err := db.Claim(AllocationRequest{Name: "next", PoolID: "prod", PrefixLen: 24}, poolDef, "claim-1", time.Now()); err == nil {
+		t.Error("expected error claiming from a reserved pool")
+	}
+}
+
+func TestParseAllocations_DropsExpiredClaims(t *testing.T) {
+	content := `version: "1.1"
+allocations:
+  prod:
+    - cidr: 10.0.0.0/24
+      id: vpc-1
+    - cidr: 10.0.1.0/24
+      id: lapsed
+      status: reservation
+      expires_at: "2000-01-01T00:00:00Z"
+    - cidr: 10.0.2.0/24
+      id: live
+      status: reservation
+      expires_at: "2999-01-01T00:00:00Z"
+`
+	db, err := ParseAllocations([]byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, found := db.FindAllocationByID("lapsed"); found {
+		t.Error("expected lapsed claim to be dropped")
+	}
+	for _, id := range []string{"vpc-1", "live"} {
+		if _, _, found := db.FindAllocationByID(id); !found {
+			t.Errorf("expected %s to be kept", id)
+		}
+	}
+}
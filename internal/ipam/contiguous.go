@@ -0,0 +1,181 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"net"
+)
+
+// Directions a contiguous block may grow in relative to its target.
+const (
+	ContiguousBefore = "before"
+	ContiguousAfter  = "after"
+	ContiguousEither = "either"
+)
+
+// ContiguousDirections lists the valid contiguous directions.
+var ContiguousDirections = []string{ContiguousBefore, ContiguousAfter, ContiguousEither}
+
+// CheckContiguousTarget verifies that targetCIDR is an existing allocation
+// the new block can sit beside: a top-level allocation of poolID, or with
+// parentCIDR set, a sub-allocation of that parent. Adjacency to anything
+// else, such as a mistyped CIDR or a block of another pool, would be
+// meaningless.
+func (d *AllocationsDatabase) CheckContiguousTarget(targetCIDR, poolID, parentCIDR string) error {
+	target, targetPoolID, found := d.FindAllocationByCIDR(targetCIDR)
+	if !found {
+		return fmt.Errorf("contiguous_with %q is not an existing allocation", targetCIDR)
+	}
+	if parentCIDR != "" {
+		if target.ParentCIDR == nil || *target.ParentCIDR != parentCIDR {
+			return fmt.Errorf("contiguous_with %q is not a sub-allocation of %s", targetCIDR, parentCIDR)
+		}
+		return nil
+	}
+	if targetPoolID != poolID {
+		return fmt.Errorf("contiguous_with %q belongs to pool %q, not %q", targetCIDR, targetPoolID, poolID)
+	}
+	if target.ParentCIDR != nil {
+		return fmt.Errorf("contiguous_with %q is a sub-allocation of %s; use parent_cidr = %q to allocate beside it", targetCIDR, *target.ParentCIDR, *target.ParentCIDR)
+	}
+	return nil
+}
+
+// FindContiguousInPool finds a block immediately adjacent to targetCIDR
+// within the pool's CIDRs (Mode 1). With ContiguousEither (or an empty
+// direction) the block before the target is preferred and the block after
+// is tried next; ContiguousBefore and ContiguousAfter try only that side.
+// Small blocks stay inside the pool's small_block_region and larger ones
+// outside it, as they do for FindNextAvailableInPool.
+func (a *Allocator) FindContiguousInPool(poolDef *PoolDefinition, existingAllocations []Allocation, prefixLen int, targetCIDR, direction string) (string, error) {
+	if err := poolDef.CheckGranularity(prefixLen); err != nil {
+		return "", err
+	}
+	occupied := append(filterTopLevelAllocations(existingAllocations), poolDef.occupiedBlocks(prefixLen)...)
+	boundaryName, boundaries := "pool", poolDef.CIDR
+	if poolDef.isSmallBlock(prefixLen) {
+		boundaryName, boundaries = "small_block_region", []string{poolDef.SmallBlockRegion}
+	}
+	return findContiguous(boundaryName, boundaries, occupied, prefixLen, poolDef.alignmentFor(prefixLen), targetCIDR, direction)
+}
+
+// FindContiguousInParent finds a block immediately adjacent to targetCIDR
+// within an existing allocation (Mode 2), using the parent as the boundary.
+// direction is as for FindContiguousInPool.
+func (a *Allocator) FindContiguousInParent(parentCIDR string, childAllocations []Allocation, prefixLen int, targetCIDR, direction string) (string, error) {
+	return findContiguous("parent", []string{parentCIDR}, childAllocations, prefixLen, prefixLen, targetCIDR, direction)
+}
+
+// findContiguous finds a block adjacent to targetCIDR that lies within one
+// of the boundary CIDRs and overlaps none of the occupied allocations.
+// boundaryName describes the boundaries in error messages. Blocks start on a
+// /alignLen boundary, which is prefixLen unless a pool granularity applies.
+// direction limits the search to one side of the target.
+func findContiguous(boundaryName string, boundaries []string, occupied []Allocation, prefixLen, alignLen int, targetCIDR, direction string) (string, error) {
+	_, targetNet, err := net.ParseCIDR(targetCIDR)
+	if err != nil {
+		return "", fmt.Errorf("invalid target CIDR %q: %w", targetCIDR, err)
+	}
+
+	// Calculate target range
+	targetStart := ipToUint32(targetNet.IP)
+	targetOnes, targetBits := targetNet.Mask.Size()
+	targetSize := uint32(1) << (targetBits - targetOnes)
+	targetEnd := targetStart + targetSize
+
+	// Desired block size and start alignment
+	blockSize := uint32(1) << (32 - prefixLen)
+	alignSize := uint32(1) << (32 - alignLen)
+
+	var beforeReason, afterReason string
+
+	// Check space immediately before target
+	if direction == ContiguousAfter {
+		beforeReason = "contiguous_direction is after"
+	} else if targetStart >= blockSize {
+		beforeStart := targetStart - blockSize
+		if beforeStart%alignSize == 0 {
+			beforeCIDR := fmt.Sprintf("%s/%d", uint32ToIP(beforeStart), prefixLen)
+			if !withinAny(boundaries, beforeCIDR) {
+				beforeReason = fmt.Sprintf("before block %s is outside %s boundaries", beforeCIDR, boundaryName)
+			} else if overlapsAny(beforeCIDR, occupied) {
+				beforeReason = fmt.Sprintf("before block %s overlaps with existing allocation", beforeCIDR)
+			} else {
+				return beforeCIDR, nil
+			}
+		} else {
+			beforeReason = fmt.Sprintf("no valid /%d boundary before target (alignment requires address divisible by %d)", prefixLen, alignSize)
+		}
+	} else {
+		beforeReason = "target is too close to start of address space for a block before it"
+	}
+
+	// Check space immediately after target
+	afterStart := targetEnd
+	if direction == ContiguousBefore {
+		afterReason = "contiguous_direction is before"
+	} else if afterStart%alignSize == 0 {
+		afterCIDR := fmt.Sprintf("%s/%d", uint32ToIP(afterStart), prefixLen)
+		if !withinAny(boundaries, afterCIDR) {
+			afterReason = fmt.Sprintf("after block %s is outside %s boundaries", afterCIDR, boundaryName)
+		} else if overlapsAny(afterCIDR, occupied) {
+			afterReason = fmt.Sprintf("after block %s overlaps with existing allocation", afterCIDR)
+		} else {
+			return afterCIDR, nil
+		}
+	} else {
+		afterReason = fmt.Sprintf("no valid /%d boundary after target (target end %s not aligned to block size %d)",
+			prefixLen, uint32ToIP(afterStart), alignSize)
+	}
+
+	return "", fmt.Errorf("no contiguous /%d space available adjacent to %s: before: %s; after: %s",
+		prefixLen, targetCIDR, beforeReason, afterReason)
+}
+
+// withinAny reports whether cidr is fully contained in one of the boundaries.
+func withinAny(boundaries []string, cidr string) bool {
+	_, candidateNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+
+	candidateStart := ipToUint32(candidateNet.IP)
+	candidateOnes, candidateBits := candidateNet.Mask.Size()
+	candidateEnd := candidateStart + uint32(1)<<(candidateBits-candidateOnes) - 1
+
+	for _, boundary := range boundaries {
+		_, boundaryNet, err := net.ParseCIDR(boundary)
+		if err != nil {
+			continue
+		}
+		boundaryStart := ipToUint32(boundaryNet.IP)
+		boundaryOnes, boundaryBits := boundaryNet.Mask.Size()
+		boundaryEnd := boundaryStart + uint32(1)<<(boundaryBits-boundaryOnes) - 1
+
+		if candidateStart >= boundaryStart && candidateEnd <= boundaryEnd {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapsAny reports whether cidr overlaps any of the allocations.
+func overlapsAny(cidr string, allocations []Allocation) bool {
+	_, candidateNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return true // Treat errors as overlap to be safe
+	}
+
+	for _, alloc := range allocations {
+		_, allocNet, err := net.ParseCIDR(alloc.CIDR)
+		if err != nil {
+			continue
+		}
+		if networksOverlap(candidateNet, allocNet) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindContiguousInParent_AfterSibling(t *testing.T) {
+	allocator := NewAllocator()
+
+	// 10.0.0.0/26 is taken, so the block before is outside the parent and
+	// the /26 right after it is chosen
+	children := []Allocation{
+		{CIDR: "10.0.0.0/26", ID: "web", ParentCIDR: strPtr("10.0.0.0/24")},
+	}
+
+	cidr, err := allocator.FindContiguousInParent("10.0.0.0/24", children, 26, "10.0.0.0/26", ContiguousEither)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.0.64/26" {
+		t.Errorf("expected 10.0.0.64/26, got %s", cidr)
+	}
+}
+
+func TestFindContiguousInParent_BeforeSibling(t *testing.T) {
+	allocator := NewAllocator()
+
+	children := []Allocation{
+		{CIDR: "10.0.0.128/26", ID: "app", ParentCIDR: strPtr("10.0.0.0/24")},
+	}
+
+	cidr, err := allocator.FindContiguousInParent("10.0.0.0/24", children, 26, "10.0.0.128/26", ContiguousEither)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.0.64/26" {
+		t.Errorf("expected 10.0.0.64/26, got %s", cidr)
+	}
+}
+
+func TestFindContiguousInParent_Impossible(t *testing.T) {
+	allocator := NewAllocator()
+
+	// The last /26 of the parent, with its only neighbour inside taken
+	children := []Allocation{
+		{CIDR: "10.0.0.128/26", ID: "app", ParentCIDR: strPtr("10.0.0.0/24")},
+		{CIDR: "10.0.0.192/26", ID: "db", ParentCIDR: strPtr("10.0.0.0/24")},
+	}
+
+	_, err := allocator.FindContiguousInParent("10.0.0.0/24", children, 26, "10.0.0.192/26", ContiguousEither)
+	if err == nil {
+		t.Fatal("expected error when no adjacent block is free")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "before block 10.0.0.128/26 overlaps with existing allocation") {
+		t.Errorf("missing before reason: %s", msg)
+	}
+	if !strings.Contains(msg, "after block 10.0.1.0/26 is outside parent boundaries") {
+		t.Errorf("missing after reason: %s", msg)
+	}
+}
+
+func TestFindContiguousInPool_IgnoresSubAllocations(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/24", ID: "vpc"},
+		{CIDR: "10.0.1.0/24", ID: "stray", ParentCIDR: strPtr("10.0.0.0/16")},
+	}
+
+	cidr, err := allocator.FindContiguousInPool(poolDef, existing, 24, "10.0.0.0/24", ContiguousEither)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.1.0/24" {
+		t.Errorf("expected 10.0.1.0/24, got %s", cidr)
+	}
+}
+
+func TestFindContiguousInPool_SmallBlockRegion(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{
+		CIDR:             []string{"10.0.0.0/16"},
+		SmallBlockRegion: "10.0.240.0/20",
+	}
+	existing := []Allocation{{CIDR: "10.0.224.0/20", ID: "vpc"}}
+
+	// The /28 before the /20 is free but outside the region
+	cidr, err := allocator.FindContiguousInPool(poolDef, existing, 28, "10.0.224.0/20", ContiguousEither)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.240.0/28" {
+		t.Errorf("expected 10.0.240.0/28 inside the region, got %s", cidr)
+	}
+	_, err = allocator.FindContiguousInPool(poolDef, existing, 28, "10.0.224.0/20", ContiguousBefore)
+	if err == nil || !strings.Contains(err.Error(), "outside small_block_region boundaries") {
+		t.Errorf("expected the block before to be outside the region, got %v", err)
+	}
+
+	// A /20 after the target would be the region itself
+	if cidr, err := allocator.FindContiguousInPool(poolDef, existing, 20, "10.0.224.0/20", ContiguousAfter); err == nil {
+		t.Errorf("expected a large block not to take the region, got %s", cidr)
+	}
+}
+
+func TestFindContiguous_AfterOnly(t *testing.T) {
+	allocator := NewAllocator()
+
+	// The /26 before the target is free, but after-only skips it
+	children := []Allocation{
+		{CIDR: "10.0.0.64/26", ID: "app", ParentCIDR: strPtr("10.0.0.0/24")},
+	}
+	cidr, err := allocator.FindContiguousInParent("10.0.0.0/24", children, 26, "10.0.0.64/26", ContiguousAfter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.0.128/26" {
+		t.Errorf("expected 10.0.0.128/26, got %s", cidr)
+	}
+
+	// The block before is taken, so after-only succeeds in the pool
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+	existing := []Allocation{{CIDR: "10.0.0.0/24"}, {CIDR: "10.0.1.0/24"}}
+	cidr, err = allocator.FindContiguousInPool(poolDef, existing, 24, "10.0.1.0/24", ContiguousAfter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cidr != "10.0.2.0/24" {
+		t.Errorf("expected 10.0.2.0/24, got %s", cidr)
+	}
+}
+
+func TestFindContiguous_BeforeOnly(t *testing.T) {
+	allocator := NewAllocator()
+
+	// Only the space after the target is free
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+	existing := []Allocation{{CIDR: "10.0.0.0/24"}, {CIDR: "10.0.1.0/24"}}
+	_, err := allocator.FindContiguousInPool(poolDef, existing, 24, "10.0.1.0/24", ContiguousBefore)
+	if err == nil {
+		t.Fatal("expected before-only to fail when only after-space is free")
+	}
+	if !strings.Contains(err.Error(), "contiguous_direction is before") {
+		t.Errorf("expected the direction in the error, got %v", err)
+	}
+
+	children := []Allocation{
+		{CIDR: "10.0.0.0/26", ID: "web", ParentCIDR: strPtr("10.0.0.0/24")},
+	}
+	if _, err := allocator.FindContiguousInParent("10.0.0.0/24", children, 26, "10.0.0.0/26", ContiguousBefore); err == nil {
+		t.Error("expected before-only to fail at the start of the parent")
+	}
+}
+
+func TestFindContiguous_EitherPrefersBefore(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+	existing := []Allocation{{CIDR: "10.0.1.0/24"}}
+
+	for _, direction := range []string{ContiguousEither, ""} {
+		cidr, err := allocator.FindContiguousInPool(poolDef, existing, 24, "10.0.1.0/24", direction)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", direction, err)
+		}
+		if cidr != "10.0.0.0/24" {
+			t.Errorf("%q: expected 10.0.0.0/24, got %s", direction, cidr)
+		}
+	}
+}
+
+func contiguousTargetDatabase() *AllocationsDatabase {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/16", ID: "vpc"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "web", ParentCIDR: strPtr("10.0.0.0/16")})
+	db.AddAllocation("dev", Allocation{CIDR: "10.1.0.0/16", ID: "dev-vpc"})
+	return db
+}
+
+func TestCheckContiguousTarget_SamePool(t *testing.T) {
+	db := contiguousTargetDatabase()
+	if err := db.CheckContiguousTarget("10.0.0.0/16", "prod", ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := db.CheckContiguousTarget("10.0.0.0/24", "prod", "10.0.0.0/16"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckContiguousTarget_OtherPool(t *testing.T) {
+	err := contiguousTargetDatabase().CheckContiguousTarget("10.1.0.0/16", "prod", "")
+	if err == nil || !strings.Contains(err.Error(), `belongs to pool "dev", not "prod"`) {
+		t.Errorf("expected other-pool error, got %v", err)
+	}
+}
+
+func TestCheckContiguousTarget_NotAllocated(t *testing.T) {
+	db := contiguousTargetDatabase()
+	err := db.CheckContiguousTarget("10.0.1.0/16", "prod", "")
+	if err == nil || !strings.Contains(err.Error(), "is not an existing allocation") {
+		t.Errorf("expected unknown-target error, got %v", err)
+	}
+
+	// A sub-allocation is not a pool-level target, and a pool allocation is
+	// not a sibling
+	if err := db.CheckContiguousTarget("10.0.0.0/24", "prod", ""); err == nil || !strings.Contains(err.Error(), `use parent_cidr = "10.0.0.0/16"`) {
+		t.Errorf("expected sub-allocation error, got %v", err)
+	}
+	if err := db.CheckContiguousTarget("10.1.0.0/16", "dev", "10.0.0.0/16"); err == nil || !strings.Contains(err.Error(), "is not a sub-allocation of 10.0.0.0/16") {
+		t.Errorf("expected sibling error, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"net"
+	"sort"
+)
+
+// DefragMove describes relocating a single allocation to a new CIDR.
+type DefragMove struct {
+	ID       string
+	Name     string
+	FromCIDR string
+	ToCIDR   string
+}
+
+// DefragPlan is an advisory re-layout of a pool's top-level allocations.
+type DefragPlan struct {
+	Moves              []DefragMove
+	LargestFreeBefore  uint64 // Largest contiguous free range today
+	LargestFreeAfter   uint64 // Largest contiguous free range after the moves
+	ReclaimedAddresses uint64 // LargestFreeAfter - LargestFreeBefore
+}
+
+// PlanDefrag computes a re-layout that packs a pool's top-level allocations
+// tightly from the pool start, largest blocks first so every block stays
+// aligned. Sub-allocations are not listed; they move with their parent.
+// Allocations in opts.Avoid stay where they are and are packed around.
+// The plan is advisory only. If packing would not grow the largest free
+// range, the plan has no moves.
+func (a *Allocator) PlanDefrag(poolDef *PoolDefinition, existingAllocations []Allocation, opts AllocateOptions) (*DefragPlan, error) {
+	topLevel := filterTopLevelAllocations(existingAllocations)
+
+	sortable := make([]sortableAllocation, 0, len(topLevel))
+	for i := range topLevel {
+		_, network, err := net.ParseCIDR(topLevel[i].CIDR)
+		if err != nil {
+			continue // Skip invalid entries
+		}
+		sortable = append(sortable, sortableAllocation{network: network, alloc: &topLevel[i]})
+	}
+
+	// Largest blocks first, then by current address for a stable plan
+	sort.SliceStable(sortable, func(i, j int) bool {
+		onesI, _ := sortable[i].network.Mask.Size()
+		onesJ, _ := sortable[j].network.Mask.Size()
+		if onesI != onesJ {
+			return onesI < onesJ
+		}
+		return compareIPs(sortable[i].network.IP, sortable[j].network.IP) < 0
+	})
+
+	var packed []Allocation
+	var moves []DefragMove
+	for _, s := range sortable {
+		ones, _ := s.network.Mask.Size()
+		target, err := a.FindNextAvailableInPoolWithOptions(poolDef, packed, ones, opts)
+		if err != nil {
+			return nil, fmt.Errorf("cannot re-place %s (%s): %w", s.alloc.CIDR, s.alloc.Name, err)
+		}
+		packed = append(packed, Allocation{CIDR: target, ID: s.alloc.ID, Name: s.alloc.Name})
+		if target != s.network.String() {
+			moves = append(moves, DefragMove{
+				ID:       s.alloc.ID,
+				Name:     s.alloc.Name,
+				FromCIDR: s.alloc.CIDR,
+				ToCIDR:   target,
+			})
+		}
+	}
+
+	avoided := filterTopLevelAllocations(opts.Avoid)
+	plan := &DefragPlan{
+		LargestFreeBefore: largestFreeInPool(poolDef, append(topLevel, avoided...)),
+		LargestFreeAfter:  largestFreeInPool(poolDef, append(packed, avoided...)),
+	}
+
+	if plan.LargestFreeAfter <= plan.LargestFreeBefore {
+		// Already as tight as packing can make it; moving blocks gains nothing
+		plan.LargestFreeAfter = plan.LargestFreeBefore
+		return plan, nil
+	}
+
+	plan.Moves = moves
+	plan.ReclaimedAddresses = plan.LargestFreeAfter - plan.LargestFreeBefore
+	return plan, nil
+}
+
+// largestFreeInPool returns the largest contiguous free range across all pool CIDRs.
+func largestFreeInPool(poolDef *PoolDefinition, allocations []Allocation) uint64 {
+	var largest uint64
+	for _, poolCIDR := range poolDef.CIDR {
+		_, poolNet, err := net.ParseCIDR(poolCIDR)
+		if err != nil {
+			continue
+		}
+		if free := largestFreeRange(poolNet, allocations); free > largest {
+			largest = free
+		}
+	}
+	return largest
+}
@@ -0,0 +1,113 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"testing"
+)
+
+func TestPlanDefrag_FragmentedPool(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/22"}}
+
+	// Two /24s with a hole between them: free space is split into two /24 ranges
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/24", ID: "id-a", Name: "a"},
+		{CIDR: "10.0.2.0/24", ID: "id-b", Name: "b"},
+	}
+
+	plan, err := allocator.PlanDefrag(poolDef, existing, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(plan.Moves) != 1 {
+		t.Fatalf("expected 1 move, got %d: %+v", len(plan.Moves), plan.Moves)
+	}
+	move := plan.Moves[0]
+	if move.ID != "id-b" || move.Name != "b" {
+		t.Errorf("expected move to preserve id-b/b, got %s/%s", move.ID, move.Name)
+	}
+	if move.FromCIDR != "10.0.2.0/24" || move.ToCIDR != "10.0.1.0/24" {
+		t.Errorf("expected 10.0.2.0/24 -> 10.0.1.0/24, got %s -> %s", move.FromCIDR, move.ToCIDR)
+	}
+
+	if plan.LargestFreeBefore != 256 {
+		t.Errorf("expected largest free before 256, got %d", plan.LargestFreeBefore)
+	}
+	if plan.LargestFreeAfter != 512 {
+		t.Errorf("expected largest free after 512, got %d", plan.LargestFreeAfter)
+	}
+	if plan.ReclaimedAddresses != 256 {
+		t.Errorf("expected 256 reclaimed addresses, got %d", plan.ReclaimedAddresses)
+	}
+}
+
+func TestPlanDefrag_PacksLargestFirst(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/24", ID: "small", Name: "small"},
+		{CIDR: "10.0.4.0/22", ID: "large", Name: "large"},
+	}
+
+	plan, err := allocator.PlanDefrag(poolDef, existing, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	targets := make(map[string]string)
+	for _, m := range plan.Moves {
+		targets[m.ID] = m.ToCIDR
+	}
+	if targets["large"] != "10.0.0.0/22" {
+		t.Errorf("expected large block packed to 10.0.0.0/22, got %q", targets["large"])
+	}
+	if targets["small"] != "10.0.4.0/24" {
+		t.Errorf("expected small block packed to 10.0.4.0/24, got %q", targets["small"])
+	}
+}
+
+func TestPlanDefrag_TightlyPackedPool(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/22"}}
+
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/24", ID: "id-a", Name: "a"},
+		{CIDR: "10.0.1.0/24", ID: "id-b", Name: "b"},
+		{CIDR: "10.0.1.0/26", ID: "id-c", Name: "child", ParentCIDR: strPtr("10.0.1.0/24")},
+	}
+
+	plan, err := allocator.PlanDefrag(poolDef, existing, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(plan.Moves) != 0 {
+		t.Errorf("expected empty plan for tightly packed pool, got %+v", plan.Moves)
+	}
+	if plan.ReclaimedAddresses != 0 {
+		t.Errorf("expected 0 reclaimed addresses, got %d", plan.ReclaimedAddresses)
+	}
+	if plan.LargestFreeBefore != 512 {
+		t.Errorf("expected largest free 512, got %d", plan.LargestFreeBefore)
+	}
+}
+
+func TestPlanDefrag_EmptyPool(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/24"}}
+
+	plan, err := allocator.PlanDefrag(poolDef, nil, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Moves) != 0 {
+		t.Errorf("expected no moves, got %+v", plan.Moves)
+	}
+	if plan.LargestFreeBefore != 256 {
+		t.Errorf("expected whole pool free (256), got %d", plan.LargestFreeBefore)
+	}
+}
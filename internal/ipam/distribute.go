@@ -0,0 +1,57 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"math/big"
+	"net"
+)
+
+// maxDistributeLevels caps how many halvings the distributed search spreads
+// over. Below that depth, slots are filled first-fit, so a /64 request in a
+// /32 does not walk billions of candidates.
+const maxDistributeLevels = 12
+
+// findDistributedInCIDR is findNextAlignedInCIDR for pools with distribute
+// set. The container is split into up to 2^maxDistributeLevels aligned
+// slots, tried in bit-reversed order (0, 1/2, 1/4, 3/4, ...), and the first
+// free block in the first slot with room wins. The order depends only on
+// the container, so the same allocations always produce the same result.
+func (a *Allocator) findDistributedInCIDR(containerCIDR string, existingAllocations []Allocation, prefixLen, alignLen int) (string, error) {
+	// The plain search validates the request and reports a full container
+	first, err := a.findNextAlignedInCIDR(containerCIDR, existingAllocations, prefixLen, alignLen)
+	if err != nil {
+		return "", err
+	}
+
+	_, containerNet, _ := net.ParseCIDR(containerCIDR)
+	ones, bits := containerNet.Mask.Size()
+	levels := alignLen - ones
+	if levels > maxDistributeLevels {
+		levels = maxDistributeLevels
+	}
+	if levels <= 0 {
+		return first, nil
+	}
+
+	start := networkRange(containerNet).start
+	slotPrefix := ones + levels
+	for i := 0; i < 1<<levels; i++ {
+		offset := new(big.Int).Lsh(big.NewInt(int64(reverseBits(i, levels))), uint(bits-slotPrefix))
+		slot := blockString(new(big.Int).Add(start, offset), slotPrefix, containerNet)
+		if cidr, err := a.findNextAlignedInCIDR(slot, existingAllocations, prefixLen, alignLen); err == nil {
+			return cidr, nil
+		}
+	}
+	return first, nil
+}
+
+// reverseBits reverses the low n bits of v.
+func reverseBits(v, n int) int {
+	out := 0
+	for i := 0; i < n; i++ {
+		out = out<<1 | v>>i&1
+	}
+	return out
+}
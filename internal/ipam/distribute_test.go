@@ -0,0 +1,82 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"net"
+	"testing"
+)
+
+func TestDistribute_SpreadsSequentialAllocations(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}, Distribute: true}
+
+	var existing []Allocation
+	for _, want := range []string{"10.0.0.0/24", "10.0.128.0/24", "10.0.64.0/24", "10.0.192.0/24"} {
+		got, err := allocator.FindNextAvailableInPool(poolDef, existing, 24)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+		existing = append(existing, Allocation{CIDR: got})
+	}
+}
+
+func TestDistribute_BatchMatchesSequential(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}, Distribute: true}
+
+	got, err := allocator.FindNextAvailableBatchInPool(poolDef, nil, 24, 3, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"10.0.0.0/24", "10.0.128.0/24", "10.0.64.0/24"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("block %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestDistribute_FillsWithoutOverlap(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/24"}, Distribute: true}
+
+	var existing []Allocation
+	for i := 0; i < 16; i++ {
+		got, err := allocator.FindNextAvailableInPool(poolDef, existing, 28)
+		if err != nil {
+			t.Fatalf("allocation %d: unexpected error: %v", i, err)
+		}
+		_, gotNet, _ := net.ParseCIDR(got)
+		for _, prior := range existing {
+			_, priorNet, _ := net.ParseCIDR(prior.CIDR)
+			if priorNet.Contains(gotNet.IP) || gotNet.Contains(priorNet.IP) {
+				t.Fatalf("allocation %d: %s overlaps %s", i, got, prior.CIDR)
+			}
+		}
+		existing = append(existing, Allocation{CIDR: got})
+	}
+
+	if got, err := allocator.FindNextAvailableInPool(poolDef, existing, 28); err == nil {
+		t.Errorf("expected a full pool to be refused, got %s", got)
+	}
+}
+
+func TestDistribute_SkipsOccupiedSlots(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}, Distribute: true}
+
+	// The upper half is taken, so the next slot in order is the lower quarter
+	existing := []Allocation{{CIDR: "10.0.0.0/24"}, {CIDR: "10.0.128.0/17"}}
+	got, err := allocator.FindNextAvailableInPool(poolDef, existing, 24)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.0.64.0/24" {
+		t.Errorf("expected 10.0.64.0/24, got %s", got)
+	}
+}
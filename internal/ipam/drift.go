@@ -0,0 +1,41 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import "sort"
+
+// UnknownAllocation is an allocation in the file whose ID the caller did
+// not list, with the pool it is recorded under.
+type UnknownAllocation struct {
+	PoolID string
+	Allocation
+}
+
+// UnknownAllocations returns the allocations in db whose IDs are not in
+// knownIDs, ordered by CIDR. Passing the IDs Terraform manages finds
+// allocations added outside it, for example by hand or another workspace.
+// The members of a contiguous_count stripe are known through their group,
+// the ID of the resource that allocated them.
+func UnknownAllocations(db *AllocationsDatabase, knownIDs []string) []UnknownAllocation {
+	known := make(map[string]bool, len(knownIDs))
+	for _, id := range knownIDs {
+		known[id] = true
+	}
+
+	var unknown []UnknownAllocation
+	for poolID, allocs := range db.Allocations {
+		for _, alloc := range allocs {
+			if !known[alloc.ID] && (alloc.Group == "" || !known[alloc.Group]) {
+				unknown = append(unknown, UnknownAllocation{PoolID: poolID, Allocation: alloc})
+			}
+		}
+	}
+	sort.SliceStable(unknown, func(i, j int) bool {
+		if unknown[i].CIDR == unknown[j].CIDR {
+			return unknown[i].ID < unknown[j].ID
+		}
+		return compareCIDRs(unknown[i].CIDR, unknown[j].CIDR)
+	})
+	return unknown
+}
@@ -0,0 +1,56 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import "testing"
+
+func TestUnknownAllocations_ExtraAllocation(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "web", Name: "web"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.2.0/24", ID: "hand-added", Name: "hand-added"})
+	db.AddAllocation("dev", Allocation{CIDR: "10.1.0.0/24", ID: "api", Name: "api"})
+
+	unknown := UnknownAllocations(db, []string{"web", "api"})
+	if len(unknown) != 1 {
+		t.Fatalf("expected 1 unknown allocation, got %d: %+v", len(unknown), unknown)
+	}
+	if unknown[0].ID != "hand-added" || unknown[0].PoolID != "prod" || unknown[0].CIDR != "10.0.2.0/24" {
+		t.Errorf("expected hand-added in prod, got %+v", unknown[0])
+	}
+}
+
+func TestUnknownAllocations_StripeKnownByGroup(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "az", Name: "az", Group: "az"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "az-2-id", Name: "az-2", Group: "az"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.2.0/24", ID: "az-3-id", Name: "az-3", Group: "az"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.3.0/24", ID: "other-2-id", Name: "other-2", Group: "other"})
+
+	// Only the resource's id is passed, as in the documented usage
+	unknown := UnknownAllocations(db, []string{"az"})
+	if len(unknown) != 1 || unknown[0].ID != "other-2-id" {
+		t.Errorf("expected only the member of the unknown stripe, got %+v", unknown)
+	}
+}
+
+func TestUnknownAllocations_OrderedByCIDR(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.10.0/24", ID: "b", Name: "b"})
+	db.AddAllocation("dev", Allocation{CIDR: "10.0.2.0/24", ID: "a", Name: "a"})
+
+	unknown := UnknownAllocations(db, nil)
+	if len(unknown) != 2 || unknown[0].ID != "a" || unknown[1].ID != "b" {
+		t.Errorf("expected a then b, got %+v", unknown)
+	}
+}
+
+func TestUnknownAllocations_AllKnown(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "web", Name: "web"})
+
+	// IDs no longer in the file are not drift in this direction
+	if unknown := UnknownAllocations(db, []string{"web", "deleted"}); len(unknown) != 0 {
+		t.Errorf("expected no unknown allocations, got %+v", unknown)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"sort"
+)
+
+// addressRange is an inclusive range of addresses, stored as integers so the
+// same arithmetic works for IPv4 and IPv6.
+type addressRange struct {
+	start *big.Int
+	end   *big.Int
+}
+
+// size returns the number of addresses in the range.
+func (r addressRange) size() *big.Int {
+	n := new(big.Int).Sub(r.end, r.start)
+	return n.Add(n, big.NewInt(1))
+}
+
+// networkRange returns the inclusive address range covered by a network.
+func networkRange(network *net.IPNet) addressRange {
+	ones, bits := network.Mask.Size()
+	start := new(big.Int).SetBytes(network.IP.To16())
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	end := new(big.Int).Add(start, size)
+	return addressRange{start: start, end: end.Sub(end, big.NewInt(1))}
+}
+
+// freeRanges returns the unallocated address ranges within the container,
+// in ascending address order. Allocations outside the container and
+// unparseable entries are ignored.
+func freeRanges(container *net.IPNet, allocations []Allocation) []addressRange {
+	var used []addressRange
+	for _, alloc := range filterAllocationsInCIDR(allocations, container) {
+		_, allocNet, err := net.ParseCIDR(alloc.CIDR)
+		if err != nil {
+			continue
+		}
+		used = append(used, networkRange(allocNet))
+	}
+	sort.Slice(used, func(i, j int) bool {
+		return used[i].start.Cmp(used[j].start) < 0
+	})
+
+	return freeWithin(networkRange(container), used)
+}
+
+// freeWithin returns the parts of bounds not covered by used, which must be
+// sorted by start address. Used ranges may overlap one another and extend
+// past bounds.
+func freeWithin(bounds addressRange, used []addressRange) []addressRange {
+	var free []addressRange
+	cursor := new(big.Int).Set(bounds.start)
+	for _, u := range used {
+		if u.start.Cmp(bounds.end) > 0 {
+			break
+		}
+		if u.start.Cmp(cursor) > 0 {
+			end := new(big.Int).Sub(u.start, big.NewInt(1))
+			free = append(free, addressRange{start: new(big.Int).Set(cursor), end: end})
+		}
+		next := new(big.Int).Add(u.end, big.NewInt(1))
+		if next.Cmp(cursor) > 0 {
+			cursor = next
+		}
+	}
+	if cursor.Cmp(bounds.end) <= 0 {
+		free = append(free, addressRange{start: cursor, end: new(big.Int).Set(bounds.end)})
+	}
+
+	return free
+}
+
+// largestFreeRange returns the size of the largest contiguous free range
+// within the container.
+func largestFreeRange(container *net.IPNet, allocations []Allocation) uint64 {
+	largest := big.NewInt(0)
+	for _, r := range freeRanges(container, allocations) {
+		if size := r.size(); size.Cmp(largest) > 0 {
+			largest = size
+		}
+	}
+	return bigToUint64(largest)
+}
+
+// largestAlignedBlock returns the largest CIDR block that fits within one
+// of the free ranges, the lowest one on ties. ok is false when nothing is
+// free.
+func largestAlignedBlock(container *net.IPNet, free []addressRange) (block string, ok bool) {
+	containerPrefixLen, bits := container.Mask.Size()
+	bestPrefixLen := bits + 1
+	for _, r := range free {
+		for prefixLen := containerPrefixLen; prefixLen < bestPrefixLen; prefixLen++ {
+			size := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLen))
+			start := alignUp(r.start, size)
+			end := new(big.Int).Add(start, size)
+			if end.Sub(end, big.NewInt(1)).Cmp(r.end) <= 0 {
+				bestPrefixLen = prefixLen
+				block = blockString(start, prefixLen, container)
+				break
+			}
+		}
+	}
+	return block, bestPrefixLen <= bits
+}
+
+// countAlignedBlocks returns how many aligned /prefixLen blocks fit in the
+// free ranges. bits is the address size of the container.
+func countAlignedBlocks(free []addressRange, prefixLen, bits int) *big.Int {
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLen))
+	count := new(big.Int)
+	for _, r := range free {
+		start := alignUp(r.start, size)
+		if start.Cmp(r.end) > 0 {
+			continue
+		}
+		span := new(big.Int).Sub(r.end, start)
+		span.Add(span, big.NewInt(1))
+		count.Add(count, span.Div(span, size))
+	}
+	return count
+}
+
+// alignUp rounds n up to a multiple of size.
+func alignUp(n, size *big.Int) *big.Int {
+	aligned := new(big.Int).Add(n, size)
+	aligned.Sub(aligned, big.NewInt(1))
+	return aligned.Sub(aligned, new(big.Int).Mod(aligned, size))
+}
+
+// noBlockError reports that no aligned /prefixLen block is free in the
+// container. When enough space is free contiguously but not on a /alignLen
+// boundary, the error says so, since two free neighbouring /24s do not make
+// a /23 unless the first starts on a /23 boundary. Either way it names the
+// largest block that would fit.
+func noBlockError(container *net.IPNet, free []addressRange, prefixLen, alignLen int) error {
+	_, bits := container.Mask.Size()
+	largest, ok := largestAlignedBlock(container, free)
+	if !ok {
+		return fmt.Errorf("no available /%d block in %s: no free space", prefixLen, container)
+	}
+
+	blockSize := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLen))
+	for _, r := range free {
+		if r.size().Cmp(blockSize) >= 0 {
+			return fmt.Errorf("no available /%d block in %s: %s - %s is free (%s addresses) but a /%d must start on a /%d boundary; the largest aligned free block is %s",
+				prefixLen, container, rangeIP(r.start, container), rangeIP(r.end, container), r.size(), prefixLen, alignLen, largest)
+		}
+	}
+	return fmt.Errorf("no available /%d block in %s: the largest aligned free block is %s", prefixLen, container, largest)
+}
+
+// rangeIP returns the address n in the container's address family.
+func rangeIP(n *big.Int, container *net.IPNet) net.IP {
+	ip := make(net.IP, net.IPv6len)
+	n.FillBytes(ip)
+	if len(container.IP) == net.IPv4len {
+		ip = ip.To4()
+	}
+	return ip
+}
+
+// bigToUint64 converts a big.Int to uint64, saturating on overflow.
+func bigToUint64(n *big.Int) uint64 {
+	if !n.IsUint64() {
+		return math.MaxUint64
+	}
+	return n.Uint64()
+}
+
+// cidrsOverlap reports whether two CIDR strings overlap. Aligned blocks
+// overlap exactly when one contains the other's network address.
+func cidrsOverlap(a, b string) bool {
+	_, aNet, err := net.ParseCIDR(a)
+	if err != nil {
+		return false
+	}
+	_, bNet, err := net.ParseCIDR(b)
+	if err != nil {
+		return false
+	}
+	return aNet.Contains(bNet.IP) || bNet.Contains(aNet.IP)
+}
@@ -0,0 +1,39 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"net"
+	"testing"
+)
+
+func TestFreeRanges(t *testing.T) {
+	_, container, _ := net.ParseCIDR("10.0.0.0/24")
+
+	allocs := []Allocation{
+		{CIDR: "10.0.0.64/26"},
+		{CIDR: "10.0.0.64/27", ParentCIDR: strPtr("10.0.0.64/26")}, // nested, must not split the range
+		{CIDR: "10.0.0.192/26"},
+		{CIDR: "10.1.0.0/24"}, // outside container
+	}
+
+	free := freeRanges(container, allocs)
+	if len(free) != 2 {
+		t.Fatalf("expected 2 free ranges, got %d", len(free))
+	}
+	if free[0].size().Int64() != 64 || free[1].size().Int64() != 64 {
+		t.Errorf("expected two 64-address ranges, got %s and %s", free[0].size(), free[1].size())
+	}
+}
+
+func TestLargestFreeRange_FullContainer(t *testing.T) {
+	_, container, _ := net.ParseCIDR("10.0.0.0/24")
+
+	if got := largestFreeRange(container, nil); got != 256 {
+		t.Errorf("expected 256 for empty container, got %d", got)
+	}
+	if got := largestFreeRange(container, []Allocation{{CIDR: "10.0.0.0/24"}}); got != 0 {
+		t.Errorf("expected 0 for fully allocated container, got %d", got)
+	}
+}
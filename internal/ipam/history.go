@@ -0,0 +1,54 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"sort"
+	"time"
+)
+
+// AllocationsSnapshot is the allocations file as of one commit.
+type AllocationsSnapshot struct {
+	CommitSHA string
+	Timestamp time.Time
+	DB        *AllocationsDatabase
+}
+
+// UtilizationPoint is a pool's usage as of one commit.
+type UtilizationPoint struct {
+	CommitSHA   string
+	Timestamp   time.Time
+	Allocations int     // All entries in the pool, including sub-allocations
+	UsedAddrs   uint64  // Addresses held by top-level allocations
+	Utilization float64 // 0-100, against the pool's current size
+}
+
+// UtilizationSeries computes a pool's usage at each snapshot, oldest first.
+// Every point is measured against the pool's current CIDRs, so points stay
+// comparable when the pool has grown since.
+func UtilizationSeries(pool PoolDefinition, poolID string, snapshots []AllocationsSnapshot) []UtilizationPoint {
+	var size uint64
+	for _, cidr := range pool.CIDR {
+		size += cidrToAddresses(cidr)
+	}
+
+	points := make([]UtilizationPoint, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		point := UtilizationPoint{CommitSHA: snapshot.CommitSHA, Timestamp: snapshot.Timestamp}
+		if snapshot.DB != nil {
+			stats := snapshot.DB.Stats()
+			point.Allocations = stats.AllocationsByPool[poolID]
+			point.UsedAddrs = stats.AddressesByPool[poolID]
+		}
+		if size > 0 {
+			point.Utilization = float64(point.UsedAddrs) / float64(size) * 100
+		}
+		points = append(points, point)
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	return points
+}
@@ -0,0 +1,50 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUtilizationSeries_Growth(t *testing.T) {
+	pool := PoolDefinition{CIDR: []string{"10.0.0.0/22"}}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	first := NewAllocationsDatabase()
+	first.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-a"})
+
+	second := NewAllocationsDatabase()
+	second.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-a"})
+	second.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "id-2", Name: "vpc-b"})
+	second.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/26", ID: "id-3", Name: "subnet", ParentCIDR: strPtr("10.0.1.0/24")})
+	second.AddAllocation("dev", Allocation{CIDR: "10.1.0.0/24", ID: "id-4", Name: "vpc-dev"})
+
+	// Newest first, as the commits API lists them
+	points := UtilizationSeries(pool, "prod", []AllocationsSnapshot{
+		{CommitSHA: "bbb", Timestamp: base.Add(48 * time.Hour), DB: second},
+		{CommitSHA: "aaa", Timestamp: base, DB: first},
+		{CommitSHA: "000", Timestamp: base.Add(-time.Hour), DB: NewAllocationsDatabase()},
+	})
+
+	if len(points) != 3 {
+		t.Fatalf("expected 3 points, got %d", len(points))
+	}
+	want := []struct {
+		sha         string
+		allocations int
+		used        uint64
+		utilization float64
+	}{
+		{"000", 0, 0, 0},
+		{"aaa", 1, 256, 25},
+		{"bbb", 3, 512, 50},
+	}
+	for i, w := range want {
+		p := points[i]
+		if p.CommitSHA != w.sha || p.Allocations != w.allocations || p.UsedAddrs != w.used || p.Utilization != w.utilization {
+			t.Errorf("point %d: expected %+v, got %+v", i, w, p)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Allocation ID schemes.
+const (
+	IDSchemeRandomUUID = "random_uuid" // Random UUIDv4 (default)
+	IDSchemeUUID5      = "uuid5_v1"    // UUIDv5 of pool, name and mask
+	IDSchemeULID       = "ulid"        // Time-sortable ULID
+)
+
+// idNamespaceV1 is the UUIDv5 namespace for IDSchemeUUID5. It is part of
+// the scheme: changing it, or the name layout hashed under it, changes
+// every ID the scheme produces.
+var idNamespaceV1 = uuid.MustParse("5b0e8d36-8f1e-4c1a-9d3b-6b1f0c2e7a41")
+
+// idComponentEscaper escapes the NUL separating the components hashed by
+// IDSchemeUUID5, and its own escape byte, so distinct inputs never hash the
+// same bytes. Components without either byte are unchanged, which keeps
+// the IDs already issued.
+var idComponentEscaper = strings.NewReplacer("\x01", "\x01\x01", "\x00", "\x01\x00")
+
+// crockford is the ULID alphabet, Crockford's base32.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewAllocationID returns an ID for a new allocation under scheme.
+// IDSchemeUUID5 derives it from the pool, name and mask, so the same
+// inputs give the same ID; the other schemes give a new one every call.
+// An empty or unknown scheme is IDSchemeRandomUUID.
+func NewAllocationID(scheme, poolID, name string, prefixLen int) string {
+	switch scheme {
+	case IDSchemeUUID5:
+		return uuid.NewSHA1(idNamespaceV1, []byte(fmt.Sprintf("%s\x00%s\x00%d", idComponentEscaper.Replace(poolID), idComponentEscaper.Replace(name), prefixLen))).String()
+	case IDSchemeULID:
+		return newULID(time.Now())
+	default:
+		return uuid.New().String()
+	}
+}
+
+// newULID returns a ULID for t: a 48-bit millisecond timestamp followed by
+// 80 random bits, as 26 characters of Crockford's base32.
+func newULID(t time.Time) string {
+	var id [16]byte
+	ms := uint64(t.UnixMilli())
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], ms)
+	copy(id[:6], ts[2:])
+	_, _ = rand.Read(id[6:])
+
+	// 128 bits in 26 characters leaves 2 spare bits at the top
+	out := make([]byte, 26)
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ulidPattern = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+
+func TestNewAllocationID_UUIDSchemes(t *testing.T) {
+	for _, scheme := range []string{"", IDSchemeRandomUUID, IDSchemeUUID5} {
+		id := NewAllocationID(scheme, "prod", "vpc-a", 16)
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			t.Errorf("scheme %q: %q is not a UUID: %v", scheme, id, err)
+			continue
+		}
+		wantVersion := uuid.Version(4)
+		if scheme == IDSchemeUUID5 {
+			wantVersion = 5
+		}
+		if parsed.Version() != wantVersion {
+			t.Errorf("scheme %q: expected UUIDv%d, got v%d", scheme, wantVersion, parsed.Version())
+		}
+	}
+}
+
+func TestNewAllocationID_UUID5Stable(t *testing.T) {
+	first := NewAllocationID(IDSchemeUUID5, "prod", "vpc-a", 16)
+	if again := NewAllocationID(IDSchemeUUID5, "prod", "vpc-a", 16); again != first {
+		t.Errorf("expected identical inputs to give %s, got %s", first, again)
+	}
+	for _, other := range []string{
+		NewAllocationID(IDSchemeUUID5, "dev", "vpc-a", 16),
+		NewAllocationID(IDSchemeUUID5, "prod", "vpc-b", 16),
+		NewAllocationID(IDSchemeUUID5, "prod", "vpc-a", 17),
+	} {
+		if other == first {
+			t.Errorf("expected different inputs to give a different ID than %s", first)
+		}
+	}
+}
+
+func TestNewAllocationID_UUID5Unambiguous(t *testing.T) {
+	pairs := [][2]string{
+		{NewAllocationID(IDSchemeUUID5, "b", "a:b", 24), NewAllocationID(IDSchemeUUID5, "a", "b", 24)},
+		{NewAllocationID(IDSchemeUUID5, "pool", "a:b", 24), NewAllocationID(IDSchemeUUID5, "pool:a", "b", 24)},
+		// The separator itself, in either component
+		{NewAllocationID(IDSchemeUUID5, "a\x00b", "c", 24), NewAllocationID(IDSchemeUUID5, "a", "b\x00c", 24)},
+		{NewAllocationID(IDSchemeUUID5, "a\x01", "b", 24), NewAllocationID(IDSchemeUUID5, "a", "\x01b", 24)},
+	}
+	for i, pair := range pairs {
+		if pair[0] == pair[1] {
+			t.Errorf("pair %d: expected distinct inputs to give distinct IDs, both got %s", i, pair[0])
+		}
+	}
+}
+
+func TestNewAllocationID_UUID5LayoutUnchanged(t *testing.T) {
+	// IDs already issued must not change for plain names
+	want := uuid.NewSHA1(idNamespaceV1, []byte("prod\x00vpc-a:east\x0016")).String()
+	if got := NewAllocationID(IDSchemeUUID5, "prod", "vpc-a:east", 16); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestNewAllocationID_RandomSchemesDiffer(t *testing.T) {
+	for _, scheme := range []string{IDSchemeRandomUUID, IDSchemeULID} {
+		if NewAllocationID(scheme, "prod", "vpc-a", 16) == NewAllocationID(scheme, "prod", "vpc-a", 16) {
+			t.Errorf("scheme %q: expected a new ID every call", scheme)
+		}
+	}
+}
+
+func TestNewAllocationID_ULID(t *testing.T) {
+	id := NewAllocationID(IDSchemeULID, "prod", "vpc-a", 16)
+	if !ulidPattern.MatchString(id) {
+		t.Errorf("%q is not a ULID", id)
+	}
+}
+
+func TestNewULID_SortsByTime(t *testing.T) {
+	base := time.Date(2024, 6, 2, 8, 12, 44, 0, time.UTC)
+	earlier := newULID(base)
+	later := newULID(base.Add(time.Millisecond))
+	if earlier >= later {
+		t.Errorf("expected %s to sort before %s", earlier, later)
+	}
+	// 2024-06-02T08:12:44Z is 1717315964000ms, 01HZC04330 in base32
+	if got := earlier[:10]; got != "01HZC04330" {
+		t.Errorf("expected timestamp 01HZC04330, got %s", got)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ImportBlocksPath is where the generated import blocks are written.
+const ImportBlocksPath = ".github/ipam/import.tf"
+
+// GenerateImportBlocks renders an import block for every allocation so
+// allocations made outside Terraform can be brought under management. Each
+// block targets github-ipam_allocation.<name>, with the name turned into a
+// valid resource label; rename the addresses to match your configuration.
+func GenerateImportBlocks(allocations *AllocationsDatabase) string {
+	var sb strings.Builder
+
+	sb.WriteString("# This file is automatically generated by the easytofu/github-ipam Terraform provider. Do not manually edit.\n")
+	sb.WriteString("# Copy the blocks you need into your configuration, next to matching github-ipam_allocation resources.\n")
+
+	if allocations == nil {
+		return sb.String()
+	}
+
+	poolIDs := make([]string, 0, len(allocations.Allocations))
+	for poolID := range allocations.Allocations {
+		poolIDs = append(poolIDs, poolID)
+	}
+	sort.Strings(poolIDs)
+
+	used := make(map[string]bool)
+	for _, poolID := range poolIDs {
+		allocs := append([]Allocation{}, allocations.Allocations[poolID]...)
+		sort.SliceStable(allocs, func(i, j int) bool {
+			return compareCIDRs(allocs[i].CIDR, allocs[j].CIDR)
+		})
+
+		for _, alloc := range allocs {
+			label := uniqueLabel(resourceLabel(alloc.Name), used)
+			sb.WriteString("\n")
+			sb.WriteString(fmt.Sprintf("# %s (pool %s)\n", alloc.CIDR, poolID))
+			sb.WriteString("import {\n")
+			sb.WriteString(fmt.Sprintf("  to = github-ipam_allocation.%s\n", label))
+			sb.WriteString(fmt.Sprintf("  id = %q\n", alloc.ID))
+			sb.WriteString("}\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// resourceLabel turns an allocation name into a valid Terraform resource
+// label: letters, digits, underscores and dashes, not starting with a digit.
+func resourceLabel(name string) string {
+	var sb strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+
+	label := sb.String()
+	if label == "" || (label[0] >= '0' && label[0] <= '9') || label[0] == '-' {
+		label = "_" + label
+	}
+	return label
+}
+
+// uniqueLabel suffixes label until it is not yet used, then records it.
+func uniqueLabel(label string, used map[string]bool) string {
+	candidate := label
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s_%d", label, n)
+	}
+	used[candidate] = true
+	return candidate
+}
@@ -0,0 +1,60 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateImportBlocks(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "8c1f5e2a-0000-4000-8000-000000000002", Name: "web tier"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "8c1f5e2a-0000-4000-8000-000000000001", Name: "1-db"})
+	db.AddAllocation("dev", Allocation{CIDR: "10.1.0.0/24", ID: "8c1f5e2a-0000-4000-8000-000000000003", Name: "web_tier"})
+
+	out := GenerateImportBlocks(db)
+
+	for _, id := range []string{
+		"8c1f5e2a-0000-4000-8000-000000000001",
+		"8c1f5e2a-0000-4000-8000-000000000002",
+		"8c1f5e2a-0000-4000-8000-000000000003",
+	} {
+		if !strings.Contains(out, `id = "`+id+`"`) {
+			t.Errorf("expected an import block for %s, got:\n%s", id, out)
+		}
+	}
+
+	// Labels are valid and unique even when names collide after cleanup
+	for _, to := range []string{
+		"to = github-ipam_allocation._1-db\n",
+		"to = github-ipam_allocation.web_tier\n",
+		"to = github-ipam_allocation.web_tier_2\n",
+	} {
+		if !strings.Contains(out, to) {
+			t.Errorf("expected %q, got:\n%s", to, out)
+		}
+	}
+
+	// Every block is opened and closed
+	if opened, closed := strings.Count(out, "import {\n"), strings.Count(out, "\n}\n"); opened != 3 || closed != 3 {
+		t.Errorf("expected 3 balanced import blocks, got %d opened and %d closed", opened, closed)
+	}
+}
+
+func TestGenerateAllFiles_ImportBlocks(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.Pools["prod"] = PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "web"})
+
+	if _, ok := GenerateAllFiles(pools, db).Files[ImportBlocksPath]; ok {
+		t.Error("import blocks should not be generated by default")
+	}
+
+	files := GenerateAllFilesWithOptions(pools, db, GenerateOptions{ImportBlocks: true})
+	if !strings.Contains(files.Files[ImportBlocksPath], `id = "id-1"`) {
+		t.Errorf("expected import block in %s, got:\n%s", ImportBlocksPath, files.Files[ImportBlocksPath])
+	}
+}
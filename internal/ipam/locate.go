@@ -0,0 +1,92 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"net"
+	"sort"
+)
+
+// Location describes where a block sits in the managed address space.
+type Location struct {
+	PoolID   string // Pool whose CIDRs contain the block; empty when none does
+	PoolCIDR string // The containing pool CIDR
+
+	Allocation       *Allocation // Tightest allocation containing the block, if any
+	AllocationPoolID string      // Pool the allocation is recorded under
+}
+
+// Locate finds the pool and the tightest allocation containing a CIDR or a
+// single IP address. When pools overlap, the tightest pool CIDR wins.
+func Locate(pools *PoolsConfig, allocations *AllocationsDatabase, cidrOrIP string) (Location, error) {
+	block, err := parseBlockOrIP(cidrOrIP)
+	if err != nil {
+		return Location{}, err
+	}
+	ones, _ := block.Mask.Size()
+
+	var loc Location
+	bestPool := -1
+	if pools != nil {
+		poolIDs := make([]string, 0, len(pools.Pools))
+		for poolID := range pools.Pools {
+			poolIDs = append(poolIDs, poolID)
+		}
+		sort.Strings(poolIDs)
+
+		for _, poolID := range poolIDs {
+			poolDef := pools.Pools[poolID]
+			poolCIDR, ok := poolDef.ContainingCIDR(block.String())
+			if !ok {
+				continue
+			}
+			_, poolNet, _ := net.ParseCIDR(poolCIDR)
+			if poolOnes, _ := poolNet.Mask.Size(); poolOnes > bestPool {
+				bestPool = poolOnes
+				loc.PoolID = poolID
+				loc.PoolCIDR = poolCIDR
+			}
+		}
+	}
+
+	bestAlloc := -1
+	if allocations != nil {
+		for poolID, allocs := range allocations.Allocations {
+			for i := range allocs {
+				_, allocNet, err := net.ParseCIDR(allocs[i].CIDR)
+				if err != nil {
+					continue
+				}
+				allocOnes, _ := allocNet.Mask.Size()
+				if allocOnes > ones || !allocNet.Contains(block.IP) {
+					continue
+				}
+				if allocOnes > bestAlloc || (allocOnes == bestAlloc && allocs[i].ID < loc.Allocation.ID) {
+					bestAlloc = allocOnes
+					loc.Allocation = &allocs[i]
+					loc.AllocationPoolID = poolID
+				}
+			}
+		}
+	}
+
+	return loc, nil
+}
+
+// parseBlockOrIP parses a CIDR, or a bare IP address as a single-address
+// block.
+func parseBlockOrIP(s string) (*net.IPNet, error) {
+	if _, network, err := net.ParseCIDR(s); err == nil {
+		return network, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("%q is neither a CIDR nor an IP address", s)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
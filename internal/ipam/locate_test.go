@@ -0,0 +1,76 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import "testing"
+
+func locateFixture() (*PoolsConfig, *AllocationsDatabase) {
+	pools := NewPoolsConfig()
+	pools.Pools["prod"] = PoolDefinition{CIDR: []string{"10.0.0.0/16", "10.2.0.0/16"}}
+	pools.Pools["dev"] = PoolDefinition{CIDR: []string{"10.1.0.0/16"}}
+
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/20", ID: "vpc", Name: "vpc"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "subnet", Name: "subnet", ParentCIDR: strPtr("10.0.0.0/20")})
+	return pools, db
+}
+
+func TestLocate_IPInAllocation(t *testing.T) {
+	pools, db := locateFixture()
+
+	loc, err := Locate(pools, db, "10.0.1.17")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loc.PoolID != "prod" || loc.PoolCIDR != "10.0.0.0/16" {
+		t.Errorf("expected pool prod 10.0.0.0/16, got %q %q", loc.PoolID, loc.PoolCIDR)
+	}
+	if loc.Allocation == nil || loc.Allocation.ID != "subnet" {
+		t.Fatalf("expected tightest allocation subnet, got %+v", loc.Allocation)
+	}
+	if loc.AllocationPoolID != "prod" {
+		t.Errorf("expected allocation pool prod, got %q", loc.AllocationPoolID)
+	}
+
+	// A CIDR larger than the subnet lands on the VPC
+	loc, _ = Locate(pools, db, "10.0.2.0/23")
+	if loc.Allocation == nil || loc.Allocation.ID != "vpc" {
+		t.Errorf("expected vpc to contain 10.0.2.0/23, got %+v", loc.Allocation)
+	}
+}
+
+func TestLocate_PoolWithoutAllocation(t *testing.T) {
+	pools, db := locateFixture()
+
+	loc, err := Locate(pools, db, "10.2.5.0/24")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loc.PoolID != "prod" || loc.PoolCIDR != "10.2.0.0/16" {
+		t.Errorf("expected pool prod 10.2.0.0/16, got %q %q", loc.PoolID, loc.PoolCIDR)
+	}
+	if loc.Allocation != nil {
+		t.Errorf("expected no allocation, got %+v", loc.Allocation)
+	}
+}
+
+func TestLocate_OutsideAllPools(t *testing.T) {
+	pools, db := locateFixture()
+
+	loc, err := Locate(pools, db, "192.168.0.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loc.PoolID != "" || loc.Allocation != nil {
+		t.Errorf("expected nothing to contain 192.168.0.1, got %+v", loc)
+	}
+}
+
+func TestLocate_Invalid(t *testing.T) {
+	pools, db := locateFixture()
+
+	if _, err := Locate(pools, db, "not-an-ip"); err == nil {
+		t.Error("expected error for invalid input")
+	}
+}
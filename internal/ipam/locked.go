@@ -0,0 +1,54 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ErrLocked is returned when a locked allocation would be changed.
+var ErrLocked = errors.New("allocation is locked")
+
+// CheckLockedChange rejects updating a locked allocation in any way other
+// than unlocking it. Unlocking has to be a change of its own, so a locked
+// block is never renamed or retagged in the same apply that unlocks it.
+// Locking says nothing about the space itself; that is what reservations
+// are for.
+func CheckLockedChange(before, after Allocation) error {
+	if !before.Locked {
+		return nil
+	}
+
+	var changed []string
+	if after.Name != before.Name {
+		changed = append(changed, "name")
+	}
+	if !maps.Equal(after.Metadata, before.Metadata) {
+		changed = append(changed, "metadata")
+	}
+	if after.GetStatus() != before.GetStatus() {
+		changed = append(changed, "status")
+	}
+	if after.VLANID != before.VLANID {
+		changed = append(changed, "vlan_id")
+	}
+	if after.Region != before.Region {
+		changed = append(changed, "region")
+	}
+	if after.CostCenter != before.CostCenter {
+		changed = append(changed, "cost_center")
+	}
+	if !slices.Equal(after.Labels, before.Labels) {
+		changed = append(changed, "labels")
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %q cannot change %s; set locked = false in a separate apply first",
+		ErrLocked, before.Name, strings.Join(changed, ", "))
+}
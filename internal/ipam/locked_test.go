@@ -0,0 +1,57 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckLockedChange_RejectsMetadataChange(t *testing.T) {
+	before := Allocation{CIDR: "203.0.113.0/24", Name: "assigned", Metadata: map[string]string{"rir": "arin"}, Locked: true}
+	after := before
+	after.Metadata = map[string]string{"rir": "ripe"}
+
+	err := CheckLockedChange(before, after)
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "cannot change metadata") {
+		t.Errorf("expected the changed field named, got %v", err)
+	}
+
+	// Unlocking in the same update does not let the change through
+	after.Locked = false
+	if err := CheckLockedChange(before, after); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked when unlocking and changing together, got %v", err)
+	}
+}
+
+func TestCheckLockedChange_UnlockThenChange(t *testing.T) {
+	locked := Allocation{CIDR: "203.0.113.0/24", Name: "assigned", Metadata: map[string]string{"rir": "arin"}, Locked: true}
+
+	unlocked := locked
+	unlocked.Locked = false
+	if err := CheckLockedChange(locked, unlocked); err != nil {
+		t.Fatalf("unexpected error unlocking: %v", err)
+	}
+
+	changed := unlocked
+	changed.Name = "renamed"
+	changed.Metadata = map[string]string{"rir": "ripe"}
+	if err := CheckLockedChange(unlocked, changed); err != nil {
+		t.Errorf("unexpected error changing an unlocked allocation: %v", err)
+	}
+}
+
+func TestCheckLockedChange_NilAndEmptyMetadataMatch(t *testing.T) {
+	before := Allocation{CIDR: "203.0.113.0/24", Name: "assigned", Locked: true}
+	after := before
+	after.Metadata = map[string]string{}
+
+	if err := CheckLockedChange(before, after); err != nil {
+		t.Errorf("expected no change between nil and empty metadata, got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+// IgnoreMetadataKeys drops the keys an external process manages from
+// explicit metadata read back for an allocation, so values it wrote don't
+// show as drift. Keys that prior, the previously known explicit metadata,
+// set are kept: configuration that sets an ignored key still manages it.
+// Like EffectiveMetadata it returns nil rather than an empty map.
+func IgnoreMetadataKeys(explicit, prior map[string]string, keys []string) map[string]string {
+	result := make(map[string]string, len(explicit))
+	for k, v := range explicit {
+		result[k] = v
+	}
+	for _, key := range keys {
+		if _, wasSet := prior[key]; !wasSet {
+			delete(result, key)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// PreserveMetadataKeys carries the keys an external process manages over
+// from the stored metadata into planned metadata that does not set them,
+// so an update from configuration does not clobber them. Like
+// EffectiveMetadata it returns nil rather than an empty map.
+func PreserveMetadataKeys(planned, stored map[string]string, keys []string) map[string]string {
+	result := make(map[string]string, len(planned))
+	for k, v := range planned {
+		result[k] = v
+	}
+	for _, key := range keys {
+		if _, set := result[key]; set {
+			continue
+		}
+		if v, ok := stored[key]; ok {
+			result[key] = v
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
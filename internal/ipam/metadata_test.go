@@ -0,0 +1,79 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIgnoreMetadataKeys(t *testing.T) {
+	stored := map[string]string{"team": "net", "scanned-by": "labeler"}
+	keys := []string{"scanned-by"}
+
+	got := IgnoreMetadataKeys(stored, map[string]string{"team": "net"}, keys)
+	if !reflect.DeepEqual(got, map[string]string{"team": "net"}) {
+		t.Errorf("expected the external key dropped, got %v", got)
+	}
+
+	// Configuration that sets an ignored key keeps managing it
+	got = IgnoreMetadataKeys(stored, map[string]string{"team": "net", "scanned-by": "me"}, keys)
+	if !reflect.DeepEqual(got, stored) {
+		t.Errorf("expected the configured key kept, got %v", got)
+	}
+
+	if got := IgnoreMetadataKeys(map[string]string{"scanned-by": "labeler"}, nil, keys); got != nil {
+		t.Errorf("expected nil when only external keys are stored, got %v", got)
+	}
+}
+
+func TestPreserveMetadataKeys(t *testing.T) {
+	stored := map[string]string{"team": "net", "scanned-by": "labeler", "stale": "x"}
+	keys := []string{"scanned-by", "missing"}
+
+	got := PreserveMetadataKeys(map[string]string{"team": "platform"}, stored, keys)
+	want := map[string]string{"team": "platform", "scanned-by": "labeler"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Planned values win over stored ones
+	got = PreserveMetadataKeys(map[string]string{"scanned-by": "me"}, stored, keys)
+	if got["scanned-by"] != "me" {
+		t.Errorf("expected the planned value to win, got %v", got)
+	}
+
+	if got := PreserveMetadataKeys(nil, nil, keys); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+}
+
+// An external key survives an update from configuration and never shows
+// in the explicit metadata compared against configuration.
+func TestExternalMetadataKeys_SurviveRename(t *testing.T) {
+	keys := []string{"scanned-by"}
+	config := map[string]string{"team": "net"}
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc", Metadata: map[string]string{"team": "net"}})
+
+	// A labeling bot edits the file
+	alloc, _, _ := db.FindAllocationByID("id-1")
+	alloc.Metadata["scanned-by"] = "labeler"
+
+	// Refresh: no drift against configuration
+	var pool *PoolDefinition
+	if got := IgnoreMetadataKeys(pool.ExplicitMetadata(alloc.Metadata, config), config, keys); !reflect.DeepEqual(got, config) {
+		t.Fatalf("expected no drift, got %v", got)
+	}
+
+	// Rename from configuration keeps the bot's key
+	alloc.Name = "vpc-renamed"
+	alloc.Metadata = pool.EffectiveMetadata(PreserveMetadataKeys(config, alloc.Metadata, keys))
+	if alloc.Metadata["scanned-by"] != "labeler" || alloc.Metadata["team"] != "net" {
+		t.Errorf("expected the external key to survive the update, got %v", alloc.Metadata)
+	}
+	if got := IgnoreMetadataKeys(pool.ExplicitMetadata(alloc.Metadata, config), config, keys); !reflect.DeepEqual(got, config) {
+		t.Errorf("expected no drift after the update, got %v", got)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+)
+
+// NetBoxExportPath is where the generated NetBox export is written.
+const NetBoxExportPath = ".github/ipam/netbox.json"
+
+// NetBox prefix statuses.
+const (
+	NetBoxStatusContainer  = "container"
+	NetBoxStatusActive     = "active"
+	NetBoxStatusReserved   = "reserved"
+	NetBoxStatusDeprecated = "deprecated"
+)
+
+// NetBoxPrefix is one prefix in NetBox's prefix import shape. Depth mirrors
+// the _depth NetBox reports for nested prefixes; NetBox derives nesting
+// from containment itself and ignores it on import.
+type NetBoxPrefix struct {
+	Prefix      string      `json:"prefix"`
+	Status      string      `json:"status"`
+	Description string      `json:"description,omitempty"`
+	Tags        []NetBoxTag `json:"tags,omitempty"`
+	Depth       int         `json:"_depth"`
+}
+
+// NetBoxTag references a NetBox tag by name.
+type NetBoxTag struct {
+	Name string `json:"name"`
+}
+
+// netBoxStatus maps an allocation's lifecycle status to a NetBox status.
+func netBoxStatus(alloc Allocation) string {
+	switch alloc.GetStatus() {
+	case StatusReservation:
+		return NetBoxStatusReserved
+	case StatusDecommissioning:
+		return NetBoxStatusDeprecated
+	default:
+		return NetBoxStatusActive
+	}
+}
+
+// netBoxTags returns an allocation's labels, then its metadata as
+// key:value tags, in a stable order.
+func netBoxTags(alloc Allocation) []NetBoxTag {
+	var tags []NetBoxTag
+	for _, label := range alloc.Labels {
+		tags = append(tags, NetBoxTag{Name: label})
+	}
+
+	keys := make([]string, 0, len(alloc.Metadata))
+	for k := range alloc.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		tags = append(tags, NetBoxTag{Name: fmt.Sprintf("%s:%s", k, alloc.Metadata[k])})
+	}
+	return tags
+}
+
+// GenerateNetBoxExport renders every pool CIDR as a container prefix, each
+// followed by the allocations inside it and their sub-allocations, depth
+// first, so a parent is always imported before its children.
+func GenerateNetBoxExport(pools *PoolsConfig, allocations *AllocationsDatabase) string {
+	prefixes := make([]NetBoxPrefix, 0)
+
+	var poolIDs []string
+	if pools != nil {
+		for poolID := range pools.Pools {
+			poolIDs = append(poolIDs, poolID)
+		}
+	}
+	sort.Strings(poolIDs)
+
+	for _, poolID := range poolIDs {
+		poolDef := pools.Pools[poolID]
+
+		var topLevel []Allocation
+		children := make(map[string][]Allocation)
+		if allocations != nil {
+			for _, alloc := range allocations.GetAllocationsForPool(poolID) {
+				if alloc.ParentCIDR == nil {
+					topLevel = append(topLevel, alloc)
+				} else {
+					children[*alloc.ParentCIDR] = append(children[*alloc.ParentCIDR], alloc)
+				}
+			}
+		}
+		sortAllocations(topLevel)
+
+		var appendTree func(alloc Allocation, depth int)
+		appendTree = func(alloc Allocation, depth int) {
+			prefixes = append(prefixes, NetBoxPrefix{
+				Prefix:      alloc.CIDR,
+				Status:      netBoxStatus(alloc),
+				Description: alloc.Name,
+				Tags:        netBoxTags(alloc),
+				Depth:       depth,
+			})
+			nested := children[alloc.CIDR]
+			sortAllocations(nested)
+			for _, child := range nested {
+				appendTree(child, depth+1)
+			}
+		}
+
+		for _, poolCIDR := range poolDef.CIDR {
+			_, container, err := net.ParseCIDR(poolCIDR)
+			if err != nil {
+				continue
+			}
+			prefixes = append(prefixes, NetBoxPrefix{
+				Prefix:      container.String(),
+				Status:      NetBoxStatusContainer,
+				Description: poolDef.Description,
+				Tags:        []NetBoxTag{{Name: "pool:" + poolID}},
+			})
+			for _, alloc := range topLevel {
+				if _, allocNet, err := net.ParseCIDR(alloc.CIDR); err == nil && container.Contains(allocNet.IP) {
+					appendTree(alloc, 1)
+				}
+			}
+		}
+	}
+
+	content, _ := json.MarshalIndent(prefixes, "", "  ") // Strings and ints only; cannot fail
+	return string(content) + "\n"
+}
+
+// sortAllocations orders allocations by CIDR in place.
+func sortAllocations(allocs []Allocation) {
+	sort.SliceStable(allocs, func(i, j int) bool {
+		return compareCIDRs(allocs[i].CIDR, allocs[j].CIDR)
+	})
+}
@@ -0,0 +1,71 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGenerateNetBoxExport(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}, Description: "Production"})
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "id-2", Name: "future", Status: StatusReservation, Reserved: true})
+	db.AddAllocation("prod", Allocation{
+		CIDR:     "10.0.0.0/24",
+		ID:       "id-1",
+		Name:     "vpc-main",
+		Labels:   []string{"web"},
+		Metadata: map[string]string{"env": "prod"},
+	})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/26", ID: "id-3", Name: "subnet-a", ParentCIDR: strPtr("10.0.0.0/24")})
+
+	var prefixes []NetBoxPrefix
+	if err := json.Unmarshal([]byte(GenerateNetBoxExport(pools, db)), &prefixes); err != nil {
+		t.Fatalf("export is not valid JSON: %v", err)
+	}
+
+	expected := []struct {
+		prefix string
+		status string
+		depth  int
+	}{
+		{"10.0.0.0/16", NetBoxStatusContainer, 0},
+		{"10.0.0.0/24", NetBoxStatusActive, 1},
+		{"10.0.0.0/26", NetBoxStatusActive, 2}, // nested directly under its parent
+		{"10.0.1.0/24", NetBoxStatusReserved, 1},
+	}
+	if len(prefixes) != len(expected) {
+		t.Fatalf("expected %d prefixes, got %+v", len(expected), prefixes)
+	}
+	for i, want := range expected {
+		got := prefixes[i]
+		if got.Prefix != want.prefix || got.Status != want.status || got.Depth != want.depth {
+			t.Errorf("prefix %d: expected %s %s depth %d, got %s %s depth %d",
+				i, want.prefix, want.status, want.depth, got.Prefix, got.Status, got.Depth)
+		}
+	}
+
+	vpc := prefixes[1]
+	if vpc.Description != "vpc-main" {
+		t.Errorf("expected the allocation name as description, got %q", vpc.Description)
+	}
+	if len(vpc.Tags) != 2 || vpc.Tags[0].Name != "web" || vpc.Tags[1].Name != "env:prod" {
+		t.Errorf("expected label and metadata tags, got %+v", vpc.Tags)
+	}
+}
+
+func TestGenerateAllFiles_NetBoxExportOptIn(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+
+	if _, ok := GenerateAllFiles(pools, NewAllocationsDatabase()).Files[NetBoxExportPath]; ok {
+		t.Error("expected no NetBox export by default")
+	}
+	files := GenerateAllFilesWithOptions(pools, NewAllocationsDatabase(), GenerateOptions{NetBoxExport: true})
+	if _, ok := files.Files[NetBoxExportPath]; !ok {
+		t.Error("expected NetBox export when enabled")
+	}
+}
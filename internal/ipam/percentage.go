@@ -0,0 +1,145 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+)
+
+// percentQuantumDivisor bounds how finely a percentage reservation is cut:
+// blocks are never smaller than 1/256 of the pool, so a percentage like 33
+// does not shatter into dozens of tiny blocks.
+const percentQuantumDivisor = 256
+
+// FindPercentageInPool picks aligned blocks totalling about pct percent of
+// the pool, treating top-level allocations and the avoid set as occupied.
+// Blocks are carved from the top of the pool down, each as large as its
+// alignment allows, so the reservation is a few large contiguous blocks
+// and the free space below it stays whole for ordinary allocations.
+//
+// The target is the percentage rounded to the nearest multiple of the
+// smallest block: 1/256 of the pool, or the pool's allocation_granularity
+// when it is set, since every block must then be exactly that size.
+// Blocks are returned in address order. Either the whole target is found
+// or an error is returned.
+func (a *Allocator) FindPercentageInPool(poolDef *PoolDefinition, existingAllocations []Allocation, pct float64, opts AllocateOptions) ([]string, error) {
+	if pct <= 0 || pct > 100 {
+		return nil, fmt.Errorf("reserve percentage must be greater than 0 and at most 100, got %g", pct)
+	}
+
+	var nets []*net.IPNet
+	total := new(big.Int)
+	for _, cidr := range poolDef.CIDR {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool CIDR %s: %w", cidr, err)
+		}
+		nets = append(nets, network)
+		total.Add(total, networkRange(network).size())
+	}
+	if len(nets) == 0 {
+		return nil, fmt.Errorf("pool has no CIDRs")
+	}
+
+	// The top of the pool is its highest addresses, whatever the order
+	// pools.yaml lists the CIDRs in
+	sort.Slice(nets, func(i, j int) bool {
+		return networkRange(nets[i]).start.Cmp(networkRange(nets[j]).start) > 0
+	})
+
+	_, bits := nets[0].Mask.Size()
+	quantum := big.NewInt(1)
+	if shift := new(big.Int).Div(total, big.NewInt(percentQuantumDivisor)).BitLen() - 1; shift > 0 {
+		quantum.Lsh(quantum, uint(shift))
+	}
+	var maxSize *big.Int
+	if poolDef.AllocationGranularity > 0 {
+		quantum = new(big.Int).Lsh(big.NewInt(1), uint(bits-poolDef.AllocationGranularity))
+		maxSize = quantum
+	}
+	if rb := poolDef.routeBoundary(); rb > 0 && rb <= bits {
+		// No block may cross the route boundary
+		boundary := new(big.Int).Lsh(big.NewInt(1), uint(bits-rb))
+		if quantum.Cmp(boundary) > 0 {
+			quantum = boundary
+		}
+		if maxSize == nil || maxSize.Cmp(boundary) > 0 {
+			maxSize = boundary
+		}
+	}
+
+	target := percentTarget(total, pct, quantum)
+	occupied := append(filterTopLevelAllocations(existingAllocations), filterTopLevelAllocations(opts.Avoid)...)
+	occupied = append(occupied, poolDef.reservedBlocks()...)
+
+	remaining := new(big.Int).Set(target)
+	var blocks []string
+	for _, network := range nets {
+		free := freeRanges(network, occupied)
+		for i := len(free) - 1; i >= 0 && remaining.Sign() > 0; i-- {
+			blocks = carveFromTop(free[i], network, remaining, quantum, maxSize, blocks)
+		}
+	}
+	if remaining.Sign() > 0 {
+		found := new(big.Int).Sub(target, remaining)
+		return nil, fmt.Errorf("only %s of the %s addresses for %g%% of the pool are free", found, target, pct)
+	}
+
+	sort.Slice(blocks, func(i, j int) bool {
+		_, a, _ := net.ParseCIDR(blocks[i])
+		_, b, _ := net.ParseCIDR(blocks[j])
+		return networkRange(a).start.Cmp(networkRange(b).start) < 0
+	})
+	return blocks, nil
+}
+
+// percentTarget returns pct percent of total rounded to the nearest
+// multiple of quantum, and at least one quantum.
+func percentTarget(total *big.Int, pct float64, quantum *big.Int) *big.Int {
+	exact := new(big.Float).Mul(new(big.Float).SetInt(total), big.NewFloat(pct/100))
+	units := new(big.Float).Quo(exact, new(big.Float).SetInt(quantum))
+	units.Add(units, big.NewFloat(0.5))
+	n, _ := units.Int(nil)
+	if n.Sign() == 0 {
+		n.SetInt64(1)
+	}
+	return n.Mul(n, quantum)
+}
+
+// carveFromTop takes aligned blocks from the top of a free range while
+// remaining allows, largest first, and subtracts them from remaining.
+// Blocks are multiples of quantum and no larger than maxSize when it is
+// set.
+func carveFromTop(free addressRange, container *net.IPNet, remaining, quantum, maxSize *big.Int, blocks []string) []string {
+	_, bits := container.Mask.Size()
+	end := new(big.Int).Add(free.end, big.NewInt(1))
+	end.Sub(end, new(big.Int).Mod(end, quantum))
+
+	for remaining.Cmp(quantum) >= 0 {
+		avail := new(big.Int).Sub(end, free.start)
+		if avail.Cmp(quantum) < 0 {
+			break
+		}
+
+		size := new(big.Int).Set(quantum)
+		for {
+			next := new(big.Int).Lsh(size, 1)
+			if next.Cmp(remaining) > 0 || next.Cmp(avail) > 0 || new(big.Int).Mod(end, next).Sign() != 0 {
+				break
+			}
+			if maxSize != nil && next.Cmp(maxSize) > 0 {
+				break
+			}
+			size = next
+		}
+
+		end.Sub(end, size)
+		blocks = append(blocks, fmt.Sprintf("%s/%d", rangeIP(end, container), bits-(size.BitLen()-1)))
+		remaining.Sub(remaining, size)
+	}
+	return blocks
+}
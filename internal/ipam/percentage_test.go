@@ -0,0 +1,110 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sumAddresses(t *testing.T, cidrs []string) int64 {
+	t.Helper()
+	var sum int64
+	for _, cidr := range cidrs {
+		n, ok := AddressCount(cidr)
+		if !ok {
+			t.Fatalf("invalid block %s", cidr)
+		}
+		sum += n
+	}
+	return sum
+}
+
+func TestFindPercentageInPool_QuarterOfEmptyPool(t *testing.T) {
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+
+	blocks, err := NewAllocator().FindPercentageInPool(poolDef, nil, 25, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(blocks, []string{"10.0.192.0/18"}) {
+		t.Errorf("expected a single /18 at the top of the pool, got %v", blocks)
+	}
+}
+
+func TestFindPercentageInPool_AroundExistingAllocations(t *testing.T) {
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/20", Name: "vpc-low"},
+		{CIDR: "10.0.254.0/24", Name: "vpc-high"},
+		{CIDR: "10.0.254.0/26", Name: "subnet", ParentCIDR: strPtr("10.0.254.0/24")},
+	}
+
+	blocks, err := NewAllocator().FindPercentageInPool(poolDef, existing, 25, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := sumAddresses(t, blocks); got != 16384 {
+		t.Errorf("expected 16384 addresses, got %d in %v", got, blocks)
+	}
+	for _, block := range blocks {
+		for _, alloc := range existing {
+			if cidrsOverlap(block, alloc.CIDR) {
+				t.Errorf("reserved %s overlaps %s (%s)", block, alloc.Name, alloc.CIDR)
+			}
+		}
+	}
+	// Carved from the top down, so the space above vpc-low stays whole
+	if blocks[0] != "10.0.191.0/24" {
+		t.Errorf("expected the lowest block at 10.0.191.0/24, got %v", blocks)
+	}
+	if err := NewAllocator().ValidatePlan("10.0.0.0/16", allocationsOf(blocks)); err != nil {
+		t.Errorf("blocks are inconsistent: %v", err)
+	}
+}
+
+func TestFindPercentageInPool_RoundsToSmallestBlock(t *testing.T) {
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+
+	// 33% of 65536 is 21626.88, rounded to a multiple of 256
+	blocks, err := NewAllocator().FindPercentageInPool(poolDef, nil, 33, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := sumAddresses(t, blocks); got != 21504 {
+		t.Errorf("expected 21504 addresses, got %d in %v", got, blocks)
+	}
+}
+
+func TestFindPercentageInPool_HonoursGranularity(t *testing.T) {
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}, AllocationGranularity: 20}
+
+	blocks, err := NewAllocator().FindPercentageInPool(poolDef, nil, 25, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"10.0.192.0/20", "10.0.208.0/20", "10.0.224.0/20", "10.0.240.0/20"}
+	if !reflect.DeepEqual(blocks, want) {
+		t.Errorf("expected %v, got %v", want, blocks)
+	}
+}
+
+func TestFindPercentageInPool_NotEnoughSpace(t *testing.T) {
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/24"}}
+	existing := []Allocation{{CIDR: "10.0.0.0/25"}}
+
+	_, err := NewAllocator().FindPercentageInPool(poolDef, existing, 75, AllocateOptions{})
+	if err == nil || !strings.Contains(err.Error(), "only 128 of the 192 addresses") {
+		t.Errorf("expected a shortfall error, got %v", err)
+	}
+}
+
+func allocationsOf(cidrs []string) []Allocation {
+	allocs := make([]Allocation, len(cidrs))
+	for i, cidr := range cidrs {
+		allocs[i] = Allocation{CIDR: cidr}
+	}
+	return allocs
+}
@@ -0,0 +1,49 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PoolExport is a pool with its allocations, split into top-level
+// allocations and sub-allocations so a module can generate resource
+// blocks for each level from live state.
+type PoolExport struct {
+	PoolID         string
+	Pool           PoolDefinition
+	Allocations    []Allocation // Entries without a parent_cidr, in CIDR order
+	SubAllocations []Allocation // Entries with a parent_cidr, in CIDR order
+}
+
+// ExportPool collects a pool and its allocations. Claims held by the
+// next_available data source are left out, since they are not managed by
+// an allocation resource.
+func ExportPool(pools *PoolsConfig, allocs *AllocationsDatabase, poolID string) (*PoolExport, error) {
+	poolDef, exists := pools.GetPool(poolID)
+	if !exists {
+		return nil, fmt.Errorf("pool %q not found", poolID)
+	}
+
+	export := &PoolExport{PoolID: poolID, Pool: *poolDef}
+	if allocs == nil {
+		return export, nil
+	}
+	for _, alloc := range allocs.GetAllocationsForPool(poolID) {
+		switch {
+		case alloc.IsClaim():
+		case alloc.ParentCIDR == nil:
+			export.Allocations = append(export.Allocations, alloc)
+		default:
+			export.SubAllocations = append(export.SubAllocations, alloc)
+		}
+	}
+	for _, level := range [][]Allocation{export.Allocations, export.SubAllocations} {
+		sort.SliceStable(level, func(i, j int) bool {
+			return compareCIDRs(level[i].CIDR, level[j].CIDR)
+		})
+	}
+	return export, nil
+}
@@ -0,0 +1,49 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExportPool(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}, Description: "Production"})
+	pools.AddPool("dev", PoolDefinition{CIDR: []string{"10.1.0.0/16"}})
+
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "id-2", Name: "vpc-b"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-a", Metadata: map[string]string{"team": "net"}})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/26", ID: "id-3", Name: "subnet-a", ParentCIDR: strPtr("10.0.0.0/24")})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.2.0/24", ID: "id-4", Name: "held", ExpiresAt: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)})
+	db.AddAllocation("dev", Allocation{CIDR: "10.1.0.0/24", ID: "id-5", Name: "vpc-dev"})
+
+	export, err := ExportPool(pools, db, "prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if export.PoolID != "prod" || export.Pool.Description != "Production" {
+		t.Errorf("unexpected pool %q: %+v", export.PoolID, export.Pool)
+	}
+
+	if len(export.Allocations) != 2 || export.Allocations[0].Name != "vpc-a" || export.Allocations[1].Name != "vpc-b" {
+		t.Fatalf("expected vpc-a and vpc-b in CIDR order, got %v", export.Allocations)
+	}
+	if export.Allocations[0].Metadata["team"] != "net" {
+		t.Errorf("expected metadata to be kept, got %v", export.Allocations[0].Metadata)
+	}
+	if len(export.SubAllocations) != 1 || export.SubAllocations[0].Name != "subnet-a" {
+		t.Fatalf("expected subnet-a as the only sub-allocation, got %v", export.SubAllocations)
+	}
+	if *export.SubAllocations[0].ParentCIDR != "10.0.0.0/24" {
+		t.Errorf("expected parent 10.0.0.0/24, got %s", *export.SubAllocations[0].ParentCIDR)
+	}
+}
+
+func TestExportPool_NotFound(t *testing.T) {
+	if _, err := ExportPool(NewPoolsConfig(), NewAllocationsDatabase(), "missing"); err == nil {
+		t.Error("expected error for a missing pool")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NextPoolCIDR finds the first /prefixLen block in privateRange for a new
+// pool. The block overlaps neither an existing pool CIDR nor any
+// allocation. Allocations normally sit inside their pool's CIDRs, but
+// pools edited outside the provider can leave strays behind. A pool over
+// one would claim addresses already in use.
+//
+// It also returns the stray allocations it stepped over. Callers report
+// them, since they point at an out-of-band edit.
+func NextPoolCIDR(privateRange string, pools *PoolsConfig, allocs *AllocationsDatabase, prefixLen int) (string, []Allocation, error) {
+	occupied := make([]Allocation, 0)
+	for _, pool := range pools.Pools {
+		for _, poolCIDR := range pool.CIDR {
+			occupied = append(occupied, Allocation{CIDR: poolCIDR})
+		}
+	}
+	var all []Allocation
+	if allocs != nil {
+		all = allocs.AllAllocations()
+	}
+
+	allocator := NewAllocator()
+	var strays []Allocation
+	for {
+		candidate, err := allocator.findNextInCIDR(privateRange, occupied, prefixLen)
+		if err != nil {
+			if len(strays) > 0 {
+				return "", strays, fmt.Errorf("%w; allocations outside any pool also occupy part of it: %s", err, DescribeAllocations(strays, allocs))
+			}
+			return "", nil, err
+		}
+
+		// Skip past every allocation under the candidate and search again
+		blocking := overlappingAllocations(all, candidate)
+		if len(blocking) == 0 {
+			return candidate, strays, nil
+		}
+		strays = append(strays, blocking...)
+		occupied = append(occupied, blocking...)
+	}
+}
+
+// overlappingAllocations returns the allocations that share addresses
+// with cidr.
+func overlappingAllocations(allocations []Allocation, cidr string) []Allocation {
+	var result []Allocation
+	for _, alloc := range allocations {
+		if cidrsOverlap(alloc.CIDR, cidr) {
+			result = append(result, alloc)
+		}
+	}
+	return result
+}
+
+// DescribeAllocations lists allocations as `name (cidr in pool "id")` for
+// error messages and warnings.
+func DescribeAllocations(allocations []Allocation, db *AllocationsDatabase) string {
+	parts := make([]string, 0, len(allocations))
+	for _, alloc := range allocations {
+		_, poolID, _ := db.FindAllocationByID(alloc.ID)
+		parts = append(parts, fmt.Sprintf("%s (%s in pool %q)", alloc.Name, alloc.CIDR, poolID))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,64 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNextPoolCIDR_SkipsPools(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+
+	got, strays, err := NextPoolCIDR("10.0.0.0/8", pools, NewAllocationsDatabase(), 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.1.0.0/16" {
+		t.Errorf("expected 10.1.0.0/16, got %s", got)
+	}
+	if len(strays) != 0 {
+		t.Errorf("expected no strays, got %v", strays)
+	}
+}
+
+func TestNextPoolCIDR_SkipsStrayAllocation(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+
+	// Left behind when "legacy" lost its 10.1.0.0/16 CIDR out of band
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-main"})
+	db.AddAllocation("legacy", Allocation{CIDR: "10.1.4.0/24", ID: "id-2", Name: "vpc-old"})
+
+	got, strays, err := NextPoolCIDR("10.0.0.0/8", pools, db, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.2.0.0/16" {
+		t.Errorf("expected the stray's /16 to be skipped, got %s", got)
+	}
+	if len(strays) != 1 || strays[0].Name != "vpc-old" {
+		t.Fatalf("expected vpc-old reported as a stray, got %v", strays)
+	}
+	if desc := DescribeAllocations(strays, db); desc != `vpc-old (10.1.4.0/24 in pool "legacy")` {
+		t.Errorf("unexpected description %q", desc)
+	}
+}
+
+func TestNextPoolCIDR_StrayFillsRange(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/17"}})
+	db := NewAllocationsDatabase()
+	db.AddAllocation("legacy", Allocation{CIDR: "10.0.128.0/18", ID: "id-1", Name: "vpc-old"})
+
+	_, _, err := NextPoolCIDR("10.0.0.0/16", pools, db, 17)
+	if err == nil {
+		t.Fatal("expected an error when only a stray allocation's range is left")
+	}
+	if !strings.Contains(err.Error(), `vpc-old (10.0.128.0/18 in pool "legacy")`) {
+		t.Errorf("expected the stray allocation in the error, got %v", err)
+	}
+}
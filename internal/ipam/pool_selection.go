@@ -0,0 +1,96 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Strategies for choosing among candidate pools.
+const (
+	SelectBestFit  = "best_fit"  // The pool with the least free space that still fits (default)
+	SelectWorstFit = "worst_fit" // The pool with the most free space, spreading allocations out
+	SelectFirstFit = "first_fit" // The first candidate, in order, that fits
+)
+
+// PoolChoice is the candidate pool picked for an allocation and the block
+// it would get there.
+type PoolChoice struct {
+	PoolID    string
+	CIDR      string
+	FreeAddrs uint64 // Free addresses in the pool before the allocation
+}
+
+// SelectPool picks one of the candidate pools to allocate a /prefixLen
+// from, and the block it would get there. A candidate fits when it is
+// allocatable, has a free block and keeps its free-space reserve
+// afterwards. Free space is counted outside top-level allocations across
+// all of the pool's CIDRs; ties go to the earlier candidate.
+func (a *Allocator) SelectPool(pools *PoolsConfig, db *AllocationsDatabase, poolIDs []string, prefixLen int, strategy string) (PoolChoice, error) {
+	if len(poolIDs) == 0 {
+		return PoolChoice{}, fmt.Errorf("no candidate pools given")
+	}
+	switch strategy {
+	case "":
+		strategy = SelectBestFit
+	case SelectBestFit, SelectWorstFit, SelectFirstFit:
+	default:
+		return PoolChoice{}, fmt.Errorf("unknown pool selection strategy %q", strategy)
+	}
+
+	var best *PoolChoice
+	var skipped []string
+	for _, poolID := range poolIDs {
+		choice, err := a.tryPool(pools, db, poolID, prefixLen)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", poolID, err))
+			continue
+		}
+		if strategy == SelectFirstFit {
+			return choice, nil
+		}
+		if best == nil ||
+			(strategy == SelectBestFit && choice.FreeAddrs < best.FreeAddrs) ||
+			(strategy == SelectWorstFit && choice.FreeAddrs > best.FreeAddrs) {
+			best = &choice
+		}
+	}
+	if best == nil {
+		return PoolChoice{}, fmt.Errorf("no candidate pool can fit a /%d: %s", prefixLen, strings.Join(skipped, "; "))
+	}
+	return *best, nil
+}
+
+// tryPool finds the block a /prefixLen would get in a pool, with the
+// pool's free space before it.
+func (a *Allocator) tryPool(pools *PoolsConfig, db *AllocationsDatabase, poolID string, prefixLen int) (PoolChoice, error) {
+	poolDef, exists := pools.GetPool(poolID)
+	if !exists {
+		return PoolChoice{}, fmt.Errorf("pool not found in pools.yaml")
+	}
+	if err := CheckPoolAllocatable(poolID, poolDef); err != nil {
+		return PoolChoice{}, err
+	}
+
+	existing := db.GetAllocationsForPool(poolID)
+	cidr, err := a.FindNextAvailableInPoolWithOptions(poolDef, existing, prefixLen, db.AllocateOptionsForPool(poolID, poolDef))
+	if err != nil {
+		return PoolChoice{}, err
+	}
+	if err := a.CheckFreeReserve(poolID, poolDef, existing, cidr); err != nil {
+		return PoolChoice{}, err
+	}
+
+	topLevel := filterTopLevelAllocations(existing)
+	var free uint64
+	for _, poolCIDR := range poolDef.CIDR {
+		available, err := a.CalculateAvailableSpace(poolCIDR, topLevel)
+		if err != nil {
+			return PoolChoice{}, err
+		}
+		free += available
+	}
+	return PoolChoice{PoolID: poolID, CIDR: cidr, FreeAddrs: free}, nil
+}
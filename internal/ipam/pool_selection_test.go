@@ -0,0 +1,70 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"strings"
+	"testing"
+)
+
+func selectionFixture() (*PoolsConfig, *AllocationsDatabase) {
+	pools := NewPoolsConfig()
+	pools.AddPool("roomy", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+	pools.AddPool("tight", PoolDefinition{CIDR: []string{"10.1.0.0/22"}})
+	pools.AddPool("frozen", PoolDefinition{CIDR: []string{"10.2.0.0/16"}, Reserved: true})
+
+	db := NewAllocationsDatabase()
+	db.AddAllocation("tight", Allocation{CIDR: "10.1.0.0/23", ID: "id-1", Name: "vpc-a"})
+	return pools, db
+}
+
+func TestSelectPool_BestFitPicksTighterPool(t *testing.T) {
+	pools, db := selectionFixture()
+
+	choice, err := NewAllocator().SelectPool(pools, db, []string{"roomy", "tight"}, 24, SelectBestFit)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if choice.PoolID != "tight" || choice.CIDR != "10.1.2.0/24" {
+		t.Errorf("expected 10.1.2.0/24 from tight, got %+v", choice)
+	}
+	if choice.FreeAddrs != 512 {
+		t.Errorf("expected 512 free addresses, got %d", choice.FreeAddrs)
+	}
+}
+
+func TestSelectPool_Strategies(t *testing.T) {
+	pools, db := selectionFixture()
+	allocator := NewAllocator()
+
+	choice, err := allocator.SelectPool(pools, db, []string{"tight", "roomy"}, 24, SelectWorstFit)
+	if err != nil || choice.PoolID != "roomy" {
+		t.Errorf("worst_fit: expected roomy, got %+v, %v", choice, err)
+	}
+
+	choice, err = allocator.SelectPool(pools, db, []string{"roomy", "tight"}, 24, SelectFirstFit)
+	if err != nil || choice.PoolID != "roomy" {
+		t.Errorf("first_fit: expected roomy, got %+v, %v", choice, err)
+	}
+
+	// Only roomy fits a /22
+	choice, err = allocator.SelectPool(pools, db, []string{"tight", "roomy"}, 22, SelectBestFit)
+	if err != nil || choice.PoolID != "roomy" {
+		t.Errorf("expected the only fitting pool, got %+v, %v", choice, err)
+	}
+}
+
+func TestSelectPool_NoneFit(t *testing.T) {
+	pools, db := selectionFixture()
+
+	_, err := NewAllocator().SelectPool(pools, db, []string{"tight", "frozen", "missing"}, 22, SelectBestFit)
+	if err == nil {
+		t.Fatal("expected an error when no candidate fits")
+	}
+	for _, want := range []string{"tight:", "frozen: cannot allocate", "missing: pool not found"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in the error, got %v", want, err)
+		}
+	}
+}
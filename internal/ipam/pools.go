@@ -4,14 +4,22 @@
 package ipam
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"net"
+	"sort"
+	"strings"
 )
 
 // PoolsConfig represents the pools.yaml file structure.
 // This file is READ-ONLY by the provider; changes require PR review.
 type PoolsConfig struct {
 	Pools map[string]PoolDefinition `yaml:"pools"`
+
+	// Proposed maps provisional pools, read from open pull requests rather
+	// than the branch, to their pull request number. Never serialized.
+	Proposed map[string]int `yaml:"-"`
 }
 
 // NewPoolsConfig creates a new empty pools configuration.
@@ -27,8 +35,89 @@ type PoolDefinition struct {
 	Description string            `yaml:"description"`           // Human-readable description
 	Metadata    map[string]string `yaml:"metadata"`              // Arbitrary key-value metadata
 	Reserved    bool              `yaml:"reserved,omitempty"`    // If true, pool is reserved (no allocations allowed)
+	AvoidPools  []string          `yaml:"avoid_pools,omitempty"` // Pools whose allocated space this pool must not use
+	ReusePolicy string            `yaml:"reuse_policy,omitempty"` // How freed space is reused (first_fit, cooldown_last)
+
+	// DefaultMetadata is merged beneath the metadata of every allocation
+	// created in the pool; an allocation's own keys win.
+	DefaultMetadata map[string]string `yaml:"default_metadata,omitempty"`
+
+	// RequireMetadataKeys lists metadata keys every new allocation in the
+	// pool must carry, such as a change ticket. default_metadata can
+	// satisfy them.
+	RequireMetadataKeys []string `yaml:"require_metadata_keys,omitempty"`
+
+	// UniqueMetadataKeys lists metadata keys whose values no two
+	// allocations in the pool may share, such as a VPC number.
+	UniqueMetadataKeys []string `yaml:"unique_metadata_keys,omitempty"`
+
+	// AllocationGranularity, when set, is the largest block the pool hands
+	// out; every allocation, however small, starts on a boundary of it.
+	AllocationGranularity int `yaml:"allocation_granularity,omitempty"`
+
+	// FillOrder is the order the pool's CIDRs are searched in (declared,
+	// smallest_first).
+	FillOrder string `yaml:"fill_order,omitempty"`
+
+	// MinFreePct is the share of the pool, in percent, that allocations
+	// must leave free as headroom for emergencies. 0 is no reserve.
+	MinFreePct float64 `yaml:"min_free_pct,omitempty"`
+
+	// SkipFirstBlock keeps the first block of each requested size at the
+	// start of the pool unused, as the null-route sink some routers expect
+	// there. A /24 request skips the first /24, a /25 request only the
+	// first /25.
+	SkipFirstBlock bool `yaml:"skip_first_block,omitempty"`
+
+	// Distribute spreads allocations across the pool instead of packing
+	// them from the start: blocks are tried in bit-reversed order, so
+	// successive allocations land far apart but always in the same places.
+	Distribute bool `yaml:"distribute,omitempty"`
+
+	// ReservedRanges are blocks inside the pool's CIDRs that allocations
+	// never use. Unlike reservation entries in the allocations file they
+	// live with the pool definition, so they change under review.
+	ReservedRanges []string `yaml:"reserved_ranges,omitempty"`
+
+	// SmallBlockRegion is a block inside the pool's CIDRs that requests of
+	// SmallBlockMask or longer are packed into. Larger requests never use
+	// it, so small subnets don't fragment the space large blocks need.
+	SmallBlockRegion string `yaml:"small_block_region,omitempty"`
+
+	// SmallBlockMask is the shortest mask routed into SmallBlockRegion.
+	// Defaults to DefaultSmallBlockMask.
+	SmallBlockMask int `yaml:"small_block_mask,omitempty"`
+
+	// RouteAligned keeps every allocation, and every stripe as a whole,
+	// inside one block of RouteBoundary, so route tables summarize cleanly
+	// at that boundary. Requests that would cross it are rejected.
+	RouteAligned bool `yaml:"route_aligned,omitempty"`
+
+	// RouteBoundary is the supernet size RouteAligned keeps allocations
+	// within. Defaults to DefaultRouteBoundary.
+	RouteBoundary int `yaml:"route_boundary,omitempty"`
 }
 
+// DefaultSmallBlockMask is the SmallBlockMask of a pool that sets a
+// small_block_region alone.
+const DefaultSmallBlockMask = 26
+
+// DefaultRouteBoundary is the RouteBoundary of a route_aligned pool that
+// does not set one.
+const DefaultRouteBoundary = 16
+
+// Reuse policies for freed space.
+const (
+	ReusePolicyFirstFit     = "first_fit"     // Lowest free block wins, freed or not (default)
+	ReusePolicyCooldownLast = "cooldown_last" // Never-used space first; freed blocks last, oldest first
+)
+
+// Fill orders for multi-CIDR pools.
+const (
+	FillOrderDeclared      = "declared"       // CIDRs in the order pools.yaml lists them (default)
+	FillOrderSmallestFirst = "smallest_first" // Smallest CIDR first, keeping large ranges whole
+)
+
 // GetPool looks up a pool by pool_id.
 func (p *PoolsConfig) GetPool(poolID string) (*PoolDefinition, bool) {
 	if p.Pools == nil {
@@ -41,6 +130,292 @@ func (p *PoolsConfig) GetPool(poolID string) (*PoolDefinition, bool) {
 	return &pool, true
 }
 
+// CheckGranularity rejects a request for a block larger than the pool's
+// allocation_granularity, or than its route_boundary, which the block would
+// then cross.
+func (p *PoolDefinition) CheckGranularity(prefixLen int) error {
+	if p.AllocationGranularity > 0 && prefixLen < p.AllocationGranularity {
+		return fmt.Errorf("requested /%d is larger than the pool's allocation_granularity /%d", prefixLen, p.AllocationGranularity)
+	}
+	if rb := p.routeBoundary(); rb > 0 && prefixLen < rb {
+		return fmt.Errorf("requested /%d would cross the pool's /%d route_boundary", prefixLen, rb)
+	}
+	return nil
+}
+
+// routeBoundary returns RouteBoundary, defaulted, or 0 when the pool is not
+// route_aligned.
+func (p *PoolDefinition) routeBoundary() int {
+	switch {
+	case !p.RouteAligned:
+		return 0
+	case p.RouteBoundary == 0:
+		return DefaultRouteBoundary
+	}
+	return p.RouteBoundary
+}
+
+// alignmentFor returns the prefix length a block of prefixLen must start on
+// a boundary of: the granularity when set, otherwise the block itself.
+func (p *PoolDefinition) alignmentFor(prefixLen int) int {
+	if p.AllocationGranularity > 0 {
+		return p.AllocationGranularity
+	}
+	return prefixLen
+}
+
+// skippedBlocks returns the block skip_first_block keeps unused for
+// requests of prefixLen, as an occupied allocation. The pool starts at its
+// first declared CIDR, whatever the fill order.
+func (p *PoolDefinition) skippedBlocks(prefixLen int) []Allocation {
+	if !p.SkipFirstBlock || len(p.CIDR) == 0 {
+		return nil
+	}
+	_, network, err := net.ParseCIDR(p.CIDR[0])
+	if err != nil {
+		return nil
+	}
+	ones, bits := network.Mask.Size()
+	if prefixLen > bits {
+		return nil
+	}
+	// A request as large as the CIDR would be the first block itself
+	if prefixLen < ones {
+		prefixLen = ones
+	}
+	return []Allocation{{CIDR: fmt.Sprintf("%s/%d", network.IP, prefixLen)}}
+}
+
+// reservedBlocks returns the pool's reserved_ranges as occupied
+// allocations.
+func (p *PoolDefinition) reservedBlocks() []Allocation {
+	blocks := make([]Allocation, 0, len(p.ReservedRanges))
+	for _, cidr := range p.ReservedRanges {
+		blocks = append(blocks, Allocation{CIDR: cidr})
+	}
+	return blocks
+}
+
+// occupiedBlocks returns the blocks the pool itself keeps out of use for
+// requests of prefixLen: its reserved_ranges, the skip_first_block block,
+// and for requests too large for it, the small_block_region.
+func (p *PoolDefinition) occupiedBlocks(prefixLen int) []Allocation {
+	blocks := append(p.reservedBlocks(), p.skippedBlocks(prefixLen)...)
+	if p.SmallBlockRegion != "" && !p.isSmallBlock(prefixLen) {
+		blocks = append(blocks, Allocation{CIDR: p.SmallBlockRegion})
+	}
+	return blocks
+}
+
+// isSmallBlock reports whether requests of prefixLen go to the pool's
+// small_block_region.
+func (p *PoolDefinition) isSmallBlock(prefixLen int) bool {
+	return p.SmallBlockRegion != "" && prefixLen >= p.smallBlockMask()
+}
+
+// smallBlockMask returns SmallBlockMask, defaulted.
+func (p *PoolDefinition) smallBlockMask() int {
+	if p.SmallBlockMask == 0 {
+		return DefaultSmallBlockMask
+	}
+	return p.SmallBlockMask
+}
+
+// searchOrder returns the CIDRs requests of prefixLen try, in order: the
+// small_block_region alone for small blocks, otherwise the pool's CIDRs.
+// smallest_first keeps the declared order among CIDRs of equal size.
+func (p *PoolDefinition) searchOrder(prefixLen int) []string {
+	if p.isSmallBlock(prefixLen) {
+		return []string{p.SmallBlockRegion}
+	}
+	if p.FillOrder != FillOrderSmallestFirst {
+		return p.CIDR
+	}
+	ordered := append([]string(nil), p.CIDR...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return prefixLenOf(ordered[i]) > prefixLenOf(ordered[j])
+	})
+	return ordered
+}
+
+// prefixLenOf returns a CIDR's prefix length, or -1 when it does not parse.
+func prefixLenOf(cidr string) int {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return -1
+	}
+	ones, _ := network.Mask.Size()
+	return ones
+}
+
+// RestrictTo returns a copy of the pool limited to one of its CIDRs, so a
+// search of a multi-CIDR pool only considers that range.
+func (p *PoolDefinition) RestrictTo(cidr string) (*PoolDefinition, error) {
+	_, want, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from_cidr %q: %w", cidr, err)
+	}
+	for i, poolCIDR := range p.CIDR {
+		if _, poolNet, err := net.ParseCIDR(poolCIDR); err == nil && poolNet.String() == want.String() {
+			restricted := *p
+			restricted.CIDR = []string{poolCIDR}
+			// A region in another range has no say over this one
+			if _, ok := restricted.ContainingCIDR(p.SmallBlockRegion); !ok {
+				restricted.SmallBlockRegion = ""
+			}
+			// skip_first_block only reserves the start of the pool's first CIDR
+			if i != 0 {
+				restricted.SkipFirstBlock = false
+			}
+			return &restricted, nil
+		}
+	}
+	return nil, fmt.Errorf("from_cidr %s is not one of the pool's CIDRs %v", cidr, p.CIDR)
+}
+
+// ContainingCIDR returns the pool CIDR that contains the given block.
+// For multi-CIDR pools this picks the specific range the block lives in.
+func (p *PoolDefinition) ContainingCIDR(cidr string) (string, bool) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", false
+	}
+	ones, _ := network.Mask.Size()
+
+	for _, poolCIDR := range p.CIDR {
+		_, poolNet, err := net.ParseCIDR(poolCIDR)
+		if err != nil {
+			continue
+		}
+		poolOnes, _ := poolNet.Mask.Size()
+		if poolOnes <= ones && poolNet.Contains(network.IP) {
+			return poolCIDR, true
+		}
+	}
+	return "", false
+}
+
+// EffectiveMetadata merges the pool's default_metadata beneath an
+// allocation's explicit metadata. Explicit keys win on conflict. No
+// metadata at all is nil, never an empty map, so it is stored and read
+// back the same way.
+func (p *PoolDefinition) EffectiveMetadata(explicit map[string]string) map[string]string {
+	merged := make(map[string]string)
+	if p != nil {
+		for k, v := range p.DefaultMetadata {
+			merged[k] = v
+		}
+	}
+	for k, v := range explicit {
+		merged[k] = v
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// ErrMissingMetadata is returned when an allocation lacks metadata keys its
+// pool requires.
+var ErrMissingMetadata = errors.New("missing required metadata")
+
+// CheckRequiredMetadata rejects effective metadata, as returned by
+// EffectiveMetadata, that lacks any of the pool's require_metadata_keys. A
+// key set to a blank value counts as missing. The error names every
+// missing key.
+func (p *PoolDefinition) CheckRequiredMetadata(metadata map[string]string) error {
+	if p == nil {
+		return nil
+	}
+	var missing []string
+	for _, key := range p.RequireMetadataKeys {
+		if strings.TrimSpace(metadata[key]) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("%w: the pool requires %s; set them in metadata or in the pool's default_metadata",
+		ErrMissingMetadata, strings.Join(missing, ", "))
+}
+
+// ErrDuplicateMetadata is returned when an allocation's value for one of
+// its pool's unique_metadata_keys is already used in the pool.
+var ErrDuplicateMetadata = errors.New("duplicate metadata value")
+
+// CheckUniqueMetadata rejects effective metadata that repeats another
+// allocation's value for any of the pool's unique_metadata_keys, naming
+// the allocation that holds it. poolAllocations are the pool's current
+// allocations. The allocation being checked, selfID, is skipped along with
+// the rest of its stripe, group, whose blocks share metadata by design.
+// Blank values are never duplicates.
+func (p *PoolDefinition) CheckUniqueMetadata(metadata map[string]string, poolAllocations []Allocation, selfID, group string) error {
+	if p == nil {
+		return nil
+	}
+	for _, key := range p.UniqueMetadataKeys {
+		value := metadata[key]
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		for _, other := range poolAllocations {
+			if other.ID == selfID || (group != "" && other.Group == group) {
+				continue
+			}
+			if other.Metadata[key] == value {
+				return fmt.Errorf("%w: %s = %q is already used by allocation %q (%s); the pool requires it to be unique",
+					ErrDuplicateMetadata, key, value, other.Name, other.CIDR)
+			}
+		}
+	}
+	return nil
+}
+
+// ExplicitMetadata recovers an allocation's explicit metadata from the
+// merged metadata stored for it. Keys that only carry the pool default are
+// dropped unless prior, the previously known explicit metadata, set them.
+// Like EffectiveMetadata it returns nil rather than an empty map.
+func (p *PoolDefinition) ExplicitMetadata(stored, prior map[string]string) map[string]string {
+	explicit := make(map[string]string)
+	for k, v := range stored {
+		if p != nil {
+			if def, isDefault := p.DefaultMetadata[k]; isDefault && def == v {
+				if _, wasSet := prior[k]; !wasSet {
+					continue
+				}
+			}
+		}
+		explicit[k] = v
+	}
+	if len(explicit) == 0 {
+		return nil
+	}
+	return explicit
+}
+
+// BlockIndex returns the zero-based position of a block among the
+// same-sized blocks of the pool CIDR containing it, e.g. 4 for the fifth
+// /24 of a /16. For multi-CIDR pools the index is relative to the
+// specific range the block lives in.
+func (p *PoolDefinition) BlockIndex(cidr string) (int64, bool) {
+	poolCIDR, found := p.ContainingCIDR(cidr)
+	if !found {
+		return 0, false
+	}
+	_, network, _ := net.ParseCIDR(cidr)
+	_, poolNet, _ := net.ParseCIDR(poolCIDR)
+
+	ones, bits := network.Mask.Size()
+	offset := new(big.Int).Sub(networkRange(network).start, networkRange(poolNet).start)
+	index := offset.Rsh(offset, uint(bits-ones))
+	if !index.IsInt64() {
+		return 0, false
+	}
+	return index.Int64(), true
+}
+
 // ListPoolIDs returns all pool IDs.
 func (p *PoolsConfig) ListPoolIDs() []string {
 	if p.Pools == nil {
@@ -73,38 +448,196 @@ func (p *PoolsConfig) RemovePool(poolID string) error {
 	return nil
 }
 
+// MergePoolsConfigs combines pool definitions split across several files,
+// keyed by file path. A pool ID defined in more than one file is an error.
+func MergePoolsConfigs(files map[string]*PoolsConfig) (*PoolsConfig, error) {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	merged := NewPoolsConfig()
+	definedIn := make(map[string]string)
+	for _, path := range paths {
+		for poolID, pool := range files[path].Pools {
+			if other, exists := definedIn[poolID]; exists {
+				return nil, fmt.Errorf("pool %s is defined in both %s and %s", poolID, other, path)
+			}
+			definedIn[poolID] = path
+			merged.Pools[poolID] = pool
+		}
+	}
+
+	return merged, nil
+}
+
 // ValidatePools ensures all pools have valid CIDRs and no overlaps.
 func (p *PoolsConfig) ValidatePools() error {
-	if p.Pools == nil {
-		return nil
+	if err := p.ValidateEachPool(); err != nil {
+		return err
 	}
 
 	allNetworks := make([]*net.IPNet, 0)
 
+	for poolID, pool := range p.Pools {
+		for _, avoid := range pool.AvoidPools {
+			if _, exists := p.Pools[avoid]; !exists {
+				return fmt.Errorf("pool %s avoids unknown pool %s", poolID, avoid)
+			}
+		}
+
+		// Check for overlaps with other pools
+		for _, cidrStr := range pool.CIDR {
+			_, network, _ := net.ParseCIDR(cidrStr)
+			for _, existing := range allNetworks {
+				if networksOverlap(network, existing) {
+					return fmt.Errorf("pool %s CIDR %s overlaps with another pool", poolID, cidrStr)
+				}
+			}
+			allNetworks = append(allNetworks, network)
+		}
+	}
+
+	return nil
+}
+
+// ValidateEachPool checks the rules that look at one pool at a time. A
+// file that holds only some of the pools is checked this way: avoid_pools
+// and overlaps between pools are only checked once every file is merged.
+func (p *PoolsConfig) ValidateEachPool() error {
 	for poolID, pool := range p.Pools {
 		if len(pool.CIDR) == 0 {
 			return fmt.Errorf("pool %s has no CIDRs defined", poolID)
 		}
 
+		switch pool.ReusePolicy {
+		case "", ReusePolicyFirstFit, ReusePolicyCooldownLast:
+		default:
+			return fmt.Errorf("pool %s has unknown reuse_policy %q (expected %s or %s)",
+				poolID, pool.ReusePolicy, ReusePolicyFirstFit, ReusePolicyCooldownLast)
+		}
+
+		switch pool.FillOrder {
+		case "", FillOrderDeclared, FillOrderSmallestFirst:
+		default:
+			return fmt.Errorf("pool %s has unknown fill_order %q (expected %s or %s)",
+				poolID, pool.FillOrder, FillOrderDeclared, FillOrderSmallestFirst)
+		}
+
+		if pool.MinFreePct < 0 || pool.MinFreePct >= 100 {
+			return fmt.Errorf("pool %s has min_free_pct %g (expected at least 0 and below 100)", poolID, pool.MinFreePct)
+		}
+
+		reservedNetworks := make([]*net.IPNet, 0, len(pool.ReservedRanges))
+		for _, reserved := range pool.ReservedRanges {
+			_, network, err := net.ParseCIDR(reserved)
+			if err != nil {
+				return fmt.Errorf("pool %s has invalid reserved range %s: %w", poolID, reserved, err)
+			}
+			if _, ok := pool.ContainingCIDR(reserved); !ok {
+				return fmt.Errorf("pool %s reserved range %s is not within any of its CIDRs %v", poolID, reserved, pool.CIDR)
+			}
+			for _, existing := range reservedNetworks {
+				if networksOverlap(network, existing) {
+					return fmt.Errorf("pool %s reserved range %s overlaps reserved range %s", poolID, reserved, existing)
+				}
+			}
+			reservedNetworks = append(reservedNetworks, network)
+		}
+
+		if pool.SmallBlockRegion != "" {
+			_, region, err := net.ParseCIDR(pool.SmallBlockRegion)
+			if err != nil {
+				return fmt.Errorf("pool %s has invalid small_block_region %s: %w", poolID, pool.SmallBlockRegion, err)
+			}
+			if _, ok := pool.ContainingCIDR(pool.SmallBlockRegion); !ok {
+				return fmt.Errorf("pool %s small_block_region %s is not within any of its CIDRs %v", poolID, pool.SmallBlockRegion, pool.CIDR)
+			}
+			ones, bits := region.Mask.Size()
+			if m := pool.SmallBlockMask; m != 0 && (m <= ones || m > bits) {
+				return fmt.Errorf("pool %s has small_block_mask /%d, expected longer than small_block_region %s and at most /%d", poolID, m, pool.SmallBlockRegion, bits)
+			}
+		} else if pool.SmallBlockMask != 0 {
+			return fmt.Errorf("pool %s sets small_block_mask without small_block_region", poolID)
+		}
+
+		if pool.RouteBoundary != 0 && !pool.RouteAligned {
+			return fmt.Errorf("pool %s sets route_boundary without route_aligned", poolID)
+		}
+
 		for _, cidrStr := range pool.CIDR {
 			_, network, err := net.ParseCIDR(cidrStr)
 			if err != nil {
 				return fmt.Errorf("pool %s has invalid CIDR %s: %w", poolID, cidrStr, err)
 			}
 
-			// Check for overlaps with other pools
-			for _, existing := range allNetworks {
-				if networksOverlap(network, existing) {
-					return fmt.Errorf("pool %s CIDR %s overlaps with another pool", poolID, cidrStr)
+			if g := pool.AllocationGranularity; g != 0 {
+				ones, bits := network.Mask.Size()
+				if g < ones || g > bits {
+					return fmt.Errorf("pool %s has allocation_granularity /%d outside CIDR %s", poolID, g, cidrStr)
+				}
+			}
+			if rb := pool.routeBoundary(); rb != 0 {
+				if _, bits := network.Mask.Size(); rb < 1 || rb > bits {
+					return fmt.Errorf("pool %s has route_boundary /%d outside the /%d address size of CIDR %s", poolID, rb, bits, cidrStr)
 				}
 			}
-			allNetworks = append(allNetworks, network)
 		}
 	}
 
 	return nil
 }
 
+// privateNetworks lists address space that is never globally routable.
+var privateNetworks = mustParseCIDRs(
+	"10.0.0.0/8",     // RFC 1918
+	"172.16.0.0/12",  // RFC 1918
+	"192.168.0.0/16", // RFC 1918
+	"100.64.0.0/10",  // RFC 6598 shared address space (CGNAT)
+	"fc00::/7",       // RFC 4193 unique local addresses
+)
+
+// IsPrivate reports whether a CIDR lies entirely within private address space.
+// Invalid CIDRs are not private.
+func IsPrivate(cidr string) bool {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+	ones, _ := network.Mask.Size()
+	for _, private := range privateNetworks {
+		privateOnes, _ := private.Mask.Size()
+		if ones >= privateOnes && private.Contains(network.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePrivate returns an error naming the CIDR if it is not private,
+// unless public space has been explicitly allowed.
+func ValidatePrivate(cidr string, allowPublic bool) error {
+	if allowPublic || IsPrivate(cidr) {
+		return nil
+	}
+	return fmt.Errorf("CIDR %s is not in private address space (RFC 1918, RFC 6598, or RFC 4193); "+
+		"set allow_public = true on the provider to permit globally-routable blocks", cidr)
+}
+
+// mustParseCIDRs parses a fixed list of CIDRs, panicking on invalid input.
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, network, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
 // networksOverlap checks if two networks overlap.
 // Two networks overlap if: startA <= endB AND startB <= endA
 func networksOverlap(a, b *net.IPNet) bool {
@@ -4,7 +4,9 @@
 package ipam
 
 import (
+	"errors"
 	"net"
+	"strings"
 	"testing"
 )
 
@@ -378,6 +380,56 @@ func TestPoolDefinition_Metadata(t *testing.T) {
 	}
 }
 
+func TestIsPrivate(t *testing.T) {
+	tests := []struct {
+		cidr     string
+		expected bool
+	}{
+		{"10.0.0.0/8", true},
+		{"10.20.0.0/16", true},
+		{"172.16.0.0/12", true},
+		{"172.31.255.0/24", true},
+		{"192.168.0.0/16", true},
+		{"192.168.10.0/24", true},
+		{"100.64.0.0/10", true},
+		{"100.127.0.0/16", true},
+		{"fd00::/8", true},
+		{"fc00::/7", true},
+		{"8.8.8.0/24", false},
+		{"172.32.0.0/16", false},
+		{"100.128.0.0/16", false},
+		{"10.0.0.0/7", false}, // wider than 10/8, includes public space
+		{"2001:db8::/32", false},
+		{"not-a-cidr", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			if got := IsPrivate(tt.cidr); got != tt.expected {
+				t.Errorf("IsPrivate(%s) = %v, want %v", tt.cidr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidatePrivate(t *testing.T) {
+	if err := ValidatePrivate("10.0.0.0/16", false); err != nil {
+		t.Errorf("private CIDR should pass: %v", err)
+	}
+
+	err := ValidatePrivate("203.0.113.0/24", false)
+	if err == nil {
+		t.Fatal("public CIDR should be rejected")
+	}
+	if !containsString(err.Error(), "203.0.113.0/24") {
+		t.Errorf("error should name the offending CIDR, got: %v", err)
+	}
+
+	if err := ValidatePrivate("203.0.113.0/24", true); err != nil {
+		t.Errorf("allow_public should permit public CIDR: %v", err)
+	}
+}
+
 // Helper function
 func mustParseCIDR(t *testing.T, cidr string) *net.IPNet {
 	t.Helper()
@@ -387,3 +439,455 @@ func mustParseCIDR(t *testing.T, cidr string) *net.IPNet {
 	}
 	return network
 }
+
+func TestValidatePools_UnknownAvoidPool(t *testing.T) {
+	config := NewPoolsConfig()
+	config.Pools["b"] = PoolDefinition{
+		CIDR:       []string{"10.0.0.0/16"},
+		AvoidPools: []string{"missing"},
+	}
+
+	err := config.ValidatePools()
+	if err == nil {
+		t.Fatal("expected error for unknown avoid_pools entry")
+	}
+	if !strings.Contains(err.Error(), "avoids unknown pool missing") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMergePoolsConfigs(t *testing.T) {
+	files := map[string]*PoolsConfig{
+		"network/pools/team-a.yaml": {Pools: map[string]PoolDefinition{
+			"team-a": {CIDR: []string{"10.0.0.0/16"}},
+		}},
+		"network/pools/team-b.yaml": {Pools: map[string]PoolDefinition{
+			"team-b-prod": {CIDR: []string{"10.1.0.0/16"}},
+			"team-b-dev":  {CIDR: []string{"10.2.0.0/16"}},
+		}},
+	}
+
+	merged, err := MergePoolsConfigs(files)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged.Pools) != 3 {
+		t.Errorf("expected 3 pools, got %d", len(merged.Pools))
+	}
+	if _, exists := merged.GetPool("team-b-dev"); !exists {
+		t.Error("expected team-b-dev in merged config")
+	}
+}
+
+func TestMergePoolsConfigs_DuplicatePoolID(t *testing.T) {
+	files := map[string]*PoolsConfig{
+		"network/pools/a.yaml": {Pools: map[string]PoolDefinition{
+			"shared": {CIDR: []string{"10.0.0.0/16"}},
+		}},
+		"network/pools/b.yaml": {Pools: map[string]PoolDefinition{
+			"shared": {CIDR: []string{"10.1.0.0/16"}},
+		}},
+	}
+
+	_, err := MergePoolsConfigs(files)
+	if err == nil {
+		t.Fatal("expected error for duplicate pool ID")
+	}
+	expected := "pool shared is defined in both network/pools/a.yaml and network/pools/b.yaml"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestMergePoolsConfigs_Empty(t *testing.T) {
+	merged, err := MergePoolsConfigs(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if merged.Pools == nil || len(merged.Pools) != 0 {
+		t.Errorf("expected empty initialized pools, got %v", merged.Pools)
+	}
+}
+
+func TestPoolDefinition_ContainingCIDR(t *testing.T) {
+	single := PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+	if got, ok := single.ContainingCIDR("10.0.4.0/24"); !ok || got != "10.0.0.0/16" {
+		t.Errorf("expected 10.0.0.0/16, got %q (%v)", got, ok)
+	}
+
+	multi := PoolDefinition{CIDR: []string{"10.0.0.0/16", "10.8.0.0/16", "172.16.0.0/12"}}
+	tests := []struct {
+		cidr     string
+		expected string
+		found    bool
+	}{
+		{"10.8.3.0/24", "10.8.0.0/16", true},
+		{"172.20.0.0/16", "172.16.0.0/12", true},
+		{"10.0.0.0/16", "10.0.0.0/16", true},
+		{"10.0.0.0/8", "", false}, // Larger than any pool CIDR
+		{"192.168.0.0/24", "", false},
+		{"invalid", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := multi.ContainingCIDR(tt.cidr)
+		if got != tt.expected || ok != tt.found {
+			t.Errorf("ContainingCIDR(%s) = %q, %v; want %q, %v", tt.cidr, got, ok, tt.expected, tt.found)
+		}
+	}
+}
+
+func TestPoolDefinition_BlockIndex(t *testing.T) {
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16", "10.8.0.0/16"}}
+	tests := []struct {
+		cidr     string
+		expected int64
+		found    bool
+	}{
+		{"10.0.0.0/24", 0, true},   // First block
+		{"10.0.1.0/24", 1, true},   // Second block
+		{"10.0.0.192/26", 3, true}, // Index is at the block's own prefix length
+		{"10.8.3.0/24", 3, true},   // Relative to the containing pool CIDR
+		{"192.168.0.0/24", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := poolDef.BlockIndex(tt.cidr)
+		if got != tt.expected || ok != tt.found {
+			t.Errorf("BlockIndex(%s) = %d, %v; want %d, %v", tt.cidr, got, ok, tt.expected, tt.found)
+		}
+	}
+}
+
+func TestPoolDefinition_BlockIndex_GapFilled(t *testing.T) {
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}}
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/24", ID: "first"},
+		{CIDR: "10.0.2.0/24", ID: "third"},
+	}
+
+	cidr, err := NewAllocator().FindNextAvailableInPool(poolDef, existing, 24)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if index, _ := poolDef.BlockIndex(cidr); index != 1 {
+		t.Errorf("expected gap-filled %s to have index 1, got %d", cidr, index)
+	}
+}
+
+func TestValidatePools_ReusePolicy(t *testing.T) {
+	config := NewPoolsConfig()
+	config.Pools["a"] = PoolDefinition{CIDR: []string{"10.0.0.0/16"}, ReusePolicy: ReusePolicyCooldownLast}
+	if err := config.ValidatePools(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	config.Pools["b"] = PoolDefinition{CIDR: []string{"10.1.0.0/16"}, ReusePolicy: "random"}
+	if err := config.ValidatePools(); err == nil || !strings.Contains(err.Error(), `unknown reuse_policy "random"`) {
+		t.Errorf("expected unknown reuse_policy error, got %v", err)
+	}
+}
+
+func TestPoolDefinition_EffectiveMetadata(t *testing.T) {
+	poolDef := &PoolDefinition{DefaultMetadata: map[string]string{"env": "prod", "team": "net"}}
+
+	merged := poolDef.EffectiveMetadata(map[string]string{"team": "apps", "app": "web"})
+	expected := map[string]string{"env": "prod", "team": "apps", "app": "web"}
+	if len(merged) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, merged)
+	}
+	for k, v := range expected {
+		if merged[k] != v {
+			t.Errorf("expected %s=%s, got %q", k, v, merged[k])
+		}
+	}
+}
+
+func TestPoolDefinition_EffectiveMetadata_NoDefaults(t *testing.T) {
+	explicit := map[string]string{"app": "web"}
+
+	for _, poolDef := range []*PoolDefinition{nil, {CIDR: []string{"10.0.0.0/16"}}} {
+		merged := poolDef.EffectiveMetadata(explicit)
+		if len(merged) != 1 || merged["app"] != "web" {
+			t.Errorf("expected explicit metadata unchanged, got %v", merged)
+		}
+	}
+}
+
+func TestPoolDefinition_EffectiveMetadata_Empty(t *testing.T) {
+	for _, poolDef := range []*PoolDefinition{nil, {CIDR: []string{"10.0.0.0/16"}}} {
+		for _, explicit := range []map[string]string{nil, {}} {
+			if merged := poolDef.EffectiveMetadata(explicit); merged != nil {
+				t.Errorf("expected nil metadata for %v, got %#v", explicit, merged)
+			}
+		}
+	}
+}
+
+func TestPoolDefinition_ExplicitMetadata_OnlyDefaults(t *testing.T) {
+	poolDef := &PoolDefinition{DefaultMetadata: map[string]string{"env": "prod"}}
+	if explicit := poolDef.ExplicitMetadata(map[string]string{"env": "prod"}, nil); explicit != nil {
+		t.Errorf("expected nil explicit metadata, got %#v", explicit)
+	}
+}
+
+func TestPoolDefinition_ExplicitMetadata(t *testing.T) {
+	poolDef := &PoolDefinition{DefaultMetadata: map[string]string{"env": "prod", "team": "net"}}
+	stored := map[string]string{"env": "prod", "team": "apps", "app": "web"}
+
+	explicit := poolDef.ExplicitMetadata(stored, nil)
+	if _, ok := explicit["env"]; ok {
+		t.Errorf("expected default-only key to be dropped, got %v", explicit)
+	}
+	if explicit["team"] != "apps" || explicit["app"] != "web" {
+		t.Errorf("expected overriding and own keys kept, got %v", explicit)
+	}
+
+	// A key set explicitly to the default value is kept when known
+	explicit = poolDef.ExplicitMetadata(stored, map[string]string{"env": "prod"})
+	if explicit["env"] != "prod" {
+		t.Errorf("expected previously set key to be kept, got %v", explicit)
+	}
+}
+
+func TestValidatePools_AllocationGranularity(t *testing.T) {
+	config := NewPoolsConfig()
+	config.Pools["a"] = PoolDefinition{CIDR: []string{"10.0.0.0/16"}, AllocationGranularity: 24}
+	if err := config.ValidatePools(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	config.Pools["b"] = PoolDefinition{CIDR: []string{"10.1.0.0/16"}, AllocationGranularity: 8}
+	if err := config.ValidatePools(); err == nil || !strings.Contains(err.Error(), "allocation_granularity /8") {
+		t.Errorf("expected granularity outside CIDR error, got %v", err)
+	}
+}
+
+func TestPoolDefinition_RestrictTo(t *testing.T) {
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/24", "10.1.0.0/24"}}
+
+	restricted, err := poolDef.RestrictTo("10.1.0.0/24")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(poolDef.CIDR) != 2 {
+		t.Errorf("expected original pool untouched, got %v", poolDef.CIDR)
+	}
+
+	// The first CIDR has room, but only the second is searched
+	got, err := NewAllocator().FindNextAvailableInPool(restricted, nil, 26)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "10.1.0.0/26" {
+		t.Errorf("expected 10.1.0.0/26, got %s", got)
+	}
+
+	if _, err := poolDef.RestrictTo("10.2.0.0/24"); err == nil || !strings.Contains(err.Error(), "not one of the pool's CIDRs") {
+		t.Errorf("expected not-in-pool error, got %v", err)
+	}
+	if _, err := poolDef.RestrictTo("10.1.0.0/25"); err == nil {
+		t.Error("expected error for a subnet of a pool CIDR")
+	}
+}
+
+func TestPoolDefinition_RestrictTo_SkipFirstBlock(t *testing.T) {
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/24", "10.1.0.0/24"}, SkipFirstBlock: true}
+	allocator := NewAllocator()
+
+	// Only the pool's first block is skipped, not that of every range
+	second, err := poolDef.RestrictTo("10.1.0.0/24")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, err := allocator.FindNextAvailableInPool(second, nil, 26); err != nil || got != "10.1.0.0/26" {
+		t.Errorf("expected 10.1.0.0/26 from the second CIDR, got %s, %v", got, err)
+	}
+
+	first, err := poolDef.RestrictTo("10.0.0.0/24")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, err := allocator.FindNextAvailableInPool(first, nil, 26); err != nil || got != "10.0.0.64/26" {
+		t.Errorf("expected 10.0.0.64/26 past the skipped first block, got %s, %v", got, err)
+	}
+}
+
+func TestValidatePools_FillOrder(t *testing.T) {
+	config := NewPoolsConfig()
+	config.Pools["a"] = PoolDefinition{CIDR: []string{"10.0.0.0/16"}, FillOrder: FillOrderSmallestFirst}
+	if err := config.ValidatePools(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	config.Pools["b"] = PoolDefinition{CIDR: []string{"10.1.0.0/16"}, FillOrder: "largest_first"}
+	if err := config.ValidatePools(); err == nil || !strings.Contains(err.Error(), `unknown fill_order "largest_first"`) {
+		t.Errorf("expected unknown fill_order error, got %v", err)
+	}
+}
+
+func TestValidatePools_MinFreePct(t *testing.T) {
+	config := NewPoolsConfig()
+	config.Pools["a"] = PoolDefinition{CIDR: []string{"10.0.0.0/16"}, MinFreePct: 10}
+	if err := config.ValidatePools(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	config.Pools["b"] = PoolDefinition{CIDR: []string{"10.1.0.0/16"}, MinFreePct: 100}
+	if err := config.ValidatePools(); err == nil || !strings.Contains(err.Error(), "min_free_pct 100") {
+		t.Errorf("expected min_free_pct error, got %v", err)
+	}
+}
+
+func TestPoolDefinition_CheckRequiredMetadata_Missing(t *testing.T) {
+	poolDef := &PoolDefinition{RequireMetadataKeys: []string{"ticket", "owner"}}
+
+	err := poolDef.CheckRequiredMetadata(poolDef.EffectiveMetadata(map[string]string{"owner": " "}))
+	if !errors.Is(err, ErrMissingMetadata) {
+		t.Fatalf("expected ErrMissingMetadata, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "owner, ticket") {
+		t.Errorf("expected every missing key named, got %v", err)
+	}
+}
+
+func TestPoolDefinition_CheckRequiredMetadata_SatisfiedByDefault(t *testing.T) {
+	poolDef := &PoolDefinition{
+		RequireMetadataKeys: []string{"ticket"},
+		DefaultMetadata:     map[string]string{"ticket": "CHG-0001"},
+	}
+
+	if err := poolDef.CheckRequiredMetadata(poolDef.EffectiveMetadata(nil)); err != nil {
+		t.Errorf("expected default_metadata to satisfy the key, got %v", err)
+	}
+}
+
+func TestPoolDefinition_CheckRequiredMetadata_Explicit(t *testing.T) {
+	poolDef := &PoolDefinition{RequireMetadataKeys: []string{"ticket"}}
+
+	if err := poolDef.CheckRequiredMetadata(poolDef.EffectiveMetadata(map[string]string{"ticket": "CHG-1234"})); err != nil {
+		t.Errorf("expected explicit metadata to satisfy the key, got %v", err)
+	}
+
+	var none *PoolDefinition
+	if err := none.CheckRequiredMetadata(nil); err != nil {
+		t.Errorf("expected no requirement without a pool, got %v", err)
+	}
+}
+
+func TestValidatePools_ReservedRanges(t *testing.T) {
+	config := NewPoolsConfig()
+	config.Pools["a"] = PoolDefinition{CIDR: []string{"10.0.0.0/16", "10.1.0.0/16"}, ReservedRanges: []string{"10.0.0.0/24", "10.1.8.0/21"}}
+	if err := config.ValidatePools(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		ranges []string
+		want   string
+	}{
+		{[]string{"10.2.0.0/24"}, "is not within any of its CIDRs"},
+		{[]string{"10.0.0.0/15"}, "is not within any of its CIDRs"},
+		{[]string{"10.0.0.0/33"}, "invalid reserved range"},
+		{[]string{"10.0.0.0/23", "10.0.1.0/24"}, "overlaps reserved range 10.0.0.0/23"},
+	} {
+		config.Pools["a"] = PoolDefinition{CIDR: []string{"10.0.0.0/16", "10.1.0.0/16"}, ReservedRanges: tc.ranges}
+		if err := config.ValidatePools(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%v: expected error containing %q, got %v", tc.ranges, tc.want, err)
+		}
+	}
+}
+
+func TestValidatePools_SmallBlockRegion(t *testing.T) {
+	config := NewPoolsConfig()
+	config.Pools["a"] = PoolDefinition{CIDR: []string{"10.0.0.0/16"}, SmallBlockRegion: "10.0.240.0/20", SmallBlockMask: 25}
+	if err := config.ValidatePools(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		pool PoolDefinition
+		want string
+	}{
+		{PoolDefinition{CIDR: []string{"10.0.0.0/16"}, SmallBlockRegion: "10.1.0.0/20"}, "is not within any of its CIDRs"},
+		{PoolDefinition{CIDR: []string{"10.0.0.0/16"}, SmallBlockRegion: "10.0.0.0/20", SmallBlockMask: 20}, "expected longer than small_block_region"},
+		{PoolDefinition{CIDR: []string{"10.0.0.0/16"}, SmallBlockMask: 26}, "without small_block_region"},
+	} {
+		config.Pools["a"] = tc.pool
+		if err := config.ValidatePools(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%+v: expected error containing %q, got %v", tc.pool, tc.want, err)
+		}
+	}
+}
+
+func uniqueMetadataPool() (*PoolDefinition, []Allocation) {
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/8"}, UniqueMetadataKeys: []string{"vpc_number"}}
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/16", ID: "id-1", Name: "vpc-a", Metadata: map[string]string{"vpc_number": "1"}},
+		{CIDR: "10.1.0.0/16", ID: "id-2", Name: "vpc-b", Metadata: map[string]string{"vpc_number": "2"}},
+		{CIDR: "10.2.0.0/16", ID: "id-3", Name: "vpc-c"},
+	}
+	return poolDef, existing
+}
+
+func TestPoolDefinition_CheckUniqueMetadata_Unique(t *testing.T) {
+	poolDef, existing := uniqueMetadataPool()
+	for _, metadata := range []map[string]string{
+		{"vpc_number": "3"},
+		{"vpc_number": ""},
+		nil,
+	} {
+		if err := poolDef.CheckUniqueMetadata(metadata, existing, "", ""); err != nil {
+			t.Errorf("%v: unexpected error: %v", metadata, err)
+		}
+	}
+}
+
+func TestPoolDefinition_CheckUniqueMetadata_Duplicate(t *testing.T) {
+	poolDef, existing := uniqueMetadataPool()
+	err := poolDef.CheckUniqueMetadata(map[string]string{"vpc_number": "2"}, existing, "", "")
+	if !errors.Is(err, ErrDuplicateMetadata) {
+		t.Fatalf("expected ErrDuplicateMetadata, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `vpc_number = "2" is already used by allocation "vpc-b" (10.1.0.0/16)`) {
+		t.Errorf("expected the conflicting allocation named, got %v", err)
+	}
+}
+
+func TestPoolDefinition_CheckUniqueMetadata_Update(t *testing.T) {
+	poolDef, existing := uniqueMetadataPool()
+
+	// Keeping its own value is fine; taking another's is not
+	if err := poolDef.CheckUniqueMetadata(map[string]string{"vpc_number": "1"}, existing, "id-1", ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := poolDef.CheckUniqueMetadata(map[string]string{"vpc_number": "1"}, existing, "id-3", ""); !errors.Is(err, ErrDuplicateMetadata) {
+		t.Errorf("expected ErrDuplicateMetadata, got %v", err)
+	}
+
+	// Blocks of one stripe share their metadata
+	existing[1].Group = "id-1"
+	existing[0].Group = "id-1"
+	if err := poolDef.CheckUniqueMetadata(map[string]string{"vpc_number": "2"}, existing, "id-1", "id-1"); err != nil {
+		t.Errorf("unexpected error within a stripe: %v", err)
+	}
+}
+
+func TestValidatePools_RouteBoundary(t *testing.T) {
+	config := NewPoolsConfig()
+	config.Pools["a"] = PoolDefinition{CIDR: []string{"10.0.0.0/8"}, RouteAligned: true}
+	if err := config.ValidatePools(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		pool PoolDefinition
+		want string
+	}{
+		{PoolDefinition{CIDR: []string{"10.0.0.0/8"}, RouteBoundary: 16}, "without route_aligned"},
+		{PoolDefinition{CIDR: []string{"10.0.0.0/8"}, RouteAligned: true, RouteBoundary: 33}, "route_boundary /33 outside"},
+	} {
+		config.Pools["a"] = tc.pool
+		if err := config.ValidatePools(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%+v: expected error containing %q, got %v", tc.pool, tc.want, err)
+		}
+	}
+}
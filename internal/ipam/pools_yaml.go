@@ -0,0 +1,134 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalPoolsPreserving encodes pools as an edit of original, the
+// pools.yaml content they were read from, so the comments and key order a
+// reviewer sees in PRs survive provider writes. Pools whose definition is
+// unchanged keep their original nodes; changed pools are re-encoded in
+// place, new pools are appended and removed pools are dropped.
+//
+// Content that is not a YAML mapping is rejected; callers fall back to a
+// plain marshal.
+func MarshalPoolsPreserving(original []byte, pools *PoolsConfig) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(original, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse pools YAML: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("pools YAML is not a mapping")
+	}
+	root := doc.Content[0]
+
+	poolsNode := mappingValue(root, "pools")
+	if poolsNode == nil {
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "pools"},
+			&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+		poolsNode = root.Content[len(root.Content)-1]
+	} else if poolsNode.Kind != yaml.MappingNode || poolsNode.Style == yaml.FlowStyle {
+		// "pools:" with no value, or "pools: {}"; keep any comments
+		*poolsNode = yaml.Node{
+			Kind:        yaml.MappingNode,
+			Tag:         "!!map",
+			HeadComment: poolsNode.HeadComment,
+			LineComment: poolsNode.LineComment,
+			FootComment: poolsNode.FootComment,
+		}
+	}
+
+	seen := make(map[string]bool)
+	kept := poolsNode.Content[:0]
+	for i := 0; i+1 < len(poolsNode.Content); i += 2 {
+		key, value := poolsNode.Content[i], poolsNode.Content[i+1]
+		want, exists := pools.Pools[key.Value]
+		if !exists {
+			continue
+		}
+		seen[key.Value] = true
+
+		var current PoolDefinition
+		if err := value.Decode(&current); err != nil || !reflect.DeepEqual(current, want) {
+			encoded, err := encodeNode(want)
+			if err != nil {
+				return nil, err
+			}
+			value = encoded
+		}
+		kept = append(kept, key, value)
+	}
+	poolsNode.Content = kept
+
+	added := make([]string, 0)
+	for poolID := range pools.Pools {
+		if !seen[poolID] {
+			added = append(added, poolID)
+		}
+	}
+	sort.Strings(added)
+	for _, poolID := range added {
+		encoded, err := encodeNode(pools.Pools[poolID])
+		if err != nil {
+			return nil, err
+		}
+		poolsNode.Content = append(poolsNode.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: poolID},
+			encoded)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(detectIndent(original))
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to serialize pools: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to serialize pools: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// mappingValue returns the value node for key in a mapping node.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func encodeNode(v interface{}) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to serialize pool: %w", err)
+	}
+	return &node, nil
+}
+
+// detectIndent returns the indentation of the first indented line, so
+// re-encoding does not reindent the whole file. yaml.v3 only emits
+// indents of 2 or more.
+func detectIndent(content []byte) int {
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed == line || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if n := len(line) - len(trimmed); n >= 2 {
+			return n
+		}
+	}
+	return 4
+}
@@ -0,0 +1,130 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const commentedPools = `# IPAM pools, reviewed by the network team
+pools:
+  # Production VPCs; ask #netops before resizing
+  prod:
+    cidr:
+      - "10.0.0.0/16"
+    description: Production
+  dev:
+    cidr:
+      - "10.1.0.0/16"
+    description: Development # shared by all teams
+`
+
+func TestMarshalPoolsPreserving_AddPool(t *testing.T) {
+	pools := parsePools(t, commentedPools)
+	pools.AddPool("staging", PoolDefinition{CIDR: []string{"10.2.0.0/16"}, Description: "Staging"})
+
+	out, err := MarshalPoolsPreserving([]byte(commentedPools), pools)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := string(out)
+
+	for _, want := range []string{
+		"# IPAM pools, reviewed by the network team",
+		"  # Production VPCs; ask #netops before resizing\n  prod:",
+		"description: Development # shared by all teams",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q to survive, got:\n%s", want, content)
+		}
+	}
+
+	// Existing pools keep their order, the new one is appended
+	if p, d, s := strings.Index(content, "prod:"), strings.Index(content, "dev:"), strings.Index(content, "staging:"); !(p < d && d < s) {
+		t.Errorf("expected prod, dev, staging order, got:\n%s", content)
+	}
+
+	roundTrip := parsePools(t, content)
+	if pool, ok := roundTrip.GetPool("staging"); !ok || pool.CIDR[0] != "10.2.0.0/16" {
+		t.Errorf("expected staging pool to be written, got %+v", roundTrip.Pools)
+	}
+}
+
+func TestMarshalPoolsPreserving_RemovePool(t *testing.T) {
+	pools := parsePools(t, commentedPools)
+	if err := pools.RemovePool("dev"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, err := MarshalPoolsPreserving([]byte(commentedPools), pools)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := string(out)
+
+	if !strings.Contains(content, "# Production VPCs; ask #netops before resizing") {
+		t.Errorf("expected comment above prod to survive, got:\n%s", content)
+	}
+	if strings.Contains(content, "dev:") || strings.Contains(content, "Development") {
+		t.Errorf("expected dev to be removed, got:\n%s", content)
+	}
+}
+
+func TestMarshalPoolsPreserving_UpdatesChangedPoolOnly(t *testing.T) {
+	pools := parsePools(t, commentedPools)
+	dev := pools.Pools["dev"]
+	dev.Description = "Developer sandboxes"
+	pools.AddPool("dev", dev)
+
+	out, err := MarshalPoolsPreserving([]byte(commentedPools), pools)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := string(out)
+
+	if !strings.Contains(content, "description: Developer sandboxes") {
+		t.Errorf("expected updated description, got:\n%s", content)
+	}
+	if !strings.Contains(content, "# Production VPCs; ask #netops before resizing\n  prod:\n    cidr:\n      - \"10.0.0.0/16\"") {
+		t.Errorf("expected prod untouched, got:\n%s", content)
+	}
+}
+
+func TestMarshalPoolsPreserving_EmptyPools(t *testing.T) {
+	original := "# Define pools here\npools: {}\n"
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+
+	out, err := MarshalPoolsPreserving([]byte(original), pools)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(string(out), "# Define pools here\n") {
+		t.Errorf("expected header comment kept, got:\n%s", out)
+	}
+	if _, ok := parsePools(t, string(out)).GetPool("prod"); !ok {
+		t.Errorf("expected prod pool, got:\n%s", out)
+	}
+}
+
+func TestMarshalPoolsPreserving_NotAMapping(t *testing.T) {
+	if _, err := MarshalPoolsPreserving([]byte("- just\n- a list\n"), NewPoolsConfig()); err == nil {
+		t.Error("expected error for non-mapping content")
+	}
+}
+
+func parsePools(t *testing.T, content string) *PoolsConfig {
+	t.Helper()
+	var pools PoolsConfig
+	if err := yaml.Unmarshal([]byte(content), &pools); err != nil {
+		t.Fatalf("failed to parse pools: %v", err)
+	}
+	if pools.Pools == nil {
+		pools.Pools = make(map[string]PoolDefinition)
+	}
+	return &pools
+}
@@ -0,0 +1,241 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+)
+
+// AllocationRequest describes an allocation that has been planned but not
+// yet created. Exactly one of PoolID or ParentCIDR is expected to be set.
+type AllocationRequest struct {
+	Name       string
+	PoolID     string // Mode 1: allocate from a pool
+	ParentCIDR string // Mode 2: sub-allocate from an existing allocation
+	Placement  string // Mode 2: PlacementLow or PlacementHigh
+	PrefixLen  int
+	Adopt      bool // Bind to an existing allocation with the same name instead of creating one
+
+	// MinPrefixLen and MaxPrefixLen bound a largest-available request,
+	// which leaves PrefixLen 0 and takes any size in between.
+	MinPrefixLen int
+	MaxPrefixLen int
+}
+
+// CheckPoolAllocatable rejects allocating from a reserved pool.
+func CheckPoolAllocatable(poolID string, poolDef *PoolDefinition) error {
+	if poolDef.Reserved {
+		return fmt.Errorf("cannot allocate from pool %q: pool is reserved (reserved pools cannot have allocations)", poolID)
+	}
+	return nil
+}
+
+// CheckFreeReserve rejects placing newCIDR in a pool when the pool would be
+// left with less free space than its min_free_pct reserve. Free space is
+// that of the pool's CIDRs outside top-level allocations, before and after
+// the new block. Pools of 2^64 addresses or more never run short, so they
+// are not checked.
+func (a *Allocator) CheckFreeReserve(poolID string, poolDef *PoolDefinition, existingAllocations []Allocation, newCIDR string) error {
+	if poolDef.MinFreePct <= 0 {
+		return nil
+	}
+
+	topLevel := filterTopLevelAllocations(existingAllocations)
+	withNew := append(append([]Allocation{}, topLevel...), Allocation{CIDR: newCIDR})
+
+	var total, freeBefore, freeAfter uint64
+	for _, poolCIDR := range poolDef.CIDR {
+		_, network, err := net.ParseCIDR(poolCIDR)
+		if err != nil {
+			continue
+		}
+		ones, bits := network.Mask.Size()
+		if bits-ones >= 64 {
+			return nil
+		}
+		total += uint64(1) << uint(bits-ones)
+
+		before, err := a.CalculateAvailableSpace(poolCIDR, topLevel)
+		if err != nil {
+			return err
+		}
+		after, err := a.CalculateAvailableSpace(poolCIDR, withNew)
+		if err != nil {
+			return err
+		}
+		freeBefore += before
+		freeAfter += after
+	}
+	if total == 0 || float64(freeAfter) >= poolDef.MinFreePct/100*float64(total) {
+		return nil
+	}
+
+	return fmt.Errorf("allocating %s from pool %q would leave %s of %s addresses free (%.1f%%), "+
+		"below the pool's min_free_pct reserve of %g%% (%s addresses are free now); "+
+		"the reserve keeps headroom for emergencies and can only be used by lowering min_free_pct in pools.yaml",
+		newCIDR, poolID, formatNumber(freeAfter), formatNumber(total), float64(freeAfter)/float64(total)*100,
+		poolDef.MinFreePct, formatNumber(freeBefore))
+}
+
+// CheckFutureSiblings rejects sub-allocating newBlocks from parentCIDR
+// unless at least count more free blocks of the same size would remain in
+// the parent afterwards, so a parent is not packed past the growth its
+// owners planned for. children are the parent's existing sub-allocations.
+func CheckFutureSiblings(parentCIDR string, children []Allocation, newBlocks []string, count int) error {
+	if count <= 0 || len(newBlocks) == 0 {
+		return nil
+	}
+	_, parentNet, err := net.ParseCIDR(parentCIDR)
+	if err != nil {
+		return fmt.Errorf("invalid parent CIDR %s: %w", parentCIDR, err)
+	}
+	_, blockNet, err := net.ParseCIDR(newBlocks[0])
+	if err != nil {
+		return fmt.Errorf("invalid CIDR %s: %w", newBlocks[0], err)
+	}
+	prefixLen, bits := blockNet.Mask.Size()
+
+	after := append([]Allocation{}, children...)
+	for _, block := range newBlocks {
+		after = append(after, Allocation{CIDR: block})
+	}
+	remaining := countAlignedBlocks(freeRanges(parentNet, after), prefixLen, bits)
+	if remaining.Cmp(big.NewInt(int64(count))) >= 0 {
+		return nil
+	}
+
+	short := new(big.Int).Sub(big.NewInt(int64(count)), remaining)
+	return fmt.Errorf("allocating %s from %s would leave room for %s more /%d blocks, %s short of ensure_future_siblings = %d",
+		strings.Join(newBlocks, ", "), parentCIDR, remaining, prefixLen, short, count)
+}
+
+// CheckPrefixFamily rejects a prefix length no CIDR it would be allocated
+// from can hold: longer than the address size of every one, such as a /33
+// from an IPv4 pool. The check only needs the pool's CIDRs or the parent,
+// so it can run at plan time. Unparseable CIDRs are left to the allocator.
+func CheckPrefixFamily(prefixLen int, cidrs []string) error {
+	maxBits := 0
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil
+		}
+		if _, bits := network.Mask.Size(); bits > maxBits {
+			maxBits = bits
+		}
+	}
+	if maxBits == 0 || prefixLen <= maxBits {
+		return nil
+	}
+	family := "IPv4"
+	if maxBits == 128 {
+		family = "IPv6"
+	}
+	return fmt.Errorf("/%d is longer than an %s address allows (at most /%d)", prefixLen, family, maxBits)
+}
+
+// CheckParentAllocatable rejects sub-allocating from a reservation or from
+// a block being decommissioned.
+func CheckParentAllocatable(parentCIDR string, parent *Allocation) error {
+	if parent.Reserved {
+		return fmt.Errorf("cannot sub-allocate from %q: parent is a reservation (reserved blocks cannot have children)", parentCIDR)
+	}
+	if parent.GetStatus() == StatusDecommissioning {
+		return fmt.Errorf("cannot sub-allocate from %q: parent is being decommissioned", parentCIDR)
+	}
+	return nil
+}
+
+// PrecheckAllocation returns advisory warnings for a planned allocation,
+// based on a snapshot of the current state. The state can change before
+// apply, so callers should surface these as warnings rather than errors.
+// Problems that apply already reports clearly (unknown pools, reserved
+// parents) are left to apply.
+func (a *Allocator) PrecheckAllocation(pools *PoolsConfig, db *AllocationsDatabase, req AllocationRequest) []string {
+	var warnings []string
+
+	if req.Name != "" {
+		existing, existingPoolID, found := db.FindAllocationByName(req.Name)
+		if found && req.Adopt {
+			// Adoption needs no new space; only a mismatch can fail
+			if err := CheckAdoptable(existing, existingPoolID, req); err != nil {
+				warnings = append(warnings, err.Error())
+			}
+			return warnings
+		}
+		if found {
+			warnings = append(warnings, fmt.Sprintf(
+				"allocation name %q already exists (used by allocation %s); apply will fail unless it is removed first",
+				req.Name, existing.CIDR))
+		}
+	}
+
+	switch {
+	case req.PoolID != "":
+		poolDef, exists := pools.GetPool(req.PoolID)
+		if !exists || poolDef.Reserved {
+			break
+		}
+		opts := db.AllocateOptionsForPool(req.PoolID, poolDef)
+		existing := db.GetAllocationsForPool(req.PoolID)
+		cidr, err := a.FindNextAvailableInPoolWithOptions(poolDef, existing, req.PrefixLen, opts)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf(
+				"pool %q currently has no room for a /%d: %s", req.PoolID, req.PrefixLen, err))
+		} else if err := a.CheckFreeReserve(req.PoolID, poolDef, existing, cidr); err != nil {
+			warnings = append(warnings, err.Error())
+		}
+	case req.ParentCIDR != "":
+		if _, _, found := db.FindAllocationByCIDR(req.ParentCIDR); !found {
+			break
+		}
+		if _, err := a.FindNextAvailableInParent(req.ParentCIDR, db.GetAllocationsForParent(req.ParentCIDR), req.PrefixLen, req.Placement); err != nil {
+			warnings = append(warnings, fmt.Sprintf(
+				"parent %q currently has no room for a /%d: %s", req.ParentCIDR, req.PrefixLen, err))
+		}
+	}
+
+	return warnings
+}
+
+// PreviewAllocation returns the block an allocation of req.PrefixLen from
+// req.PoolID or req.ParentCIDR would be given now, or an error saying why
+// it would be refused. It runs the checks a create runs against the same
+// snapshot, from reserved pools and parents to nesting depth and a pool's
+// free-space reserve; req.Name is not checked.
+func (a *Allocator) PreviewAllocation(pools *PoolsConfig, db *AllocationsDatabase, req AllocationRequest, maxNestingDepth int) (string, error) {
+	if req.PoolID != "" {
+		poolDef, exists := pools.GetPool(req.PoolID)
+		if !exists {
+			return "", fmt.Errorf("pool_id %q not found in pools.yaml", req.PoolID)
+		}
+		if err := CheckPoolAllocatable(req.PoolID, poolDef); err != nil {
+			return "", err
+		}
+		existing := db.GetAllocationsForPool(req.PoolID)
+		cidr, err := a.FindNextAvailableInPoolWithOptions(poolDef, existing, req.PrefixLen, db.AllocateOptionsForPool(req.PoolID, poolDef))
+		if err != nil {
+			return "", err
+		}
+		if err := a.CheckFreeReserve(req.PoolID, poolDef, existing, cidr); err != nil {
+			return "", err
+		}
+		return cidr, nil
+	}
+
+	parent, _, found := db.FindAllocationByCIDR(req.ParentCIDR)
+	if !found {
+		return "", fmt.Errorf("parent_cidr %q not found in allocations", req.ParentCIDR)
+	}
+	if err := CheckParentAllocatable(req.ParentCIDR, parent); err != nil {
+		return "", err
+	}
+	if err := db.CheckNestingDepth(req.ParentCIDR, maxNestingDepth); err != nil {
+		return "", err
+	}
+	return a.FindNextAvailableInParent(req.ParentCIDR, db.GetAllocationsForParent(req.ParentCIDR), req.PrefixLen, req.Placement)
+}
@@ -0,0 +1,308 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"strings"
+	"testing"
+)
+
+func precheckFixture() (*PoolsConfig, *AllocationsDatabase) {
+	pools := NewPoolsConfig()
+	pools.Pools["small"] = PoolDefinition{CIDR: []string{"10.0.0.0/24"}}
+
+	db := NewAllocationsDatabase()
+	db.Allocations["small"] = []Allocation{
+		{CIDR: "10.0.0.0/25", ID: "a", Name: "app-a"},
+	}
+	return pools, db
+}
+
+func TestPrecheckAllocation_NoWarnings(t *testing.T) {
+	pools, db := precheckFixture()
+
+	warnings := NewAllocator().PrecheckAllocation(pools, db, AllocationRequest{
+		Name:      "app-b",
+		PoolID:    "small",
+		PrefixLen: 25,
+	})
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+}
+
+func TestPrecheckAllocation_DuplicateName(t *testing.T) {
+	pools, db := precheckFixture()
+
+	warnings := NewAllocator().PrecheckAllocation(pools, db, AllocationRequest{
+		Name:      "app-a",
+		PoolID:    "small",
+		PrefixLen: 26,
+	})
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %v", warnings)
+	}
+	if !strings.Contains(warnings[0], `"app-a" already exists`) {
+		t.Errorf("unexpected warning: %s", warnings[0])
+	}
+}
+
+func TestPrecheckAllocation_PoolExhausted(t *testing.T) {
+	pools, db := precheckFixture()
+
+	// Only a /25 is left, so a /24 cannot fit
+	warnings := NewAllocator().PrecheckAllocation(pools, db, AllocationRequest{
+		Name:      "app-b",
+		PoolID:    "small",
+		PrefixLen: 24,
+	})
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %v", warnings)
+	}
+	if !strings.Contains(warnings[0], `pool "small" currently has no room for a /24`) {
+		t.Errorf("unexpected warning: %s", warnings[0])
+	}
+}
+
+func TestPrecheckAllocation_ParentExhausted(t *testing.T) {
+	pools, db := precheckFixture()
+	db.Allocations["small"] = append(db.Allocations["small"],
+		Allocation{CIDR: "10.0.0.0/26", ID: "s1", Name: "sub-1", ParentCIDR: strPtr("10.0.0.0/25")},
+		Allocation{CIDR: "10.0.0.64/26", ID: "s2", Name: "sub-2", ParentCIDR: strPtr("10.0.0.0/25")},
+	)
+
+	warnings := NewAllocator().PrecheckAllocation(pools, db, AllocationRequest{
+		Name:       "sub-3",
+		ParentCIDR: "10.0.0.0/25",
+		PrefixLen:  26,
+	})
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %v", warnings)
+	}
+	if !strings.Contains(warnings[0], `parent "10.0.0.0/25" currently has no room`) {
+		t.Errorf("unexpected warning: %s", warnings[0])
+	}
+}
+
+func TestPrecheckAllocation_UnknownPoolLeftToApply(t *testing.T) {
+	pools, db := precheckFixture()
+
+	warnings := NewAllocator().PrecheckAllocation(pools, db, AllocationRequest{
+		Name:      "app-b",
+		PoolID:    "missing",
+		PrefixLen: 24,
+	})
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+}
+
+func TestPrecheckAllocation_AdoptExisting(t *testing.T) {
+	pools, db := precheckFixture()
+	allocator := NewAllocator()
+
+	// A matching allocation is adopted, so no duplicate-name warning
+	warnings := allocator.PrecheckAllocation(pools, db, AllocationRequest{
+		Name:      "app-a",
+		PoolID:    "small",
+		PrefixLen: 25,
+		Adopt:     true,
+	})
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+
+	// A mismatched mask is reported instead
+	warnings = allocator.PrecheckAllocation(pools, db, AllocationRequest{
+		Name:      "app-a",
+		PoolID:    "small",
+		PrefixLen: 26,
+		Adopt:     true,
+	})
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "cannot adopt") {
+		t.Errorf("expected adoption mismatch warning, got %v", warnings)
+	}
+}
+
+func TestCheckPoolAllocatable(t *testing.T) {
+	if err := CheckPoolAllocatable("reserved", &PoolDefinition{Reserved: true}); err == nil || !strings.Contains(err.Error(), "pool is reserved") {
+		t.Errorf("expected reserved pool error, got %v", err)
+	}
+	if err := CheckPoolAllocatable("open", &PoolDefinition{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckParentAllocatable(t *testing.T) {
+	reserved := &Allocation{CIDR: "10.0.0.0/16"}
+	reserved.SetStatus(StatusReservation)
+	if err := CheckParentAllocatable("10.0.0.0/16", reserved); err == nil || !strings.Contains(err.Error(), "parent is a reservation") {
+		t.Errorf("expected reserved parent error, got %v", err)
+	}
+
+	decommissioning := &Allocation{CIDR: "10.1.0.0/16"}
+	decommissioning.SetStatus(StatusDecommissioning)
+	if err := CheckParentAllocatable("10.1.0.0/16", decommissioning); err == nil || !strings.Contains(err.Error(), "decommissioned") {
+		t.Errorf("expected decommissioning parent error, got %v", err)
+	}
+
+	if err := CheckParentAllocatable("10.2.0.0/16", &Allocation{CIDR: "10.2.0.0/16"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckFreeReserve(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/22"}, MinFreePct: 25} // 1024 addresses, 256 held back
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/24"},
+		{CIDR: "10.0.0.0/26", ParentCIDR: strPtr("10.0.0.0/24")}, // inside its parent, not extra usage
+	}
+
+	// 768 -> 512 free stays above the 256 reserve
+	if err := allocator.CheckFreeReserve("prod", poolDef, existing, "10.0.1.0/24"); err != nil {
+		t.Errorf("expected allocation under the reserve to be allowed, got: %v", err)
+	}
+
+	// 768 -> 256 free exactly meets the reserve
+	if err := allocator.CheckFreeReserve("prod", poolDef, existing, "10.0.2.0/23"); err != nil {
+		t.Errorf("expected allocation down to the reserve to be allowed, got: %v", err)
+	}
+
+	existing = append(existing, Allocation{CIDR: "10.0.1.0/24"})
+
+	// 512 -> 0 free breaches the reserve
+	err := allocator.CheckFreeReserve("prod", poolDef, existing, "10.0.2.0/23")
+	if err == nil {
+		t.Fatal("expected allocation breaching the reserve to be rejected")
+	}
+	for _, want := range []string{"would leave 0 of 1,024 addresses free", "min_free_pct reserve of 25%", "512 addresses are free now"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error, got: %v", want, err)
+		}
+	}
+
+	// No reserve by default
+	poolDef.MinFreePct = 0
+	if err := allocator.CheckFreeReserve("prod", poolDef, existing, "10.0.2.0/23"); err != nil {
+		t.Errorf("expected no reserve by default, got: %v", err)
+	}
+}
+
+func TestPrecheckAllocation_FreeReserve(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/22"}, MinFreePct: 50})
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/23", ID: "id-1", Name: "half"})
+
+	warnings := NewAllocator().PrecheckAllocation(pools, db, AllocationRequest{Name: "more", PoolID: "prod", PrefixLen: 24})
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "min_free_pct") {
+		t.Errorf("expected a reserve warning, got %v", warnings)
+	}
+}
+
+func TestCheckPrefixFamily(t *testing.T) {
+	for _, tc := range []struct {
+		prefixLen int
+		cidrs     []string
+		want      string
+	}{
+		{33, []string{"10.0.0.0/16"}, "/33 is longer than an IPv4 address allows (at most /32)"},
+		{129, []string{"fd00::/48"}, "/129 is longer than an IPv6 address allows (at most /128)"},
+		{24, []string{"10.0.0.0/16"}, ""},
+		{32, []string{"10.0.0.0/16"}, ""},
+		{64, []string{"10.0.0.0/16", "fd00::/48"}, ""},
+		{33, []string{"not-a-cidr"}, ""},
+	} {
+		err := CheckPrefixFamily(tc.prefixLen, tc.cidrs)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("/%d in %v: unexpected error: %v", tc.prefixLen, tc.cidrs, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("/%d in %v: expected %q, got %v", tc.prefixLen, tc.cidrs, tc.want, err)
+		}
+	}
+}
+
+func TestPreviewAllocation_Satisfiable(t *testing.T) {
+	pools, db := precheckFixture()
+	allocator := NewAllocator()
+
+	cidr, err := allocator.PreviewAllocation(pools, db, AllocationRequest{PoolID: "small", PrefixLen: 26}, 0)
+	if err != nil || cidr != "10.0.0.128/26" {
+		t.Errorf("expected 10.0.0.128/26, got %s, %v", cidr, err)
+	}
+	cidr, err = allocator.PreviewAllocation(pools, db, AllocationRequest{ParentCIDR: "10.0.0.0/25", PrefixLen: 27}, 0)
+	if err != nil || cidr != "10.0.0.0/27" {
+		t.Errorf("expected 10.0.0.0/27, got %s, %v", cidr, err)
+	}
+
+	// Nothing was written
+	if n := len(db.GetAllocationsForPool("small")); n != 1 {
+		t.Errorf("expected the snapshot untouched, got %d allocations", n)
+	}
+}
+
+func TestPreviewAllocation_Unsatisfiable(t *testing.T) {
+	pools, db := precheckFixture()
+	pools.Pools["held"] = PoolDefinition{CIDR: []string{"10.1.0.0/16"}, Reserved: true}
+	allocator := NewAllocator()
+
+	for _, tc := range []struct {
+		req    AllocationRequest
+		reason string
+	}{
+		{AllocationRequest{PoolID: "small", PrefixLen: 24}, "no available /24 block"},
+		{AllocationRequest{PoolID: "missing", PrefixLen: 24}, `pool_id "missing" not found`},
+		{AllocationRequest{PoolID: "held", PrefixLen: 24}, "pool is reserved"},
+		{AllocationRequest{ParentCIDR: "10.0.0.0/25", PrefixLen: 24}, "larger than container"},
+		{AllocationRequest{ParentCIDR: "10.9.0.0/16", PrefixLen: 24}, `parent_cidr "10.9.0.0/16" not found`},
+	} {
+		cidr, err := allocator.PreviewAllocation(pools, db, tc.req, 0)
+		if err == nil || !strings.Contains(err.Error(), tc.reason) {
+			t.Errorf("%+v: expected %q, got %s, %v", tc.req, tc.reason, cidr, err)
+		}
+	}
+}
+
+func TestCheckFutureSiblings_RoomLeft(t *testing.T) {
+	parent := "10.0.0.0/22"
+	children := []Allocation{{CIDR: "10.0.0.0/24", ID: "a", Name: "subnet-a", ParentCIDR: &parent}}
+
+	// Taking 10.0.1.0/24 leaves 10.0.2.0/24 and 10.0.3.0/24
+	if err := CheckFutureSiblings(parent, children, []string{"10.0.1.0/24"}, 2); err != nil {
+		t.Errorf("expected room for 2 more /24s, got %v", err)
+	}
+}
+
+func TestCheckFutureSiblings_NoRoom(t *testing.T) {
+	parent := "10.0.0.0/22"
+	children := []Allocation{
+		{CIDR: "10.0.0.0/24", ID: "a", Name: "subnet-a", ParentCIDR: &parent},
+		// Splits the last /24, so only 10.0.2.0/24 stays whole
+		{CIDR: "10.0.3.128/25", ID: "b", Name: "subnet-b", ParentCIDR: &parent},
+	}
+
+	err := CheckFutureSiblings(parent, children, []string{"10.0.1.0/24"}, 2)
+	if err == nil {
+		t.Fatal("expected the shortfall to be rejected")
+	}
+	if !strings.Contains(err.Error(), "room for 1 more /24 blocks, 1 short of ensure_future_siblings = 2") {
+		t.Errorf("expected the shortfall in the error, got %v", err)
+	}
+}
+
+func TestCheckFutureSiblings_Stripe(t *testing.T) {
+	// Every block of a contiguous run counts as taken
+	err := CheckFutureSiblings("10.0.0.0/22", nil, []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24"}, 2)
+	if err == nil || !strings.Contains(err.Error(), "1 short") {
+		t.Errorf("expected 1 short, got %v", err)
+	}
+	if err := CheckFutureSiblings("10.0.0.0/22", nil, []string{"10.0.0.0/24"}, 0); err != nil {
+		t.Errorf("expected no check without a count, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// AWS managed prefix list limits.
+const (
+	PrefixListMaxEntries     = 1000 // Largest max_entries AWS accepts for a prefix list
+	PrefixListMaxDescription = 255  // Longest entry description AWS accepts
+)
+
+// PrefixListEntry is one entry of an AWS managed prefix list.
+type PrefixListEntry struct {
+	CIDR        string
+	Description string
+}
+
+// FilterByLabels returns the allocations carrying every one of labels.
+func FilterByLabels(allocations []Allocation, labels []string) []Allocation {
+	var result []Allocation
+	for _, alloc := range allocations {
+		if hasLabels(alloc, labels) {
+			result = append(result, alloc)
+		}
+	}
+	return result
+}
+
+// hasLabels reports whether an allocation carries every one of labels.
+func hasLabels(alloc Allocation, labels []string) bool {
+	for _, want := range labels {
+		found := false
+		for _, label := range alloc.Labels {
+			if label == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// PrefixListEntries shapes allocations as prefix list entries, described
+// by allocation name and ordered by CIDR. Blocks inside another listed
+// block, like sub-allocations of a listed parent, would only repeat it and
+// are left out, as are claims. family, when set to "IPv4" or "IPv6", keeps
+// only that family, since a prefix list holds one.
+func PrefixListEntries(allocations []Allocation, family string) []PrefixListEntry {
+	type candidate struct {
+		network *net.IPNet
+		alloc   Allocation
+	}
+	var candidates []candidate
+	for _, alloc := range allocations {
+		if alloc.IsClaim() {
+			continue
+		}
+		_, network, err := net.ParseCIDR(alloc.CIDR)
+		if err != nil {
+			continue
+		}
+		isIPv4 := network.IP.To4() != nil
+		if (family == "IPv4" && !isIPv4) || (family == "IPv6" && isIPv4) {
+			continue
+		}
+		candidates = append(candidates, candidate{network: network, alloc: alloc})
+	}
+
+	// Larger blocks first at each address, so a block sorts after any
+	// block containing it
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if c := compareIPs(candidates[i].network.IP, candidates[j].network.IP); c != 0 {
+			return c < 0
+		}
+		onesI, _ := candidates[i].network.Mask.Size()
+		onesJ, _ := candidates[j].network.Mask.Size()
+		return onesI < onesJ
+	})
+
+	entries := make([]PrefixListEntry, 0, len(candidates))
+	var last *net.IPNet
+	for _, c := range candidates {
+		if last != nil && len(last.IP) == len(c.network.IP) && last.Contains(c.network.IP) {
+			continue
+		}
+		last = c.network
+
+		description := c.alloc.Name
+		if len(description) > PrefixListMaxDescription {
+			description = strings.ToValidUTF8(description[:PrefixListMaxDescription], "")
+		}
+		entries = append(entries, PrefixListEntry{CIDR: c.network.String(), Description: description})
+	}
+	return entries
+}
+
+// CheckPrefixListSize rejects more entries than a prefix list created with
+// maxEntries can hold.
+func CheckPrefixListSize(entries []PrefixListEntry, maxEntries int) error {
+	if len(entries) > maxEntries {
+		return fmt.Errorf("%d entries, more than the prefix list's max_entries of %d", len(entries), maxEntries)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrefixListEntries_Pool(t *testing.T) {
+	parent := "10.0.0.0/16"
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.1.0.0/16", ID: "id-1", Name: "vpc-b"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/16", ID: "id-2", Name: "vpc-a"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.4.0/24", ID: "id-3", Name: "subnet-a", ParentCIDR: &parent})
+	db.AddAllocation("prod", Allocation{CIDR: "10.2.0.0/24", ID: "id-4", Name: "claim", ExpiresAt: "2099-01-01T00:00:00Z"})
+	db.AddAllocation("prod", Allocation{CIDR: "fd00::/48", ID: "id-5", Name: "vpc-v6"})
+
+	got := PrefixListEntries(db.GetAllocationsForPool("prod"), "IPv4")
+	want := []PrefixListEntry{
+		{CIDR: "10.0.0.0/16", Description: "vpc-a"},
+		{CIDR: "10.1.0.0/16", Description: "vpc-b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if got := PrefixListEntries(db.GetAllocationsForPool("prod"), "IPv6"); len(got) != 1 || got[0].CIDR != "fd00::/48" {
+		t.Errorf("expected only the IPv6 block, got %v", got)
+	}
+}
+
+func TestPrefixListEntries_TruncatesDescription(t *testing.T) {
+	got := PrefixListEntries([]Allocation{{CIDR: "10.0.0.0/24", Name: strings.Repeat("a", 300)}}, "")
+	if len(got) != 1 || len(got[0].Description) != PrefixListMaxDescription {
+		t.Errorf("expected a %d character description, got %v", PrefixListMaxDescription, got)
+	}
+}
+
+func TestFilterByLabels(t *testing.T) {
+	allocations := []Allocation{
+		{CIDR: "10.0.0.0/24", Name: "a", Labels: []string{"egress", "prod"}},
+		{CIDR: "10.0.1.0/24", Name: "b", Labels: []string{"egress"}},
+		{CIDR: "10.0.2.0/24", Name: "c"},
+	}
+
+	got := FilterByLabels(allocations, []string{"prod", "egress"})
+	if len(got) != 1 || got[0].Name != "a" {
+		t.Errorf("expected only a to carry both labels, got %v", got)
+	}
+}
+
+func TestCheckPrefixListSize(t *testing.T) {
+	entries := PrefixListEntries([]Allocation{
+		{CIDR: "10.0.0.0/24", Name: "a"},
+		{CIDR: "10.0.2.0/24", Name: "b"},
+		{CIDR: "10.0.4.0/24", Name: "c"},
+	}, "")
+
+	if err := CheckPrefixListSize(entries, 3); err != nil {
+		t.Errorf("unexpected error at the limit: %v", err)
+	}
+	err := CheckPrefixListSize(entries, 2)
+	if err == nil || !strings.Contains(err.Error(), "3 entries, more than the prefix list's max_entries of 2") {
+		t.Errorf("expected a max_entries error, got %v", err)
+	}
+}
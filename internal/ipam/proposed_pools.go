@@ -0,0 +1,34 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+// AddProposed adds the pools from an open pull request that the branch does
+// not define yet, marking them provisional. Pools already defined always
+// win, so a pull request editing an existing pool changes nothing, and the
+// first pull request to propose a pool keeps it.
+func (p *PoolsConfig) AddProposed(proposed *PoolsConfig, prNumber int) {
+	if proposed == nil {
+		return
+	}
+	for poolID, poolDef := range proposed.Pools {
+		if _, exists := p.Pools[poolID]; exists {
+			continue
+		}
+		if p.Pools == nil {
+			p.Pools = make(map[string]PoolDefinition)
+		}
+		if p.Proposed == nil {
+			p.Proposed = make(map[string]int)
+		}
+		p.Pools[poolID] = poolDef
+		p.Proposed[poolID] = prNumber
+	}
+}
+
+// ProposedIn returns the pull request a provisional pool was read from.
+// ok is false for pools defined on the branch.
+func (p *PoolsConfig) ProposedIn(poolID string) (prNumber int, ok bool) {
+	prNumber, ok = p.Proposed[poolID]
+	return prNumber, ok
+}
@@ -0,0 +1,34 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import "testing"
+
+func TestAddProposed(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+
+	proposed := NewPoolsConfig()
+	proposed.AddPool("prod", PoolDefinition{CIDR: []string{"10.9.0.0/16"}})
+	proposed.AddPool("staging", PoolDefinition{CIDR: []string{"10.1.0.0/16"}})
+	pools.AddProposed(proposed, 42)
+
+	if prod, _ := pools.GetPool("prod"); prod.CIDR[0] != "10.0.0.0/16" {
+		t.Errorf("expected the branch definition of prod to win, got %v", prod.CIDR)
+	}
+	if _, ok := pools.ProposedIn("prod"); ok {
+		t.Error("expected prod not to be provisional")
+	}
+	if pr, ok := pools.ProposedIn("staging"); !ok || pr != 42 {
+		t.Errorf("expected staging from PR #42, got %d %v", pr, ok)
+	}
+
+	// A later pull request proposing the same pool does not replace it
+	later := NewPoolsConfig()
+	later.AddPool("staging", PoolDefinition{CIDR: []string{"10.2.0.0/16"}})
+	pools.AddProposed(later, 43)
+	if pr, _ := pools.ProposedIn("staging"); pr != 42 {
+		t.Errorf("expected staging to stay from PR #42, got #%d", pr)
+	}
+}
@@ -6,6 +6,7 @@ package ipam
 import (
 	"fmt"
 	"net"
+	"path"
 	"sort"
 	"strings"
 )
@@ -27,6 +28,28 @@ var PrivateRanges = []PrivateRange{
 	{Name: "Class C", CIDR: "192.168.0.0/16", StartIP: "192.168.0.0", EndIP: "192.168.255.255", TotalAddrs: 65536, PrefixLen: 16},
 }
 
+// Documentation detail levels for the main README.
+const (
+	DocsDetailFull    = "full"    // Address space layout including free ranges
+	DocsDetailSummary = "summary" // Per-pool utilization and counts only
+)
+
+// DefaultPoolPageSize is the number of top-level allocations rendered on a
+// single pool page before it is split into numbered pages.
+const DefaultPoolPageSize = 500
+
+// PoolPagesDir is where the generated pool pages are written.
+const PoolPagesDir = ".github/ipam/pools"
+
+// GenerateOptions controls what the generated docs contain.
+type GenerateOptions struct {
+	DetailLevel string // DocsDetailFull (default) or DocsDetailSummary
+	PageSize    int    // Top-level allocations per pool page; defaults to DefaultPoolPageSize
+
+	ImportBlocks bool // Also write import blocks for every allocation to ImportBlocksPath
+	NetBoxExport bool // Also write prefixes in NetBox's import shape to NetBoxExportPath
+}
+
 // GeneratedFiles holds all generated markdown files.
 type GeneratedFiles struct {
 	Files map[string]string // path -> content
@@ -34,24 +57,72 @@ type GeneratedFiles struct {
 
 // GenerateAllFiles generates the main README and all pool detail pages.
 func GenerateAllFiles(pools *PoolsConfig, allocations *AllocationsDatabase) *GeneratedFiles {
+	return GenerateAllFilesWithOptions(pools, allocations, GenerateOptions{})
+}
+
+// GenerateAllFilesWithOptions is GenerateAllFiles with control over the
+// README detail level and pool page size.
+func GenerateAllFilesWithOptions(pools *PoolsConfig, allocations *AllocationsDatabase, opts GenerateOptions) *GeneratedFiles {
 	files := &GeneratedFiles{
 		Files: make(map[string]string),
 	}
 
 	// Generate main README
-	files.Files[".github/README.md"] = generateMainREADME(pools, allocations)
+	if opts.DetailLevel == DocsDetailSummary {
+		files.Files[".github/README.md"] = generateSummaryREADME(pools, allocations)
+	} else {
+		files.Files[".github/README.md"] = generateMainREADME(pools, allocations)
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPoolPageSize
+	}
 
 	// Generate pool detail pages
 	if pools != nil && pools.Pools != nil {
 		for poolName := range pools.Pools {
-			path := fmt.Sprintf(".github/ipam/pools/%s.md", poolName)
-			files.Files[path] = generatePoolPage(poolName, pools, allocations)
+			for i, content := range generatePoolPages(poolName, pools, allocations, pageSize) {
+				path := fmt.Sprintf("%s/%s", PoolPagesDir, poolPageName(poolName, i+1))
+				files.Files[path] = content
+			}
 		}
 	}
 
+	if opts.ImportBlocks {
+		files.Files[ImportBlocksPath] = GenerateImportBlocks(allocations)
+	}
+
+	if opts.NetBoxExport {
+		files.Files[NetBoxExportPath] = GenerateNetBoxExport(pools, allocations)
+	}
+
 	return files
 }
 
+// poolPageName returns the path of a pool page relative to PoolPagesDir.
+// The first page keeps the plain pool name so links from the README stay
+// stable; later pages go in a directory named after the pool, so they
+// can't collide with the first page of a pool called <pool>-N.
+func poolPageName(poolName string, page int) string {
+	if page <= 1 {
+		return poolName + ".md"
+	}
+	return fmt.Sprintf("%s/page-%d.md", poolName, page)
+}
+
+// poolPageLink returns the link from one page of a pool to another,
+// relative to the directory the linking page lives in.
+func poolPageLink(poolName string, from, to int) string {
+	if from <= 1 {
+		return poolPageName(poolName, to)
+	}
+	if to <= 1 {
+		return "../" + path.Base(poolName) + ".md"
+	}
+	return path.Base(poolPageName(poolName, to))
+}
+
 func generateMainREADME(pools *PoolsConfig, allocations *AllocationsDatabase) string {
 	var sb strings.Builder
 
@@ -67,8 +138,14 @@ func generateMainREADME(pools *PoolsConfig, allocations *AllocationsDatabase) st
 	sb.WriteString("|:-------|:------------|\n")
 	sb.WriteString("| 🔵&nbsp;&nbsp;Allocated | In active use. Do not reclaim. |\n")
 	sb.WriteString("| 🟠&nbsp;&nbsp;Reserved | Saved for future projects. Contact Network Team. |\n")
+	sb.WriteString("| 🔴&nbsp;&nbsp;Decommissioning | Being torn down. Not yet free. |\n")
 	sb.WriteString("| ⚪&nbsp;&nbsp;Available | Free space. Safe to allocate. |\n\n")
 
+	var stats AllocationStats
+	if allocations != nil {
+		stats = allocations.Stats()
+	}
+
 	// Process each private range
 	for _, pr := range PrivateRanges {
 		poolsInRange := getPoolsInRange(pools, pr.CIDR)
@@ -81,7 +158,7 @@ func generateMainREADME(pools *PoolsConfig, allocations *AllocationsDatabase) st
 		}
 
 		// Build block table for this range
-		blocks := buildRangeBlocks(pr, poolsInRange, pools, allocations)
+		blocks := buildRangeBlocks(pr, poolsInRange, pools, stats)
 
 		// Render as table
 		sb.WriteString("| Status | Pool Name | CIDR | Size | Allocated | Utilization |\n")
@@ -116,6 +193,59 @@ func generateMainREADME(pools *PoolsConfig, allocations *AllocationsDatabase) st
 	return sb.String()
 }
 
+// generateSummaryREADME renders a compact README listing each pool's
+// utilization and allocation count, without free ranges. Per-allocation
+// detail lives on the pool pages.
+func generateSummaryREADME(pools *PoolsConfig, allocations *AllocationsDatabase) string {
+	var sb strings.Builder
+
+	sb.WriteString("# IP Address Space Overview\n\n")
+	sb.WriteString("> ⚠️&nbsp;&nbsp;**IMPORTANT**&nbsp;&nbsp;⚠️<br>\n")
+	sb.WriteString(">\n")
+	sb.WriteString("> This documentation is automatically generated by the `easytofu/github-ipam` Terraform provider. Do not manually edit\n")
+	sb.WriteString("> this page, changes will be overwritten when allocations are updated. For more information please view the Terraform\n")
+	sb.WriteString("> provider registry at: https://registry.terraform.io/providers/easytofu/github-ipam/latest/docs\n\n")
+
+	var stats AllocationStats
+	if allocations != nil {
+		stats = allocations.Stats()
+	}
+
+	for _, pr := range PrivateRanges {
+		poolsInRange := getPoolsInRange(pools, pr.CIDR)
+
+		sb.WriteString(fmt.Sprintf("## %s Address Space (%s - %s)\n\n", pr.Name, pr.StartIP, pr.EndIP))
+
+		if len(poolsInRange) == 0 {
+			sb.WriteString("*No pools allocated*\n\n")
+			continue
+		}
+
+		sort.Slice(poolsInRange, func(i, j int) bool {
+			return compareCIDRs(poolsInRange[i].CIDR, poolsInRange[j].CIDR)
+		})
+
+		sb.WriteString("| Pool Name | CIDR | Allocations | Allocated | Utilization |\n")
+		sb.WriteString("|:----------|:-----|------------:|:----------|:------------|\n")
+
+		for _, info := range poolsInRange {
+			block := makePoolBlock(info, pools, stats)
+			allocated, util := "—", "—"
+			if block.Type == "pool" {
+				allocated = fmt.Sprintf("%s/%s (%.1f%%)", formatNumber(block.UsedAddrs), formatNumber(block.Size), block.Utilization)
+				util = renderUtilBarOnly(block.Utilization)
+			}
+			sb.WriteString(fmt.Sprintf("| [%s](%s) | `%s` | %s | %s | %s |\n",
+				block.Name, block.Link, block.CIDR,
+				formatNumber(uint64(stats.AllocationsByPool[info.Name])), allocated, util))
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
 // Block represents a block in the address space.
 type Block struct {
 	CIDR        string
@@ -188,7 +318,7 @@ func renderUtilBarOnly(pct float64) string {
 	return strings.Repeat("█", filled) + strings.Repeat("░", 10-filled)
 }
 
-func buildRangeBlocks(pr PrivateRange, poolsInRange []PoolInfo, pools *PoolsConfig, allocations *AllocationsDatabase) []Block {
+func buildRangeBlocks(pr PrivateRange, poolsInRange []PoolInfo, pools *PoolsConfig, stats AllocationStats) []Block {
 	var blocks []Block
 
 	_, rangeNet, _ := net.ParseCIDR(pr.CIDR)
@@ -226,7 +356,7 @@ func buildRangeBlocks(pr PrivateRange, poolsInRange []PoolInfo, pools *PoolsConf
 		}
 
 		// The pool itself
-		blocks = append(blocks, makePoolBlock(pr.info, pools, allocations))
+		blocks = append(blocks, makePoolBlock(pr.info, pools, stats))
 		current = pr.end
 	}
 
@@ -250,7 +380,7 @@ func makeAvailableBlock(start, end uint32) Block {
 	}
 }
 
-func makePoolBlock(info PoolInfo, pools *PoolsConfig, allocations *AllocationsDatabase) Block {
+func makePoolBlock(info PoolInfo, pools *PoolsConfig, stats AllocationStats) Block {
 	_, pNet, _ := net.ParseCIDR(info.CIDR)
 	pStart := ipToUint32(pNet.IP)
 	pSize := cidrToAddresses(info.CIDR)
@@ -266,14 +396,7 @@ func makePoolBlock(info PoolInfo, pools *PoolsConfig, allocations *AllocationsDa
 	}
 
 	// Calculate utilization
-	var usedAddrs uint64
-	if allocations != nil {
-		for _, alloc := range allocations.GetAllocationsForPool(info.Name) {
-			if alloc.ParentCIDR == nil {
-				usedAddrs += cidrToAddresses(alloc.CIDR)
-			}
-		}
-	}
+	usedAddrs := stats.AddressesByPool[info.Name]
 	util := 0.0
 	if pSize > 0 {
 		util = float64(usedAddrs) / float64(pSize) * 100
@@ -294,12 +417,13 @@ func makePoolBlock(info PoolInfo, pools *PoolsConfig, allocations *AllocationsDa
 	}
 }
 
-func generatePoolPage(poolName string, pools *PoolsConfig, allocations *AllocationsDatabase) string {
-	var sb strings.Builder
-
+// generatePoolPages renders a pool's detail page. Pools with more than
+// pageSize top-level allocations are split across numbered pages; the
+// overview is only shown on the first page.
+func generatePoolPages(poolName string, pools *PoolsConfig, allocations *AllocationsDatabase, pageSize int) []string {
 	poolDef, exists := pools.GetPool(poolName)
 	if !exists {
-		return fmt.Sprintf("# Pool: %s\n\n*Pool not found*\n", poolName)
+		return []string{fmt.Sprintf("# Pool: %s\n\n*Pool not found*\n", poolName)}
 	}
 
 	cidr := ""
@@ -307,23 +431,25 @@ func generatePoolPage(poolName string, pools *PoolsConfig, allocations *Allocati
 		cidr = poolDef.CIDR[0]
 	}
 
-	sb.WriteString(fmt.Sprintf("# %s\n\n", poolName))
-	sb.WriteString("> ⚠️&nbsp;&nbsp;**IMPORTANT**&nbsp;&nbsp;⚠️<br>\n")
-	sb.WriteString(">\n")
-	sb.WriteString("> This documentation is automatically generated by the `easytofu/github-ipam` Terraform provider. Do not manually edit\n")
-	sb.WriteString("> this page, changes will be overwritten when allocations are updated. For more information please view the Terraform\n")
-	sb.WriteString("> provider registry at: https://registry.terraform.io/providers/easytofu/github-ipam/latest/docs\n\n")
-	sb.WriteString("[← Back to Overview](../README.md)\n\n")
+	var header strings.Builder
+	header.WriteString(fmt.Sprintf("# %s\n\n", poolName))
+	header.WriteString("> ⚠️&nbsp;&nbsp;**IMPORTANT**&nbsp;&nbsp;⚠️<br>\n")
+	header.WriteString(">\n")
+	header.WriteString("> This documentation is automatically generated by the `easytofu/github-ipam` Terraform provider. Do not manually edit\n")
+	header.WriteString("> this page, changes will be overwritten when allocations are updated. For more information please view the Terraform\n")
+	header.WriteString("> provider registry at: https://registry.terraform.io/providers/easytofu/github-ipam/latest/docs\n\n")
+	header.WriteString("[← Back to Overview](../README.md)\n\n")
 
 	if poolDef.Reserved {
-		sb.WriteString("> 🟠 **RESERVED** — This pool is reserved for future use. Allocations are not permitted.\n\n")
+		header.WriteString("> 🟠 **RESERVED** — This pool is reserved for future use. Allocations are not permitted.\n\n")
 	}
 
 	if poolDef.Description != "" {
-		sb.WriteString(fmt.Sprintf("*%s*\n\n", poolDef.Description))
+		header.WriteString(fmt.Sprintf("*%s*\n\n", poolDef.Description))
 	}
 
 	// Pool info
+	var overview strings.Builder
 	poolSize := cidrToAddresses(cidr)
 	_, pNet, _ := net.ParseCIDR(cidr)
 	pStart := ipToUint32(pNet.IP)
@@ -332,29 +458,33 @@ func generatePoolPage(poolName string, pools *PoolsConfig, allocations *Allocati
 
 	// Get allocations
 	var poolAllocs []Allocation
+	var usedAddrs uint64
 	if allocations != nil {
 		poolAllocs = allocations.GetAllocationsForPool(poolName)
+		usedAddrs = allocations.Stats().AddressesByPool[poolName]
 	}
 
-	// Calculate stats
-	var usedAddrs uint64
-	for _, alloc := range poolAllocs {
-		if alloc.ParentCIDR == nil {
-			usedAddrs += cidrToAddresses(alloc.CIDR)
-		}
-	}
 	util := 0.0
 	if poolSize > 0 {
 		util = float64(usedAddrs) / float64(poolSize) * 100
 	}
 
-	sb.WriteString("## Overview\n\n")
-	sb.WriteString("| Property | Value |\n")
-	sb.WriteString("|:---------|:------|\n")
-	sb.WriteString(fmt.Sprintf("| Pool Name | %s |\n", poolName))
-	sb.WriteString(fmt.Sprintf("| CIDR | `%s` (%s - %s) |\n", cidr, rangeStart, rangeEnd))
-	sb.WriteString(fmt.Sprintf("| Total Addresses | %s |\n", formatNumber(poolSize)))
-	sb.WriteString(fmt.Sprintf("| Allocated | %s/%s (%.1f%%) |\n", formatNumber(usedAddrs), formatNumber(poolSize), util))
+	overview.WriteString("## Overview\n\n")
+	overview.WriteString("| Property | Value |\n")
+	overview.WriteString("|:---------|:------|\n")
+	overview.WriteString(fmt.Sprintf("| Pool Name | %s |\n", poolName))
+	overview.WriteString(fmt.Sprintf("| CIDR | `%s` (%s - %s) |\n", cidr, rangeStart, rangeEnd))
+	overview.WriteString(fmt.Sprintf("| Total Addresses | %s |\n", formatNumber(poolSize)))
+	overview.WriteString(fmt.Sprintf("| Allocated | %s/%s (%.1f%%) |\n", formatNumber(usedAddrs), formatNumber(poolSize), util))
+	if len(poolDef.ReservedRanges) > 0 {
+		overview.WriteString(fmt.Sprintf("| Reserved Ranges | `%s` |\n", strings.Join(poolDef.ReservedRanges, "`, `")))
+	}
+	if poolDef.SmallBlockRegion != "" {
+		overview.WriteString(fmt.Sprintf("| Small Block Region | `%s` (/%d and longer) |\n", poolDef.SmallBlockRegion, poolDef.smallBlockMask()))
+	}
+	if rb := poolDef.routeBoundary(); rb > 0 {
+		overview.WriteString(fmt.Sprintf("| Route Boundary | /%d |\n", rb))
+	}
 	// Add metadata items as rows
 	if len(poolDef.Metadata) > 0 {
 		var metaKeys []string
@@ -363,13 +493,10 @@ func generatePoolPage(poolName string, pools *PoolsConfig, allocations *Allocati
 		}
 		sort.Strings(metaKeys)
 		for _, k := range metaKeys {
-			sb.WriteString(fmt.Sprintf("| %s | %s |\n", toTitleCase(k), poolDef.Metadata[k]))
+			overview.WriteString(fmt.Sprintf("| %s | %s |\n", toTitleCase(k), poolDef.Metadata[k]))
 		}
 	}
-	sb.WriteString("\n")
-
-	// Allocations table with available gaps
-	sb.WriteString("## Allocations\n\n")
+	overview.WriteString("\n")
 
 	// Separate top-level and child allocations
 	var topLevelAllocs []Allocation
@@ -382,6 +509,27 @@ func generatePoolPage(poolName string, pools *PoolsConfig, allocations *Allocati
 		}
 	}
 
+	// Reserved ranges get their own rows among the allocations, unless an
+	// allocation made before the range was reserved overlaps it
+	reservedRows := make(map[string]bool)
+	for _, reserved := range poolDef.ReservedRanges {
+		_, rNet, err := net.ParseCIDR(reserved)
+		if err != nil || rNet.IP.To4() == nil || !pNet.Contains(rNet.IP) {
+			continue
+		}
+		overlaps := false
+		for _, alloc := range topLevelAllocs {
+			if _, aNet, err := net.ParseCIDR(alloc.CIDR); err == nil && networksOverlap(rNet, aNet) {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			reservedRows[rNet.String()] = true
+			topLevelAllocs = append(topLevelAllocs, Allocation{CIDR: rNet.String()})
+		}
+	}
+
 	// Sort child allocations by CIDR
 	for parentCIDR := range childAllocsByParent {
 		children := childAllocsByParent[parentCIDR]
@@ -391,13 +539,14 @@ func generatePoolPage(poolName string, pools *PoolsConfig, allocations *Allocati
 		childAllocsByParent[parentCIDR] = children
 	}
 
-	sb.WriteString("| Status | Name | CIDR | Addresses |\n")
-	sb.WriteString("|:-------|:-----|:-----|----------:|\n")
-
+	// Allocations table with available gaps, one entry per top-level
+	// allocation so sub-allocations and gaps stay on the same page
+	var entries []string
+	var totalAddrs, totalUsable uint64 // Top-level allocations only; children sit inside them
 	if len(topLevelAllocs) == 0 {
 		// Show entire pool as available
 		cidrWithRange := fmt.Sprintf("`%s` (%s - %s)", cidr, rangeStart, rangeEnd)
-		sb.WriteString(fmt.Sprintf("| ⚪&nbsp;&nbsp;Available | — | %s | %s |\n",
+		entries = append(entries, fmt.Sprintf("| ⚪&nbsp;&nbsp;Available | — | %s | %s | — |\n",
 			cidrWithRange, formatNumber(poolSize)))
 	} else {
 		// Sort allocations by CIDR
@@ -410,6 +559,7 @@ func generatePoolPage(poolName string, pools *PoolsConfig, allocations *Allocati
 		poolEnd := pStart + uint32(poolSize)
 
 		for _, alloc := range topLevelAllocs {
+			var rows strings.Builder
 			_, aNet, _ := net.ParseCIDR(alloc.CIDR)
 			aStart := ipToUint32(aNet.IP)
 			aSize := cidrToAddresses(alloc.CIDR)
@@ -420,18 +570,26 @@ func generatePoolPage(poolName string, pools *PoolsConfig, allocations *Allocati
 				gapSize := aStart - current
 				gapCIDR := findBestCIDR(current, gapSize)
 				gapRange := fmt.Sprintf("`%s` (%s - %s)", gapCIDR, uint32ToIP(current), uint32ToIP(aStart-1))
-				sb.WriteString(fmt.Sprintf("| ⚪&nbsp;&nbsp;Available | — | %s | %s |\n",
+				rows.WriteString(fmt.Sprintf("| ⚪&nbsp;&nbsp;Available | — | %s | %s | — |\n",
 					gapRange, formatNumber(uint64(gapSize))))
 			}
 
-			// Show the allocation
-			status := "🔵&nbsp;&nbsp;Allocated"
-			if alloc.Reserved {
-				status = "🟠&nbsp;&nbsp;Reserved"
+			if reservedRows[alloc.CIDR] {
+				cidrWithRange := fmt.Sprintf("`%s` (%s - %s)", alloc.CIDR, uint32ToIP(aStart), uint32ToIP(aEnd-1))
+				rows.WriteString(fmt.Sprintf("| 🟠&nbsp;&nbsp;Reserved | *Reserved range (pools.yaml)* | %s | %s | — |\n",
+					cidrWithRange, formatNumber(aSize)))
+				current = aEnd
+				entries = append(entries, rows.String())
+				continue
 			}
+
+			// Show the allocation
+			status := allocationStatusLabel(alloc)
 			cidrWithRange := fmt.Sprintf("`%s` (%s - %s)", alloc.CIDR, uint32ToIP(aStart), uint32ToIP(aEnd-1))
-			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
-				status, alloc.Name, cidrWithRange, formatNumber(aSize)))
+			rows.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+				status, allocationLabel(alloc), cidrWithRange, formatNumber(aSize), formatNumber(usableHosts(alloc.CIDR))))
+			totalAddrs += aSize
+			totalUsable += usableHosts(alloc.CIDR)
 
 			// Show child allocations (subnets) nested under this allocation
 			if children, hasChildren := childAllocsByParent[alloc.CIDR]; hasChildren {
@@ -441,18 +599,16 @@ func generatePoolPage(poolName string, pools *PoolsConfig, allocations *Allocati
 					cSize := cidrToAddresses(child.CIDR)
 					cEnd := cStart + uint32(cSize)
 
-					childStatus := "🔵&nbsp;&nbsp;Allocated"
-					if child.Reserved {
-						childStatus = "🟠&nbsp;&nbsp;Reserved"
-					}
+					childStatus := allocationStatusLabel(child)
 					childCIDRRange := fmt.Sprintf("`%s` (%s - %s)", child.CIDR, uint32ToIP(cStart), uint32ToIP(cEnd-1))
 					// Indent child name with └ prefix
-					sb.WriteString(fmt.Sprintf("| %s | &nbsp;&nbsp;└&nbsp;%s | %s | %s |\n",
-						childStatus, child.Name, childCIDRRange, formatNumber(cSize)))
+					rows.WriteString(fmt.Sprintf("| %s | &nbsp;&nbsp;└&nbsp;%s | %s | %s | %s |\n",
+						childStatus, allocationLabel(child), childCIDRRange, formatNumber(cSize), formatNumber(usableHosts(child.CIDR))))
 				}
 			}
 
 			current = aEnd
+			entries = append(entries, rows.String())
 		}
 
 		// Show available gap at the end
@@ -460,14 +616,84 @@ func generatePoolPage(poolName string, pools *PoolsConfig, allocations *Allocati
 			gapSize := poolEnd - current
 			gapCIDR := findBestCIDR(current, gapSize)
 			gapRange := fmt.Sprintf("`%s` (%s - %s)", gapCIDR, uint32ToIP(current), uint32ToIP(poolEnd-1))
-			sb.WriteString(fmt.Sprintf("| ⚪&nbsp;&nbsp;Available | — | %s | %s |\n",
-				gapRange, formatNumber(uint64(gapSize))))
+			entries[len(entries)-1] += fmt.Sprintf("| ⚪&nbsp;&nbsp;Available | — | %s | %s | — |\n",
+				gapRange, formatNumber(uint64(gapSize)))
 		}
 	}
 
-	sb.WriteString("\n")
+	pageCount := (len(entries) + pageSize - 1) / pageSize
+	pages := make([]string, 0, pageCount)
+	for page := 1; page <= pageCount; page++ {
+		var pb strings.Builder
+		pb.WriteString(header.String())
+		if page == 1 {
+			pb.WriteString(overview.String())
+		}
 
-	return sb.String()
+		pb.WriteString("## Allocations\n\n")
+		if pageCount > 1 {
+			pb.WriteString(poolPageNav(poolName, page, pageCount))
+		}
+		pb.WriteString("| Status | Name | CIDR | Addresses | Usable Hosts |\n")
+		pb.WriteString("|:-------|:-----|:-----|----------:|-------------:|\n")
+
+		last := page * pageSize
+		if last > len(entries) {
+			last = len(entries)
+		}
+		for _, entry := range entries[(page-1)*pageSize : last] {
+			pb.WriteString(entry)
+		}
+		if page == pageCount && len(topLevelAllocs) > 0 {
+			pb.WriteString(fmt.Sprintf("| **Totals** | | | **%s** | **%s** |\n",
+				formatNumber(totalAddrs), formatNumber(totalUsable)))
+		}
+		pb.WriteString("\n")
+
+		pages = append(pages, pb.String())
+	}
+
+	return pages
+}
+
+// poolPageNav renders the page links shown above a paginated allocations table.
+func poolPageNav(poolName string, page, pageCount int) string {
+	parts := []string{fmt.Sprintf("Page %d of %d", page, pageCount)}
+	if page > 1 {
+		parts = append(parts, fmt.Sprintf("[← Previous](%s)", poolPageLink(poolName, page, page-1)))
+	}
+	if page < pageCount {
+		parts = append(parts, fmt.Sprintf("[Next →](%s)", poolPageLink(poolName, page, page+1)))
+	}
+	return strings.Join(parts, " · ") + "\n\n"
+}
+
+// allocationStatusLabel returns the status icon and label for an allocation row.
+func allocationStatusLabel(alloc Allocation) string {
+	switch alloc.GetStatus() {
+	case StatusReservation:
+		return "🟠&nbsp;&nbsp;Reserved"
+	case StatusDecommissioning:
+		return "🔴&nbsp;&nbsp;Decommissioning"
+	default:
+		return "🔵&nbsp;&nbsp;Allocated"
+	}
+}
+
+// allocationLabel returns the name shown in an allocation row, with the
+// region and VLAN ID when they are set.
+func allocationLabel(alloc Allocation) string {
+	var tags []string
+	if alloc.Region != "" {
+		tags = append(tags, alloc.Region)
+	}
+	if alloc.VLANID != 0 {
+		tags = append(tags, fmt.Sprintf("VLAN %d", alloc.VLANID))
+	}
+	if len(tags) == 0 {
+		return alloc.Name
+	}
+	return fmt.Sprintf("%s (%s)", alloc.Name, strings.Join(tags, ", "))
 }
 
 // PoolInfo holds pool information for sorting.
@@ -515,6 +741,21 @@ func cidrToAddresses(cidr string) uint64 {
 	return uint64(1) << (bits - ones)
 }
 
+// usableHosts returns the host addresses of a block. IPv4 blocks lose their
+// network and broadcast addresses, except /31 point-to-point links (RFC
+// 3021) and /32 host routes, which use every address.
+func usableHosts(cidr string) uint64 {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return 0
+	}
+	addrs := cidrToAddresses(cidr)
+	if ones, bits := network.Mask.Size(); bits == 32 && ones < 31 {
+		return addrs - 2
+	}
+	return addrs
+}
+
 func formatNumber(n uint64) string {
 	// Format with thousands separators
 	s := fmt.Sprintf("%d", n)
@@ -529,13 +770,20 @@ func formatNumber(n uint64) string {
 	return string(result)
 }
 
+// compareCIDRs orders blocks by network address, then larger blocks first
+// so a parent sorts ahead of a child sharing its start address.
 func compareCIDRs(a, b string) bool {
-	ipA, _, _ := net.ParseCIDR(a)
-	ipB, _, _ := net.ParseCIDR(b)
-	if ipA == nil || ipB == nil {
+	_, netA, errA := net.ParseCIDR(a)
+	_, netB, errB := net.ParseCIDR(b)
+	if errA != nil || errB != nil {
 		return a < b
 	}
-	return ipToUint32(ipA) < ipToUint32(ipB)
+	if c := compareIPs(netA.IP, netB.IP); c != 0 {
+		return c < 0
+	}
+	onesA, _ := netA.Mask.Size()
+	onesB, _ := netB.Mask.Size()
+	return onesA < onesB
 }
 
 // ipToUint32 is defined in pools.go (shared in ipam package)
@@ -4,6 +4,7 @@
 package ipam
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -392,6 +393,25 @@ func TestPoolPage_ReservedAllocationIcon(t *testing.T) {
 	}
 }
 
+func TestPoolPage_DecommissioningAllocationIcon(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+	allocs := NewAllocationsDatabase()
+	allocs.AddAllocation("prod", Allocation{
+		CIDR:   "10.0.0.0/24",
+		ID:     "id-1",
+		Name:   "old-vpc",
+		Status: StatusDecommissioning,
+	})
+
+	result := GenerateAllFiles(pools, allocs)
+	poolPage := result.Files[".github/ipam/pools/prod.md"]
+
+	if !strings.Contains(poolPage, "🔴&nbsp;&nbsp;Decommissioning | old-vpc") {
+		t.Error("pool page should show Decommissioning status for decommissioning allocation")
+	}
+}
+
 func TestPoolPage_NoAllocationHCL(t *testing.T) {
 	pools := NewPoolsConfig()
 	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
@@ -557,6 +577,82 @@ func TestCIDRToAddresses(t *testing.T) {
 	}
 }
 
+func TestUsableHosts(t *testing.T) {
+	tests := []struct {
+		cidr     string
+		expected uint64
+	}{
+		{"10.0.0.0/24", 254},
+		{"10.0.0.0/30", 2},
+		{"10.0.0.0/31", 2},
+		{"10.0.0.0/32", 1},
+		{"fd00::/120", 256},
+		{"not-a-cidr", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			if result := usableHosts(tt.cidr); result != tt.expected {
+				t.Errorf("usableHosts(%s) = %d, want %d", tt.cidr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPoolPage_UsableHosts(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+	allocs := NewAllocationsDatabase()
+	allocs.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-main"})
+	allocs.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/31", ID: "id-2", Name: "p2p-link"})
+
+	result := GenerateAllFiles(pools, allocs)
+	poolPage := result.Files[".github/ipam/pools/prod.md"]
+
+	for _, want := range []string{
+		"| Addresses | Usable Hosts |",
+		"vpc-main | `10.0.0.0/24` (10.0.0.0 - 10.0.0.255) | 256 | 254 |",
+		"p2p-link | `10.0.1.0/31` (10.0.1.0 - 10.0.1.1) | 2 | 2 |",
+		"| **Totals** | | | **258** | **256** |",
+	} {
+		if !strings.Contains(poolPage, want) {
+			t.Errorf("pool page missing %q:\n%s", want, poolPage)
+		}
+	}
+}
+
+func TestPoolPage_VLANID(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+	allocs := NewAllocationsDatabase()
+	allocs.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-main"})
+	allocs.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/26", ID: "id-2", Name: "app", ParentCIDR: strPtr("10.0.0.0/24"), VLANID: 120})
+
+	poolPage := GenerateAllFiles(pools, allocs).Files[".github/ipam/pools/prod.md"]
+	if !strings.Contains(poolPage, "└&nbsp;app (VLAN 120) |") {
+		t.Errorf("expected VLAN ID next to the name:\n%s", poolPage)
+	}
+	if strings.Contains(poolPage, "vpc-main (VLAN") {
+		t.Errorf("expected no VLAN label without a VLAN ID:\n%s", poolPage)
+	}
+}
+
+func TestPoolPage_Region(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+	allocs := NewAllocationsDatabase()
+	allocs.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "id-1", Name: "vpc-east", Region: "us-east-1"})
+	allocs.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/26", ID: "id-2", Name: "app", ParentCIDR: strPtr("10.0.0.0/24"), Region: "us-east-1", VLANID: 120})
+
+	poolPage := GenerateAllFiles(pools, allocs).Files[".github/ipam/pools/prod.md"]
+	if !strings.Contains(poolPage, "| vpc-east (us-east-1) |") {
+		t.Errorf("expected region next to the name:\n%s", poolPage)
+	}
+	if !strings.Contains(poolPage, "└&nbsp;app (us-east-1, VLAN 120) |") {
+		t.Errorf("expected region and VLAN ID next to the name:\n%s", poolPage)
+	}
+}
+
 func TestCIDRToAddresses_Invalid(t *testing.T) {
 	result := cidrToAddresses("not-a-cidr")
 	if result != 0 {
@@ -597,6 +693,9 @@ func TestCompareCIDRs(t *testing.T) {
 		{"a before b", "10.0.0.0/24", "10.0.1.0/24", true},
 		{"a after b", "10.0.1.0/24", "10.0.0.0/24", false},
 		{"different classes", "10.0.0.0/8", "192.168.0.0/16", true},
+		{"parent before child", "10.0.0.0/16", "10.0.0.0/24", true},
+		{"child after parent", "10.0.0.0/24", "10.0.0.0/16", false},
+		{"ipv6", "fd00::/64", "fd00:0:0:1::/64", true},
 	}
 
 	for _, tt := range tests {
@@ -608,3 +707,132 @@ func TestCompareCIDRs(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateAllFilesWithOptions_SummaryOmitsAllocationRows(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+	allocs := NewAllocationsDatabase()
+	allocs.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "a", Name: "vpc-alpha"})
+	allocs.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "b", Name: "vpc-beta"})
+
+	result := GenerateAllFilesWithOptions(pools, allocs, GenerateOptions{DetailLevel: DocsDetailSummary})
+	readme := result.Files[".github/README.md"]
+
+	if strings.Contains(readme, "vpc-alpha") || strings.Contains(readme, "Available") {
+		t.Errorf("summary README should not list allocations or free ranges:\n%s", readme)
+	}
+	if !strings.Contains(readme, "| [prod](ipam/pools/prod.md) | `10.0.0.0/16` | 2 | 512/65,536 (0.8%) |") {
+		t.Errorf("summary README should show pool count and utilization:\n%s", readme)
+	}
+
+	// Pool pages keep the full detail
+	if !strings.Contains(result.Files[".github/ipam/pools/prod.md"], "vpc-alpha") {
+		t.Error("pool page should still list allocations in summary mode")
+	}
+}
+
+func TestGenerateAllFilesWithOptions_PaginatesPoolPages(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+	allocs := NewAllocationsDatabase()
+	for i := 0; i < 5; i++ {
+		allocs.AddAllocation("prod", Allocation{
+			CIDR: fmt.Sprintf("10.0.%d.0/24", i),
+			ID:   fmt.Sprintf("id-%d", i),
+			Name: fmt.Sprintf("vpc-%d", i),
+		})
+	}
+
+	result := GenerateAllFilesWithOptions(pools, allocs, GenerateOptions{PageSize: 2})
+
+	first := result.Files[".github/ipam/pools/prod.md"]
+	second := result.Files[".github/ipam/pools/prod/page-2.md"]
+	third := result.Files[".github/ipam/pools/prod/page-3.md"]
+	if first == "" || second == "" || third == "" {
+		t.Fatalf("expected three pool pages, got %d files", len(result.Files))
+	}
+	if _, exists := result.Files[".github/ipam/pools/prod/page-4.md"]; exists {
+		t.Error("unexpected fourth page")
+	}
+
+	if !strings.Contains(first, "## Overview") || strings.Contains(second, "## Overview") {
+		t.Error("overview should only be on the first page")
+	}
+	if !strings.Contains(first, "vpc-1") || strings.Contains(first, "vpc-2") {
+		t.Errorf("first page should hold vpc-0 and vpc-1 only:\n%s", first)
+	}
+	if !strings.Contains(second, "Page 2 of 3 · [← Previous](../prod.md) · [Next →](page-3.md)") {
+		t.Errorf("second page missing navigation:\n%s", second)
+	}
+	if !strings.Contains(third, "vpc-4") || !strings.Contains(third, "Available") {
+		t.Errorf("last page should hold vpc-4 and the trailing free range:\n%s", third)
+	}
+}
+
+func TestGenerateAllFiles_PoolPagesDontCollideWithPoolNames(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+	pools.AddPool("prod-2", PoolDefinition{CIDR: []string{"10.1.0.0/16"}})
+	allocs := NewAllocationsDatabase()
+	for i := 0; i < 3; i++ {
+		allocs.AddAllocation("prod", Allocation{
+			CIDR: fmt.Sprintf("10.0.%d.0/24", i),
+			ID:   fmt.Sprintf("id-%d", i),
+			Name: fmt.Sprintf("vpc-%d", i),
+		})
+	}
+	allocs.AddAllocation("prod-2", Allocation{CIDR: "10.1.0.0/24", ID: "other", Name: "vpc-other"})
+
+	result := GenerateAllFilesWithOptions(pools, allocs, GenerateOptions{PageSize: 2})
+
+	if page := result.Files[".github/ipam/pools/prod-2.md"]; !strings.Contains(page, "vpc-other") {
+		t.Errorf("pool prod-2 should keep its own page:\n%s", page)
+	}
+	second := result.Files[".github/ipam/pools/prod/page-2.md"]
+	if !strings.Contains(second, "vpc-2") {
+		t.Errorf("second page of prod should hold vpc-2:\n%s", second)
+	}
+	if !strings.Contains(result.Files[".github/ipam/pools/prod.md"], "[Next →](prod/page-2.md)") {
+		t.Error("first page should link to the second")
+	}
+}
+
+func TestGenerateAllFiles_SinglePoolPageBelowThreshold(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}})
+	allocs := NewAllocationsDatabase()
+	allocs.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "a", Name: "vpc-alpha"})
+
+	result := GenerateAllFiles(pools, allocs)
+
+	if strings.Contains(result.Files[".github/ipam/pools/prod.md"], "Page 1 of") {
+		t.Error("single page should not render navigation")
+	}
+	if _, exists := result.Files[".github/ipam/pools/prod/page-2.md"]; exists {
+		t.Error("unexpected second page")
+	}
+}
+
+func TestGeneratePoolPage_ShowsReservedRanges(t *testing.T) {
+	pools := NewPoolsConfig()
+	pools.AddPool("prod", PoolDefinition{CIDR: []string{"10.0.0.0/16"}, ReservedRanges: []string{"10.0.4.0/24"}})
+	allocs := NewAllocationsDatabase()
+	allocs.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "a", Name: "vpc-alpha"})
+
+	page := GenerateAllFiles(pools, allocs).Files[".github/ipam/pools/prod.md"]
+
+	if !strings.Contains(page, "| Reserved Ranges | `10.0.4.0/24` |") {
+		t.Errorf("expected the reserved ranges in the overview:\n%s", page)
+	}
+	row := "| 🟠&nbsp;&nbsp;Reserved | *Reserved range (pools.yaml)* | `10.0.4.0/24` (10.0.4.0 - 10.0.4.255) | 256 | — |"
+	if !strings.Contains(page, row) {
+		t.Errorf("expected a reserved row for the range:\n%s", page)
+	}
+	gap := "| ⚪&nbsp;&nbsp;Available | — | `10.0.1.0—10.0.3.255`"
+	if !strings.Contains(page, gap) || strings.Index(page, gap) > strings.Index(page, row) {
+		t.Errorf("expected the free space before the range listed ahead of it:\n%s", page)
+	}
+	if !strings.Contains(page, "| **Totals** | | | **256** | **254** |") {
+		t.Errorf("expected the reserved range left out of the totals:\n%s", page)
+	}
+}
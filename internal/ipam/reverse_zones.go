@@ -0,0 +1,38 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"net"
+)
+
+// ReverseZones returns the /24-aligned in-addr.arpa zones covering an IPv4
+// block, in address order. A block larger than /24 spans several zones; a
+// block smaller than /24 returns the single zone containing it, which it
+// only partly fills.
+func ReverseZones(cidr string) ([]string, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %s: %w", cidr, err)
+	}
+	ip := network.IP.To4()
+	if ip == nil {
+		return nil, fmt.Errorf("reverse zones are only supported for IPv4, got %s", cidr)
+	}
+
+	ones, _ := network.Mask.Size()
+	count := 1
+	if ones < 24 {
+		count = 1 << uint(24-ones)
+	}
+
+	start := ipToUint32(ip) >> 8
+	zones := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		n := start + uint32(i)
+		zones = append(zones, fmt.Sprintf("%d.%d.%d.in-addr.arpa", n&0xff, (n>>8)&0xff, (n>>16)&0xff))
+	}
+	return zones, nil
+}
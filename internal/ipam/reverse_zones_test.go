@@ -0,0 +1,46 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReverseZones(t *testing.T) {
+	tests := []struct {
+		cidr     string
+		expected []string
+	}{
+		{"10.0.0.0/24", []string{"0.0.10.in-addr.arpa"}},
+		{"10.0.0.0/23", []string{"0.0.10.in-addr.arpa", "1.0.10.in-addr.arpa"}},
+		{"192.168.5.64/26", []string{"5.168.192.in-addr.arpa"}}, // Containing zone
+		{"172.16.254.0/22", []string{
+			"252.16.172.in-addr.arpa",
+			"253.16.172.in-addr.arpa",
+			"254.16.172.in-addr.arpa",
+			"255.16.172.in-addr.arpa",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			zones, err := ReverseZones(tt.cidr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(zones, tt.expected) {
+				t.Errorf("ReverseZones(%s) = %v, want %v", tt.cidr, zones, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReverseZones_Errors(t *testing.T) {
+	for _, cidr := range []string{"invalid", "fd00::/48"} {
+		if _, err := ReverseZones(cidr); err == nil {
+			t.Errorf("expected error for %s", cidr)
+		}
+	}
+}
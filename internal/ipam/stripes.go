@@ -0,0 +1,163 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+)
+
+// Stripes are runs of equal, back-to-back blocks allocated together, e.g.
+// one /24 per availability zone. Each block is a separate allocation; they
+// share a Group so they can be found, changed and released together.
+
+// FindContiguousRun finds count back-to-back blocks of prefixLen within the
+// container (Mode 2: a parent allocation). Every block starts on its own
+// /prefixLen boundary, and the lowest run overlapping nothing wins. Either
+// the whole run is returned or an error.
+func (a *Allocator) FindContiguousRun(containerCIDR string, existingAllocations []Allocation, prefixLen, count int) ([]string, error) {
+	return findRun(containerCIDR, existingAllocations, prefixLen, count, 0)
+}
+
+// FindContiguousRunInPool finds a run of count blocks in one of a pool's
+// CIDRs (Mode 1), trying them in fill order and treating top-level
+// allocations and the avoid set as occupied. A run never spans two pool
+// CIDRs, even adjacent ones, nor two blocks of a route_aligned pool's
+// route_boundary.
+func (a *Allocator) FindContiguousRunInPool(poolDef *PoolDefinition, existingAllocations []Allocation, prefixLen, count int, opts AllocateOptions) ([]string, error) {
+	if err := poolDef.CheckGranularity(prefixLen); err != nil {
+		return nil, err
+	}
+	if alignLen := poolDef.alignmentFor(prefixLen); count > 1 && alignLen != prefixLen {
+		return nil, fmt.Errorf("back-to-back /%d blocks cannot each start on the pool's /%d allocation_granularity boundary", prefixLen, alignLen)
+	}
+
+	occupied := filterTopLevelAllocations(existingAllocations)
+	occupied = append(occupied, filterTopLevelAllocations(opts.Avoid)...)
+	occupied = append(occupied, poolDef.occupiedBlocks(prefixLen)...)
+
+	var skippedReasons []string
+	for _, poolCIDR := range poolDef.searchOrder(prefixLen) {
+		run, err := findRun(poolCIDR, occupied, prefixLen, count, poolDef.routeBoundary())
+		if err == nil {
+			return run, nil
+		}
+		skippedReasons = append(skippedReasons, fmt.Sprintf("%s: %v", poolCIDR, err))
+	}
+	if len(skippedReasons) == 1 {
+		return nil, fmt.Errorf("no run of %d contiguous /%d blocks in pool: %s", count, prefixLen, skippedReasons[0])
+	}
+	return nil, fmt.Errorf("no run of %d contiguous /%d blocks in pool (tried %d CIDRs): %v", count, prefixLen, len(poolDef.CIDR), skippedReasons)
+}
+
+// findRun finds the lowest run of count blocks in the container. When
+// boundaryLen is set, the run must also lie within one /boundaryLen block.
+func findRun(containerCIDR string, existingAllocations []Allocation, prefixLen, count, boundaryLen int) ([]string, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("count must be at least 1, got %d", count)
+	}
+
+	_, containerNet, err := net.ParseCIDR(containerCIDR)
+	if err != nil {
+		return nil, fmt.Errorf("invalid container CIDR %s: %w", containerCIDR, err)
+	}
+	containerPrefixLen, bits := containerNet.Mask.Size()
+	if prefixLen < containerPrefixLen {
+		return nil, fmt.Errorf("requested prefix /%d is larger than container /%d", prefixLen, containerPrefixLen)
+	}
+	if prefixLen > bits {
+		return nil, fmt.Errorf("requested prefix /%d exceeds address size /%d", prefixLen, bits)
+	}
+
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLen))
+	runSize := new(big.Int).Mul(size, big.NewInt(int64(count)))
+
+	var boundary *big.Int
+	if boundaryLen > 0 && boundaryLen <= bits {
+		boundary = new(big.Int).Lsh(big.NewInt(1), uint(bits-boundaryLen))
+		if runSize.Cmp(boundary) > 0 {
+			return nil, fmt.Errorf("a run of %d /%d blocks would cross the pool's /%d route_boundary", count, prefixLen, boundaryLen)
+		}
+	}
+
+	most := big.NewInt(0)
+	for _, r := range freeRanges(containerNet, existingAllocations) {
+		start := alignUp(r.start, size)
+		if start.Cmp(r.end) > 0 {
+			continue
+		}
+
+		// A run that would straddle a boundary starts at the next one
+		// instead; from there it fits within a single boundary block
+		if boundary != nil {
+			runEnd := new(big.Int).Add(start, runSize)
+			runEnd.Sub(runEnd, big.NewInt(1))
+			if next := alignUp(start, boundary); next.Cmp(start) > 0 && runEnd.Cmp(next) >= 0 {
+				before := new(big.Int).Add(r.end, big.NewInt(1))
+				if next.Cmp(before) < 0 {
+					before.Set(next)
+				}
+				if fit := before.Sub(before, start).Div(before, size); fit.Cmp(most) > 0 {
+					most = fit
+				}
+				start = next
+				if start.Cmp(r.end) > 0 {
+					continue
+				}
+			}
+		}
+
+		room := new(big.Int).Sub(r.end, start)
+		room.Add(room, big.NewInt(1))
+		if room.Cmp(runSize) < 0 {
+			if fit := room.Div(room, size); fit.Cmp(most) > 0 {
+				most = fit
+			}
+			continue
+		}
+
+		run := make([]string, count)
+		for i := range run {
+			run[i] = blockString(start, prefixLen, containerNet)
+			start = new(big.Int).Add(start, size)
+		}
+		return run, nil
+	}
+
+	return nil, fmt.Errorf("no run of %d contiguous /%d blocks in %s: at most %s fit back to back", count, prefixLen, containerCIDR, most)
+}
+
+// ShareStripeSettings copies the settings every block of a stripe shares
+// from the block that was updated. Names and metadata are per block and
+// left to the caller.
+func (a *Allocation) ShareStripeSettings(from Allocation) {
+	a.VLANID = from.VLANID
+	a.Region = from.Region
+	a.CostCenter = from.CostCenter
+	a.Status = from.Status
+	a.Reserved = from.Reserved
+	a.Locked = from.Locked
+	a.UpdatedAt = from.UpdatedAt
+}
+
+// GroupMembers returns the allocations of a stripe in CIDR order, with the
+// pool they belong to.
+func (d *AllocationsDatabase) GroupMembers(groupID string) ([]Allocation, string) {
+	var members []Allocation
+	var groupPoolID string
+	for poolID, allocations := range d.Allocations {
+		for _, alloc := range allocations {
+			if alloc.Group == groupID {
+				members = append(members, alloc)
+				groupPoolID = poolID
+			}
+		}
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return compareCIDRs(members[i].CIDR, members[j].CIDR)
+	})
+	return members, groupPoolID
+}
@@ -0,0 +1,154 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFindContiguousRun_Feasible(t *testing.T) {
+	allocator := NewAllocator()
+	existing := []Allocation{{CIDR: "10.0.0.0/24"}, {CIDR: "10.0.1.128/25"}}
+
+	run, err := allocator.FindContiguousRun("10.0.0.0/21", existing, 24, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"10.0.2.0/24", "10.0.3.0/24", "10.0.4.0/24"}
+	if fmt.Sprint(run) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, run)
+	}
+}
+
+func TestFindContiguousRun_OnlyNonContiguousSpace(t *testing.T) {
+	allocator := NewAllocator()
+	// 10.0.0.0/24 and 10.0.2.0/24 are free, but not next to each other
+	existing := []Allocation{{CIDR: "10.0.1.0/24"}, {CIDR: "10.0.3.0/24"}}
+
+	run, err := allocator.FindContiguousRun("10.0.0.0/22", existing, 24, 2)
+	if err == nil {
+		t.Fatalf("expected error, got %v", run)
+	}
+	if !strings.Contains(err.Error(), "no run of 2 contiguous /24 blocks in 10.0.0.0/22: at most 1 fit back to back") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Each single block still fits on its own
+	if _, err := allocator.FindContiguousRun("10.0.0.0/22", existing, 24, 1); err != nil {
+		t.Errorf("unexpected error for a run of 1: %v", err)
+	}
+}
+
+func TestFindContiguousRunInPool(t *testing.T) {
+	allocator := NewAllocator()
+	poolDef := &PoolDefinition{CIDR: []string{"10.0.0.0/23", "10.1.0.0/22"}}
+	existing := []Allocation{
+		{CIDR: "10.0.0.0/24"},
+		{CIDR: "10.0.1.0/28", ParentCIDR: strPtr("10.0.0.0/24")}, // children don't occupy pool space
+	}
+
+	// The first CIDR has a single /24 left, so the run comes from the second
+	run, err := allocator.FindContiguousRunInPool(poolDef, existing, 24, 3, AllocateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run[0] != "10.1.0.0/24" || len(run) != 3 {
+		t.Errorf("expected a run starting at 10.1.0.0/24, got %v", run)
+	}
+
+	if _, err := allocator.FindContiguousRunInPool(poolDef, existing, 24, 5, AllocateOptions{}); err == nil {
+		t.Error("expected error: no pool CIDR holds five contiguous /24s")
+	}
+
+	granular := &PoolDefinition{CIDR: []string{"10.0.0.0/16"}, AllocationGranularity: 20}
+	if _, err := allocator.FindContiguousRunInPool(granular, nil, 24, 3, AllocateOptions{}); err == nil {
+		t.Error("expected error: back-to-back /24s cannot each start on a /20 boundary")
+	}
+}
+
+func TestGroupMembers(t *testing.T) {
+	db := NewAllocationsDatabase()
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.1.0/24", ID: "b", Group: "a"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.0.0/24", ID: "a", Group: "a"})
+	db.AddAllocation("prod", Allocation{CIDR: "10.0.2.0/24", ID: "c"})
+
+	members, poolID := db.GroupMembers("a")
+	if poolID != "prod" || len(members) != 2 || members[0].ID != "a" || members[1].ID != "b" {
+		t.Errorf("expected members a, b in prod, got %v in %q", members, poolID)
+	}
+}
+
+func TestShareStripeSettings_LockAndUnlock(t *testing.T) {
+	db := NewAllocationsDatabase()
+	for i, cidr := range []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24"} {
+		db.AddAllocation("prod", Allocation{CIDR: cidr, ID: fmt.Sprintf("id-%d", i), Name: fmt.Sprintf("az-%d", i), Group: "id-0"})
+	}
+
+	// Update the first block, then carry the change to the rest as the
+	// allocation resource does
+	setLocked := func(locked bool) {
+		members, _ := db.GroupMembers("id-0")
+		leader := members[0]
+		leader.Locked = locked
+		for _, member := range members {
+			if member.ID != leader.ID {
+				member.ShareStripeSettings(leader)
+			} else {
+				member = leader
+			}
+			if err := db.RemoveAllocation("prod", member.ID); err != nil {
+				t.Fatal(err)
+			}
+			db.AddAllocation("prod", member)
+		}
+	}
+
+	for _, locked := range []bool{true, false} {
+		setLocked(locked)
+		members, _ := db.GroupMembers("id-0")
+		for _, member := range members {
+			if member.Locked != locked {
+				t.Errorf("locked=%v: member %s has locked=%v", locked, member.Name, member.Locked)
+			}
+		}
+	}
+}
+
+func TestFindContiguousRunInPool_RouteAligned(t *testing.T) {
+	allocator := NewAllocator()
+	// Only 10.0.254.0/23 and 10.1.0.0/23 are free, on either side of a /16 boundary
+	var existing []Allocation
+	for _, cidr := range []string{
+		"10.0.0.0/17", "10.0.128.0/18", "10.0.192.0/19", "10.0.224.0/20", "10.0.240.0/21", "10.0.248.0/22", "10.0.252.0/23",
+		"10.1.2.0/23", "10.1.4.0/22", "10.1.8.0/21", "10.1.16.0/20", "10.1.32.0/19", "10.1.64.0/18", "10.1.128.0/17",
+	} {
+		existing = append(existing, Allocation{CIDR: cidr})
+	}
+
+	plain := &PoolDefinition{CIDR: []string{"10.0.0.0/15"}}
+	run, err := allocator.FindContiguousRunInPool(plain, existing, 24, 4, AllocateOptions{})
+	if err != nil || run[0] != "10.0.254.0/24" || run[3] != "10.1.1.0/24" {
+		t.Fatalf("expected a run across the /16 boundary without route_aligned, got %v, %v", run, err)
+	}
+
+	aligned := &PoolDefinition{CIDR: []string{"10.0.0.0/15"}, RouteAligned: true}
+	if run, err := allocator.FindContiguousRunInPool(aligned, existing, 24, 4, AllocateOptions{}); err == nil {
+		t.Errorf("expected the straddling run to be rejected, got %v", run)
+	} else if !strings.Contains(err.Error(), "at most 2 fit back to back") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Two blocks fit below the boundary
+	run, err = allocator.FindContiguousRunInPool(aligned, existing, 24, 2, AllocateOptions{})
+	if err != nil || fmt.Sprint(run) != "[10.0.254.0/24 10.0.255.0/24]" {
+		t.Errorf("expected the run below the boundary, got %v, %v", run, err)
+	}
+
+	// A run larger than the boundary can never fit
+	if _, err := allocator.FindContiguousRunInPool(aligned, nil, 17, 4, AllocateOptions{}); err == nil || !strings.Contains(err.Error(), "route_boundary") {
+		t.Errorf("expected the run to be rejected for its size, got %v", err)
+	}
+}
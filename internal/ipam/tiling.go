@@ -0,0 +1,83 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+)
+
+// TilingResult reports how a parent's children cover it.
+type TilingResult struct {
+	Tiled    bool     // Children cover the parent exactly, with no gaps or overlaps
+	Gaps     []string // Uncovered space as CIDRs, in address order
+	Overlaps []string // Children overlapping an earlier child, in address order
+}
+
+// CheckTiling reports whether children exactly tile the parent CIDR. Gaps
+// come from the free ranges of the parent, split into the fewest aligned
+// blocks. Children outside the parent are ignored. Gaps and Overlaps are
+// empty, not nil, when there are none.
+func CheckTiling(parentCIDR string, children []Allocation) (TilingResult, error) {
+	_, parent, err := net.ParseCIDR(parentCIDR)
+	if err != nil {
+		return TilingResult{}, fmt.Errorf("invalid parent CIDR %q: %w", parentCIDR, err)
+	}
+
+	result := TilingResult{Gaps: []string{}, Overlaps: []string{}}
+	for _, r := range freeRanges(parent, children) {
+		result.Gaps = append(result.Gaps, rangeBlocks(r, parent)...)
+	}
+
+	type child struct {
+		cidr string
+		r    addressRange
+	}
+	var inside []child
+	for _, alloc := range filterAllocationsInCIDR(children, parent) {
+		_, allocNet, err := net.ParseCIDR(alloc.CIDR)
+		if err != nil {
+			continue
+		}
+		inside = append(inside, child{cidr: allocNet.String(), r: networkRange(allocNet)})
+	}
+	sort.Slice(inside, func(i, j int) bool {
+		return inside[i].r.start.Cmp(inside[j].r.start) < 0
+	})
+	var reach *big.Int
+	for _, c := range inside {
+		if reach != nil && c.r.start.Cmp(reach) <= 0 {
+			result.Overlaps = append(result.Overlaps, c.cidr)
+		}
+		if reach == nil || c.r.end.Cmp(reach) > 0 {
+			reach = c.r.end
+		}
+	}
+
+	result.Tiled = len(result.Gaps) == 0 && len(result.Overlaps) == 0
+	return result, nil
+}
+
+// rangeBlocks splits an address range into the fewest aligned CIDR blocks,
+// in address order.
+func rangeBlocks(r addressRange, container *net.IPNet) []string {
+	containerPrefixLen, bits := container.Mask.Size()
+	var blocks []string
+	start := new(big.Int).Set(r.start)
+	for start.Cmp(r.end) <= 0 {
+		prefixLen := containerPrefixLen
+		for ; prefixLen < bits; prefixLen++ {
+			size := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLen))
+			end := new(big.Int).Add(start, size)
+			if new(big.Int).Mod(start, size).Sign() == 0 && end.Sub(end, big.NewInt(1)).Cmp(r.end) <= 0 {
+				break
+			}
+		}
+		blocks = append(blocks, blockString(start, prefixLen, container))
+		start.Add(start, new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLen)))
+	}
+	return blocks
+}
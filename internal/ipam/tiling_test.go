@@ -0,0 +1,88 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package ipam
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckTiling_FullyTiled(t *testing.T) {
+	children := []Allocation{
+		{CIDR: "10.0.0.0/25", Name: "a"},
+		{CIDR: "10.0.0.128/26", Name: "b"},
+		{CIDR: "10.0.0.192/26", Name: "c"},
+	}
+
+	result, err := CheckTiling("10.0.0.0/24", children)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Tiled || len(result.Gaps) != 0 || len(result.Overlaps) != 0 {
+		t.Errorf("expected the parent to be tiled, got %+v", result)
+	}
+}
+
+func TestCheckTiling_Gap(t *testing.T) {
+	children := []Allocation{
+		{CIDR: "10.0.0.0/26", Name: "a"},
+		{CIDR: "10.0.0.192/26", Name: "c"},
+	}
+
+	result, err := CheckTiling("10.0.0.0/24", children)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Tiled {
+		t.Error("expected a gap to fail the check")
+	}
+	if want := []string{"10.0.0.64/26", "10.0.0.128/26"}; !reflect.DeepEqual(result.Gaps, want) {
+		t.Errorf("expected gaps %v, got %v", want, result.Gaps)
+	}
+}
+
+func TestCheckTiling_PartialCoverage(t *testing.T) {
+	children := []Allocation{{CIDR: "10.0.0.0/24", Name: "a"}}
+
+	result, err := CheckTiling("10.0.0.0/22", children)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"10.0.1.0/24", "10.0.2.0/23"}; !reflect.DeepEqual(result.Gaps, want) {
+		t.Errorf("expected gaps %v, got %v", want, result.Gaps)
+	}
+
+	empty, err := CheckTiling("10.0.0.0/22", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"10.0.0.0/22"}; empty.Tiled || !reflect.DeepEqual(empty.Gaps, want) {
+		t.Errorf("expected the whole parent as a gap, got %+v", empty)
+	}
+}
+
+func TestCheckTiling_Overlap(t *testing.T) {
+	children := []Allocation{
+		{CIDR: "10.0.0.0/25", Name: "a"},
+		{CIDR: "10.0.0.64/26", Name: "b"},
+		{CIDR: "10.0.0.128/25", Name: "c"},
+	}
+
+	result, err := CheckTiling("10.0.0.0/24", children)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Tiled || len(result.Gaps) != 0 {
+		t.Errorf("expected an overlap without gaps, got %+v", result)
+	}
+	if want := []string{"10.0.0.64/26"}; !reflect.DeepEqual(result.Overlaps, want) {
+		t.Errorf("expected overlaps %v, got %v", want, result.Overlaps)
+	}
+}
+
+func TestCheckTiling_InvalidParent(t *testing.T) {
+	if _, err := CheckTiling("not-a-cidr", nil); err == nil {
+		t.Error("expected an error for an invalid parent CIDR")
+	}
+}
@@ -5,14 +5,22 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
 	"github.com/easytofu/terraform-provider-ipam-github/internal/datasources"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
 	"github.com/easytofu/terraform-provider-ipam-github/internal/resources"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
@@ -27,13 +35,37 @@ type GitIPAMProvider struct {
 // GitIPAMProviderModel describes the provider data model.
 type GitIPAMProviderModel struct {
 	Token           types.String `tfsdk:"token"`
+	TokenFile       types.String `tfsdk:"token_file"`
 	Owner           types.String `tfsdk:"owner"`
 	Repository      types.String `tfsdk:"repository"`
 	Branch          types.String `tfsdk:"branch"`
 	PoolsFile       types.String `tfsdk:"pools_file"`
+	PoolsWriteFile  types.String `tfsdk:"pools_write_file"`
+	PoolsOwner      types.String `tfsdk:"pools_owner"`
+	PoolsRepository types.String `tfsdk:"pools_repository"`
+	PoolsBranch     types.String `tfsdk:"pools_branch"`
 	AllocationsFile types.String `tfsdk:"allocations_file"`
 	MaxRetries      types.Int64  `tfsdk:"max_retries"`
+	ConflictRetries types.Int64  `tfsdk:"conflict_retries"`
+	TransientRetry  types.Int64  `tfsdk:"transient_retries"`
 	BaseDelayMs     types.Int64  `tfsdk:"base_delay_ms"`
+	AllowPublic     types.Bool   `tfsdk:"allow_public"`
+	AllowedRegions  types.List   `tfsdk:"allowed_regions"`
+	CommitTrailer   types.String `tfsdk:"commit_trailer"`
+	RunID           types.String `tfsdk:"run_id"`
+	Workspace       types.String `tfsdk:"workspace"`
+	DocsDetailLevel types.String `tfsdk:"docs_detail_level"`
+	DeferDocs       types.Bool   `tfsdk:"defer_docs"`
+	DocsStrict      types.Bool   `tfsdk:"docs_strict"`
+	ImportBlocks    types.Bool   `tfsdk:"generate_import_blocks"`
+	NetBoxExport    types.Bool   `tfsdk:"generate_netbox_export"`
+	MaxNestingDepth types.Int64  `tfsdk:"max_nesting_depth"`
+	PreserveFormat  types.Bool   `tfsdk:"preserve_pools_format"`
+	IncludeOpenPRs  types.Bool   `tfsdk:"include_open_prs"`
+	ArchiveDelete   types.Bool   `tfsdk:"archive_deletions"`
+	TolerateUnknown types.Bool   `tfsdk:"tolerate_unknown_fields"`
+	Changelog       types.Bool   `tfsdk:"generate_changelog"`
+	IDScheme        types.String `tfsdk:"id_scheme"`
 }
 
 // New creates a new provider instance.
@@ -65,12 +97,22 @@ This provider enables GitOps-native IP address management without the cost of AW
 		Attributes: map[string]schema.Attribute{
 			"token": schema.StringAttribute{
 				Description: "GitHub Personal Access Token or App Installation Token. " +
-					"Can also be set via GITHUB_TOKEN environment variable.",
+					"Can also be set via GITHUB_TOKEN environment variable. Conflicts with token_file.",
 				MarkdownDescription: "GitHub Personal Access Token or App Installation Token. " +
-					"Can also be set via `GITHUB_TOKEN` environment variable.",
-				Required:  true,
+					"Can also be set via `GITHUB_TOKEN` environment variable. Conflicts with `token_file`.",
+				Optional:  true,
 				Sensitive: true,
 			},
+			"token_file": schema.StringAttribute{
+				Description: "Path to a file holding the GitHub token, for CI systems that mount secrets as files. " +
+					"Surrounding whitespace is trimmed. Can also be set via GITHUB_TOKEN_FILE environment variable. Conflicts with token.",
+				MarkdownDescription: "Path to a file holding the GitHub token, for CI systems that mount secrets as files. " +
+					"Surrounding whitespace is trimmed. Can also be set via `GITHUB_TOKEN_FILE` environment variable. Conflicts with `token`.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("token")),
+				},
+			},
 			"owner": schema.StringAttribute{
 				Description:         "GitHub repository owner (user or organization).",
 				MarkdownDescription: "GitHub repository owner (user or organization).",
@@ -88,11 +130,41 @@ This provider enables GitOps-native IP address management without the cost of AW
 			},
 			"pools_file": schema.StringAttribute{
 				Description: "Path to pools.yaml in repository. Defaults to 'config/pools.yaml'. " +
-					"This file is read-only by the provider; pool definitions are managed via PR.",
+					"This file is read-only by the provider; pool definitions are managed via PR. " +
+					"May be a glob (e.g. 'network/pools/*.yaml') or a directory ending in '/' to merge several files; " +
+					"a pool ID defined in more than one file is an error.",
 				MarkdownDescription: "Path to pools.yaml in repository. Defaults to `config/pools.yaml`. " +
-					"This file is read-only by the provider; pool definitions are managed via PR.",
+					"This file is read-only by the provider; pool definitions are managed via PR. " +
+					"May be a glob (e.g. `network/pools/*.yaml`) or a directory ending in `/` to merge several files; " +
+					"a pool ID defined in more than one file is an error.",
+				Optional: true,
+			},
+			"pools_write_file": schema.StringAttribute{
+				Description: "File that the github-ipam_pool resource writes to. Defaults to pools_file. " +
+					"Required to manage pools when pools_file is a glob or directory.",
+				MarkdownDescription: "File that the `github-ipam_pool` resource writes to. Defaults to `pools_file`. " +
+					"Required to manage pools when `pools_file` is a glob or directory.",
+				Optional: true,
+			},
+			"pools_owner": schema.StringAttribute{
+				Description: "Owner of the repository holding the pools file, when pools are governed in a separate repository " +
+					"from allocations. Defaults to owner.",
+				MarkdownDescription: "Owner of the repository holding the pools file, when pools are governed in a separate repository " +
+					"from allocations. Defaults to `owner`.",
+				Optional: true,
+			},
+			"pools_repository": schema.StringAttribute{
+				Description: "Repository holding the pools file. Pools are read from and written to it, while allocations " +
+					"and generated docs stay in repository. Defaults to repository.",
+				MarkdownDescription: "Repository holding the pools file. Pools are read from and written to it, while allocations " +
+					"and generated docs stay in `repository`. Defaults to `repository`.",
 				Optional: true,
 			},
+			"pools_branch": schema.StringAttribute{
+				Description:         "Branch of the pools repository to read pools from. Defaults to branch.",
+				MarkdownDescription: "Branch of the pools repository to read pools from. Defaults to `branch`.",
+				Optional:            true,
+			},
 			"allocations_file": schema.StringAttribute{
 				Description: "Path to allocations.yaml in repository. Defaults to 'config/allocations.yaml'. " +
 					"This file is read-write by the provider with optimistic concurrency control.",
@@ -101,8 +173,22 @@ This provider enables GitOps-native IP address management without the cost of AW
 				Optional: true,
 			},
 			"max_retries": schema.Int64Attribute{
-				Description:         "Maximum retry attempts on conflict. Defaults to 10.",
-				MarkdownDescription: "Maximum retry attempts on conflict. Defaults to `10`.",
+				Description: "Sets both conflict_retries and transient_retries, for configurations written before they were split. " +
+					"Either one set explicitly takes precedence.",
+				MarkdownDescription: "Sets both `conflict_retries` and `transient_retries`, for configurations written before they were split. " +
+					"Either one set explicitly takes precedence.",
+				Optional: true,
+			},
+			"conflict_retries": schema.Int64Attribute{
+				Description: "Maximum retry attempts when another writer changed the allocations or pools file first. " +
+					"Contended workspaces may need more. Defaults to 10.",
+				MarkdownDescription: "Maximum retry attempts when another writer changed the allocations or pools file first. " +
+					"Contended workspaces may need more. Defaults to `10`.",
+				Optional: true,
+			},
+			"transient_retries": schema.Int64Attribute{
+				Description:         "Maximum retry attempts when GitHub answers with a 5xx error. Defaults to 3.",
+				MarkdownDescription: "Maximum retry attempts when GitHub answers with a `5xx` error. Defaults to `3`.",
 				Optional:            true,
 			},
 			"base_delay_ms": schema.Int64Attribute{
@@ -110,6 +196,137 @@ This provider enables GitOps-native IP address management without the cost of AW
 				MarkdownDescription: "Base delay in milliseconds for exponential backoff. Defaults to `200`.",
 				Optional:            true,
 			},
+			"allow_public": schema.BoolAttribute{
+				Description: "Permit pools and allocations outside private address space " +
+					"(RFC 1918, RFC 6598 100.64.0.0/10, and RFC 4193 fc00::/7). Defaults to false.",
+				MarkdownDescription: "Permit pools and allocations outside private address space " +
+					"(RFC 1918, RFC 6598 `100.64.0.0/10`, and RFC 4193 `fc00::/7`). Defaults to `false`.",
+				Optional: true,
+			},
+			"allowed_regions": schema.ListAttribute{
+				Description: "Regions allocations may be tagged with through their region attribute. " +
+					"Defaults to allowing any region.",
+				MarkdownDescription: "Regions allocations may be tagged with through their `region` attribute. " +
+					"Defaults to allowing any region.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"commit_trailer": schema.StringAttribute{
+				Description: "Go template appended as a git trailer to every IPAM commit, e.g. 'Run-ID: {{.RunID}}'. " +
+					"Available fields are RunID and Workspace. The subject line is unchanged.",
+				MarkdownDescription: "Go template appended as a git trailer to every IPAM commit, e.g. `Run-ID: {{.RunID}}`. " +
+					"Available fields are `RunID` and `Workspace`. The subject line is unchanged.",
+				Optional: true,
+			},
+			"run_id": schema.StringAttribute{
+				Description:         "Run ID for the commit trailer. Defaults to the TFC_RUN_ID environment variable.",
+				MarkdownDescription: "Run ID for the commit trailer. Defaults to the `TFC_RUN_ID` environment variable.",
+				Optional:            true,
+			},
+			"workspace": schema.StringAttribute{
+				Description:         "Workspace name for the commit trailer. Defaults to the TFC_WORKSPACE_NAME environment variable.",
+				MarkdownDescription: "Workspace name for the commit trailer. Defaults to the `TFC_WORKSPACE_NAME` environment variable.",
+				Optional:            true,
+			},
+			"docs_detail_level": schema.StringAttribute{
+				Description: "Detail level of the generated README: 'full' (default) renders the address space layout including free ranges, " +
+					"'summary' renders only per-pool utilization and allocation counts. Pool pages are unaffected.",
+				MarkdownDescription: "Detail level of the generated README: `full` (default) renders the address space layout including free ranges, " +
+					"`summary` renders only per-pool utilization and allocation counts. Pool pages are unaffected.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(ipam.DocsDetailFull, ipam.DocsDetailSummary),
+				},
+			},
+			"defer_docs": schema.BoolAttribute{
+				Description: "Skip README regeneration after each change and leave it to a single github-ipam_docs resource, " +
+					"so an apply produces one docs commit. Intermediate states have no docs. Defaults to false.",
+				MarkdownDescription: "Skip README regeneration after each change and leave it to a single `github-ipam_docs` resource, " +
+					"so an apply produces one docs commit. Intermediate states have no docs. Defaults to `false`.",
+				Optional: true,
+			},
+			"docs_strict": schema.BoolAttribute{
+				Description: "Fail the apply when README regeneration fails instead of logging a warning. " +
+					"The IPAM change itself is still committed, and every docs file that can be written still is; " +
+					"the error lists each one that failed. Defaults to false.",
+				MarkdownDescription: "Fail the apply when README regeneration fails instead of logging a warning. " +
+					"The IPAM change itself is still committed, and every docs file that can be written still is; " +
+					"the error lists each one that failed. Defaults to `false`.",
+				Optional: true,
+			},
+			"generate_import_blocks": schema.BoolAttribute{
+				Description: "Also generate .github/ipam/import.tf with an import block for every allocation, " +
+					"to help bring allocations made outside Terraform under management. Defaults to false.",
+				MarkdownDescription: "Also generate `.github/ipam/import.tf` with an `import` block for every allocation, " +
+					"to help bring allocations made outside Terraform under management. Defaults to `false`.",
+				Optional: true,
+			},
+			"generate_netbox_export": schema.BoolAttribute{
+				Description: "Also generate .github/ipam/netbox.json with every pool and allocation as a prefix in NetBox's import shape, " +
+					"for syncing with NetBox. Defaults to false.",
+				MarkdownDescription: "Also generate `.github/ipam/netbox.json` with every pool and allocation as a prefix in NetBox's import shape, " +
+					"for syncing with NetBox. Defaults to `false`.",
+				Optional: true,
+			},
+			"generate_changelog": schema.BoolAttribute{
+				Description: "Record every allocation the provider creates, releases or updates, and prepend them to " +
+					".github/ipam/changelog.md as one entry whenever the docs are written. Defaults to false.",
+				MarkdownDescription: "Record every allocation the provider creates, releases or updates, and prepend them to " +
+					"`.github/ipam/changelog.md` as one entry whenever the docs are written. Defaults to `false`.",
+				Optional: true,
+			},
+			"id_scheme": schema.StringAttribute{
+				Description: "How new allocations get their ID: 'random_uuid' (default) a random UUIDv4, 'uuid5_v1' a UUIDv5 of " +
+					"the pool, name and mask, so recreating the same allocation gives the same ID, or 'ulid' a ULID, which sorts by " +
+					"creation time. Existing allocations keep their IDs.",
+				MarkdownDescription: "How new allocations get their ID: `random_uuid` (default) a random UUIDv4, `uuid5_v1` a UUIDv5 of " +
+					"the pool, name and mask, so recreating the same allocation gives the same ID, or `ulid` a ULID, which sorts by " +
+					"creation time. Existing allocations keep their IDs.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(ipam.IDSchemeRandomUUID, ipam.IDSchemeUUID5, ipam.IDSchemeULID),
+				},
+			},
+			"max_nesting_depth": schema.Int64Attribute{
+				Description: "Deepest allowed allocation level, counting pool allocations as level 1. " +
+					"Sub-allocations that would nest deeper are rejected. Unlimited by default.",
+				MarkdownDescription: "Deepest allowed allocation level, counting pool allocations as level 1. " +
+					"Sub-allocations that would nest deeper are rejected. Unlimited by default.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"preserve_pools_format": schema.BoolAttribute{
+				Description: "Write pools.yaml as an edit of the file that was read, so comments and key order survive " +
+					"provider writes. Pools the provider changes are re-formatted. Defaults to false.",
+				MarkdownDescription: "Write `pools.yaml` as an edit of the file that was read, so comments and key order survive " +
+					"provider writes. Pools the provider changes are re-formatted. Defaults to `false`.",
+				Optional: true,
+			},
+			"include_open_prs": schema.BoolAttribute{
+				Description: "Let plans see pools added by open pull requests against the branch, read at each pull request's head. " +
+					"Such pools are provisional: data sources report them as such and allocating from one fails until it is merged. " +
+					"The token needs read access to pull requests. Defaults to false.",
+				MarkdownDescription: "Let plans see pools added by open pull requests against the branch, read at each pull request's head. " +
+					"Such pools are provisional: data sources report them as such and allocating from one fails until it is merged. " +
+					"The token needs read access to pull requests. Defaults to `false`.",
+				Optional: true,
+			},
+			"archive_deletions": schema.BoolAttribute{
+				Description: "Move deleted allocations to the archive section of the allocations file, stamped with deleted_at, " +
+					"instead of dropping them. Their blocks are still free for reuse. Defaults to false.",
+				MarkdownDescription: "Move deleted allocations to the `archived` section of the allocations file, stamped with `deleted_at`, " +
+					"instead of dropping them. Their blocks are still free for reuse. Defaults to `false`.",
+				Optional: true,
+			},
+			"tolerate_unknown_fields": schema.BoolAttribute{
+				Description: "Ignore unknown fields in the allocations file. Set to false to fail on them instead, " +
+					"catching misspelled keys from hand edits before the next write drops them. Defaults to true.",
+				MarkdownDescription: "Ignore unknown fields in the allocations file. Set to `false` to fail on them instead, " +
+					"catching misspelled keys from hand edits before the next write drops them. Defaults to `true`.",
+				Optional: true,
+			},
 		},
 	}
 }
@@ -129,47 +346,255 @@ func (p *GitIPAMProvider) Configure(ctx context.Context, req provider.ConfigureR
 		branch = config.Branch.ValueString()
 	}
 
-	poolsFile := "config/pools.yaml"
-	if !config.PoolsFile.IsNull() {
-		poolsFile = config.PoolsFile.ValueString()
+	// Check the repository and branch, then read repository-level defaults;
+	// explicit attributes override them. Without a known repository there
+	// is nothing to check or read yet.
+	// The token comes from the configuration, a file or the environment;
+	// an unknown token or path leaves it to be resolved once known
+	var token string
+	if !config.Token.IsUnknown() && !config.TokenFile.IsUnknown() {
+		var err error
+		token, err = client.ResolveToken(config.Token.ValueString(), config.TokenFile.ValueString())
+		if err != nil {
+			if errors.Is(err, client.ErrNoToken) {
+				resp.Diagnostics.AddAttributeError(path.Root("token"), "Missing GitHub Token", err.Error())
+			} else {
+				resp.Diagnostics.AddAttributeError(path.Root("token_file"), "Invalid Token File", err.Error())
+			}
+			return
+		}
 	}
 
-	allocationsFile := "config/allocations.yaml"
-	if !config.AllocationsFile.IsNull() {
-		allocationsFile = config.AllocationsFile.ValueString()
+	known := !config.Token.IsUnknown() && !config.TokenFile.IsUnknown() && !config.Owner.IsUnknown() && !config.Repository.IsUnknown() && !config.Branch.IsUnknown()
+	repoConfig := &client.RepoConfig{}
+	owner, repository := config.Owner.ValueString(), config.Repository.ValueString()
+	canWrite := true
+	if known {
+		bootstrap := client.NewGitHubClient(
+			token,
+			owner,
+			repository,
+			branch, "", "", 0, 0, client.Options{},
+		)
+		if err := bootstrap.CheckLocation(ctx); err != nil {
+			switch {
+			case errors.Is(err, client.ErrRepositoryNotFound):
+				resp.Diagnostics.AddAttributeError(path.Root("repository"), "Repository Not Found", err.Error())
+			case errors.Is(err, client.ErrBranchNotFound):
+				resp.Diagnostics.AddAttributeError(path.Root("branch"), "Branch Not Found", err.Error())
+			default:
+				resp.Diagnostics.AddError("Failed to Check Repository", err.Error())
+			}
+			return
+		}
+
+		// Casing differences are harmless; a rename or transfer only works
+		// for reads, so point at the new location
+		canonicalOwner, canonicalRepo := bootstrap.Location()
+		if !strings.EqualFold(canonicalOwner+"/"+canonicalRepo, owner+"/"+repository) {
+			resp.Diagnostics.AddWarning(
+				"Repository Moved",
+				fmt.Sprintf("%s/%s has been renamed or transferred to %s/%s. The provider uses the new location for this run; "+
+					"set owner = %q and repository = %q to match.", owner, repository, canonicalOwner, canonicalRepo, canonicalOwner, canonicalRepo),
+			)
+		}
+		owner, repository = canonicalOwner, canonicalRepo
+
+		// Read-only tokens still serve data sources; writes fail before
+		// reaching GitHub instead of with a bare 403 or 404
+		if canWrite = bootstrap.TokenCanWrite(); !canWrite {
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("token"),
+				"Read-Only GitHub Token",
+				fmt.Sprintf("The token can read %s/%s but not write to it. Data sources work; creating, changing or deleting resources "+
+					"will fail. Use a classic token with the repo scope, or a fine-grained token with Contents read and write access, to make changes.", owner, repository),
+			)
+		}
+
+		var err error
+		repoConfig, err = bootstrap.GetRepoConfig(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Failed to Read Repository Config",
+				fmt.Sprintf("Unable to read %s: %s", client.RepoConfigPath, err),
+			)
+			return
+		}
 	}
+	var allowedRegions []string
+	if !config.AllowedRegions.IsNull() {
+		resp.Diagnostics.Append(config.AllowedRegions.ElementsAs(ctx, &allowedRegions, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	settings := repoConfig.Overlay(client.RepoConfig{
+		PoolsFile:            config.PoolsFile.ValueStringPointer(),
+		PoolsWriteFile:       config.PoolsWriteFile.ValueStringPointer(),
+		PoolsOwner:           config.PoolsOwner.ValueStringPointer(),
+		PoolsRepository:      config.PoolsRepository.ValueStringPointer(),
+		PoolsBranch:          config.PoolsBranch.ValueStringPointer(),
+		AllocationsFile:      config.AllocationsFile.ValueStringPointer(),
+		MaxRetries:           config.MaxRetries.ValueInt64Pointer(),
+		ConflictRetries:      config.ConflictRetries.ValueInt64Pointer(),
+		TransientRetries:     config.TransientRetry.ValueInt64Pointer(),
+		BaseDelayMs:          config.BaseDelayMs.ValueInt64Pointer(),
+		AllowPublic:          config.AllowPublic.ValueBoolPointer(),
+		CommitTrailer:        config.CommitTrailer.ValueStringPointer(),
+		DocsDetailLevel:      config.DocsDetailLevel.ValueStringPointer(),
+		DeferDocs:            config.DeferDocs.ValueBoolPointer(),
+		DocsStrict:           config.DocsStrict.ValueBoolPointer(),
+		GenerateImportBlocks: config.ImportBlocks.ValueBoolPointer(),
+		GenerateNetBoxExport: config.NetBoxExport.ValueBoolPointer(),
+		GenerateChangelog:    config.Changelog.ValueBoolPointer(),
+		MaxNestingDepth:      config.MaxNestingDepth.ValueInt64Pointer(),
+		PreservePoolsFormat:  config.PreserveFormat.ValueBoolPointer(),
+		IncludeOpenPRs:       config.IncludeOpenPRs.ValueBoolPointer(),
+		AllowedRegions:       allowedRegions,
+		ArchiveDeletions:     config.ArchiveDelete.ValueBoolPointer(),
+		TolerateUnknown:      config.TolerateUnknown.ValueBoolPointer(),
+		IDScheme:             config.IDScheme.ValueStringPointer(),
+	})
+	if err := settings.Validate(); err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid Repository Config",
+			fmt.Sprintf("%s: %s", client.RepoConfigPath, err),
+		)
+		return
+	}
+
+	// Pools may be governed in a separate repository; check it the same
+	// way, and follow a rename of it too
+	poolsOwner := valueOr(settings.PoolsOwner, owner)
+	poolsRepository := valueOr(settings.PoolsRepository, repository)
+	poolsBranch := valueOr(settings.PoolsBranch, branch)
+	poolsKnown := known && !config.PoolsOwner.IsUnknown() && !config.PoolsRepository.IsUnknown() && !config.PoolsBranch.IsUnknown()
+	if poolsKnown && (poolsOwner != owner || poolsRepository != repository || poolsBranch != branch) {
+		bootstrap := client.NewGitHubClient(
+			token,
+			poolsOwner,
+			poolsRepository,
+			poolsBranch, "", "", 0, 0, client.Options{},
+		)
+		if err := bootstrap.CheckLocation(ctx); err != nil {
+			switch {
+			case errors.Is(err, client.ErrRepositoryNotFound):
+				resp.Diagnostics.AddAttributeError(path.Root("pools_repository"), "Pools Repository Not Found", err.Error())
+			case errors.Is(err, client.ErrBranchNotFound):
+				resp.Diagnostics.AddAttributeError(path.Root("pools_branch"), "Pools Branch Not Found", err.Error())
+			default:
+				resp.Diagnostics.AddError("Failed to Check Pools Repository", err.Error())
+			}
+			return
+		}
 
-	maxRetries := int64(10)
-	if !config.MaxRetries.IsNull() {
-		maxRetries = config.MaxRetries.ValueInt64()
+		canonicalOwner, canonicalRepo := bootstrap.Location()
+		if !strings.EqualFold(canonicalOwner+"/"+canonicalRepo, poolsOwner+"/"+poolsRepository) {
+			resp.Diagnostics.AddWarning(
+				"Pools Repository Moved",
+				fmt.Sprintf("%s/%s has been renamed or transferred to %s/%s. The provider uses the new location for this run; "+
+					"set pools_owner = %q and pools_repository = %q to match.", poolsOwner, poolsRepository, canonicalOwner, canonicalRepo, canonicalOwner, canonicalRepo),
+			)
+		}
+		poolsOwner, poolsRepository = canonicalOwner, canonicalRepo
 	}
 
-	baseDelayMs := int64(200)
-	if !config.BaseDelayMs.IsNull() {
-		baseDelayMs = config.BaseDelayMs.ValueInt64()
+	poolsFile := valueOr(settings.PoolsFile, "config/pools.yaml")
+	allocationsFile := valueOr(settings.AllocationsFile, "config/allocations.yaml")
+	// max_retries predates the split caps and sets both
+	conflictRetries := valueOr(settings.ConflictRetries, valueOr(settings.MaxRetries, 10))
+	transientRetries := valueOr(settings.TransientRetries, valueOr(settings.MaxRetries, 3))
+	baseDelayMs := valueOr(settings.BaseDelayMs, 200)
+
+	commitTrailer := valueOr(settings.CommitTrailer, "")
+	if err := client.ValidateCommitTrailer(commitTrailer); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("commit_trailer"),
+			"Invalid Commit Trailer",
+			err.Error(),
+		)
+		return
 	}
 
+	commitInfo := client.CommitInfoFromEnv(client.CommitInfo{
+		RunID:     config.RunID.ValueString(),
+		Workspace: config.Workspace.ValueString(),
+	})
+
 	// Create GitHub client
 	ghClient := client.NewGitHubClient(
-		config.Token.ValueString(),
-		config.Owner.ValueString(),
-		config.Repository.ValueString(),
+		token,
+		owner,
+		repository,
 		branch,
 		poolsFile,
 		allocationsFile,
-		int(maxRetries),
+		int(conflictRetries),
 		baseDelayMs,
+		client.Options{
+			AllowPublic:     valueOr(settings.AllowPublic, false),
+			AllowedRegions:  settings.AllowedRegions,
+			CommitTrailer:   commitTrailer,
+			CommitInfo:      commitInfo,
+			PoolsWriteFile:  valueOr(settings.PoolsWriteFile, ""),
+			PoolsOwner:      poolsOwner,
+			PoolsRepo:       poolsRepository,
+			PoolsBranch:     poolsBranch,
+			DocsDetailLevel: valueOr(settings.DocsDetailLevel, ""),
+			DeferDocs:       valueOr(settings.DeferDocs, false),
+			DocsStrict:      valueOr(settings.DocsStrict, false),
+			ImportBlocks:    valueOr(settings.GenerateImportBlocks, false),
+			NetBoxExport:    valueOr(settings.GenerateNetBoxExport, false),
+			MaxNestingDepth: int(valueOr(settings.MaxNestingDepth, 0)),
+
+			PreservePoolsFormat: valueOr(settings.PreservePoolsFormat, false),
+			IncludeOpenPRs:      valueOr(settings.IncludeOpenPRs, false),
+			ArchiveDeletions:    valueOr(settings.ArchiveDeletions, false),
+			StrictAllocations:   !valueOr(settings.TolerateUnknown, true),
+			Changelog:           valueOr(settings.GenerateChangelog, false),
+			IDScheme:            valueOr(settings.IDScheme, ipam.IDSchemeRandomUUID),
+			TransientRetries:    int(transientRetries),
+			ReadOnly:            !canWrite,
+		},
 	)
 
+	// A missing pools file is created on first read, which on an existing
+	// repository usually means pools_file points at the wrong path
+	if known {
+		exists, err := ghClient.PoolsFileExists(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to Check Pools File", err.Error())
+			return
+		}
+		if !exists {
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("pools_file"),
+				"Pools File Not Found",
+				fmt.Sprintf("%s does not exist in %s/%s on branch %q and will be created empty on first use. Check pools_file if pools are expected.", poolsFile, poolsOwner, poolsRepository, poolsBranch),
+			)
+		}
+	}
+
 	// Make the client available to resources and data sources
 	resp.DataSourceData = ghClient
 	resp.ResourceData = ghClient
 }
 
+// valueOr returns the value of a setting, or def when it is unset.
+func valueOr[T any](setting *T, def T) T {
+	if setting == nil {
+		return def
+	}
+	return *setting
+}
+
 func (p *GitIPAMProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		resources.NewAllocationResource,
+		resources.NewSmartAllocationResource,
+		resources.NewDocsResource,
 		resources.NewPoolResource,
+		resources.NewReservationPlanResource,
 	}
 }
 
@@ -177,8 +602,22 @@ func (p *GitIPAMProvider) DataSources(ctx context.Context) []func() datasource.D
 	return []func() datasource.DataSource{
 		datasources.NewPoolsDataSource,
 		datasources.NewPoolDataSource,
+		datasources.NewPoolExportDataSource,
+		datasources.NewPoolHistoryDataSource,
 		datasources.NewAllocationDataSource,
 		datasources.NewAllocationsDataSource,
+		datasources.NewArchivedAllocationsDataSource,
 		datasources.NewNextAvailableDataSource,
+		datasources.NewDefragPlanDataSource,
+		datasources.NewReverseZonesDataSource,
+		datasources.NewLocateDataSource,
+		datasources.NewTilingCheckDataSource,
+		datasources.NewRouteSummaryDataSource,
+		datasources.NewChargebackDataSource,
+		datasources.NewPrefixListDataSource,
+		datasources.NewHealthcheckDataSource,
+		datasources.NewCanAllocateDataSource,
+		datasources.NewDriftDataSource,
+		datasources.NewProviderStatsDataSource,
 	}
 }
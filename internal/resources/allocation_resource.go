@@ -5,18 +5,22 @@ package resources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"strings"
+	"time"
 
 	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
 	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
-	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
@@ -29,6 +33,7 @@ var (
 	_ resource.Resource                = &AllocationResource{}
 	_ resource.ResourceWithConfigure   = &AllocationResource{}
 	_ resource.ResourceWithImportState = &AllocationResource{}
+	_ resource.ResourceWithModifyPlan  = &AllocationResource{}
 )
 
 // NewAllocationResource creates a new allocation resource.
@@ -44,15 +49,40 @@ type AllocationResource struct {
 
 // AllocationResourceModel describes the resource data model.
 type AllocationResourceModel struct {
-	ID             types.String `tfsdk:"id"`
-	PoolID         types.String `tfsdk:"pool_id"`
-	ParentCIDR     types.String `tfsdk:"parent_cidr"`
-	CIDRMask       types.Int64  `tfsdk:"cidr_mask"`
-	CIDR           types.String `tfsdk:"cidr"`
-	Name           types.String `tfsdk:"name"`
-	Status         types.String `tfsdk:"status"`
-	ContiguousWith types.String `tfsdk:"contiguous_with"`
-	Metadata       types.Map    `tfsdk:"metadata"`
+	ID             types.String  `tfsdk:"id"`
+	PoolID         types.String  `tfsdk:"pool_id"`
+	ParentCIDR     types.String  `tfsdk:"parent_cidr"`
+	ParentName     types.String  `tfsdk:"parent_name"`
+	FromCIDR       types.String  `tfsdk:"from_cidr"`
+	CIDRMask       types.Int64   `tfsdk:"cidr_mask"`
+	MinMask        types.Int64   `tfsdk:"min_mask"`
+	MaxMask        types.Int64   `tfsdk:"max_mask"`
+	CIDR           types.String  `tfsdk:"cidr"`
+	CIDRs          types.List    `tfsdk:"cidrs"`
+	ContiguousCnt  types.Int64   `tfsdk:"contiguous_count"`
+	FutureSibs     types.Int64   `tfsdk:"ensure_future_siblings"`
+	Placement      types.String  `tfsdk:"placement"`
+	PoolCIDR       types.String  `tfsdk:"pool_cidr"`
+	PoolIndex      types.Int64   `tfsdk:"pool_index"`
+	FirstIP        types.String  `tfsdk:"first_ip"`
+	LastIP         types.String  `tfsdk:"last_ip"`
+	UsableFirstIP  types.String  `tfsdk:"usable_first_ip"`
+	UsableLastIP   types.String  `tfsdk:"usable_last_ip"`
+	AddressCount   types.Int64   `tfsdk:"address_count"`
+	ChildAvailable types.Int64   `tfsdk:"child_available_addresses"`
+	ChildUtilPct   types.Float64 `tfsdk:"child_utilization_pct"`
+	Name           types.String  `tfsdk:"name"`
+	Status         types.String  `tfsdk:"status"`
+	ContiguousWith types.String  `tfsdk:"contiguous_with"`
+	ContiguousDir  types.String  `tfsdk:"contiguous_direction"`
+	VLANID         types.Int64   `tfsdk:"vlan_id"`
+	Region         types.String  `tfsdk:"region"`
+	CostCenter     types.String  `tfsdk:"cost_center"`
+	Metadata       types.Map     `tfsdk:"metadata"`
+	EffectiveMeta  types.Map     `tfsdk:"effective_metadata"`
+	IgnoreMetaKeys types.List    `tfsdk:"ignore_external_metadata_keys"`
+	AdoptExisting  types.Bool    `tfsdk:"adopt_existing"`
+	Locked         types.Bool    `tfsdk:"locked"`
 }
 
 func (r *AllocationResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -81,8 +111,8 @@ Exactly one of ` + "`pool_id`" + ` or ` + "`parent_cidr`" + ` must be specified.
 			},
 			"pool_id": schema.StringAttribute{
 				Optional:            true,
-				Description:         "Pool ID from pools.yaml to allocate from (Mode 1). Mutually exclusive with parent_cidr.",
-				MarkdownDescription: "Pool ID from pools.yaml to allocate from (Mode 1). Mutually exclusive with `parent_cidr`.",
+				Description:         "Pool ID from pools.yaml to allocate from (Mode 1). Mutually exclusive with parent_cidr and parent_name.",
+				MarkdownDescription: "Pool ID from pools.yaml to allocate from (Mode 1). Mutually exclusive with `parent_cidr` and `parent_name`.",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.RequiresReplace(),
 				},
@@ -90,31 +120,240 @@ Exactly one of ` + "`pool_id`" + ` or ` + "`parent_cidr`" + ` must be specified.
 					stringvalidator.ExactlyOneOf(path.Expressions{
 						path.MatchRoot("pool_id"),
 						path.MatchRoot("parent_cidr"),
+						path.MatchRoot("parent_name"),
 					}...),
 				},
 			},
 			"parent_cidr": schema.StringAttribute{
 				Optional: true,
+				Computed: true,
 				Description: "CIDR of an existing allocation to sub-allocate from (Mode 2). " +
-					"Use this for allocating subnets within a VPC CIDR. Mutually exclusive with pool_id.",
+					"Use this for allocating subnets within a VPC CIDR. Mutually exclusive with pool_id, " +
+					"which stays null for sub-allocations after refresh and import. Resolved from parent_name when that is set.",
 				MarkdownDescription: "CIDR of an existing allocation to sub-allocate from (Mode 2). " +
-					"Use this for allocating subnets within a VPC CIDR. Mutually exclusive with `pool_id`.",
+					"Use this for allocating subnets within a VPC CIDR. Mutually exclusive with `pool_id`, " +
+					"which stays null for sub-allocations after refresh and import. Resolved from `parent_name` when that is set.",
 				PlanModifiers: []planmodifier.String{
+					parentCIDRFromName{r: r},
 					stringplanmodifier.RequiresReplace(),
 				},
 			},
+			"parent_name": schema.StringAttribute{
+				Optional: true,
+				Description: "Name of an existing allocation to sub-allocate from, instead of its CIDR (Mode 2). " +
+					"Lets a subnet reference github-ipam_allocation.vpc.name rather than a hardcoded CIDR. " +
+					"The resolved CIDR is stored in parent_cidr. Mutually exclusive with pool_id and parent_cidr.",
+				MarkdownDescription: "Name of an existing allocation to sub-allocate from, instead of its CIDR (Mode 2). " +
+					"Lets a subnet reference `github-ipam_allocation.vpc.name` rather than a hardcoded CIDR. " +
+					"The resolved CIDR is stored in `parent_cidr`. Mutually exclusive with `pool_id` and `parent_cidr`.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"from_cidr": schema.StringAttribute{
+				Optional: true,
+				Description: "For multi-CIDR pools, the pool CIDR to allocate from instead of trying each in turn. " +
+					"Must be one of the pool's CIDRs. Only valid with pool_id.",
+				MarkdownDescription: "For multi-CIDR pools, the pool CIDR to allocate from instead of trying each in turn. " +
+					"Must be one of the pool's CIDRs. Only valid with `pool_id`.",
+				PlanModifiers: []planmodifier.String{
+					// Imported allocations have no from_cidr recorded; only
+					// changing an applied constraint to another re-allocates,
+					// setting or removing one just records it
+					stringplanmodifier.RequiresReplaceIf(
+						func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+							resp.RequiresReplace = !req.StateValue.IsNull() && !req.PlanValue.IsNull()
+						},
+						"Changing from_cidr re-allocates the block.",
+						"Changing `from_cidr` re-allocates the block.",
+					),
+				},
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("parent_cidr"), path.MatchRoot("parent_name")),
+				},
+			},
 			"cidr_mask": schema.Int64Attribute{
-				Required:            true,
-				Description:         "Prefix length for the allocation (e.g., 16 for /16, 24 for /24).",
-				MarkdownDescription: "Prefix length for the allocation (e.g., `16` for /16, `24` for /24).",
+				Optional: true,
+				Computed: true,
+				Description: "Prefix length for the allocation (e.g., 16 for /16, 24 for /24). " +
+					"Exactly one of cidr_mask or min_mask and max_mask must be specified; with a range this is the size allocated.",
+				MarkdownDescription: "Prefix length for the allocation (e.g., `16` for /16, `24` for /24). " +
+					"Exactly one of `cidr_mask` or `min_mask` and `max_mask` must be specified; with a range this is the size allocated.",
 				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
 					int64planmodifier.RequiresReplace(),
 				},
+				Validators: []validator.Int64{
+					int64validator.Between(0, 128),
+					int64validator.ExactlyOneOf(path.MatchRoot("cidr_mask"), path.MatchRoot("min_mask")),
+				},
+			},
+			"min_mask": schema.Int64Attribute{
+				Optional: true,
+				Description: "Shortest prefix length, i.e. the largest block, to allocate. With max_mask, allocates " +
+					"the largest free block between the two instead of a fixed cidr_mask.",
+				MarkdownDescription: "Shortest prefix length, i.e. the largest block, to allocate. With `max_mask`, allocates " +
+					"the largest free block between the two instead of a fixed `cidr_mask`.",
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceOnceSet(),
+				},
+				Validators: []validator.Int64{
+					int64validator.Between(1, 128),
+					int64validator.AlsoRequires(path.MatchRoot("max_mask")),
+					int64validator.ConflictsWith(path.MatchRoot("contiguous_with")),
+				},
+			},
+			"max_mask": schema.Int64Attribute{
+				Optional:            true,
+				Description:         "Longest prefix length, i.e. the smallest block, to accept with min_mask.",
+				MarkdownDescription: "Longest prefix length, i.e. the smallest block, to accept with `min_mask`.",
+				PlanModifiers: []planmodifier.Int64{
+					requiresReplaceOnceSet(),
+				},
+				Validators: []validator.Int64{
+					int64validator.Between(1, 128),
+					int64validator.AlsoRequires(path.MatchRoot("min_mask")),
+					int64validator.AtLeastSumOf(path.MatchRoot("min_mask")),
+				},
 			},
 			"cidr": schema.StringAttribute{
 				Computed:            true,
-				Description:         "Allocated CIDR block (computed at apply time).",
-				MarkdownDescription: "Allocated CIDR block (computed at apply time).",
+				Description:         "Allocated CIDR block (computed at apply time). With contiguous_count, the first block of the run.",
+				MarkdownDescription: "Allocated CIDR block (computed at apply time). With `contiguous_count`, the first block of the run.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cidrs": schema.ListAttribute{
+				Computed:            true,
+				ElementType:         types.StringType,
+				Description:         "Every allocated block in address order: the run with contiguous_count, otherwise just cidr.",
+				MarkdownDescription: "Every allocated block in address order: the run with `contiguous_count`, otherwise just `cidr`.",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"contiguous_count": schema.Int64Attribute{
+				Optional: true,
+				Description: "Allocate this many back-to-back blocks of cidr_mask in one commit, e.g. one per availability zone. " +
+					"Each block is a separate allocation; they share this resource's ID as their group and are named " +
+					"name, name-2, name-3 and so on. Fails without allocating anything if no contiguous run is free.",
+				MarkdownDescription: "Allocate this many back-to-back blocks of `cidr_mask` in one commit, e.g. one per availability zone. " +
+					"Each block is a separate allocation; they share this resource's ID as their group and are named " +
+					"`name`, `name-2`, `name-3` and so on. Fails without allocating anything if no contiguous run is free.",
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+				Validators: []validator.Int64{
+					int64validator.AtLeast(2),
+					int64validator.ConflictsWith(
+						path.MatchRoot("min_mask"),
+						path.MatchRoot("contiguous_with"),
+						path.MatchRoot("adopt_existing"),
+					),
+				},
+			},
+			"ensure_future_siblings": schema.Int64Attribute{
+				Optional: true,
+				Description: "When sub-allocating, fail unless at least this many more free blocks of the same mask " +
+					"remain in the parent after this allocation, so the parent keeps room to grow. Only checked on create.",
+				MarkdownDescription: "When sub-allocating, fail unless at least this many more free blocks of the same mask " +
+					"remain in the parent after this allocation, so the parent keeps room to grow. Only checked on create.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+					int64validator.ConflictsWith(path.MatchRoot("pool_id")),
+				},
+			},
+			"placement": schema.StringAttribute{
+				Optional: true,
+				Description: "Where to place a sub-allocation in its parent: 'low' (default) takes the lowest free block, " +
+					"'high' the highest, e.g. for NAT or transit subnets at the top of a VPC. Only affects create.",
+				MarkdownDescription: "Where to place a sub-allocation in its parent: `low` (default) takes the lowest free block, " +
+					"`high` the highest, e.g. for NAT or transit subnets at the top of a VPC. Only affects create.",
+				Validators: []validator.String{
+					stringvalidator.OneOf(ipam.PlacementLow, ipam.PlacementHigh),
+					stringvalidator.ConflictsWith(
+						path.MatchRoot("pool_id"),
+						path.MatchRoot("min_mask"),
+						path.MatchRoot("contiguous_count"),
+						path.MatchRoot("contiguous_with"),
+					),
+				},
+			},
+			"pool_cidr": schema.StringAttribute{
+				Computed: true,
+				Description: "CIDR of the owning pool that contains this allocation. For multi-CIDR pools, " +
+					"the specific pool CIDR containing the block. Also set for sub-allocations.",
+				MarkdownDescription: "CIDR of the owning pool that contains this allocation. For multi-CIDR pools, " +
+					"the specific pool CIDR containing the block. Also set for sub-allocations.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"pool_index": schema.Int64Attribute{
+				Computed: true,
+				Description: "Zero-based index of the block among the same-sized blocks of pool_cidr, " +
+					"e.g. 4 for the fifth /24 carved from the pool.",
+				MarkdownDescription: "Zero-based index of the block among the same-sized blocks of `pool_cidr`, " +
+					"e.g. `4` for the fifth /24 carved from the pool.",
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"first_ip": schema.StringAttribute{
+				Computed:            true,
+				Description:         "First address of the allocated block.",
+				MarkdownDescription: "First address of the allocated block.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"last_ip": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Last address of the allocated block.",
+				MarkdownDescription: "Last address of the allocated block.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"usable_first_ip": schema.StringAttribute{
+				Computed: true,
+				Description: "First host address of the block. Skips the network address for IPv4 blocks larger than /31; " +
+					"otherwise equal to first_ip.",
+				MarkdownDescription: "First host address of the block. Skips the network address for IPv4 blocks larger than /31; " +
+					"otherwise equal to `first_ip`.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"address_count": schema.Int64Attribute{
+				Computed: true,
+				Description: "Number of addresses in the block, derived from cidr on every read. " +
+					"Null for IPv6 blocks too large to represent.",
+				MarkdownDescription: "Number of addresses in the block, derived from `cidr` on every read. " +
+					"Null for IPv6 blocks too large to represent.",
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"child_available_addresses": schema.Int64Attribute{
+				Computed: true,
+				Description: "Addresses of the block not yet sub-allocated by allocations with this block as parent_cidr, " +
+					"refreshed on every read. Equal to address_count for a block without children.",
+				MarkdownDescription: "Addresses of the block not yet sub-allocated by allocations with this block as `parent_cidr`, " +
+					"refreshed on every read. Equal to `address_count` for a block without children.",
+			},
+			"child_utilization_pct": schema.Float64Attribute{
+				Computed:            true,
+				Description:         "Percentage of the block sub-allocated to its children, 0-100.",
+				MarkdownDescription: "Percentage of the block sub-allocated to its children, 0-100.",
+			},
+			"usable_last_ip": schema.StringAttribute{
+				Computed: true,
+				Description: "Last host address of the block. Skips the broadcast address for IPv4 blocks larger than /31; " +
+					"otherwise equal to last_ip.",
+				MarkdownDescription: "Last host address of the block. Skips the broadcast address for IPv4 blocks larger than /31; " +
+					"otherwise equal to `last_ip`.",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
 				},
@@ -125,33 +364,109 @@ Exactly one of ` + "`pool_id`" + ` or ` + "`parent_cidr`" + ` must be specified.
 				MarkdownDescription: "Human-readable name for this allocation. Can be updated in-place.",
 			},
 			"status": schema.StringAttribute{
-				Optional:            true,
-				Computed:            true,
-				Description:         "Status of the allocation: 'allocation' (default) or 'reservation'. Reservations cannot be used for sub-allocations.",
-				MarkdownDescription: "Status of the allocation: `allocation` (default) or `reservation`. Reservations hold space for future use.",
+				Optional: true,
+				Computed: true,
+				Description: "Status of the allocation: 'allocation' (default), 'reservation', or 'decommissioning'. " +
+					"Reservations and decommissioning blocks cannot be used for sub-allocations.",
+				MarkdownDescription: "Status of the allocation: `allocation` (default), `reservation`, or `decommissioning`. " +
+					"Reservations hold space for future use; decommissioning blocks are being torn down but are not yet free.",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
 				},
 				Validators: []validator.String{
-					stringvalidator.OneOf("allocation", "reservation"),
+					stringvalidator.OneOf(ipam.AllocationStatuses...),
 				},
 			},
 			"contiguous_with": schema.StringAttribute{
 				Optional: true,
 				Description: "CIDR of an existing allocation that this block must be immediately adjacent to. " +
-					"If the constraint cannot be satisfied, the plan will fail.",
+					"With pool_id it must be a top-level allocation of that pool; with parent_cidr, a sub-allocation of that " +
+					"parent, and the block must also fit within the parent. If the constraint cannot be satisfied, the plan will fail.",
 				MarkdownDescription: "CIDR of an existing allocation that this block must be immediately adjacent to. " +
-					"If the constraint cannot be satisfied, the plan will fail.",
+					"With `pool_id` it must be a top-level allocation of that pool; with `parent_cidr`, a sub-allocation of that " +
+					"parent, and the block must also fit within the parent. If the constraint cannot be satisfied, the plan will fail.",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.RequiresReplace(),
 				},
 			},
+			"contiguous_direction": schema.StringAttribute{
+				Optional: true,
+				Description: "Side of contiguous_with to place the block on: 'before', 'after', or 'either' (default), " +
+					"which tries before first. Only used when the block is allocated.",
+				MarkdownDescription: "Side of `contiguous_with` to place the block on: `before`, `after`, or `either` (default), " +
+					"which tries before first. Use `after` to always grow upward. Only used when the block is allocated.",
+				Validators: []validator.String{
+					stringvalidator.OneOf(ipam.ContiguousDirections...),
+					stringvalidator.AlsoRequires(path.MatchRoot("contiguous_with")),
+				},
+			},
+			"vlan_id": schema.Int64Attribute{
+				Optional:            true,
+				Description:         "802.1Q VLAN ID carried by the block, between 1 and 4094. Can be changed in place.",
+				MarkdownDescription: "802.1Q VLAN ID carried by the block, between 1 and 4094. Can be changed in place.",
+				Validators: []validator.Int64{
+					int64validator.Between(ipam.MinVLANID, ipam.MaxVLANID),
+				},
+			},
+			"region": schema.StringAttribute{
+				Optional: true,
+				Description: "Cloud region the block is used in, e.g. us-east-1. Must be one of the provider's " +
+					"allowed_regions when that is set. Can be changed in place.",
+				MarkdownDescription: "Cloud region the block is used in, e.g. `us-east-1`. Must be one of the provider's " +
+					"`allowed_regions` when that is set. Can be changed in place.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"cost_center": schema.StringAttribute{
+				Optional: true,
+				Description: "Cost center the block's address space is charged to, for internal chargeback. " +
+					"Can be changed in place.",
+				MarkdownDescription: "Cost center the block's address space is charged to, for internal chargeback " +
+					"through `github-ipam_chargeback`. Can be changed in place.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
 			"metadata": schema.MapAttribute{
 				Optional:            true,
 				ElementType:         types.StringType,
 				Description:         "Key-value metadata for the allocation.",
 				MarkdownDescription: "Key-value metadata for the allocation.",
 			},
+			"effective_metadata": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "Metadata stored for the allocation: the pool's default_metadata merged beneath metadata, " +
+					"with keys set in metadata winning.",
+				MarkdownDescription: "Metadata stored for the allocation: the pool's `default_metadata` merged beneath `metadata`, " +
+					"with keys set in `metadata` winning.",
+			},
+			"ignore_external_metadata_keys": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Metadata keys written by tools outside Terraform, such as a labeling bot. Unless metadata sets them, " +
+					"they are left out of metadata on refresh and kept on update, so they don't cause drift or get removed.",
+				MarkdownDescription: "Metadata keys written by tools outside Terraform, such as a labeling bot. Unless `metadata` sets them, " +
+					"they are left out of `metadata` on refresh and kept on update, so they don't cause drift or get removed. " +
+					"They still show in `effective_metadata`.",
+			},
+			"adopt_existing": schema.BoolAttribute{
+				Optional: true,
+				Description: "If true and an allocation with the same name already exists with a matching pool or parent " +
+					"and mask, bind to it instead of failing. Use when onboarding allocations made outside Terraform.",
+				MarkdownDescription: "If `true` and an allocation with the same name already exists with a matching pool or parent " +
+					"and mask, bind to it instead of failing. Use when onboarding allocations made outside Terraform.",
+			},
+			"locked": schema.BoolAttribute{
+				Optional: true,
+				Description: "If true, in-place changes such as name, metadata, status, VLAN ID, region and cost center are " +
+					"refused until locked is set to false in an apply of its own. Use for blocks that must not drift, such as " +
+					"legally assigned ranges. Does not hold back deletion or replacement.",
+				MarkdownDescription: "If `true`, in-place changes such as `name`, `metadata`, `status`, `vlan_id`, `region` and `cost_center` are " +
+					"refused until `locked` is set to `false` in an apply of its own. Use for blocks that must not drift, such as " +
+					"legally assigned ranges. Does not hold back deletion or replacement.",
+			},
 		},
 	}
 }
@@ -175,6 +490,92 @@ func (r *AllocationResource) Configure(ctx context.Context, req resource.Configu
 	r.allocator = ipam.NewAllocator()
 }
 
+// ModifyPlan rejects regions outside allowed_regions, and warns about name
+// collisions and pool exhaustion for new allocations. State can change
+// before apply, so the latter are warnings only.
+func (r *AllocationResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Destroys have a null plan
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	// The provider may not be configured yet (e.g. during validate)
+	if r.client == nil {
+		return
+	}
+
+	var plan AllocationResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.checkRegion(plan); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("region"), "Region Not Allowed", err.Error())
+		return
+	}
+
+	// Only check creates further; updates keep their CIDR, which has to
+	// satisfy any constraint they add
+	if !req.State.Raw.IsNull() {
+		var state AllocationResourceModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		checkAddedConstraints(ctx, state, plan, &resp.Diagnostics)
+		return
+	}
+
+	if plan.Name.IsUnknown() || plan.CIDRMask.IsUnknown() || plan.PoolID.IsUnknown() || plan.ParentCIDR.IsUnknown() || plan.AdoptExisting.IsUnknown() {
+		return
+	}
+
+	// A mask too long for the family fails at apply with a less helpful
+	// error, so it is rejected outright once the family is known
+	if !plan.CIDRMask.IsNull() && !plan.ParentCIDR.IsNull() {
+		if err := ipam.CheckPrefixFamily(int(plan.CIDRMask.ValueInt64()), []string{plan.ParentCIDR.ValueString()}); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("cidr_mask"), "Invalid CIDR Mask",
+				fmt.Sprintf("cidr_mask %s for parent %s.", err, plan.ParentCIDR.ValueString()))
+			return
+		}
+	}
+
+	pools, err := r.client.GetPoolsWithProposed(ctx)
+	if err != nil {
+		tflog.Debug(ctx, "Skipping plan-time allocation check", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	if poolDef, ok := pools.GetPool(plan.PoolID.ValueString()); ok && !plan.CIDRMask.IsNull() {
+		if err := ipam.CheckPrefixFamily(int(plan.CIDRMask.ValueInt64()), poolDef.CIDR); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("cidr_mask"), "Invalid CIDR Mask",
+				fmt.Sprintf("cidr_mask %s for pool %q.", err, plan.PoolID.ValueString()))
+			return
+		}
+	}
+	if prNumber, ok := pools.ProposedIn(plan.PoolID.ValueString()); ok {
+		resp.Diagnostics.AddWarning("Pool Is Provisional",
+			fmt.Sprintf("Pool %q is only defined in open pull request #%d. The apply will fail until that pull request is merged.", plan.PoolID.ValueString(), prNumber))
+	}
+	db, _, err := r.client.GetAllocations(ctx)
+	if err != nil {
+		tflog.Debug(ctx, "Skipping plan-time allocation check", map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	warnings := r.allocator.PrecheckAllocation(pools, db, ipam.AllocationRequest{
+		Name:       plan.Name.ValueString(),
+		PoolID:     plan.PoolID.ValueString(),
+		ParentCIDR: plan.ParentCIDR.ValueString(),
+		Placement:  plan.Placement.ValueString(),
+		PrefixLen:  int(plan.CIDRMask.ValueInt64()),
+		Adopt:      plan.AdoptExisting.ValueBool(),
+	})
+	for _, w := range warnings {
+		resp.Diagnostics.AddWarning("Allocation May Fail at Apply", w)
+	}
+}
+
 func (r *AllocationResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var plan AllocationResourceModel
 
@@ -183,8 +584,13 @@ func (r *AllocationResource) Create(ctx context.Context, req resource.CreateRequ
 		return
 	}
 
-	// Generate a unique random UUID for this allocation
-	allocationID := uuid.New().String()
+	// A region unknown at plan time is only checked now
+	if err := r.checkRegion(plan); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("region"), "Region Not Allowed", err.Error())
+		return
+	}
+
+	allocationID := r.client.NewAllocationID(plan.PoolID.ValueString(), plan.Name.ValueString(), int(plan.CIDRMask.ValueInt64()))
 
 	tflog.Debug(ctx, "Creating allocation", map[string]interface{}{
 		"allocation_id": allocationID,
@@ -193,7 +599,18 @@ func (r *AllocationResource) Create(ctx context.Context, req resource.CreateRequ
 	})
 
 	var allocatedCIDR string
-	retryConfig := client.NewRetryConfig(r.client.MaxRetries(), r.client.BaseDelay().Milliseconds())
+	var allocatedBlocks []string
+	var poolCIDR types.String
+	var poolIndex types.Int64
+	var effectiveMetadata map[string]string
+	var adopted *ipam.Allocation
+	var adoptedChildren []ipam.Allocation
+	retryConfig := r.client.RetryConfig()
+
+	// With min_mask and max_mask the largest free block in between is taken
+	rangeMode := !plan.MinMask.IsNull()
+	stripeCount := int(plan.ContiguousCnt.ValueInt64())
+	minPrefix, maxPrefix := int(plan.MinMask.ValueInt64()), int(plan.MaxMask.ValueInt64())
 
 	err := client.WithRetry(ctx, retryConfig, func(ctx context.Context, attempt int) (bool, error) {
 		// Read pools.yaml (read-only)
@@ -208,13 +625,69 @@ func (r *AllocationResource) Create(ctx context.Context, req resource.CreateRequ
 			return false, fmt.Errorf("failed to read allocations: %w", err)
 		}
 
-		// Check for duplicate name
-		if existing, _, found := db.FindAllocationByName(plan.Name.ValueString()); found {
-			return false, fmt.Errorf("allocation name %q already exists (used by allocation %s)", plan.Name.ValueString(), existing.CIDR)
+		// Resolve parent_name now, in case the parent was unknown at plan time
+		if !plan.ParentName.IsNull() {
+			resolved, err := db.ResolveParentName(plan.ParentName.ValueString())
+			if err != nil {
+				return false, err
+			}
+			if !plan.ParentCIDR.IsUnknown() && plan.ParentCIDR.ValueString() != resolved {
+				return false, fmt.Errorf("parent_name %q now names %s, not %s as planned; plan again", plan.ParentName.ValueString(), resolved, plan.ParentCIDR.ValueString())
+			}
+			plan.ParentCIDR = types.StringValue(resolved)
+		}
+
+		// Check for duplicate name, adopting the existing allocation if asked to
+		if existing, existingPoolID, found := db.FindAllocationByName(plan.Name.ValueString()); found {
+			if !plan.AdoptExisting.ValueBool() {
+				return false, fmt.Errorf("allocation name %q already exists (used by allocation %s)", plan.Name.ValueString(), existing.CIDR)
+			}
+			if err := ipam.CheckAdoptable(existing, existingPoolID, ipam.AllocationRequest{
+				Name:         plan.Name.ValueString(),
+				PoolID:       plan.PoolID.ValueString(),
+				ParentCIDR:   plan.ParentCIDR.ValueString(),
+				PrefixLen:    int(plan.CIDRMask.ValueInt64()),
+				MinPrefixLen: int(plan.MinMask.ValueInt64()),
+				MaxPrefixLen: int(plan.MaxMask.ValueInt64()),
+			}); err != nil {
+				return false, err
+			}
+
+			// Adopting a claim from the next_available data source makes it
+			// permanent, so it must be written before it lapses
+			if existing.IsClaim() {
+				status := ipam.StatusAllocation
+				if !plan.Status.IsNull() && !plan.Status.IsUnknown() {
+					status = plan.Status.ValueString()
+				}
+				existing.ExpiresAt = ""
+				existing.SetStatus(status)
+
+				commitMsg := fmt.Sprintf("ipam: take over claim %s (%s)", existing.CIDR, existing.Name)
+				err = r.client.UpdateAllocations(ctx, db, sha, commitMsg)
+				if r.client.IsConflictError(err) {
+					tflog.Debug(ctx, "Conflict detected, will retry", map[string]interface{}{
+						"attempt": attempt,
+					})
+					return true, err
+				}
+				if err != nil {
+					return false, err
+				}
+			}
+
+			found := *existing
+			adopted = &found
+			poolCIDR = poolCIDRValue(pools, existingPoolID, found.CIDR)
+			poolIndex = poolIndexValue(pools, existingPoolID, found.CIDR)
+			effectiveMetadata = found.Metadata
+			adoptedChildren = db.GetAllocationsForParent(found.CIDR)
+			return false, nil
 		}
 
 		var newCIDR string
 		var poolID string
+		var stripe []string
 
 		if !plan.PoolID.IsNull() {
 			// Mode 1: Allocate from pool defined in pools.yaml
@@ -225,26 +698,64 @@ func (r *AllocationResource) Create(ctx context.Context, req resource.CreateRequ
 			}
 
 			// Check if pool is reserved - cannot allocate from reserved pools
-			if poolDef.Reserved {
-				return false, fmt.Errorf("cannot allocate from pool %q: pool is reserved (reserved pools cannot have allocations)", poolID)
+			if err := ipam.CheckPoolAllocatable(poolID, poolDef); err != nil {
+				return false, err
 			}
 
 			existingAllocs := db.GetAllocationsForPool(poolID)
+			opts := db.AllocateOptionsForPool(poolID, poolDef)
+			fullPoolDef := poolDef
+
+			// Search only the requested range of a multi-CIDR pool
+			if !plan.FromCIDR.IsNull() {
+				poolDef, err = poolDef.RestrictTo(plan.FromCIDR.ValueString())
+				if err != nil {
+					return false, fmt.Errorf("cannot allocate from pool %q: %w", poolID, err)
+				}
+			}
 
 			// Check if contiguous_with is specified
-			if !plan.ContiguousWith.IsNull() {
+			if stripeCount > 0 {
+				stripe, err = r.allocator.FindContiguousRunInPool(poolDef, existingAllocs, int(plan.CIDRMask.ValueInt64()), stripeCount, opts)
+				if err != nil {
+					return false, fmt.Errorf("allocation from pool %s failed: %w", poolID, err)
+				}
+				container, _ := poolDef.ContainingCIDR(stripe[0])
+				if err := r.validateStripe(container, stripe); err != nil {
+					return false, err
+				}
+				newCIDR = stripe[0]
+			} else if !plan.ContiguousWith.IsNull() {
 				targetCIDR := plan.ContiguousWith.ValueString()
-				newCIDR, err = findContiguousCIDR(poolDef, existingAllocs, int(plan.CIDRMask.ValueInt64()), targetCIDR)
+				if err := db.CheckContiguousTarget(targetCIDR, poolID, ""); err != nil {
+					return false, err
+				}
+				occupied := append(append([]ipam.Allocation{}, existingAllocs...), opts.Avoid...)
+				newCIDR, err = r.allocator.FindContiguousInPool(poolDef, occupied, int(plan.CIDRMask.ValueInt64()), targetCIDR, plan.ContiguousDir.ValueString())
 				if err != nil {
 					return false, fmt.Errorf("contiguous allocation failed: %w", err)
 				}
+			} else if rangeMode {
+				newCIDR, err = r.allocator.FindLargestAvailableInPool(poolDef, existingAllocs, minPrefix, maxPrefix, opts)
+				if err != nil {
+					return false, fmt.Errorf("allocation from pool %s failed: %w", poolID, err)
+				}
 			} else {
-				newCIDR, err = r.allocator.FindNextAvailableInPool(poolDef, existingAllocs, int(plan.CIDRMask.ValueInt64()))
+				newCIDR, err = r.allocator.FindNextAvailableInPoolWithOptions(poolDef, existingAllocs, int(plan.CIDRMask.ValueInt64()), opts)
 				if err != nil {
 					return false, fmt.Errorf("allocation from pool %s failed: %w", poolID, err)
 				}
 			}
 
+			// The free-space reserve covers the whole pool, whichever range was searched
+			occupied := append([]ipam.Allocation{}, existingAllocs...)
+			for _, block := range blocksOf(stripe, newCIDR) {
+				if err := r.allocator.CheckFreeReserve(poolID, fullPoolDef, occupied, block); err != nil {
+					return false, err
+				}
+				occupied = append(occupied, ipam.Allocation{CIDR: block})
+			}
+
 			tflog.Debug(ctx, "Allocated from pool", map[string]interface{}{
 				"pool_id": poolID,
 				"cidr":    newCIDR,
@@ -260,15 +771,46 @@ func (r *AllocationResource) Create(ctx context.Context, req resource.CreateRequ
 			}
 			poolID = parentPoolID
 
-			// Check if parent is reserved - cannot sub-allocate from reserved blocks
-			if parentAlloc.Reserved {
-				return false, fmt.Errorf("cannot sub-allocate from %q: parent is a reservation (reserved blocks cannot have children)", parentCIDR)
+			// Reserved blocks and blocks being torn down cannot gain children
+			if err := ipam.CheckParentAllocatable(parentCIDR, parentAlloc); err != nil {
+				return false, err
+			}
+
+			if err := db.CheckNestingDepth(parentCIDR, r.client.MaxNestingDepth()); err != nil {
+				return false, err
 			}
 
 			childAllocs := db.GetAllocationsForParent(parentCIDR)
-			newCIDR, err = r.allocator.FindNextAvailableInParent(parentCIDR, childAllocs, int(plan.CIDRMask.ValueInt64()))
-			if err != nil {
-				return false, fmt.Errorf("sub-allocation from %s failed: %w", parentCIDR, err)
+
+			// Check if contiguous_with is specified
+			if stripeCount > 0 {
+				stripe, err = r.allocator.FindContiguousRun(parentCIDR, childAllocs, int(plan.CIDRMask.ValueInt64()), stripeCount)
+				if err != nil {
+					return false, fmt.Errorf("sub-allocation from %s failed: %w", parentCIDR, err)
+				}
+				if err := r.validateStripe(parentCIDR, stripe); err != nil {
+					return false, err
+				}
+				newCIDR = stripe[0]
+			} else if !plan.ContiguousWith.IsNull() {
+				targetCIDR := plan.ContiguousWith.ValueString()
+				if err := db.CheckContiguousTarget(targetCIDR, poolID, parentCIDR); err != nil {
+					return false, err
+				}
+				newCIDR, err = r.allocator.FindContiguousInParent(parentCIDR, childAllocs, int(plan.CIDRMask.ValueInt64()), targetCIDR, plan.ContiguousDir.ValueString())
+				if err != nil {
+					return false, fmt.Errorf("contiguous sub-allocation failed: %w", err)
+				}
+			} else if rangeMode {
+				newCIDR, err = r.allocator.FindLargestAvailable(parentCIDR, childAllocs, minPrefix, maxPrefix)
+				if err != nil {
+					return false, fmt.Errorf("sub-allocation from %s failed: %w", parentCIDR, err)
+				}
+			} else {
+				newCIDR, err = r.allocator.FindNextAvailableInParent(parentCIDR, childAllocs, int(plan.CIDRMask.ValueInt64()), plan.Placement.ValueString())
+				if err != nil {
+					return false, fmt.Errorf("sub-allocation from %s failed: %w", parentCIDR, err)
+				}
 			}
 
 			tflog.Debug(ctx, "Sub-allocated from parent", map[string]interface{}{
@@ -277,6 +819,25 @@ func (r *AllocationResource) Create(ctx context.Context, req resource.CreateRequ
 			})
 		}
 
+		blocks := blocksOf(stripe, newCIDR)
+		if !plan.ParentCIDR.IsNull() && !plan.FutureSibs.IsNull() {
+			parentCIDR := plan.ParentCIDR.ValueString()
+			if err := ipam.CheckFutureSiblings(parentCIDR, db.GetAllocationsForParent(parentCIDR), blocks, int(plan.FutureSibs.ValueInt64())); err != nil {
+				return false, err
+			}
+		}
+		for i, block := range blocks {
+			if err := ipam.ValidatePrivate(block, r.client.AllowPublic()); err != nil {
+				return false, err
+			}
+			if i == 0 {
+				continue
+			}
+			if existing, _, found := db.FindAllocationByName(stripeName(plan.Name.ValueString(), i)); found {
+				return false, fmt.Errorf("allocation name %q already exists (used by allocation %s)", existing.Name, existing.CIDR)
+			}
+		}
+
 		// Build metadata map
 		metadata := make(map[string]string)
 		if !plan.Metadata.IsNull() {
@@ -286,6 +847,14 @@ func (r *AllocationResource) Create(ctx context.Context, req resource.CreateRequ
 				return false, fmt.Errorf("failed to parse metadata: %s", diagnosticsToString(diags))
 			}
 		}
+		poolDef, _ := pools.GetPool(poolID)
+		metadata = poolDef.EffectiveMetadata(metadata)
+		if err := poolDef.CheckRequiredMetadata(metadata); err != nil {
+			return false, fmt.Errorf("pool %q: %w", poolID, err)
+		}
+		if err := poolDef.CheckUniqueMetadata(metadata, db.GetAllocationsForPool(poolID), "", ""); err != nil {
+			return false, fmt.Errorf("pool %q: %w", poolID, err)
+		}
 
 		// Build parent CIDR pointer
 		var parentCIDRPtr *string
@@ -295,11 +864,9 @@ func (r *AllocationResource) Create(ctx context.Context, req resource.CreateRequ
 		}
 
 		// Determine status (default to "allocation")
-		status := "allocation"
-		isReserved := false
-		if !plan.Status.IsNull() && plan.Status.ValueString() == "reservation" {
-			status = "reservation"
-			isReserved = true
+		status := ipam.StatusAllocation
+		if !plan.Status.IsNull() && !plan.Status.IsUnknown() {
+			status = plan.Status.ValueString()
 		}
 
 		// Build contiguous_with pointer
@@ -315,18 +882,34 @@ func (r *AllocationResource) Create(ctx context.Context, req resource.CreateRequ
 			Name:           plan.Name.ValueString(),
 			ParentCIDR:     parentCIDRPtr,
 			Metadata:       metadata,
-			Reserved:       isReserved,
 			ContiguousWith: contiguousWithPtr,
+			VLANID:         int(plan.VLANID.ValueInt64()),
+			Region:         plan.Region.ValueString(),
+			CostCenter:     plan.CostCenter.ValueString(),
+			Locked:         plan.Locked.ValueBool(),
+		}
+		allocation.SetStatus(status)
+
+		// Blocks of a stripe are separate allocations grouped under this
+		// resource's ID, which the first of them keeps
+		for i, block := range blocks {
+			member := allocation
+			member.CIDR = block
+			member.Name = stripeName(plan.Name.ValueString(), i)
+			if i > 0 {
+				member.ID = r.client.NewAllocationID(plan.PoolID.ValueString(), member.Name, int(plan.CIDRMask.ValueInt64()))
+			}
+			if len(blocks) > 1 {
+				member.Group = allocationID
+			}
+			db.AddAllocation(poolID, member)
 		}
-		_ = status // Used for logging
-
-		db.AddAllocation(poolID, allocation)
 
 		action := "allocate"
-		if isReserved {
+		if allocation.Reserved {
 			action = "reserve"
 		}
-		commitMsg := fmt.Sprintf("ipam: %s %s (%s)", action, newCIDR, plan.Name.ValueString())
+		commitMsg := fmt.Sprintf("ipam: %s %s (%s)", action, strings.Join(blocks, ", "), plan.Name.ValueString())
 		err = r.client.UpdateAllocations(ctx, db, sha, commitMsg)
 		if r.client.IsConflictError(err) {
 			tflog.Debug(ctx, "Conflict detected, will retry", map[string]interface{}{
@@ -337,19 +920,69 @@ func (r *AllocationResource) Create(ctx context.Context, req resource.CreateRequ
 
 		if err == nil {
 			allocatedCIDR = newCIDR
+			allocatedBlocks = blocks
+			poolCIDR = poolCIDRValue(pools, poolID, newCIDR)
+			poolIndex = poolIndexValue(pools, poolID, newCIDR)
+			effectiveMetadata = metadata
 		}
 		return false, err
 	})
 
+	if errors.Is(err, ipam.ErrMissingMetadata) {
+		resp.Diagnostics.AddAttributeError(path.Root("metadata"), "Missing Required Metadata", err.Error())
+		return
+	}
+	if errors.Is(err, ipam.ErrDuplicateMetadata) {
+		resp.Diagnostics.AddAttributeError(path.Root("metadata"), "Duplicate Metadata Value", err.Error())
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to allocate CIDR", err.Error())
 		return
 	}
 
+	if adopted != nil {
+		// Nothing was written; bind state to the existing allocation
+		plan.ID = types.StringValue(adopted.ID)
+		plan.CIDR = types.StringValue(adopted.CIDR)
+		plan.CIDRMask = cidrMaskValue(adopted.CIDR)
+		plan.CIDRs = cidrsValue(ctx, []string{adopted.CIDR}, &resp.Diagnostics)
+		plan.PoolCIDR = poolCIDR
+		plan.PoolIndex = poolIndex
+		plan.EffectiveMeta = metadataValue(ctx, effectiveMetadata, &resp.Diagnostics)
+		setAddressBounds(&plan)
+		setChildSpace(&plan, adoptedChildren)
+		if plan.Status.IsNull() || plan.Status.IsUnknown() {
+			plan.Status = types.StringValue(adopted.GetStatus())
+		}
+
+		tflog.Info(ctx, "Adopted existing allocation", map[string]interface{}{
+			"id":   adopted.ID,
+			"cidr": adopted.CIDR,
+			"name": adopted.Name,
+		})
+
+		resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+		return
+	}
+
+	r.client.RecordAllocations(len(allocatedBlocks), 0)
+
 	plan.ID = types.StringValue(allocationID)
 	plan.CIDR = types.StringValue(allocatedCIDR)
+	plan.CIDRs = cidrsValue(ctx, allocatedBlocks, &resp.Diagnostics)
+	if !plan.ParentCIDR.IsNull() {
+		// Sub-allocations never track their pool; see locationValues
+		plan.PoolID = types.StringNull()
+	}
+	plan.CIDRMask = cidrMaskValue(allocatedCIDR)
+	plan.PoolCIDR = poolCIDR
+	plan.PoolIndex = poolIndex
+	plan.EffectiveMeta = metadataValue(ctx, effectiveMetadata, &resp.Diagnostics)
+	setAddressBounds(&plan)
+	setChildSpace(&plan, nil)
 	if plan.Status.IsNull() || plan.Status.IsUnknown() {
-		plan.Status = types.StringValue("allocation")
+		plan.Status = types.StringValue(ipam.StatusAllocation)
 	}
 
 	tflog.Info(ctx, "Created allocation", map[string]interface{}{
@@ -359,12 +992,8 @@ func (r *AllocationResource) Create(ctx context.Context, req resource.CreateRequ
 		"status": plan.Status.ValueString(),
 	})
 
-	// Regenerate README (best effort, don't fail on error)
-	if err := r.client.RegenerateREADME(ctx); err != nil {
-		tflog.Warn(ctx, "Failed to regenerate README", map[string]interface{}{
-			"error": err.Error(),
-		})
-	}
+	// Regenerate README (best effort unless docs_strict is set)
+	resp.Diagnostics.Append(r.client.RefreshDocsDiagnostics(ctx)...)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
@@ -396,37 +1025,59 @@ func (r *AllocationResource) Read(ctx context.Context, req resource.ReadRequest,
 		return
 	}
 
+	pools, err := r.client.GetPools(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read pools", err.Error())
+		return
+	}
+
 	// Update state with current values from the database
 	state.CIDR = types.StringValue(alloc.CIDR)
+	state.PoolCIDR = poolCIDRValue(pools, poolID, alloc.CIDR)
+	state.PoolIndex = poolIndexValue(pools, poolID, alloc.CIDR)
+	setAddressBounds(&state)
+	setChildSpace(&state, db.GetAllocationsForParent(alloc.CIDR))
 	state.Name = types.StringValue(alloc.Name)
 
-	// Set status based on Reserved flag
-	if alloc.Reserved {
-		state.Status = types.StringValue("reservation")
-	} else {
-		state.Status = types.StringValue("allocation")
-	}
+	// Set status (derived from Reserved for legacy entries)
+	state.Status = types.StringValue(alloc.GetStatus())
 
 	// Set contiguous_with if present
 	if alloc.ContiguousWith != nil {
 		state.ContiguousWith = types.StringValue(*alloc.ContiguousWith)
 	}
 
-	if alloc.ParentCIDR != nil {
-		state.ParentCIDR = types.StringValue(*alloc.ParentCIDR)
-	} else {
-		// If no parent CIDR, set pool_id
-		state.PoolID = types.StringValue(poolID)
+	state.VLANID = vlanIDValue(alloc.VLANID)
+	state.Region = regionValue(alloc.Region)
+	state.CostCenter = costCenterValue(alloc.CostCenter)
+	if alloc.Locked || !state.Locked.IsNull() {
+		state.Locked = types.BoolValue(alloc.Locked)
 	}
-
-	// Update metadata if present
-	if len(alloc.Metadata) > 0 {
-		metadataValue, diags := types.MapValueFrom(ctx, types.StringType, alloc.Metadata)
-		resp.Diagnostics.Append(diags...)
-		if resp.Diagnostics.HasError() {
-			return
-		}
-		state.Metadata = metadataValue
+	state.CIDRs = cidrsValue(ctx, groupCIDRs(db, alloc), &resp.Diagnostics)
+	state.PoolID, state.ParentCIDR = locationValues(alloc, poolID)
+
+	// Update metadata if present, keeping pool defaults out of the
+	// explicit metadata so they don't show as drift
+	var priorMetadata map[string]string
+	if !state.Metadata.IsNull() && !state.Metadata.IsUnknown() {
+		resp.Diagnostics.Append(state.Metadata.ElementsAs(ctx, &priorMetadata, false)...)
+	}
+	var ignoredKeys []string
+	if !state.IgnoreMetaKeys.IsNull() && !state.IgnoreMetaKeys.IsUnknown() {
+		resp.Diagnostics.Append(state.IgnoreMetaKeys.ElementsAs(ctx, &ignoredKeys, false)...)
+	}
+	poolDef, _ := pools.GetPool(poolID)
+	explicit := ipam.IgnoreMetadataKeys(poolDef.ExplicitMetadata(alloc.Metadata, priorMetadata), priorMetadata, ignoredKeys)
+	if len(explicit) > 0 {
+		state.Metadata = metadataValue(ctx, explicit, &resp.Diagnostics)
+	} else if len(priorMetadata) > 0 {
+		// Metadata removed outside Terraform reads back as null, like an
+		// allocation created without any; an explicit {} is left alone
+		state.Metadata = types.MapNull(types.StringType)
+	}
+	state.EffectiveMeta = metadataValue(ctx, alloc.Metadata, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
@@ -440,16 +1091,26 @@ func (r *AllocationResource) Update(ctx context.Context, req resource.UpdateRequ
 		return
 	}
 
-	// Name, metadata, and status can be updated in-place
+	// A region unknown at plan time is only checked now
+	if err := r.checkRegion(plan); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("region"), "Region Not Allowed", err.Error())
+		return
+	}
+
+	// Name, metadata, status, VLAN ID, region and cost center can be
+	// updated in-place
 	tflog.Debug(ctx, "Updating allocation", map[string]interface{}{
 		"id":   plan.ID.ValueString(),
 		"name": plan.Name.ValueString(),
 	})
 
-	retryConfig := client.NewRetryConfig(r.client.MaxRetries(), r.client.BaseDelay().Milliseconds())
+	retryConfig := r.client.RetryConfig()
 
 	// Capture the CIDR from the database to set in state after update
 	var allocCIDR string
+	var allocCIDRs []string
+	var children []ipam.Allocation
+	var effectiveMetadata map[string]string
 
 	err := client.WithRetry(ctx, retryConfig, func(ctx context.Context, attempt int) (bool, error) {
 		db, sha, err := r.client.GetAllocations(ctx)
@@ -464,6 +1125,9 @@ func (r *AllocationResource) Update(ctx context.Context, req resource.UpdateRequ
 
 		// Capture CIDR for state update
 		allocCIDR = alloc.CIDR
+		before := *alloc
+		allocCIDRs = groupCIDRs(db, alloc)
+		children = db.GetAllocationsForParent(alloc.CIDR)
 
 		// Check for duplicate name before making any changes
 		newName := plan.Name.ValueString()
@@ -485,22 +1149,74 @@ func (r *AllocationResource) Update(ctx context.Context, req resource.UpdateRequ
 				return false, fmt.Errorf("failed to parse metadata: %s", diagnosticsToString(diags))
 			}
 		}
-		alloc.Metadata = metadata
+		pools, err := r.client.GetPools(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read pools: %w", err)
+		}
+		var ignoredKeys []string
+		if !plan.IgnoreMetaKeys.IsNull() {
+			diags := plan.IgnoreMetaKeys.ElementsAs(ctx, &ignoredKeys, false)
+			resp.Diagnostics.Append(diags...)
+			if diags.HasError() {
+				return false, fmt.Errorf("failed to parse ignore_external_metadata_keys: %s", diagnosticsToString(diags))
+			}
+		}
+		poolDef, _ := pools.GetPool(poolID)
+		alloc.Metadata = poolDef.EffectiveMetadata(ipam.PreserveMetadataKeys(metadata, alloc.Metadata, ignoredKeys))
+		effectiveMetadata = alloc.Metadata
+		if err := poolDef.CheckUniqueMetadata(alloc.Metadata, db.GetAllocationsForPool(poolID), alloc.ID, alloc.Group); err != nil {
+			return false, fmt.Errorf("pool %q: %w", poolID, err)
+		}
+
+		alloc.VLANID = int(plan.VLANID.ValueInt64())
+		alloc.Region = plan.Region.ValueString()
+		alloc.CostCenter = plan.CostCenter.ValueString()
+		alloc.Locked = plan.Locked.ValueBool()
+
+		// Update status (allows transitioning between lifecycle states)
+		if !plan.Status.IsNull() && !plan.Status.IsUnknown() {
+			alloc.SetStatus(plan.Status.ValueString())
+		}
 
-		// Update status (allows converting between allocation and reservation)
-		if !plan.Status.IsNull() {
-			alloc.Reserved = plan.Status.ValueString() == "reservation"
+		if err := ipam.CheckLockedChange(before, *alloc); err != nil {
+			return false, err
 		}
 
-		// Remove old and add updated allocation
-		if err := db.RemoveAllocation(poolID, alloc.ID); err != nil {
+		alloc.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+
+		// Remove old and add updated allocation, then carry the change
+		// to the rest of its stripe
+		updated := *alloc
+		if err := db.RemoveAllocation(poolID, updated.ID); err != nil {
 			return false, err
 		}
-		db.AddAllocation(poolID, *alloc)
+		db.AddAllocation(poolID, updated)
+		if updated.Group != "" {
+			members, _ := db.GroupMembers(updated.Group)
+			for i, member := range members {
+				if member.ID == updated.ID {
+					continue
+				}
+				member.Name = stripeName(newName, i)
+				if existing, _, found := db.FindAllocationByName(member.Name); found && existing.Group != updated.Group {
+					return false, fmt.Errorf("cannot rename allocation to %q: name %q already exists (used by allocation %s)", newName, member.Name, existing.CIDR)
+				}
+				member.Metadata = poolDef.EffectiveMetadata(ipam.PreserveMetadataKeys(metadata, member.Metadata, ignoredKeys))
+				member.ShareStripeSettings(updated)
+				if err := db.RemoveAllocation(poolID, member.ID); err != nil {
+					return false, err
+				}
+				db.AddAllocation(poolID, member)
+			}
+		}
+		alloc = &updated
 
 		action := "update"
-		if alloc.Reserved {
+		switch alloc.GetStatus() {
+		case ipam.StatusReservation:
 			action = "update reservation"
+		case ipam.StatusDecommissioning:
+			action = "decommission"
 		}
 		commitMsg := fmt.Sprintf("ipam: %s %s (%s)", action, alloc.CIDR, plan.Name.ValueString())
 		err = r.client.UpdateAllocations(ctx, db, sha, commitMsg)
@@ -510,6 +1226,14 @@ func (r *AllocationResource) Update(ctx context.Context, req resource.UpdateRequ
 		return false, err
 	})
 
+	if errors.Is(err, ipam.ErrLocked) {
+		resp.Diagnostics.AddAttributeError(path.Root("locked"), "Allocation Locked", err.Error())
+		return
+	}
+	if errors.Is(err, ipam.ErrDuplicateMetadata) {
+		resp.Diagnostics.AddAttributeError(path.Root("metadata"), "Duplicate Metadata Value", err.Error())
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to update allocation", err.Error())
 		return
@@ -517,13 +1241,12 @@ func (r *AllocationResource) Update(ctx context.Context, req resource.UpdateRequ
 
 	// Set the CIDR from the database (it's immutable, so always use the stored value)
 	plan.CIDR = types.StringValue(allocCIDR)
+	plan.CIDRs = cidrsValue(ctx, allocCIDRs, &resp.Diagnostics)
+	plan.EffectiveMeta = metadataValue(ctx, effectiveMetadata, &resp.Diagnostics)
+	setChildSpace(&plan, children)
 
-	// Regenerate README (best effort, don't fail on error)
-	if err := r.client.RegenerateREADME(ctx); err != nil {
-		tflog.Warn(ctx, "Failed to regenerate README", map[string]interface{}{
-			"error": err.Error(),
-		})
-	}
+	// Regenerate README (best effort unless docs_strict is set)
+	resp.Diagnostics.Append(r.client.RefreshDocsDiagnostics(ctx)...)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
@@ -541,7 +1264,8 @@ func (r *AllocationResource) Delete(ctx context.Context, req resource.DeleteRequ
 		"cidr": state.CIDR.ValueString(),
 	})
 
-	retryConfig := client.NewRetryConfig(r.client.MaxRetries(), r.client.BaseDelay().Milliseconds())
+	var deleted int
+	retryConfig := r.client.RetryConfig()
 
 	err := client.WithRetry(ctx, retryConfig, func(ctx context.Context, attempt int) (bool, error) {
 		db, sha, err := r.client.GetAllocations(ctx)
@@ -550,7 +1274,7 @@ func (r *AllocationResource) Delete(ctx context.Context, req resource.DeleteRequ
 		}
 
 		// Find and remove the allocation
-		_, poolID, found := db.FindAllocationByID(state.ID.ValueString())
+		alloc, poolID, found := db.FindAllocationByID(state.ID.ValueString())
 		if !found {
 			// Already deleted
 			tflog.Debug(ctx, "Allocation already deleted", map[string]interface{}{
@@ -559,21 +1283,45 @@ func (r *AllocationResource) Delete(ctx context.Context, req resource.DeleteRequ
 			return false, nil
 		}
 
-		// Check for child allocations
-		childAllocs := db.GetAllocationsForParent(state.CIDR.ValueString())
-		if len(childAllocs) > 0 {
-			return false, fmt.Errorf("cannot delete allocation %s: has %d child allocations", state.CIDR.ValueString(), len(childAllocs))
+		// A stripe is released as a whole
+		ids := []string{alloc.ID}
+		if alloc.Group != "" {
+			members, _ := db.GroupMembers(alloc.Group)
+			ids = ids[:0]
+			for _, member := range members {
+				ids = append(ids, member.ID)
+			}
 		}
 
-		if err := db.RemoveAllocation(poolID, state.ID.ValueString()); err != nil {
-			return false, err
+		reuseLast := r.poolReusesFreedLast(ctx, poolID)
+		deletedAt := time.Now()
+		freed := make([]string, 0, len(ids))
+		for _, id := range ids {
+			// Children are re-checked against this attempt's read; a child
+			// created since an earlier attempt refuses the delete
+			removed, err := db.RemoveLeafAllocation(poolID, id)
+			if err != nil {
+				return false, err
+			}
+
+			// Remember freed space for pools that reuse it last
+			if removed.ParentCIDR == nil && reuseLast {
+				db.RecordFreed(poolID, removed.CIDR)
+			}
+			if r.client.ArchiveDeletions() {
+				db.Archive(poolID, *removed, deletedAt)
+			}
+			freed = append(freed, removed.CIDR)
 		}
 
-		commitMsg := fmt.Sprintf("ipam: deallocate %s (%s)", state.CIDR.ValueString(), state.Name.ValueString())
+		commitMsg := fmt.Sprintf("ipam: deallocate %s (%s)", strings.Join(freed, ", "), state.Name.ValueString())
 		err = r.client.UpdateAllocations(ctx, db, sha, commitMsg)
 		if r.client.IsConflictError(err) {
 			return true, err
 		}
+		if err == nil {
+			deleted = len(freed)
+		}
 		return false, err
 	})
 
@@ -582,17 +1330,17 @@ func (r *AllocationResource) Delete(ctx context.Context, req resource.DeleteRequ
 		return
 	}
 
+	if deleted > 0 {
+		r.client.RecordAllocations(0, deleted)
+	}
+
 	tflog.Info(ctx, "Deleted allocation", map[string]interface{}{
 		"id":   state.ID.ValueString(),
 		"cidr": state.CIDR.ValueString(),
 	})
 
-	// Regenerate README (best effort, don't fail on error)
-	if err := r.client.RegenerateREADME(ctx); err != nil {
-		tflog.Warn(ctx, "Failed to regenerate README", map[string]interface{}{
-			"error": err.Error(),
-		})
-	}
+	// Regenerate README (best effort unless docs_strict is set)
+	resp.Diagnostics.Append(r.client.RefreshDocsDiagnostics(ctx)...)
 }
 
 func (r *AllocationResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
@@ -628,27 +1376,46 @@ func (r *AllocationResource) ImportState(ctx context.Context, req resource.Impor
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), alloc.Name)...)
 
 	// Set status
-	status := "allocation"
-	if alloc.Reserved {
-		status = "reservation"
-	}
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("status"), status)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("status"), alloc.GetStatus())...)
 
 	// Set pool_id or parent_cidr based on allocation type
-	if alloc.ParentCIDR != nil {
-		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("parent_cidr"), *alloc.ParentCIDR)...)
-	} else {
-		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("pool_id"), poolID)...)
-	}
+	poolIDValue, parentCIDRValue := locationValues(alloc, poolID)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("pool_id"), poolIDValue)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("parent_cidr"), parentCIDRValue)...)
 
 	// Set contiguous_with if present
 	if alloc.ContiguousWith != nil {
 		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("contiguous_with"), *alloc.ContiguousWith)...)
 	}
 
-	// Set metadata if present
-	if len(alloc.Metadata) > 0 {
-		metadataValue, diags := types.MapValueFrom(ctx, types.StringType, alloc.Metadata)
+	if alloc.VLANID != 0 {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("vlan_id"), int64(alloc.VLANID))...)
+	}
+	if alloc.Region != "" {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("region"), alloc.Region)...)
+	}
+	if alloc.CostCenter != "" {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("cost_center"), alloc.CostCenter)...)
+	}
+	if alloc.Locked {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("locked"), true)...)
+	}
+
+	blocks := groupCIDRs(db, alloc)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("cidrs"), blocks)...)
+	if len(blocks) > 1 {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("contiguous_count"), int64(len(blocks)))...)
+	}
+
+	// Set metadata if present, leaving out keys that only carry a pool default
+	pools, err := r.client.GetPools(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read pools", err.Error())
+		return
+	}
+	poolDef, _ := pools.GetPool(poolID)
+	if explicit := poolDef.ExplicitMetadata(alloc.Metadata, nil); len(explicit) > 0 {
+		metadataValue, diags := types.MapValueFrom(ctx, types.StringType, explicit)
 		resp.Diagnostics.Append(diags...)
 		if resp.Diagnostics.HasError() {
 			return
@@ -663,128 +1430,303 @@ func (r *AllocationResource) ImportState(ctx context.Context, req resource.Impor
 	})
 }
 
-// findContiguousCIDR finds a CIDR block that is immediately adjacent to the target CIDR.
-func findContiguousCIDR(pool *ipam.PoolDefinition, allocs []ipam.Allocation, prefixLen int, targetCIDR string) (string, error) {
-	_, targetNet, err := net.ParseCIDR(targetCIDR)
+// poolReusesFreedLast reports whether a pool uses the cooldown_last reuse
+// policy. Failing to read pools only skips recording, never the delete.
+func (r *AllocationResource) poolReusesFreedLast(ctx context.Context, poolID string) bool {
+	pools, err := r.client.GetPools(ctx)
 	if err != nil {
-		return "", fmt.Errorf("invalid target CIDR %q: %w", targetCIDR, err)
+		tflog.Warn(ctx, "Failed to read pools; not recording freed block", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return false
 	}
+	poolDef, exists := pools.GetPool(poolID)
+	return exists && poolDef.ReusePolicy == ipam.ReusePolicyCooldownLast
+}
 
-	// Calculate target range
-	targetStart := ipToUint32(targetNet.IP)
-	targetOnes, targetBits := targetNet.Mask.Size()
-	targetSize := uint32(1) << (targetBits - targetOnes)
-	targetEnd := targetStart + targetSize
+// cidrMaskValue returns the prefix length of a block, which is only known
+// after apply when the size was chosen from a min_mask/max_mask range.
+func cidrMaskValue(cidr string) types.Int64 {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return types.Int64Null()
+	}
+	ones, _ := network.Mask.Size()
+	return types.Int64Value(int64(ones))
+}
 
-	// Desired block size
-	blockSize := uint32(1) << (32 - prefixLen)
+// locationValues returns the pool_id and parent_cidr attributes for an
+// allocation: exactly one of them is set, so refreshes and imports agree
+// with the configuration that created it.
+func locationValues(alloc *ipam.Allocation, poolID string) (types.String, types.String) {
+	trackedPoolID, parentCIDR := alloc.Location(poolID)
+	if parentCIDR != "" {
+		return types.StringNull(), types.StringValue(parentCIDR)
+	}
+	return types.StringValue(trackedPoolID), types.StringNull()
+}
 
-	var beforeReason, afterReason string
+// validateStripe checks that the blocks of a stripe are disjoint and lie in
+// container before any of them is committed.
+func (r *AllocationResource) validateStripe(container string, stripe []string) error {
+	planned := make([]ipam.Allocation, len(stripe))
+	for i, block := range stripe {
+		planned[i] = ipam.Allocation{CIDR: block}
+	}
+	if err := r.allocator.ValidatePlan(container, planned); err != nil {
+		return fmt.Errorf("contiguous run is inconsistent: %w", err)
+	}
+	return nil
+}
 
-	// Check space immediately before target
-	if targetStart >= blockSize {
-		beforeStart := targetStart - blockSize
-		if beforeStart%blockSize == 0 {
-			beforeCIDR := fmt.Sprintf("%s/%d", uint32ToIP(beforeStart), prefixLen)
-			if !isInPool(pool, beforeCIDR) {
-				beforeReason = fmt.Sprintf("before block %s is outside pool boundaries", beforeCIDR)
-			} else if overlapsAny(beforeCIDR, allocs) {
-				beforeReason = fmt.Sprintf("before block %s overlaps with existing allocation", beforeCIDR)
-			} else {
-				return beforeCIDR, nil
-			}
-		} else {
-			beforeReason = fmt.Sprintf("no valid /%d boundary before target (alignment requires address divisible by %d)", prefixLen, blockSize)
-		}
-	} else {
-		beforeReason = "target is too close to start of address space for a block before it"
+// blocksOf returns the blocks an allocation creates: the stripe, or cidr
+// alone.
+func blocksOf(stripe []string, cidr string) []string {
+	if len(stripe) > 0 {
+		return stripe
 	}
+	return []string{cidr}
+}
 
-	// Check space immediately after target
-	afterStart := targetEnd
-	if afterStart%blockSize == 0 {
-		afterCIDR := fmt.Sprintf("%s/%d", uint32ToIP(afterStart), prefixLen)
-		if !isInPool(pool, afterCIDR) {
-			afterReason = fmt.Sprintf("after block %s is outside pool boundaries", afterCIDR)
-		} else if overlapsAny(afterCIDR, allocs) {
-			afterReason = fmt.Sprintf("after block %s overlaps with existing allocation", afterCIDR)
-		} else {
-			return afterCIDR, nil
-		}
-	} else {
-		afterReason = fmt.Sprintf("no valid /%d boundary after target (target end %s not aligned to block size %d)",
-			prefixLen, uint32ToIP(afterStart), blockSize)
+// stripeName returns the name of the i-th block of a stripe. The first
+// block keeps the resource's name.
+func stripeName(name string, i int) string {
+	if i == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s-%d", name, i+1)
+}
+
+// groupCIDRs returns the blocks of the stripe an allocation belongs to, or
+// its own block when it is not part of one.
+func groupCIDRs(db *ipam.AllocationsDatabase, alloc *ipam.Allocation) []string {
+	if alloc.Group == "" {
+		return []string{alloc.CIDR}
+	}
+	members, _ := db.GroupMembers(alloc.Group)
+	blocks := make([]string, 0, len(members))
+	for _, member := range members {
+		blocks = append(blocks, member.CIDR)
 	}
+	return blocks
+}
 
-	return "", fmt.Errorf("no contiguous /%d space available adjacent to %s: before: %s; after: %s",
-		prefixLen, targetCIDR, beforeReason, afterReason)
+// cidrsValue converts blocks to the cidrs list attribute.
+func cidrsValue(ctx context.Context, blocks []string, diags *diag.Diagnostics) types.List {
+	value, d := types.ListValueFrom(ctx, types.StringType, blocks)
+	diags.Append(d...)
+	return value
 }
 
-func isInPool(pool *ipam.PoolDefinition, cidr string) bool {
-	_, candidateNet, err := net.ParseCIDR(cidr)
-	if err != nil {
-		return false
+// vlanIDValue returns a stored VLAN ID, null when none is set.
+func vlanIDValue(id int) types.Int64 {
+	if id == 0 {
+		return types.Int64Null()
 	}
+	return types.Int64Value(int64(id))
+}
 
-	for _, poolCIDR := range pool.CIDR {
-		_, poolNet, err := net.ParseCIDR(poolCIDR)
-		if err != nil {
-			continue
-		}
-		// Check if candidate is fully contained in pool
-		candidateStart := ipToUint32(candidateNet.IP)
-		candidateOnes, candidateBits := candidateNet.Mask.Size()
-		candidateEnd := candidateStart + uint32(1)<<(candidateBits-candidateOnes) - 1
+// regionValue returns a stored region, null when none is set.
+func regionValue(region string) types.String {
+	if region == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(region)
+}
 
-		poolStart := ipToUint32(poolNet.IP)
-		poolOnes, poolBits := poolNet.Mask.Size()
-		poolEnd := poolStart + uint32(1)<<(poolBits-poolOnes) - 1
+// costCenterValue returns a stored cost center, null when none is set.
+func costCenterValue(costCenter string) types.String {
+	if costCenter == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(costCenter)
+}
 
-		if candidateStart >= poolStart && candidateEnd <= poolEnd {
-			return true
-		}
+// checkRegion checks a planned region against the provider's
+// allowed_regions. Unset regions pass, and so do unknown ones, which Create
+// and Update check again once they are known.
+func (r *AllocationResource) checkRegion(plan AllocationResourceModel) error {
+	if plan.Region.IsNull() || plan.Region.IsUnknown() {
+		return nil
 	}
-	return false
+	return ipam.ValidateRegion(plan.Region.ValueString(), r.client.AllowedRegions())
 }
 
-func uint32ToIP(n uint32) string {
-	return fmt.Sprintf("%d.%d.%d.%d",
-		(n>>24)&0xFF,
-		(n>>16)&0xFF,
-		(n>>8)&0xFF,
-		n&0xFF)
+// parentCIDRFromName plans parent_cidr, which is computed only for
+// allocations that name their parent with parent_name. The name is
+// resolved against the allocations file where it can be, so parent_cidr is
+// known at plan time and its RequiresReplace fires only when the parent
+// actually changes. A parent that does not exist yet leaves it unknown for
+// Create to resolve. On an update the current parent is kept only while
+// parent_name is unchanged; a new name that can't be resolved yet may be
+// a different allocation, so parent_cidr is left unknown and the block is
+// replaced.
+type parentCIDRFromName struct {
+	r *AllocationResource
 }
 
-func overlapsAny(cidr string, allocs []ipam.Allocation) bool {
-	_, candidateNet, err := net.ParseCIDR(cidr)
-	if err != nil {
-		return true // Treat errors as overlap to be safe
+func (m parentCIDRFromName) Description(ctx context.Context) string {
+	return "Resolves parent_cidr from parent_name."
+}
+
+func (m parentCIDRFromName) MarkdownDescription(ctx context.Context) string {
+	return "Resolves `parent_cidr` from `parent_name`."
+}
+
+func (m parentCIDRFromName) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if !req.ConfigValue.IsNull() || !req.PlanValue.IsUnknown() {
+		return
 	}
 
-	candidateStart := ipToUint32(candidateNet.IP)
-	candidateOnes, candidateBits := candidateNet.Mask.Size()
-	candidateEnd := candidateStart + uint32(1)<<(candidateBits-candidateOnes)
+	var name types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("parent_name"), &name)...)
+	if resp.Diagnostics.HasError() || name.IsUnknown() {
+		return
+	}
+	if name.IsNull() {
+		resp.PlanValue = req.StateValue
+		return
+	}
 
-	for _, alloc := range allocs {
-		if alloc.ParentCIDR != nil {
-			continue // Skip sub-allocations
+	resolved := types.StringUnknown()
+	if m.r.client != nil {
+		if db, _, err := m.r.client.GetAllocations(ctx); err == nil {
+			if cidr, err := db.ResolveParentName(name.ValueString()); err == nil {
+				resolved = types.StringValue(cidr)
+			}
 		}
-
-		_, allocNet, err := net.ParseCIDR(alloc.CIDR)
-		if err != nil {
-			continue
+	}
+	if resolved.IsUnknown() && !req.State.Raw.IsNull() {
+		var stateName types.String
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("parent_name"), &stateName)...)
+		if stateName.Equal(name) {
+			resp.PlanValue = req.StateValue
+			return
 		}
+	}
+	resp.PlanValue = resolved
+}
+
+// requiresReplaceOnceSet re-allocates when an applied size range changes
+// to another. Imported allocations have no range recorded, so setting one
+// afterwards only records it, as does removing one.
+func requiresReplaceOnceSet() planmodifier.Int64 {
+	return int64planmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.Int64Request, resp *int64planmodifier.RequiresReplaceIfFuncResponse) {
+			resp.RequiresReplace = !req.StateValue.IsNull() && !req.PlanValue.IsNull()
+		},
+		"Changing the size range re-allocates the block.",
+		"Changing the size range re-allocates the block.",
+	)
+}
 
-		allocStart := ipToUint32(allocNet.IP)
-		allocOnes, allocBits := allocNet.Mask.Size()
-		allocEnd := allocStart + uint32(1)<<(allocBits-allocOnes)
+// checkAddedConstraints rejects a from_cidr or size range newly set on an
+// existing allocation when its blocks don't already satisfy it. Setting
+// one doesn't re-allocate, so it would otherwise record a constraint the
+// allocation breaks.
+func checkAddedConstraints(ctx context.Context, state, plan AllocationResourceModel, diags *diag.Diagnostics) {
+	var stripe []string
+	diags.Append(state.CIDRs.ElementsAs(ctx, &stripe, false)...)
+	blocks := blocksOf(stripe, state.CIDR.ValueString())
+
+	if state.FromCIDR.IsNull() && !plan.FromCIDR.IsNull() && !plan.FromCIDR.IsUnknown() {
+		from := ipam.PoolDefinition{CIDR: []string{plan.FromCIDR.ValueString()}}
+		for _, block := range blocks {
+			if _, ok := from.ContainingCIDR(block); !ok {
+				diags.AddAttributeError(path.Root("from_cidr"), "Allocation Outside from_cidr",
+					fmt.Sprintf("The allocated block %s is not inside from_cidr %s. Setting from_cidr on an existing allocation only records it; "+
+						"use the pool CIDR that holds the block, or replace the resource to re-allocate.", block, plan.FromCIDR.ValueString()))
+				return
+			}
+		}
+	}
 
-		// Check for overlap
-		if candidateStart < allocEnd && candidateEnd > allocStart {
-			return true
+	if state.MinMask.IsNull() && !plan.MinMask.IsNull() && !plan.MinMask.IsUnknown() && !plan.MaxMask.IsUnknown() {
+		size := cidrMaskValue(state.CIDR.ValueString())
+		if size.IsNull() {
+			return
+		}
+		if size.ValueInt64() < plan.MinMask.ValueInt64() || size.ValueInt64() > plan.MaxMask.ValueInt64() {
+			diags.AddAttributeError(path.Root("min_mask"), "Allocation Outside Size Range",
+				fmt.Sprintf("The allocated block %s is a /%d, outside min_mask %d and max_mask %d. Setting a size range on an existing allocation only records it; "+
+					"widen the range, or replace the resource to re-allocate.", state.CIDR.ValueString(), size.ValueInt64(), plan.MinMask.ValueInt64(), plan.MaxMask.ValueInt64()))
 		}
 	}
-	return false
+}
+
+// poolCIDRValue returns the CIDR of the pool range containing the block,
+// or null if the pool no longer exists or does not contain it.
+func poolCIDRValue(pools *ipam.PoolsConfig, poolID, cidr string) types.String {
+	poolDef, exists := pools.GetPool(poolID)
+	if !exists {
+		return types.StringNull()
+	}
+	poolCIDR, found := poolDef.ContainingCIDR(cidr)
+	if !found {
+		return types.StringNull()
+	}
+	return types.StringValue(poolCIDR)
+}
+
+// metadataValue converts metadata to a map value, null when empty.
+func metadataValue(ctx context.Context, metadata map[string]string, diags *diag.Diagnostics) types.Map {
+	if len(metadata) == 0 {
+		return types.MapNull(types.StringType)
+	}
+	value, d := types.MapValueFrom(ctx, types.StringType, metadata)
+	diags.Append(d...)
+	return value
+}
+
+// poolIndexValue returns the block's index within its pool CIDR, or null
+// if the pool no longer exists or does not contain it.
+func poolIndexValue(pools *ipam.PoolsConfig, poolID, cidr string) types.Int64 {
+	poolDef, exists := pools.GetPool(poolID)
+	if !exists {
+		return types.Int64Null()
+	}
+	index, found := poolDef.BlockIndex(cidr)
+	if !found {
+		return types.Int64Null()
+	}
+	return types.Int64Value(index)
+}
+
+// setAddressBounds fills the attributes derived from the model's CIDR:
+// first/last addresses and the address count. They are never read back
+// from the database, so they cannot drift from the CIDR.
+func setAddressBounds(m *AllocationResourceModel) {
+	if count, ok := ipam.AddressCount(m.CIDR.ValueString()); ok {
+		m.AddressCount = types.Int64Value(count)
+	} else {
+		m.AddressCount = types.Int64Null()
+	}
+
+	bounds, err := ipam.BlockBounds(m.CIDR.ValueString())
+	if err != nil {
+		m.FirstIP = types.StringNull()
+		m.LastIP = types.StringNull()
+		m.UsableFirstIP = types.StringNull()
+		m.UsableLastIP = types.StringNull()
+		return
+	}
+	m.FirstIP = types.StringValue(bounds.First)
+	m.LastIP = types.StringValue(bounds.Last)
+	m.UsableFirstIP = types.StringValue(bounds.UsableFirst)
+	m.UsableLastIP = types.StringValue(bounds.UsableLast)
+}
+
+// setChildSpace sets the child space attributes from the allocations whose
+// parent is m's block.
+func setChildSpace(m *AllocationResourceModel, children []ipam.Allocation) {
+	available, utilization, ok := ipam.ChildSpace(m.CIDR.ValueString(), children)
+	if !ok {
+		m.ChildAvailable = types.Int64Null()
+		m.ChildUtilPct = types.Float64Null()
+		return
+	}
+	m.ChildAvailable = types.Int64Value(available)
+	m.ChildUtilPct = types.Float64Value(utilization)
 }
 
 // diagnosticsToString converts diagnostics to a string for error messages.
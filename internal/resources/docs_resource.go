@@ -0,0 +1,149 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package resources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var (
+	_ resource.Resource              = &DocsResource{}
+	_ resource.ResourceWithConfigure = &DocsResource{}
+)
+
+// NewDocsResource creates a new docs resource.
+func NewDocsResource() resource.Resource {
+	return &DocsResource{}
+}
+
+// DocsResource regenerates the IPAM documentation once per apply.
+type DocsResource struct {
+	client *client.GitHubClient
+}
+
+// DocsResourceModel describes the resource data model.
+type DocsResourceModel struct {
+	ID       types.String `tfsdk:"id"`
+	Triggers types.Map    `tfsdk:"triggers"`
+}
+
+func (r *DocsResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_docs"
+}
+
+func (r *DocsResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Regenerates the IPAM documentation in a single commit per apply. Use with defer_docs.",
+		MarkdownDescription: `Regenerates the IPAM documentation in a single commit per apply.
+
+With ` + "`defer_docs = true`" + ` on the provider, resources no longer regenerate the README after
+every change. Place one ` + "`github-ipam_docs`" + ` resource in the configuration, make it depend on
+the allocations, and change ` + "`triggers`" + ` whenever they change so the docs are written once,
+after everything else.
+
+Intermediate states between changes have no docs, and destroying allocations does not
+regenerate them, since this resource is destroyed first.
+
+**Example:**
+` + "```hcl" + `
+resource "github-ipam_docs" "this" {
+  triggers = {
+    allocations = sha1(jsonencode([for a in github-ipam_allocation.subnets : a.cidr]))
+  }
+}
+` + "```",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Identifier for this resource.",
+				MarkdownDescription: "Identifier for this resource.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"triggers": schema.MapAttribute{
+				Optional:            true,
+				ElementType:         types.StringType,
+				Description:         "Arbitrary values that regenerate the docs when they change.",
+				MarkdownDescription: "Arbitrary values that regenerate the docs when they change.",
+			},
+		},
+	}
+}
+
+func (r *DocsResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	ghClient, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.client = ghClient
+}
+
+func (r *DocsResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan DocsResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.FlushDocs(ctx); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Regenerate Docs",
+			fmt.Sprintf("Unable to regenerate IPAM docs: %s", err),
+		)
+		return
+	}
+	tflog.Info(ctx, "Regenerated IPAM docs")
+
+	plan.ID = types.StringValue("docs")
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *DocsResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Nothing to refresh; the docs are derived from pools and allocations
+}
+
+func (r *DocsResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan DocsResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.FlushDocs(ctx); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Regenerate Docs",
+			fmt.Sprintf("Unable to regenerate IPAM docs: %s", err),
+		)
+		return
+	}
+	tflog.Info(ctx, "Regenerated IPAM docs")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *DocsResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Generated docs are left in place
+}
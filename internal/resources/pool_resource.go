@@ -6,7 +6,6 @@ package resources
 import (
 	"context"
 	"fmt"
-	"net"
 
 	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
 	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
@@ -20,15 +19,18 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var (
-	_ resource.Resource                = &PoolResource{}
-	_ resource.ResourceWithConfigure   = &PoolResource{}
-	_ resource.ResourceWithImportState = &PoolResource{}
+	_ resource.Resource                   = &PoolResource{}
+	_ resource.ResourceWithConfigure      = &PoolResource{}
+	_ resource.ResourceWithImportState    = &PoolResource{}
+	_ resource.ResourceWithValidateConfig = &PoolResource{}
+	_ resource.ResourceWithModifyPlan     = &PoolResource{}
 )
 
 // NewPoolResource creates a new pool resource.
@@ -50,6 +52,13 @@ type PoolResourceModel struct {
 	Description  types.String `tfsdk:"description"`
 	Reserved     types.Bool   `tfsdk:"reserved"`
 	Metadata     types.Map    `tfsdk:"metadata"`
+	Manage       types.Bool   `tfsdk:"manage"`
+}
+
+// managed reports whether the resource owns the pool definition; only an
+// explicit manage = false makes it read-only.
+func (m PoolResourceModel) managed() bool {
+	return m.Manage.IsNull() || m.Manage.IsUnknown() || m.Manage.ValueBool()
 }
 
 func (r *PoolResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -85,6 +94,18 @@ resource "github-ipam_pool" "stake" {
 }
 
 # Output: cidr = "10.0.0.0/12" (or next available)
+` + "```" + `
+
+**Referencing a pool managed by pull request:** with ` + "`manage = false`" + ` the resource never
+writes pools.yaml. It adopts the existing definition of ` + "`name`" + `, and ` + "`cidr`" + `, ` + "`description`" + `,
+` + "`reserved`" + ` and ` + "`metadata`" + ` follow whatever the file says; destroying it only removes it from state.
+Only ` + "`name`" + ` may be set in this mode.
+
+` + "```hcl" + `
+resource "github-ipam_pool" "shared" {
+  name   = "shared"
+  manage = false
+}
 ` + "```",
 		Attributes: map[string]schema.Attribute{
 			"name": schema.StringAttribute{
@@ -96,25 +117,27 @@ resource "github-ipam_pool" "stake" {
 				},
 			},
 			"private_range": schema.StringAttribute{
-				Required:            true,
-				Description:         "Private IP range to allocate from: 10.0.0.0/8, 172.16.0.0/12, or 192.168.0.0/16.",
-				MarkdownDescription: "Private IP range to allocate from: `10.0.0.0/8`, `172.16.0.0/12`, or `192.168.0.0/16`.",
+				Optional:            true,
+				Description:         "Private IP range to allocate from: 10.0.0.0/8, 172.16.0.0/12, or 192.168.0.0/16. Required unless manage is false.",
+				MarkdownDescription: "Private IP range to allocate from: `10.0.0.0/8`, `172.16.0.0/12`, or `192.168.0.0/16`. Required unless `manage` is `false`.",
 				Validators: []validator.String{
 					stringvalidator.OneOf("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"),
 				},
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.RequiresReplaceIf(replaceStringIfManaged, "Changing private_range of a managed pool replaces it.",
+						"Changing `private_range` of a managed pool replaces it."),
 				},
 			},
 			"block_size": schema.Int64Attribute{
-				Required:            true,
-				Description:         "CIDR prefix length for the pool (e.g., 12 for /12, 16 for /16).",
-				MarkdownDescription: "CIDR prefix length for the pool (e.g., `12` for /12, `16` for /16).",
+				Optional:            true,
+				Description:         "CIDR prefix length for the pool (e.g., 12 for /12, 16 for /16). Required unless manage is false.",
+				MarkdownDescription: "CIDR prefix length for the pool (e.g., `12` for /12, `16` for /16). Required unless `manage` is `false`.",
 				Validators: []validator.Int64{
 					int64validator.Between(8, 28),
 				},
 				PlanModifiers: []planmodifier.Int64{
-					int64planmodifier.RequiresReplace(),
+					int64planmodifier.RequiresReplaceIf(replaceInt64IfManaged, "Changing block_size of a managed pool replaces it.",
+						"Changing `block_size` of a managed pool replaces it."),
 				},
 			},
 			"cidr": schema.StringAttribute{
@@ -127,20 +150,30 @@ resource "github-ipam_pool" "stake" {
 			},
 			"description": schema.StringAttribute{
 				Optional:            true,
+				Computed:            true,
 				Description:         "Human-readable description of the pool.",
 				MarkdownDescription: "Human-readable description of the pool.",
 			},
 			"reserved": schema.BoolAttribute{
 				Optional:            true,
+				Computed:            true,
 				Description:         "If true, this pool is reserved and allocations are not allowed.",
 				MarkdownDescription: "If `true`, this pool is reserved and allocations are not allowed. Reserved pools hold space for future use.",
 			},
 			"metadata": schema.MapAttribute{
 				Optional:            true,
+				Computed:            true,
 				ElementType:         types.StringType,
 				Description:         "Key-value metadata for the pool.",
 				MarkdownDescription: "Key-value metadata for the pool.",
 			},
+			"manage": schema.BoolAttribute{
+				Optional: true,
+				Description: "If false, the pool is only referenced: it must already be defined in pools.yaml, " +
+					"its attributes are read from there and the provider never writes it. Defaults to true.",
+				MarkdownDescription: "If `false`, the pool is only referenced: it must already be defined in pools.yaml, " +
+					"its attributes are read from there and the provider never writes it. Defaults to `true`.",
+			},
 		},
 	}
 }
@@ -162,6 +195,71 @@ func (r *PoolResource) Configure(ctx context.Context, req resource.ConfigureRequ
 	r.client = ghClient
 }
 
+func (r *PoolResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config PoolResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() || config.Manage.IsUnknown() {
+		return
+	}
+
+	if config.managed() {
+		if config.PrivateRange.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("private_range"), "Missing Required Attribute",
+				"private_range is required unless manage is false.")
+		}
+		if config.BlockSize.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("block_size"), "Missing Required Attribute",
+				"block_size is required unless manage is false.")
+		}
+		return
+	}
+
+	// An unmanaged pool takes everything but its name from pools.yaml
+	for _, attr := range []struct {
+		name string
+		set  bool
+	}{
+		{"private_range", !config.PrivateRange.IsNull()},
+		{"block_size", !config.BlockSize.IsNull()},
+		{"description", !config.Description.IsNull()},
+		{"reserved", !config.Reserved.IsNull()},
+		{"metadata", !config.Metadata.IsNull()},
+	} {
+		if attr.set {
+			resp.Diagnostics.AddAttributeError(path.Root(attr.name), "Attribute Not Allowed",
+				fmt.Sprintf("%s cannot be set when manage is false; the pool's definition is read from pools.yaml.", attr.name))
+		}
+	}
+}
+
+func (r *PoolResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Destroys have a null plan
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan, config PoolResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() || plan.Manage.IsUnknown() || !plan.managed() {
+		// Unmanaged attributes keep their state, or are read at create
+		return
+	}
+
+	// A managed pool clears what its configuration leaves out, planned as
+	// Read reports it so removing an attribute is not perpetual drift
+	if config.Description.IsNull() {
+		plan.Description = types.StringValue("")
+	}
+	if config.Reserved.IsNull() {
+		plan.Reserved = types.BoolValue(false)
+	}
+	if config.Metadata.IsNull() {
+		plan.Metadata = types.MapNull(types.StringType)
+	}
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
+}
+
 func (r *PoolResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var plan PoolResourceModel
 
@@ -171,6 +269,15 @@ func (r *PoolResource) Create(ctx context.Context, req resource.CreateRequest, r
 	}
 
 	poolName := plan.Name.ValueString()
+	if !plan.managed() {
+		r.adoptPool(ctx, &plan, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+		return
+	}
+
 	privateRange := plan.PrivateRange.ValueString()
 	blockSize := int(plan.BlockSize.ValueInt64())
 
@@ -181,7 +288,9 @@ func (r *PoolResource) Create(ctx context.Context, req resource.CreateRequest, r
 	})
 
 	var allocatedCIDR string
-	retryConfig := client.NewRetryConfig(r.client.MaxRetries(), r.client.BaseDelay().Milliseconds())
+	var strays []ipam.Allocation
+	var allocsDB *ipam.AllocationsDatabase
+	retryConfig := r.client.RetryConfig()
 
 	err := client.WithRetry(ctx, retryConfig, func(ctx context.Context, attempt int) (bool, error) {
 		// Read pools.yaml with SHA for OCC
@@ -190,25 +299,37 @@ func (r *PoolResource) Create(ctx context.Context, req resource.CreateRequest, r
 			return false, fmt.Errorf("failed to read pools: %w", err)
 		}
 
+		// Name and overlap checks must see pools from every file, not just the one we write
+		allPools := pools
+		if r.client.PoolsSplitAcrossFiles() {
+			allPools, err = r.client.GetPools(ctx)
+			if err != nil {
+				return false, fmt.Errorf("failed to read pools: %w", err)
+			}
+		}
+
 		// Check if pool name already exists
-		if _, exists := pools.GetPool(poolName); exists {
+		if _, exists := allPools.GetPool(poolName); exists {
 			return false, fmt.Errorf("pool with name %q already exists", poolName)
 		}
 
-		// Use private_range directly as parent CIDR
-		parentCIDR := privateRange
-
-		// Collect all existing CIDRs from all pools
-		var existingCIDRs []string
-		for _, pool := range pools.Pools {
-			existingCIDRs = append(existingCIDRs, pool.CIDR...)
+		// Allocations are checked too, in case pools were edited out of band
+		// and left some outside every pool's CIDRs
+		db, _, err := r.client.GetAllocations(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read allocations: %w", err)
 		}
 
-		// Find next available CIDR
-		newCIDR, err := findNextAvailableCIDR(parentCIDR, existingCIDRs, blockSize)
+		// Find next available CIDR, using private_range as the parent
+		newCIDR, skipped, err := ipam.NextPoolCIDR(privateRange, allPools, db, blockSize)
 		if err != nil {
 			return false, fmt.Errorf("failed to allocate CIDR from %s: %w", privateRange, err)
 		}
+		strays, allocsDB = skipped, db
+
+		if err := ipam.ValidatePrivate(newCIDR, r.client.AllowPublic()); err != nil {
+			return false, err
+		}
 
 		// Build metadata map
 		metadata := make(map[string]string)
@@ -251,17 +372,20 @@ func (r *PoolResource) Create(ctx context.Context, req resource.CreateRequest, r
 
 	plan.CIDR = types.StringValue(allocatedCIDR)
 
+	if len(strays) > 0 {
+		resp.Diagnostics.AddWarning("Allocations Outside Any Pool",
+			fmt.Sprintf("Skipped addresses in %s held by allocations that no pool's CIDRs cover: %s. "+
+				"These usually remain after a pool was edited outside the provider.",
+				privateRange, ipam.DescribeAllocations(strays, allocsDB)))
+	}
+
 	tflog.Info(ctx, "Created pool", map[string]interface{}{
 		"name": poolName,
 		"cidr": allocatedCIDR,
 	})
 
-	// Regenerate README (best effort, don't fail on error)
-	if err := r.client.RegenerateREADME(ctx); err != nil {
-		tflog.Warn(ctx, "Failed to regenerate README", map[string]interface{}{
-			"error": err.Error(),
-		})
-	}
+	// Regenerate README (best effort unless docs_strict is set)
+	resp.Diagnostics.Append(r.client.RefreshDocsDiagnostics(ctx)...)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
@@ -280,13 +404,13 @@ func (r *PoolResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		"name": poolName,
 	})
 
-	pools, err := r.client.GetPools(ctx)
+	// Reading never writes, even for a pools file that does not exist yet
+	poolDef, exists, err := r.client.LookupPool(ctx, poolName)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to read pools", err.Error())
 		return
 	}
 
-	poolDef, exists := pools.GetPool(poolName)
 	if !exists {
 		tflog.Warn(ctx, "Pool not found, removing from state", map[string]interface{}{
 			"name": poolName,
@@ -295,23 +419,10 @@ func (r *PoolResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	// Update state with current values
-	if len(poolDef.CIDR) > 0 {
-		state.CIDR = types.StringValue(poolDef.CIDR[0])
-	}
-
-	state.Description = types.StringValue(poolDef.Description)
-	state.Reserved = types.BoolValue(poolDef.Reserved)
-
-	if len(poolDef.Metadata) > 0 {
-		metadataValue, diags := types.MapValueFrom(ctx, types.StringType, poolDef.Metadata)
-		resp.Diagnostics.Append(diags...)
-		if resp.Diagnostics.HasError() {
-			return
-		}
-		state.Metadata = metadataValue
-	} else {
-		state.Metadata = types.MapNull(types.StringType)
+	// Update state with current values; edits made in pools.yaml show as drift
+	setPoolValues(ctx, &state, poolDef, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
@@ -331,7 +442,16 @@ func (r *PoolResource) Update(ctx context.Context, req resource.UpdateRequest, r
 		"name": poolName,
 	})
 
-	retryConfig := client.NewRetryConfig(r.client.MaxRetries(), r.client.BaseDelay().Milliseconds())
+	if !plan.managed() {
+		r.adoptPool(ctx, &plan, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+		return
+	}
+
+	retryConfig := r.client.RetryConfig()
 
 	// Capture the CIDR from the database to set in state after update
 	var poolCIDR string
@@ -344,6 +464,9 @@ func (r *PoolResource) Update(ctx context.Context, req resource.UpdateRequest, r
 
 		existingPool, exists := pools.GetPool(poolName)
 		if !exists {
+			if r.client.PoolsSplitAcrossFiles() {
+				return false, fmt.Errorf("pool %s not found in the pools write file; pools defined in other files must be edited there", poolName)
+			}
 			return false, fmt.Errorf("pool %s not found", poolName)
 		}
 
@@ -362,13 +485,13 @@ func (r *PoolResource) Update(ctx context.Context, req resource.UpdateRequest, r
 			}
 		}
 
-		// Keep existing CIDR, update description, reserved, and metadata
-		poolDef := ipam.PoolDefinition{
-			CIDR:        existingPool.CIDR,
-			Description: plan.Description.ValueString(),
-			Metadata:    metadata,
-			Reserved:    plan.Reserved.ValueBool(),
-		}
+		// Keep the CIDRs and the settings only pools.yaml defines (avoid_pools,
+		// reuse_policy, fill_order, ...), update description, reserved, and
+		// metadata
+		poolDef := *existingPool
+		poolDef.Description = plan.Description.ValueString()
+		poolDef.Metadata = metadata
+		poolDef.Reserved = plan.Reserved.ValueBool()
 
 		pools.AddPool(poolName, poolDef)
 
@@ -392,12 +515,8 @@ func (r *PoolResource) Update(ctx context.Context, req resource.UpdateRequest, r
 		"name": poolName,
 	})
 
-	// Regenerate README (best effort, don't fail on error)
-	if err := r.client.RegenerateREADME(ctx); err != nil {
-		tflog.Warn(ctx, "Failed to regenerate README", map[string]interface{}{
-			"error": err.Error(),
-		})
-	}
+	// Regenerate README (best effort unless docs_strict is set)
+	resp.Diagnostics.Append(r.client.RefreshDocsDiagnostics(ctx)...)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
@@ -416,7 +535,14 @@ func (r *PoolResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 		"name": poolName,
 	})
 
-	retryConfig := client.NewRetryConfig(r.client.MaxRetries(), r.client.BaseDelay().Milliseconds())
+	if !state.managed() {
+		tflog.Info(ctx, "Removing unmanaged pool from state; pools.yaml is left as is", map[string]interface{}{
+			"name": poolName,
+		})
+		return
+	}
+
+	retryConfig := r.client.RetryConfig()
 
 	err := client.WithRetry(ctx, retryConfig, func(ctx context.Context, attempt int) (bool, error) {
 		pools, poolsSHA, err := r.client.GetPoolsWithSHA(ctx)
@@ -424,6 +550,16 @@ func (r *PoolResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 			return false, fmt.Errorf("failed to read pools: %w", err)
 		}
 
+		if _, exists := pools.GetPool(poolName); !exists && r.client.PoolsSplitAcrossFiles() {
+			allPools, err := r.client.GetPools(ctx)
+			if err != nil {
+				return false, fmt.Errorf("failed to read pools: %w", err)
+			}
+			if _, elsewhere := allPools.GetPool(poolName); elsewhere {
+				return false, fmt.Errorf("cannot delete pool %s: it is defined in another pools file and must be removed there", poolName)
+			}
+		}
+
 		if _, exists := pools.GetPool(poolName); !exists {
 			// Already deleted
 			tflog.Debug(ctx, "Pool already deleted", map[string]interface{}{
@@ -482,12 +618,8 @@ func (r *PoolResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 		"name": poolName,
 	})
 
-	// Regenerate README (best effort, don't fail on error)
-	if err := r.client.RegenerateREADME(ctx); err != nil {
-		tflog.Warn(ctx, "Failed to regenerate README", map[string]interface{}{
-			"error": err.Error(),
-		})
-	}
+	// Regenerate README (best effort unless docs_strict is set)
+	resp.Diagnostics.Append(r.client.RefreshDocsDiagnostics(ctx)...)
 }
 
 func (r *PoolResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
@@ -498,104 +630,66 @@ func (r *PoolResource) ImportState(ctx context.Context, req resource.ImportState
 	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
 }
 
-// findNextAvailableCIDR finds the next available CIDR block within the parent range
-// that doesn't overlap with any existing CIDRs.
-func findNextAvailableCIDR(parentCIDR string, existingCIDRs []string, prefixLen int) (string, error) {
-	_, parentNet, err := net.ParseCIDR(parentCIDR)
+// adoptPool sets m from the pools.yaml definition of an unmanaged pool,
+// which must already exist. Nothing is written.
+func (r *PoolResource) adoptPool(ctx context.Context, m *PoolResourceModel, diags *diag.Diagnostics) {
+	poolName := m.Name.ValueString()
+	poolDef, exists, err := r.client.LookupPool(ctx, poolName)
 	if err != nil {
-		return "", fmt.Errorf("invalid parent CIDR %s: %w", parentCIDR, err)
-	}
-
-	parentPrefixLen, _ := parentNet.Mask.Size()
-	if prefixLen < parentPrefixLen {
-		return "", fmt.Errorf("requested prefix /%d is larger than parent range /%d", prefixLen, parentPrefixLen)
+		diags.AddError("Failed to read pools", err.Error())
+		return
 	}
-
-	// Parse existing CIDRs into networks
-	var existingNets []*net.IPNet
-	for _, cidr := range existingCIDRs {
-		_, network, err := net.ParseCIDR(cidr)
-		if err != nil {
-			continue // Skip invalid CIDRs
-		}
-		existingNets = append(existingNets, network)
+	if !exists {
+		diags.AddAttributeError(path.Root("name"), "Pool Not Found",
+			fmt.Sprintf("Pool %q is not defined in pools.yaml. With manage = false the pool must already exist; "+
+				"add it there, or set manage = true to create it.", poolName))
+		return
 	}
 
-	// Calculate the step size for the requested prefix
-	// For a /12, we need to step by 2^(32-12) = 2^20 = 1048576
-	stepBits := 32 - prefixLen
-	step := uint32(1) << stepBits
+	setPoolValues(ctx, m, poolDef, diags)
 
-	// Start from the beginning of the parent range
-	startIP := ipToUint32(parentNet.IP)
-	endIP := startIP + (uint32(1) << (32 - parentPrefixLen)) - 1
+	tflog.Info(ctx, "Adopted pool managed outside Terraform", map[string]interface{}{
+		"name": poolName,
+		"cidr": m.CIDR.ValueString(),
+	})
+}
 
-	// Align start to the prefix boundary
-	if startIP%step != 0 {
-		startIP = ((startIP / step) + 1) * step
+// setPoolValues sets the attributes pools.yaml defines from poolDef.
+func setPoolValues(ctx context.Context, m *PoolResourceModel, poolDef *ipam.PoolDefinition, diags *diag.Diagnostics) {
+	if len(poolDef.CIDR) > 0 {
+		m.CIDR = types.StringValue(poolDef.CIDR[0])
+	} else if m.CIDR.IsUnknown() {
+		m.CIDR = types.StringNull()
 	}
 
-	// Iterate through possible blocks
-	for candidateStart := startIP; candidateStart+step-1 <= endIP; candidateStart += step {
-		candidateNet := uint32ToCIDR(candidateStart, prefixLen)
-
-		_, candidateIPNet, err := net.ParseCIDR(candidateNet)
-		if err != nil {
-			continue
-		}
-
-		// Check if this candidate overlaps with any existing network
-		overlaps := false
-		for _, existing := range existingNets {
-			if networksOverlap(candidateIPNet, existing) {
-				overlaps = true
-				break
-			}
-		}
+	m.Description = types.StringValue(poolDef.Description)
+	m.Reserved = types.BoolValue(poolDef.Reserved)
 
-		if !overlaps {
-			return candidateNet, nil
-		}
+	if len(poolDef.Metadata) > 0 {
+		metadataValue, d := types.MapValueFrom(ctx, types.StringType, poolDef.Metadata)
+		diags.Append(d...)
+		m.Metadata = metadataValue
+	} else {
+		m.Metadata = types.MapNull(types.StringType)
 	}
-
-	return "", fmt.Errorf("no available /%d block in %s", prefixLen, parentCIDR)
 }
 
-// ipToUint32 converts a net.IP to uint32.
-func ipToUint32(ip net.IP) uint32 {
-	ip = ip.To4()
-	if ip == nil {
-		return 0
-	}
-	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+// replaceStringIfManaged and replaceInt64IfManaged replace the pool only
+// when it is managed before and after the change: an unmanaged pool was
+// never created by the resource, so switching manage must not destroy it.
+func replaceStringIfManaged(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+	resp.RequiresReplace = managedBeforeAndAfter(ctx, req.State, req.Plan, &resp.Diagnostics)
 }
 
-// uint32ToCIDR converts a uint32 IP and prefix length to CIDR notation.
-func uint32ToCIDR(ip uint32, prefixLen int) string {
-	return fmt.Sprintf("%d.%d.%d.%d/%d",
-		(ip>>24)&0xFF,
-		(ip>>16)&0xFF,
-		(ip>>8)&0xFF,
-		ip&0xFF,
-		prefixLen,
-	)
+func replaceInt64IfManaged(ctx context.Context, req planmodifier.Int64Request, resp *int64planmodifier.RequiresReplaceIfFuncResponse) {
+	resp.RequiresReplace = managedBeforeAndAfter(ctx, req.State, req.Plan, &resp.Diagnostics)
 }
 
-// networksOverlap checks if two networks overlap.
-// Two networks overlap if: startA <= endB AND startB <= endA
-func networksOverlap(a, b *net.IPNet) bool {
-	// Get start and end of network A
-	aStart := ipToUint32(a.IP)
-	aOnes, aBits := a.Mask.Size()
-	aEnd := aStart + (uint32(1) << (aBits - aOnes)) - 1
-
-	// Get start and end of network B
-	bStart := ipToUint32(b.IP)
-	bOnes, bBits := b.Mask.Size()
-	bEnd := bStart + (uint32(1) << (bBits - bOnes)) - 1
-
-	// Two ranges overlap if startA <= endB AND startB <= endA
-	return aStart <= bEnd && bStart <= aEnd
+func managedBeforeAndAfter(ctx context.Context, state tfsdk.State, plan tfsdk.Plan, diags *diag.Diagnostics) bool {
+	var before, after types.Bool
+	diags.Append(state.GetAttribute(ctx, path.Root("manage"), &before)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("manage"), &after)...)
+	return PoolResourceModel{Manage: before}.managed() && PoolResourceModel{Manage: after}.managed()
 }
 
 // poolDiagnosticsToString converts diagnostics to a string for error messages.
@@ -0,0 +1,503 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/float64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var (
+	_ resource.Resource              = &ReservationPlanResource{}
+	_ resource.ResourceWithConfigure = &ReservationPlanResource{}
+)
+
+// NewReservationPlanResource creates a new reservation plan resource.
+func NewReservationPlanResource() resource.Resource {
+	return &ReservationPlanResource{}
+}
+
+// ReservationPlanResource defines the resource implementation.
+type ReservationPlanResource struct {
+	client    *client.GitHubClient
+	allocator *ipam.Allocator
+}
+
+// ReservationPlanResourceModel describes the resource data model.
+type ReservationPlanResourceModel struct {
+	ID             types.String  `tfsdk:"id"`
+	Name           types.String  `tfsdk:"name"`
+	PoolID         types.String  `tfsdk:"pool_id"`
+	CIDRMask       types.Int64   `tfsdk:"cidr_mask"`
+	BlockCount     types.Int64   `tfsdk:"block_count"`
+	ReservePct     types.Float64 `tfsdk:"reserve_pct"`
+	Metadata       types.Map     `tfsdk:"metadata"`
+	CIDRs          types.List    `tfsdk:"cidrs"`
+	ReservationIDs types.List    `tfsdk:"reservation_ids"`
+}
+
+func (r *ReservationPlanResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_reservation_plan"
+}
+
+func (r *ReservationPlanResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Pre-carves a pool into a fixed number of equally-sized reservations in a single commit.",
+		MarkdownDescription: `Pre-carves a pool into a fixed number of equally-sized reservations in a single commit.
+
+Useful when standing up a region, e.g. reserving one /20 per availability zone. Reservations are
+placed contiguously wherever the pool's free space allows. If the pool cannot fit all ` + "`block_count`" + `
+blocks, nothing is committed.
+
+Alternatively, set ` + "`reserve_pct`" + ` instead of ` + "`cidr_mask`" + ` and ` + "`block_count`" + ` to reserve a share of
+the pool, e.g. 25% for a future product. The provider carves that many addresses from the top of the pool
+as a few large aligned blocks, leaving the space below whole. The share is rounded to a multiple of the
+smallest block, 1/256 of the pool or its allocation_granularity.
+
+Each reservation is stored as a separate entry in allocations.yaml named ` + "`<name>-<index>`" + `.
+
+**Example:**
+` + "```hcl" + `
+resource "github-ipam_reservation_plan" "azs" {
+  name        = "use1-az"
+  pool_id     = "production"
+  cidr_mask   = 20
+  block_count = 3
+}
+
+# Output: cidrs = ["10.0.0.0/20", "10.0.16.0/20", "10.0.32.0/20"]
+` + "```",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Unique identifier for this reservation plan (UUID).",
+				MarkdownDescription: "Unique identifier for this reservation plan (UUID).",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Required:            true,
+				Description:         "Name prefix for the reservations. Each reservation is named <name>-<index>.",
+				MarkdownDescription: "Name prefix for the reservations. Each reservation is named `<name>-<index>`.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"pool_id": schema.StringAttribute{
+				Required:            true,
+				Description:         "Pool ID from pools.yaml to reserve from.",
+				MarkdownDescription: "Pool ID from pools.yaml to reserve from.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"cidr_mask": schema.Int64Attribute{
+				Optional:            true,
+				Description:         "Prefix length for each reservation (e.g., 20 for /20). Requires block_count.",
+				MarkdownDescription: "Prefix length for each reservation (e.g., `20` for /20). Requires `block_count`.",
+				Validators: []validator.Int64{
+					int64validator.AlsoRequires(path.MatchRoot("block_count")),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"block_count": schema.Int64Attribute{
+				Optional:            true,
+				Description:         "Number of reservations to create. Requires cidr_mask.",
+				MarkdownDescription: "Number of reservations to create. Requires `cidr_mask`.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+					int64validator.AlsoRequires(path.MatchRoot("cidr_mask")),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"reserve_pct": schema.Float64Attribute{
+				Optional: true,
+				Description: "Share of the pool to reserve, in percent, as aligned blocks from the top of the pool. " +
+					"Mutually exclusive with cidr_mask and block_count.",
+				MarkdownDescription: "Share of the pool to reserve, in percent, as aligned blocks from the top of the pool. " +
+					"Mutually exclusive with `cidr_mask` and `block_count`.",
+				Validators: []validator.Float64{
+					float64validator.Between(0.01, 100),
+					float64validator.ExactlyOneOf(path.MatchRoot("cidr_mask")),
+					float64validator.ConflictsWith(path.MatchRoot("block_count")),
+				},
+				PlanModifiers: []planmodifier.Float64{
+					float64planmodifier.RequiresReplace(),
+				},
+			},
+			"metadata": schema.MapAttribute{
+				Optional:            true,
+				ElementType:         types.StringType,
+				Description:         "Key-value metadata applied to every reservation.",
+				MarkdownDescription: "Key-value metadata applied to every reservation.",
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"cidrs": schema.ListAttribute{
+				Computed:            true,
+				ElementType:         types.StringType,
+				Description:         "Reserved CIDR blocks, in allocation order.",
+				MarkdownDescription: "Reserved CIDR blocks, in allocation order.",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"reservation_ids": schema.ListAttribute{
+				Computed:            true,
+				ElementType:         types.StringType,
+				Description:         "IDs of the reservations in allocations.yaml, matching the order of cidrs.",
+				MarkdownDescription: "IDs of the reservations in allocations.yaml, matching the order of `cidrs`.",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *ReservationPlanResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	ghClient, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.client = ghClient
+	r.allocator = ipam.NewAllocator()
+}
+
+func (r *ReservationPlanResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan ReservationPlanResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	planID := uuid.New().String()
+	poolID := plan.PoolID.ValueString()
+	prefixLen := int(plan.CIDRMask.ValueInt64())
+	count := int(plan.BlockCount.ValueInt64())
+	pct := plan.ReservePct.ValueFloat64()
+	byPercentage := !plan.ReservePct.IsNull()
+
+	tflog.Debug(ctx, "Creating reservation plan", map[string]interface{}{
+		"id":        planID,
+		"pool_id":   poolID,
+		"cidr_mask": prefixLen,
+		"count":     count,
+		"pct":       pct,
+	})
+
+	metadata := make(map[string]string)
+	if !plan.Metadata.IsNull() {
+		resp.Diagnostics.Append(plan.Metadata.ElementsAs(ctx, &metadata, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	var reservedCIDRs, reservationIDs []string
+	retryConfig := r.client.RetryConfig()
+
+	err := client.WithRetry(ctx, retryConfig, func(ctx context.Context, attempt int) (bool, error) {
+		pools, err := r.client.GetPools(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read pools: %w", err)
+		}
+
+		db, sha, err := r.client.GetAllocations(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read allocations: %w", err)
+		}
+
+		poolDef, exists := pools.GetPool(poolID)
+		if !exists {
+			return false, fmt.Errorf("pool_id %q not found in pools.yaml", poolID)
+		}
+		if poolDef.Reserved {
+			return false, fmt.Errorf("cannot reserve from pool %q: pool is reserved (reserved pools cannot have allocations)", poolID)
+		}
+
+		opts := db.AllocateOptionsForPool(poolID, poolDef)
+		var cidrs []string
+		if byPercentage {
+			cidrs, err = r.allocator.FindPercentageInPool(poolDef, db.GetAllocationsForPool(poolID), pct, opts)
+		} else {
+			cidrs, err = r.allocator.FindNextAvailableBatchInPool(poolDef, db.GetAllocationsForPool(poolID), prefixLen, count, opts)
+		}
+		if err != nil {
+			return false, fmt.Errorf("reservation plan for pool %s failed: %w", poolID, err)
+		}
+		count := len(cidrs)
+
+		// Check every generated name up front so nothing is committed on collision
+		names := make([]string, count)
+		for i := range names {
+			names[i] = fmt.Sprintf("%s-%d", plan.Name.ValueString(), i)
+			if existing, _, found := db.FindAllocationByName(names[i]); found {
+				return false, fmt.Errorf("allocation name %q already exists (used by allocation %s)", names[i], existing.CIDR)
+			}
+		}
+
+		// A batch may spill across a multi-CIDR pool's ranges; each range's
+		// share must be consistent on its own
+		planned := make(map[string][]ipam.Allocation)
+		for _, cidr := range cidrs {
+			if err := ipam.ValidatePrivate(cidr, r.client.AllowPublic()); err != nil {
+				return false, err
+			}
+			container, _ := poolDef.ContainingCIDR(cidr)
+			planned[container] = append(planned[container], ipam.Allocation{CIDR: cidr})
+		}
+		for container, entries := range planned {
+			if err := r.allocator.ValidatePlan(container, entries); err != nil {
+				return false, fmt.Errorf("reservation plan for pool %s is inconsistent: %w", poolID, err)
+			}
+		}
+
+		ids := make([]string, count)
+		for i, cidr := range cidrs {
+			ids[i] = uuid.New().String()
+			reservation := ipam.Allocation{
+				CIDR:     cidr,
+				ID:       ids[i],
+				Name:     names[i],
+				Metadata: metadata,
+			}
+			reservation.SetStatus(ipam.StatusReservation)
+			db.AddAllocation(poolID, reservation)
+		}
+
+		commitMsg := fmt.Sprintf("ipam: reserve %d x /%d in %s (%s)", count, prefixLen, poolID, plan.Name.ValueString())
+		if byPercentage {
+			commitMsg = fmt.Sprintf("ipam: reserve %g%% of %s as %d blocks (%s)", pct, poolID, count, plan.Name.ValueString())
+		}
+		err = r.client.UpdateAllocations(ctx, db, sha, commitMsg)
+		if r.client.IsConflictError(err) {
+			tflog.Debug(ctx, "Conflict detected, will retry", map[string]interface{}{
+				"attempt": attempt,
+			})
+			return true, err
+		}
+
+		if err == nil {
+			reservedCIDRs = cidrs
+			reservationIDs = ids
+			r.client.RecordAllocations(len(cidrs), 0)
+		}
+		return false, err
+	})
+
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create reservation plan", err.Error())
+		return
+	}
+
+	cidrList, diags := types.ListValueFrom(ctx, types.StringType, reservedCIDRs)
+	resp.Diagnostics.Append(diags...)
+	idList, diags := types.ListValueFrom(ctx, types.StringType, reservationIDs)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.ID = types.StringValue(planID)
+	plan.CIDRs = cidrList
+	plan.ReservationIDs = idList
+
+	tflog.Info(ctx, "Created reservation plan", map[string]interface{}{
+		"id":    planID,
+		"cidrs": reservedCIDRs,
+	})
+
+	// Regenerate README (best effort unless docs_strict is set)
+	resp.Diagnostics.Append(r.client.RefreshDocsDiagnostics(ctx)...)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *ReservationPlanResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state ReservationPlanResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var ids []string
+	resp.Diagnostics.Append(state.ReservationIDs.ElementsAs(ctx, &ids, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	db, _, err := r.client.GetAllocations(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read allocations", err.Error())
+		return
+	}
+
+	var foundIDs, foundCIDRs []string
+	for _, id := range ids {
+		alloc, _, found := db.FindAllocationByID(id)
+		if !found {
+			tflog.Warn(ctx, "Reservation from plan not found", map[string]interface{}{
+				"plan_id":        state.ID.ValueString(),
+				"reservation_id": id,
+			})
+			continue
+		}
+		foundIDs = append(foundIDs, id)
+		foundCIDRs = append(foundCIDRs, alloc.CIDR)
+	}
+
+	if len(foundIDs) == 0 {
+		tflog.Warn(ctx, "Reservation plan not found, removing from state", map[string]interface{}{
+			"id": state.ID.ValueString(),
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	cidrList, diags := types.ListValueFrom(ctx, types.StringType, foundCIDRs)
+	resp.Diagnostics.Append(diags...)
+	idList, diags := types.ListValueFrom(ctx, types.StringType, foundIDs)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.CIDRs = cidrList
+	state.ReservationIDs = idList
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (r *ReservationPlanResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// All configurable attributes require replacement, so only carry computed values forward.
+	var plan, state ReservationPlanResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.ID = state.ID
+	plan.CIDRs = state.CIDRs
+	plan.ReservationIDs = state.ReservationIDs
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *ReservationPlanResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state ReservationPlanResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var ids []string
+	resp.Diagnostics.Append(state.ReservationIDs.ElementsAs(ctx, &ids, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, "Deleting reservation plan", map[string]interface{}{
+		"id":    state.ID.ValueString(),
+		"count": len(ids),
+	})
+
+	retryConfig := r.client.RetryConfig()
+
+	err := client.WithRetry(ctx, retryConfig, func(ctx context.Context, attempt int) (bool, error) {
+		db, sha, err := r.client.GetAllocations(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read allocations: %w", err)
+		}
+
+		removed := 0
+		deletedAt := time.Now()
+		for _, id := range ids {
+			alloc, poolID, found := db.FindAllocationByID(id)
+			if !found {
+				continue
+			}
+			if children := db.GetAllocationsForParent(alloc.CIDR); len(children) > 0 {
+				return false, fmt.Errorf("cannot delete reservation %s: has %d child allocations", alloc.CIDR, len(children))
+			}
+			if r.client.ArchiveDeletions() {
+				db.Archive(poolID, *alloc, deletedAt)
+			}
+			if err := db.RemoveAllocation(poolID, id); err != nil {
+				return false, err
+			}
+			removed++
+		}
+
+		if removed == 0 {
+			// Already deleted
+			tflog.Debug(ctx, "Reservation plan already deleted", map[string]interface{}{
+				"id": state.ID.ValueString(),
+			})
+			return false, nil
+		}
+
+		commitMsg := fmt.Sprintf("ipam: release %d reservations (%s)", removed, state.Name.ValueString())
+		err = r.client.UpdateAllocations(ctx, db, sha, commitMsg)
+		if r.client.IsConflictError(err) {
+			return true, err
+		}
+		if err == nil {
+			r.client.RecordAllocations(0, removed)
+		}
+		return false, err
+	})
+
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to delete reservation plan", err.Error())
+		return
+	}
+
+	tflog.Info(ctx, "Deleted reservation plan", map[string]interface{}{
+		"id": state.ID.ValueString(),
+	})
+
+	// Regenerate README (best effort unless docs_strict is set)
+	resp.Diagnostics.Append(r.client.RefreshDocsDiagnostics(ctx)...)
+}
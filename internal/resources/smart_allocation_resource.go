@@ -0,0 +1,483 @@
+// Copyright (c) EasyTofu
+// SPDX-License-Identifier: MPL-2.0
+
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/easytofu/terraform-provider-ipam-github/internal/client"
+	"github.com/easytofu/terraform-provider-ipam-github/internal/ipam"
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var (
+	_ resource.Resource              = &SmartAllocationResource{}
+	_ resource.ResourceWithConfigure = &SmartAllocationResource{}
+)
+
+// NewSmartAllocationResource creates a new smart allocation resource.
+func NewSmartAllocationResource() resource.Resource {
+	return &SmartAllocationResource{}
+}
+
+// SmartAllocationResource defines the resource implementation.
+type SmartAllocationResource struct {
+	client    *client.GitHubClient
+	allocator *ipam.Allocator
+}
+
+// SmartAllocationResourceModel describes the resource data model.
+type SmartAllocationResourceModel struct {
+	ID       types.String `tfsdk:"id"`
+	Name     types.String `tfsdk:"name"`
+	PoolIDs  types.List   `tfsdk:"pool_ids"`
+	CIDRMask types.Int64  `tfsdk:"cidr_mask"`
+	Strategy types.String `tfsdk:"strategy"`
+	Metadata types.Map    `tfsdk:"metadata"`
+	PoolID   types.String `tfsdk:"pool_id"`
+	CIDR     types.String `tfsdk:"cidr"`
+}
+
+func (r *SmartAllocationResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_smart_allocation"
+}
+
+func (r *SmartAllocationResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Allocates a CIDR block from whichever of several candidate pools the strategy picks, in a single commit.",
+		MarkdownDescription: `Allocates a CIDR block from whichever of several candidate pools the strategy picks, in a single commit.
+
+With the default ` + "`best_fit`" + ` strategy the block comes from the pool with the least free space that
+still fits it, which packs pools tightly and keeps the roomiest ones for large requests. ` + "`worst_fit`" + `
+picks the pool with the most free space instead, and ` + "`first_fit`" + ` the first candidate that fits.
+A pool fits when it is not reserved, has a free block of the size and keeps its ` + "`min_free_pct`" + `
+reserve. The chosen pool is reported in ` + "`pool_id`" + `.
+
+**Example:**
+` + "```hcl" + `
+resource "github-ipam_smart_allocation" "lab" {
+  name      = "vpc-lab"
+  pool_ids  = ["lab-east", "lab-west"]
+  cidr_mask = 24
+}
+` + "```",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Unique identifier for this allocation (UUID).",
+				MarkdownDescription: "Unique identifier for this allocation (UUID).",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Required:            true,
+				Description:         "Allocation name. Must be unique across all allocations.",
+				MarkdownDescription: "Allocation name. Must be unique across all allocations.",
+			},
+			"pool_ids": schema.ListAttribute{
+				Required:            true,
+				ElementType:         types.StringType,
+				Description:         "Candidate pool IDs from pools.yaml. Ties go to the earlier pool.",
+				MarkdownDescription: "Candidate pool IDs from pools.yaml. Ties go to the earlier pool.",
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+					listvalidator.UniqueValues(),
+				},
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"cidr_mask": schema.Int64Attribute{
+				Required:            true,
+				Description:         "Prefix length of the block (e.g., 24 for /24).",
+				MarkdownDescription: "Prefix length of the block (e.g., `24` for /24).",
+				Validators: []validator.Int64{
+					int64validator.Between(1, 128),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"strategy": schema.StringAttribute{
+				Optional:            true,
+				Description:         "How to choose among the candidate pools: best_fit (default), worst_fit or first_fit.",
+				MarkdownDescription: "How to choose among the candidate pools: `best_fit` (default), `worst_fit` or `first_fit`.",
+				Validators: []validator.String{
+					stringvalidator.OneOf(ipam.SelectBestFit, ipam.SelectWorstFit, ipam.SelectFirstFit),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"metadata": schema.MapAttribute{
+				Optional:            true,
+				ElementType:         types.StringType,
+				Description:         "Key-value metadata for the allocation.",
+				MarkdownDescription: "Key-value metadata for the allocation.",
+			},
+			"pool_id": schema.StringAttribute{
+				Computed:            true,
+				Description:         "The candidate pool the block was allocated from.",
+				MarkdownDescription: "The candidate pool the block was allocated from.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cidr": schema.StringAttribute{
+				Computed:            true,
+				Description:         "The allocated CIDR block.",
+				MarkdownDescription: "The allocated CIDR block.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *SmartAllocationResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	ghClient, ok := req.ProviderData.(*client.GitHubClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *client.GitHubClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.client = ghClient
+	r.allocator = ipam.NewAllocator()
+}
+
+func (r *SmartAllocationResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan SmartAllocationResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var poolIDs []string
+	resp.Diagnostics.Append(plan.PoolIDs.ElementsAs(ctx, &poolIDs, false)...)
+	explicit := make(map[string]string)
+	if !plan.Metadata.IsNull() {
+		resp.Diagnostics.Append(plan.Metadata.ElementsAs(ctx, &explicit, false)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	allocationID := uuid.New().String()
+	prefixLen := int(plan.CIDRMask.ValueInt64())
+	strategy := plan.Strategy.ValueString()
+
+	tflog.Debug(ctx, "Creating smart allocation", map[string]interface{}{
+		"allocation_id": allocationID,
+		"pool_ids":      poolIDs,
+		"cidr_mask":     prefixLen,
+		"strategy":      strategy,
+	})
+
+	var chosen ipam.PoolChoice
+	retryConfig := r.client.RetryConfig()
+
+	err := client.WithRetry(ctx, retryConfig, func(ctx context.Context, attempt int) (bool, error) {
+		pools, err := r.client.GetPools(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read pools: %w", err)
+		}
+
+		db, sha, err := r.client.GetAllocations(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read allocations: %w", err)
+		}
+
+		if existing, _, found := db.FindAllocationByName(plan.Name.ValueString()); found {
+			return false, fmt.Errorf("allocation name %q already exists (used by allocation %s)", plan.Name.ValueString(), existing.CIDR)
+		}
+
+		// Free space is re-read on every attempt, so a conflicting write
+		// can change which pool wins
+		choice, err := r.allocator.SelectPool(pools, db, poolIDs, prefixLen, strategy)
+		if err != nil {
+			return false, err
+		}
+		if err := ipam.ValidatePrivate(choice.CIDR, r.client.AllowPublic()); err != nil {
+			return false, err
+		}
+
+		poolDef, _ := pools.GetPool(choice.PoolID)
+		metadata := poolDef.EffectiveMetadata(explicit)
+		if err := poolDef.CheckRequiredMetadata(metadata); err != nil {
+			return false, fmt.Errorf("pool %q: %w", choice.PoolID, err)
+		}
+		if err := poolDef.CheckUniqueMetadata(metadata, db.GetAllocationsForPool(choice.PoolID), "", ""); err != nil {
+			return false, fmt.Errorf("pool %q: %w", choice.PoolID, err)
+		}
+		allocation := ipam.Allocation{
+			CIDR:     choice.CIDR,
+			ID:       allocationID,
+			Name:     plan.Name.ValueString(),
+			Metadata: metadata,
+		}
+		allocation.SetStatus(ipam.StatusAllocation)
+		db.AddAllocation(choice.PoolID, allocation)
+
+		commitMsg := fmt.Sprintf("ipam: allocate %s from %s (%s)", choice.CIDR, choice.PoolID, plan.Name.ValueString())
+		err = r.client.UpdateAllocations(ctx, db, sha, commitMsg)
+		if r.client.IsConflictError(err) {
+			tflog.Debug(ctx, "Conflict detected, will retry", map[string]interface{}{
+				"attempt": attempt,
+			})
+			return true, err
+		}
+
+		if err == nil {
+			chosen = choice
+		}
+		return false, err
+	})
+
+	if errors.Is(err, ipam.ErrMissingMetadata) {
+		resp.Diagnostics.AddAttributeError(path.Root("metadata"), "Missing Required Metadata", err.Error())
+		return
+	}
+	if errors.Is(err, ipam.ErrDuplicateMetadata) {
+		resp.Diagnostics.AddAttributeError(path.Root("metadata"), "Duplicate Metadata Value", err.Error())
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to allocate CIDR", err.Error())
+		return
+	}
+
+	r.client.RecordAllocations(1, 0)
+
+	plan.ID = types.StringValue(allocationID)
+	plan.PoolID = types.StringValue(chosen.PoolID)
+	plan.CIDR = types.StringValue(chosen.CIDR)
+
+	tflog.Info(ctx, "Created smart allocation", map[string]interface{}{
+		"id":      allocationID,
+		"pool_id": chosen.PoolID,
+		"cidr":    chosen.CIDR,
+	})
+
+	// Regenerate README (best effort unless docs_strict is set)
+	resp.Diagnostics.Append(r.client.RefreshDocsDiagnostics(ctx)...)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *SmartAllocationResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state SmartAllocationResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pools, err := r.client.GetPools(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read pools", err.Error())
+		return
+	}
+	db, _, err := r.client.GetAllocations(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read allocations", err.Error())
+		return
+	}
+
+	alloc, poolID, found := db.FindAllocationByID(state.ID.ValueString())
+	if !found {
+		tflog.Warn(ctx, "Allocation not found, removing from state", map[string]interface{}{
+			"id": state.ID.ValueString(),
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	state.Name = types.StringValue(alloc.Name)
+	state.PoolID = types.StringValue(poolID)
+	state.CIDR = types.StringValue(alloc.CIDR)
+
+	// Keep pool defaults out of the explicit metadata so they don't show
+	// as drift, as the allocation resource does
+	var priorMetadata map[string]string
+	if !state.Metadata.IsNull() && !state.Metadata.IsUnknown() {
+		resp.Diagnostics.Append(state.Metadata.ElementsAs(ctx, &priorMetadata, false)...)
+	}
+	poolDef, _ := pools.GetPool(poolID)
+	if explicit := poolDef.ExplicitMetadata(alloc.Metadata, priorMetadata); len(explicit) > 0 {
+		state.Metadata = metadataValue(ctx, explicit, &resp.Diagnostics)
+	} else if len(priorMetadata) > 0 {
+		state.Metadata = types.MapNull(types.StringType)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (r *SmartAllocationResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan SmartAllocationResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	explicit := make(map[string]string)
+	if !plan.Metadata.IsNull() {
+		resp.Diagnostics.Append(plan.Metadata.ElementsAs(ctx, &explicit, false)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Name and metadata can be updated in-place
+	retryConfig := r.client.RetryConfig()
+
+	err := client.WithRetry(ctx, retryConfig, func(ctx context.Context, attempt int) (bool, error) {
+		pools, err := r.client.GetPools(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read pools: %w", err)
+		}
+
+		db, sha, err := r.client.GetAllocations(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read allocations: %w", err)
+		}
+
+		alloc, poolID, found := db.FindAllocationByID(plan.ID.ValueString())
+		if !found {
+			return false, fmt.Errorf("allocation %s not found", plan.ID.ValueString())
+		}
+
+		newName := plan.Name.ValueString()
+		if existing, _, found := db.FindAllocationByName(newName); found && existing.ID != alloc.ID {
+			return false, fmt.Errorf("cannot rename allocation to %q: name already exists (used by allocation %s)", newName, existing.CIDR)
+		}
+
+		poolDef, _ := pools.GetPool(poolID)
+		updated := *alloc
+		updated.Name = newName
+		updated.Metadata = poolDef.EffectiveMetadata(explicit)
+		if err := poolDef.CheckUniqueMetadata(updated.Metadata, db.GetAllocationsForPool(poolID), updated.ID, updated.Group); err != nil {
+			return false, fmt.Errorf("pool %q: %w", poolID, err)
+		}
+		updated.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+		if err := db.RemoveAllocation(poolID, updated.ID); err != nil {
+			return false, err
+		}
+		db.AddAllocation(poolID, updated)
+
+		commitMsg := fmt.Sprintf("ipam: update %s (%s)", updated.CIDR, newName)
+		err = r.client.UpdateAllocations(ctx, db, sha, commitMsg)
+		if r.client.IsConflictError(err) {
+			return true, err
+		}
+		return false, err
+	})
+
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to update allocation", err.Error())
+		return
+	}
+
+	// Regenerate README (best effort unless docs_strict is set)
+	resp.Diagnostics.Append(r.client.RefreshDocsDiagnostics(ctx)...)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *SmartAllocationResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state SmartAllocationResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, "Deleting smart allocation", map[string]interface{}{
+		"id":   state.ID.ValueString(),
+		"cidr": state.CIDR.ValueString(),
+	})
+
+	var deleted bool
+	retryConfig := r.client.RetryConfig()
+
+	err := client.WithRetry(ctx, retryConfig, func(ctx context.Context, attempt int) (bool, error) {
+		pools, err := r.client.GetPools(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read pools: %w", err)
+		}
+
+		db, sha, err := r.client.GetAllocations(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read allocations: %w", err)
+		}
+
+		_, poolID, found := db.FindAllocationByID(state.ID.ValueString())
+		if !found {
+			// Already deleted
+			return false, nil
+		}
+
+		removed, err := db.RemoveLeafAllocation(poolID, state.ID.ValueString())
+		if err != nil {
+			return false, err
+		}
+		if poolDef, exists := pools.GetPool(poolID); exists && poolDef.ReusePolicy == ipam.ReusePolicyCooldownLast {
+			db.RecordFreed(poolID, removed.CIDR)
+		}
+		if r.client.ArchiveDeletions() {
+			db.Archive(poolID, *removed, time.Now())
+		}
+
+		commitMsg := fmt.Sprintf("ipam: deallocate %s (%s)", removed.CIDR, state.Name.ValueString())
+		err = r.client.UpdateAllocations(ctx, db, sha, commitMsg)
+		if r.client.IsConflictError(err) {
+			return true, err
+		}
+		deleted = err == nil
+		return false, err
+	})
+
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to deallocate CIDR", err.Error())
+		return
+	}
+
+	if deleted {
+		r.client.RecordAllocations(0, 1)
+	}
+
+	tflog.Info(ctx, "Deleted smart allocation", map[string]interface{}{
+		"id":   state.ID.ValueString(),
+		"cidr": state.CIDR.ValueString(),
+	})
+
+	// Regenerate README (best effort unless docs_strict is set)
+	resp.Diagnostics.Append(r.client.RefreshDocsDiagnostics(ctx)...)
+}